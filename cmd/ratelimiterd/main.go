@@ -0,0 +1,132 @@
+// Command ratelimiterd serves the Limiter service of the remote package, so
+// that the services of any language calling Stream share its quota: they
+// acquire a lease before each call and release it with the rate limit of the
+// response, see remote/ratelimiter.proto. The limiters are configured through
+// the STREAM_RL_* environment variables, see config.FromEnv, the config file
+// being reloaded whenever it changes.
+//
+//	ratelimiterd -addr :7070 -lease 30s
+//
+// With -proxy-addr, it also serves a reverse proxy to the Stream api, see the
+// sidecar package, for the services to be rate limited by pointing their SDK
+// at it, without any change to their code:
+//
+//	ratelimiterd -proxy-addr :8080 -proxy-target https://chat.stream-io-api.com
+//
+// On SIGINT or SIGTERM, the new calls are refused while the leases granted,
+// and the requests proxied, are over, for up to -shutdown-timeout.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/config"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/remote"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/sidecar"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "ratelimiterd:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("ratelimiterd", flag.ContinueOnError)
+	addr := flags.String("addr", ":7070", "address to listen on")
+	lease := flags.Duration("lease", remote.DefaultLease, "lease of the calls not setting it")
+	maxLease := flags.Duration("max-lease", 5*time.Minute, "longest lease granted, 0 for unbounded")
+	shutdownTimeout := flags.Duration("shutdown-timeout", 30*time.Second, "how long to wait for the leases on shutdown")
+	reload := flags.Duration("reload-interval", 10*time.Second, "how often to check the config file for changes")
+	proxyAddr := flags.String("proxy-addr", "", "address to serve the reverse proxy to the Stream api on, none when empty")
+	proxyTarget := flags.String("proxy-target", sidecar.DefaultTarget, "Stream api the reverse proxy forwards to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	manager, err := config.NewFromEnv()
+	if err != nil {
+		return err
+	}
+	if path := os.Getenv(config.EnvFile); path != "" {
+		config.Watch(ctx, path, manager, *reload, func(err error) {
+			slog.Warn("config not reloaded", "error", err)
+		})
+	}
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	if *proxyAddr != "" {
+		proxy, err := sidecar.New(*proxyTarget, manager)
+		if err != nil {
+			return err
+		}
+		proxyServer := &http.Server{Handler: proxy}
+		proxyLis, err := net.Listen("tcp", *proxyAddr)
+		if err != nil {
+			return err
+		}
+		slog.Info("proxying", "addr", proxyLis.Addr().String(), "target", *proxyTarget)
+		go proxyServer.Serve(proxyLis)
+		defer func() {
+			// the requests limited are over once manager is drained, the
+			// other ones are given as long
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+			defer cancel()
+			proxyServer.Shutdown(shutdownCtx)
+		}()
+	}
+	slog.Info("serving", "addr", lis.Addr().String())
+	return serve(ctx, lis, manager, *shutdownTimeout, remote.WithDefaultLease(*lease), remote.WithMaxLease(*maxLease))
+}
+
+// serve serves the Limiter service of manager, and the gRPC health service,
+// on lis until ctx is done, then drains manager for up to shutdownTimeout.
+func serve(ctx context.Context, lis net.Listener, manager *rate_limiter.LimiterManager, shutdownTimeout time.Duration, opts ...remote.ServiceOption) error {
+	server := grpc.NewServer()
+	remote.NewLimiterService(manager, opts...).Register(server)
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(lis) }()
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	healthServer.Shutdown()
+	drainCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := manager.Drain(drainCtx)
+	if err != nil {
+		slog.Warn("leases not released before shutdown", "error", err)
+		server.Stop()
+	} else {
+		server.GracefulStop()
+	}
+	if served := <-served; served != nil && !errors.Is(served, grpc.ErrServerStopped) {
+		return served
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/remote"
+)
+
+func TestServe(t *testing.T) {
+	lis := bufconn.Listen(1 << 16)
+	conn, err := grpc.NewClient("passthrough:///ratelimiterd",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	manager := rate_limiter.NewLimiterManager()
+	served := make(chan error, 1)
+	go func() { served <- serve(ctx, lis, manager, time.Second) }()
+
+	health, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, health.Status)
+
+	client := remote.NewClient(conn)
+	lease, err := client.Acquire(context.Background(), rate_limiter.QueryUsers)
+	require.NoError(t, err)
+
+	// shutting down waits for the lease
+	cancel()
+	require.Eventually(t, func() bool {
+		other, err := client.TryAcquire(context.Background(), rate_limiter.QueryChannels)
+		if err == nil {
+			other.Release(context.Background(), nil, false)
+		}
+		return status.Code(err) == codes.Unavailable
+	}, time.Second, time.Millisecond, "refused while draining")
+	health, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, health.Status)
+	select {
+	case <-served:
+		t.Fatal("stopped before the lease was released")
+	default:
+	}
+	require.NoError(t, lease.Release(context.Background(), nil, false))
+	assert.NoError(t, <-served)
+}
+
+func TestServeShutdownTimeout(t *testing.T) {
+	lis := bufconn.Listen(1 << 16)
+	conn, err := grpc.NewClient("passthrough:///ratelimiterd",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serve(ctx, lis, rate_limiter.NewLimiterManager(), 10*time.Millisecond) }()
+	_, err = remote.NewClient(conn).Acquire(context.Background(), rate_limiter.QueryUsers)
+	require.NoError(t, err)
+
+	cancel()
+	assert.ErrorIs(t, <-served, context.DeadlineExceeded)
+}
+
+func TestRunInvalidFlags(t *testing.T) {
+	assert.Error(t, run(context.Background(), []string{"-lease", "forever"}))
+}
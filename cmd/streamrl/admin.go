@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// pause pauses the limiters of the endpoints of args.
+func pause(ctx context.Context, args []string, stdout io.Writer) error {
+	return setPaused(ctx, "pause", args, stdout)
+}
+
+// resume resumes the limiters of the endpoints of args.
+func resume(ctx context.Context, args []string, stdout io.Writer) error {
+	return setPaused(ctx, "resume", args, stdout)
+}
+
+func setPaused(ctx context.Context, action string, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet(action, flag.ContinueOnError)
+	admin := adminFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *admin == "" {
+		return errors.New("-admin is required")
+	}
+	if flags.NArg() == 0 {
+		return errors.New("no endpoint given")
+	}
+	var body struct {
+		Limiters []rate_limiter.LimiterStatus `json:"limiters"`
+	}
+	if err := adminRequest(ctx, http.MethodPost, *admin, action, url.Values{"api": flags.Args()}, &body); err != nil {
+		return err
+	}
+	return printStatuses(stdout, body.Limiters, nil)
+}
+
+// events prints the events of the limiters of the endpoints of args, all of
+// them by default, until ctx is done.
+func events(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("events", flag.ContinueOnError)
+	admin := adminFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *admin == "" {
+		return errors.New("-admin is required")
+	}
+	resp, err := adminDo(ctx, http.MethodGet, *admin, "events", url.Values{"api": flags.Args()})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	lines := bufio.NewScanner(resp.Body)
+	for lines.Scan() {
+		var event rate_limiter.EventStatus
+		if err := json.Unmarshal(lines.Bytes(), &event); err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, formatEvent(event))
+	}
+	if ctx.Err() != nil {
+		// interrupted
+		return nil
+	}
+	return lines.Err()
+}
+
+func formatEvent(event rate_limiter.EventStatus) string {
+	line := fmt.Sprintf("%s %-14s %s", formatTime(event.At), event.Type, event.Api)
+	if event.ResumeAt != nil {
+		line += " resume_at=" + formatTime(*event.ResumeAt)
+	}
+	if event.Pending > 0 {
+		line += fmt.Sprintf(" pending=%d", event.Pending)
+	}
+	if event.Error != "" {
+		line += fmt.Sprintf(" error=%q", event.Error)
+	}
+	return line
+}
+
+// adminDo sends a request for action to the admin handler at admin.
+func adminDo(ctx context.Context, method, admin, action string, query url.Values) (*http.Response, error) {
+	u := strings.TrimSuffix(admin, "/") + "/" + action
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("%s %s: %s: %s", method, u, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp, nil
+}
+
+// adminRequest sends a request for action to the admin handler at admin,
+// decoding its JSON response into body.
+func adminRequest(ctx context.Context, method, admin, action string, query url.Values, body any) error {
+	resp, err := adminDo(ctx, method, admin, action, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(body)
+}
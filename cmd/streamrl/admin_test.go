@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+func TestPauseResume(t *testing.T) {
+	manager := rate_limiter.NewLimiterManager()
+	server := httptest.NewServer(rate_limiter.AdminHandler(manager))
+	defer server.Close()
+
+	var stdout bytes.Buffer
+	require.NoError(t, run(context.Background(), []string{"pause", "-admin", server.URL, "QueryUsers", "SendMessage"}, &stdout))
+	assert.True(t, manager.For(rate_limiter.QueryUsers).Paused())
+	assert.True(t, manager.For(rate_limiter.SendMessage).Paused())
+	assert.Len(t, strings.Split(strings.TrimSpace(stdout.String()), "\n"), 3)
+
+	require.NoError(t, run(context.Background(), []string{"resume", "-admin", server.URL, "QueryUsers"}, &stdout))
+	assert.False(t, manager.For(rate_limiter.QueryUsers).Paused())
+	assert.True(t, manager.For(rate_limiter.SendMessage).Paused())
+
+	assert.ErrorContains(t, run(context.Background(), []string{"pause", "-admin", server.URL}, &stdout), "no endpoint given")
+	assert.ErrorContains(t, run(context.Background(), []string{"pause", "QueryUsers"}, &stdout), "-admin is required")
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	assert.ErrorContains(t, run(context.Background(), []string{"pause", "-admin", notFound.URL, "QueryUsers"}, &stdout), "404 Not Found")
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestEvents(t *testing.T) {
+	manager := rate_limiter.NewLimiterManager()
+	users := manager.For(rate_limiter.QueryUsers)
+	server := httptest.NewServer(rate_limiter.AdminHandler(manager))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var stdout syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, []string{"events", "-admin", server.URL, "QueryUsers"}, &stdout)
+	}()
+
+	// until subscribed
+	assert.Eventually(t, func() bool {
+		require.NoError(t, users.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+			return &stream.Response{}, nil
+		}))
+		return strings.Contains(stdout.String(), "CallAdmitted")
+	}, 5*time.Second, 10*time.Millisecond)
+	reset := time.Now().Add(time.Minute).Truncate(time.Second)
+	require.NoError(t, users.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: reset.Unix()}}, nil
+	}))
+	assert.Eventually(t, func() bool {
+		return strings.Contains(stdout.String(), "QueryUsers resume_at="+formatTime(reset))
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
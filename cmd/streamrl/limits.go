@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// newStreamClient returns the client fetching the rate limits, replaced by the
+// tests.
+var newStreamClient = func() (rate_limiter.RateLimitsGetter, error) {
+	return stream.NewClientFromEnvVars()
+}
+
+var platforms = map[string]struct {
+	option stream.GetRateLimitsOption
+	limits func(resp stream.GetRateLimitsResponse) stream.RateLimitsMap
+}{
+	"server":  {stream.WithServerSide(), func(resp stream.GetRateLimitsResponse) stream.RateLimitsMap { return resp.ServerSide }},
+	"android": {stream.WithAndroid(), func(resp stream.GetRateLimitsResponse) stream.RateLimitsMap { return resp.Android }},
+	"ios":     {stream.WithIOS(), func(resp stream.GetRateLimitsResponse) stream.RateLimitsMap { return resp.IOS }},
+	"web":     {stream.WithWeb(), func(resp stream.GetRateLimitsResponse) stream.RateLimitsMap { return resp.Web }},
+}
+
+// limits prints the current rate limits of the endpoints of args, all of them
+// by default.
+func limits(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("limits", flag.ContinueOnError)
+	platform := flags.String("platform", "server", "platform of the limits: server, android, ios or web")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	p, ok := platforms[*platform]
+	if !ok {
+		return fmt.Errorf("unknown platform %q", *platform)
+	}
+	client, err := newStreamClient()
+	if err != nil {
+		return err
+	}
+	opts := []stream.GetRateLimitsOption{p.option}
+	if flags.NArg() > 0 {
+		opts = append(opts, stream.WithEndpoints(flags.Args()...))
+	}
+	resp, err := client.GetRateLimits(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	reported := p.limits(resp)
+	endpoints := make([]string, 0, len(reported))
+	for endpoint := range reported {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ENDPOINT\tLIMIT\tREMAINING\tRESET")
+	for _, endpoint := range endpoints {
+		info := reported[endpoint]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", endpoint, info.Limit, info.Remaining, formatTime(info.ResetTime()))
+	}
+	return w.Flush()
+}
+
+// formatTime formats t in UTC, "-" for the zero time.
+func formatTime(t time.Time) string {
+	if t.IsZero() || t.Unix() == 0 {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+type rateLimitsGetterFunc func(ctx context.Context, options ...stream.GetRateLimitsOption) (stream.GetRateLimitsResponse, error)
+
+func (f rateLimitsGetterFunc) GetRateLimits(ctx context.Context, options ...stream.GetRateLimitsOption) (stream.GetRateLimitsResponse, error) {
+	return f(ctx, options...)
+}
+
+func TestLimits(t *testing.T) {
+	reset := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var options int
+	defer func(original func() (rate_limiter.RateLimitsGetter, error)) {
+		newStreamClient = original
+	}(newStreamClient)
+	newStreamClient = func() (rate_limiter.RateLimitsGetter, error) {
+		return rateLimitsGetterFunc(func(ctx context.Context, opts ...stream.GetRateLimitsOption) (stream.GetRateLimitsResponse, error) {
+			options = len(opts)
+			return stream.GetRateLimitsResponse{
+				Web: stream.RateLimitsMap{"QueryUsers": {Limit: 100, Remaining: 40, Reset: reset.Unix()}},
+				ServerSide: stream.RateLimitsMap{
+					"SendMessage": {Limit: 1000, Remaining: 999, Reset: reset.Unix()},
+					"QueryUsers":  {Limit: 100, Remaining: 0},
+				},
+			}, nil
+		}), nil
+	}
+
+	var stdout bytes.Buffer
+	require.NoError(t, run(context.Background(), []string{"limits"}, &stdout))
+	assert.Equal(t, 1, options)
+	assert.Equal(t, []string{
+		"ENDPOINT     LIMIT  REMAINING  RESET",
+		"QueryUsers   100    0          -",
+		"SendMessage  1000   999        2024-05-01T12:00:00Z",
+	}, strings.Split(strings.TrimSpace(stdout.String()), "\n"))
+
+	stdout.Reset()
+	require.NoError(t, run(context.Background(), []string{"limits", "-platform", "web", "QueryUsers"}, &stdout))
+	assert.Equal(t, 2, options, "filtered by endpoint")
+	assert.Contains(t, stdout.String(), "QueryUsers  100    40         2024-05-01T12:00:00Z")
+
+	assert.ErrorContains(t, run(context.Background(), []string{"limits", "-platform", "desktop"}, &stdout), `unknown platform "desktop"`)
+}
@@ -0,0 +1,79 @@
+// Command streamrl inspects and steers the Stream rate limits and the
+// limiters of the running services:
+//
+//	streamrl limits [-platform server] [endpoint...]
+//	streamrl state [-admin url | -ratelimiterd addr | -redis addr | -etcd url] [endpoint...]
+//	streamrl pause [-admin url] endpoint...
+//	streamrl resume [-admin url] endpoint...
+//	streamrl events [-admin url] [endpoint...]
+//
+// limits fetches the current rate limits of the Stream app, with the
+// credentials of the STREAM_KEY and STREAM_SECRET environment variables. The
+// other commands talk to the rate_limiter.AdminHandler of a service, at the
+// URL of -admin or of the STREAMRL_ADMIN environment variable, while state
+// also reads the limiters of ratelimiterd, or the state shared through Redis
+// or etcd.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// envAdmin is the environment variable of the default -admin URL.
+const envAdmin = "STREAMRL_ADMIN"
+
+const usage = `usage: streamrl <command> [flags] [endpoint...]
+
+commands:
+  limits   fetch the current rate limits of the Stream app
+  state    show the state of the limiters of a service, or the shared state
+  pause    pause the limiters of endpoints
+  resume   resume the limiters of endpoints
+  events   tail the events of the limiters
+
+Run streamrl <command> -h for the flags of a command.
+`
+
+var commands = map[string]func(ctx context.Context, args []string, stdout io.Writer) error{
+	"limits": limits,
+	"state":  state,
+	"pause":  pause,
+	"resume": resume,
+	"events": events,
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "streamrl:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return flag.ErrHelp
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	return command(ctx, args[1:], stdout)
+}
+
+// adminFlag adds the -admin flag to flags.
+func adminFlag(flags *flag.FlagSet) *string {
+	return flags.String("admin", os.Getenv(envAdmin), "URL of the admin handler of the service, e.g. http://localhost:8080/debug/ratelimiter/")
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunUnknownCommand(t *testing.T) {
+	var stdout bytes.Buffer
+	assert.ErrorContains(t, run(context.Background(), []string{"unknown"}, &stdout), `unknown command "unknown"`)
+	assert.ErrorIs(t, run(context.Background(), nil, &stdout), flag.ErrHelp)
+	assert.Empty(t, stdout.String())
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/etcdstore"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/redisstore"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/remote"
+)
+
+// state prints the state of the limiters of a service, or the state shared by
+// the services through a Store, of the endpoints of args, all of them by
+// default.
+func state(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("state", flag.ContinueOnError)
+	admin := adminFlag(flags)
+	ratelimiterd := flags.String("ratelimiterd", "", "address of ratelimiterd, e.g. localhost:7070")
+	redisAddr := flags.String("redis", "", "address of the Redis server sharing the state, e.g. localhost:6379")
+	etcd := flags.String("etcd", "", "URL of the etcd sharing the state, e.g. http://localhost:2379")
+	keyPrefix := flags.String("key-prefix", "", "key prefix of the shared state, the default of the store when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	apis := flags.Args()
+
+	switch {
+	case *redisAddr != "":
+		var opts []redisstore.Option
+		if *keyPrefix != "" {
+			opts = append(opts, redisstore.WithKeyPrefix(*keyPrefix))
+		}
+		client := redis.NewClient(&redis.Options{Addr: *redisAddr})
+		defer client.Close()
+		return printStore(ctx, stdout, redisstore.New(client, opts...), apis)
+	case *etcd != "":
+		var opts []etcdstore.Option
+		if *keyPrefix != "" {
+			opts = append(opts, etcdstore.WithKeyPrefix(*keyPrefix))
+		}
+		return printStore(ctx, stdout, etcdstore.New(etcdstore.NewGateway(*etcd, nil), opts...), apis)
+	case *ratelimiterd != "":
+		conn, err := grpc.NewClient(*ratelimiterd, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		statuses, err := remote.NewClient(conn).Stats(ctx)
+		if err != nil {
+			return err
+		}
+		return printStatuses(stdout, statuses, apis)
+	case *admin != "":
+		var body struct {
+			Limiters []rate_limiter.LimiterStatus `json:"limiters"`
+		}
+		if err := adminRequest(ctx, "GET", *admin, "status", nil, &body); err != nil {
+			return err
+		}
+		return printStatuses(stdout, body.Limiters, apis)
+	}
+	return errors.New("one of -admin, -ratelimiterd, -redis or -etcd is required")
+}
+
+// printStatuses prints the statuses of apis, all of them when empty.
+func printStatuses(stdout io.Writer, statuses []rate_limiter.LimiterStatus, apis []string) error {
+	selected := map[string]bool{}
+	for _, api := range apis {
+		selected[api] = true
+	}
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "API\tREMAINING\tRESET\tBLOCKED\tPAUSED\tIN FLIGHT\tQUEUED\tCALLS 1M\tREJECTED 1M")
+	for _, st := range statuses {
+		if len(selected) > 0 && !selected[st.Api] {
+			continue
+		}
+		reset := "-"
+		if st.ResetAt != nil {
+			reset = formatTime(*st.ResetAt)
+		}
+		remaining := "-" // before the first response
+		if st.Remaining >= 0 {
+			remaining = strconv.FormatInt(st.Remaining, 10)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%t\t%d\t%d\t%d\t%d\n", st.Api, remaining, reset, st.Blocked, st.Paused,
+			st.InFlight, st.QueueDepth, st.Stats.LastMinute.Calls, st.Stats.LastMinute.Rejections)
+	}
+	return w.Flush()
+}
+
+// printStore prints the state of apis in store, of every known endpoint when
+// empty, skipping the unknown ones.
+func printStore(ctx context.Context, stdout io.Writer, store rate_limiter.Store, apis []string) error {
+	if len(apis) == 0 {
+		for _, endpoint := range rate_limiter.Endpoints() {
+			apis = append(apis, string(endpoint.Name))
+		}
+	}
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "API\tLIMIT\tREMAINING\tRESET\tBLOCKED UNTIL")
+	for _, api := range apis {
+		st, err := store.Load(ctx, api)
+		if err != nil {
+			return err
+		}
+		if st == (rate_limiter.State{}) {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n", api, st.Limit, st.Remaining, formatTime(st.Reset), formatTime(st.BlockedUntil))
+	}
+	return w.Flush()
+}
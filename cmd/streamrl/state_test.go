@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/redisstore"
+)
+
+func TestStateAdmin(t *testing.T) {
+	manager := rate_limiter.NewLimiterManager()
+	manager.For(rate_limiter.QueryUsers).Pause()
+	manager.For(rate_limiter.SendMessage)
+	server := httptest.NewServer(rate_limiter.AdminHandler(manager))
+	defer server.Close()
+
+	var stdout bytes.Buffer
+	require.NoError(t, run(context.Background(), []string{"state", "-admin", server.URL + "/debug/ratelimiter/", "QueryUsers"}, &stdout))
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, []string{"QueryUsers", "-", "-", "true", "true", "0", "0", "0", "0"}, strings.Fields(lines[1]))
+
+	t.Setenv(envAdmin, server.URL)
+	stdout.Reset()
+	require.NoError(t, run(context.Background(), []string{"state"}, &stdout))
+	assert.Len(t, strings.Split(strings.TrimSpace(stdout.String()), "\n"), 3)
+}
+
+func TestStateRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	reset := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := redisstore.New(client, redisstore.WithKeyPrefix("test:"))
+	require.NoError(t, store.Observe(context.Background(), "QueryUsers", rate_limiter.LimitInfo{Limit: 100, Remaining: 40, Reset: reset.Unix()}, reset.Add(-time.Minute)))
+
+	var stdout bytes.Buffer
+	require.NoError(t, run(context.Background(), []string{"state", "-redis", server.Addr(), "-key-prefix", "test:"}, &stdout))
+	assert.Equal(t, []string{
+		"API         LIMIT  REMAINING  RESET                 BLOCKED UNTIL",
+		"QueryUsers  100    40         2024-05-01T12:00:00Z  -",
+	}, strings.Split(strings.TrimSpace(stdout.String()), "\n"))
+
+	assert.ErrorContains(t, run(context.Background(), []string{"state"}, &stdout), "is required")
+}
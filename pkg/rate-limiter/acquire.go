@@ -0,0 +1,50 @@
+package rate_limiter
+
+import (
+	"context"
+	"sync"
+)
+
+// Acquire takes a slot as CallApiWithContext does before calling the api, for
+// operations made of several calls or of requests not going through the Stream
+// client. The returned release gives the slot back, reporting the rate limit
+// info of the latest response, nil when none was received; it must be called
+// once the operation is over. An exhausted quota blocks the api until the reset
+// as after a call.
+func (r *RateLimiter) Acquire(ctx context.Context) (release func(info *LimitInfo), err error) {
+	return r.acquireLease(ctx, false)
+}
+
+// TryAcquire is like Acquire, but takes the slot only if available right
+// away, as TryCallApi does, otherwise it returns an ErrRateLimited.
+func (r *RateLimiter) TryAcquire() (release func(info *LimitInfo), err error) {
+	return r.acquireLease(context.Background(), true)
+}
+
+func (r *RateLimiter) acquireLease(ctx context.Context, try bool) (func(info *LimitInfo), error) {
+	acquired := make(chan struct{})
+	released := make(chan *LimitInfo)
+	done := make(chan error, 1)
+	go func() {
+		done <- r.run(ctx, Invocation{Try: try, call: limitedFunc(func() (LimitInfo, bool, error) {
+			close(acquired)
+			info := <-released
+			if info == nil {
+				return LimitInfo{}, false, nil
+			}
+			return *info, true, nil
+		})})
+	}()
+	select {
+	case <-acquired:
+	case err := <-done:
+		return nil, err
+	}
+	var once sync.Once
+	return func(info *LimitInfo) {
+		once.Do(func() {
+			released <- info
+			<-done
+		})
+	}, nil
+}
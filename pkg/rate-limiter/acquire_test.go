@@ -0,0 +1,67 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquire(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	release, err := rLimit.Acquire(context.Background())
+	require.NoError(t, err)
+
+	// the single slot is taken until released
+	var rateLimited ErrRateLimited
+	assert.ErrorAs(t, rLimit.TryCallApi(nil), &rateLimited)
+
+	reset := time.Now().Add(time.Minute).Unix()
+	release(&LimitInfo{Limit: 10, Remaining: 0, Reset: reset})
+	release(nil) // released once
+	assert.True(t, rLimit.IsBlocked())
+	assert.Equal(t, int64(0), rLimit.Remaining())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = rLimit.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestAcquireReleaseWithoutInfo(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	release, err := rLimit.Acquire(context.Background())
+	require.NoError(t, err)
+	release(nil)
+	assert.False(t, rLimit.IsBlocked())
+
+	release, err = rLimit.Acquire(context.Background())
+	require.NoError(t, err)
+	release(&LimitInfo{Remaining: 5, Reset: time.Now().Add(time.Minute).Unix()})
+	assert.Equal(t, int64(5), rLimit.Remaining())
+}
+
+func TestAcquireClosed(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	require.NoError(t, rLimit.Close(context.Background()))
+	_, err := rLimit.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestTryAcquire(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	release, err := rLimit.TryAcquire()
+	require.NoError(t, err)
+
+	var rateLimited ErrRateLimited
+	_, err = rLimit.TryAcquire()
+	assert.ErrorAs(t, err, &rateLimited, "slot taken")
+
+	reset := time.Now().Add(time.Minute)
+	release(&LimitInfo{Limit: 10, Remaining: 0, Reset: reset.Unix()})
+	_, err = rLimit.TryAcquire()
+	require.ErrorAs(t, err, &rateLimited, "blocked")
+	assert.Equal(t, reset.Unix(), rateLimited.ResetAt.Unix())
+}
@@ -0,0 +1,138 @@
+package rate_limiter
+
+import (
+	"math"
+	"sync"
+)
+
+// aimd adapts the concurrency of a limiter between 1 and its slots: additive
+// increase after each successful call, multiplicative decrease when Stream
+// answers with a 429 or reports the quota exhausted. The concurrency is lowered
+// by parking tokens in the token channel, as if held by calls in flight.
+type aimd struct {
+	mu     sync.Mutex
+	slots  int
+	limit  float64
+	parked int
+}
+
+func newAIMD(slots int) *aimd {
+	return &aimd{slots: slots, limit: float64(slots)}
+}
+
+// increase raises the limit by one slot over limit successful calls.
+func (a *aimd) increase(token chan struct{}, release func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.limit = math.Min(a.limit+1/a.limit, float64(a.slots))
+	a.rebalance(token, release)
+}
+
+// decrease halves the limit, down to a single slot.
+func (a *aimd) decrease(token chan struct{}, release func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.limit = math.Max(a.limit/2, 1)
+	a.rebalance(token, release)
+}
+
+// resize changes the slots to n, lowering the limit down to n.
+func (a *aimd) resize(n int, token chan struct{}, release func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.slots = n
+	a.limit = math.Min(a.limit, float64(n))
+	a.rebalance(token, release)
+}
+
+// rebalance parks or releases tokens so that limit slots are left to the
+// calls. Tokens held by calls in flight are parked once released.
+func (a *aimd) rebalance(token chan struct{}, release func()) {
+	target := a.slots - int(a.limit)
+	for a.parked > target {
+		release()
+		a.parked--
+	}
+	for a.parked < target {
+		select {
+		case token <- struct{}{}:
+			a.parked++
+		default:
+			return
+		}
+	}
+}
+
+// absorb parks the token of a call being released when fewer tokens than
+// needed could be parked so far, reporting whether the token is now parked.
+func (a *aimd) absorb() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.parked < a.slots-int(a.limit) {
+		a.parked++
+		return true
+	}
+	return false
+}
+
+// getParked returns the number of parked tokens.
+func (a *aimd) getParked() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.parked
+}
+
+// concurrency returns the number of calls currently allowed in flight.
+func (a *aimd) concurrency() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return int(a.limit)
+}
+
+// WithAdaptiveConcurrency tunes the concurrency of the limiter against the
+// effective limit applied by Stream: starting from the WithMaxConcurrency
+// slots, it is halved on each 429 or exhausted quota and raised back by one
+// slot every as many successful calls as the current concurrency.
+func WithAdaptiveConcurrency() Option {
+	return func(r *RateLimiter) {
+		// sized by NewRateLimiter once the slots are known
+		r.aimd = newAIMD(1)
+	}
+}
+
+// Concurrency returns how many calls may currently be in flight at the same
+// time, lower than the WithMaxConcurrency slots while adapting or ramping up,
+// see WithSlowStart.
+func (r *RateLimiter) Concurrency() int {
+	concurrency := max(cap(r.tokens()), 1)
+	if r.aimd != nil {
+		concurrency = r.aimd.concurrency()
+	}
+	if r.slowStart != nil {
+		concurrency = min(concurrency, r.slowStart.limit(r.getClock().Now()))
+	}
+	return concurrency
+}
+
+// release gives back the token of a call, to the first queued caller if any,
+// unless the adaptive concurrency keeps it parked.
+func (r *RateLimiter) release() {
+	if r.aimd != nil && r.aimd.absorb() {
+		return
+	}
+	r.queue.release(&r.token)
+}
+
+// adapt updates the adaptive concurrency after a call, failed with a 429 or
+// reporting the quota exhausted when overloaded.
+func (r *RateLimiter) adapt(overloaded bool) {
+	if r.aimd == nil {
+		return
+	}
+	release := func() { r.queue.release(&r.token) }
+	if overloaded {
+		r.aimd.decrease(r.tokens(), release)
+	} else {
+		r.aimd.increase(r.tokens(), release)
+	}
+}
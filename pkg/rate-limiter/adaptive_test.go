@@ -0,0 +1,76 @@
+package rate_limiter
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestAdaptiveConcurrency(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithAdaptiveConcurrency(), WithMaxConcurrency(4))
+	assert.Equal(t, 4, rLimit.Concurrency())
+
+	assert.Error(t, rLimit.TryCallApi(func() (resp *stream.Response, err error) {
+		return nil, stream.Error{StatusCode: http.StatusTooManyRequests, RateLimit: &stream.RateLimitInfo{Reset: 1001}}
+	}))
+	assert.Equal(t, 2, rLimit.Concurrency())
+	assert.Equal(t, 2, len(rLimit.tokens()), "two slots parked")
+
+	blockUntil(t, clock, 1)
+	clock.Advance(time.Second)
+	assert.Eventually(t, func() bool { return !rLimit.IsBlocked() }, time.Second, time.Millisecond)
+
+	// one more slot after about as many successes as the concurrency
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, rLimit.TryCallApi(func() (resp *stream.Response, err error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 100, Remaining: 50, Reset: 1060}}, nil
+		}))
+	}
+	assert.Equal(t, 3, rLimit.Concurrency())
+	assert.Equal(t, 1, len(rLimit.tokens()))
+}
+
+func TestAdaptiveConcurrencyParksReleasedTokens(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithAdaptiveConcurrency(), WithMaxConcurrency(4))
+
+	var wg sync.WaitGroup
+	inFlight := make(chan struct{}, 4)
+	release := make(chan error)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+				inFlight <- struct{}{}
+				return nil, <-release
+			})
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		<-inFlight
+	}
+	// every slot is taken when the 429 halves the concurrency
+	release <- stream.Error{StatusCode: http.StatusTooManyRequests}
+	assert.Eventually(t, func() bool { return rLimit.Concurrency() == 2 }, time.Second, time.Millisecond)
+	for i := 0; i < 3; i++ {
+		release <- assert.AnError
+	}
+	wg.Wait()
+
+	assert.Equal(t, 2, rLimit.Concurrency())
+	assert.Equal(t, 2, len(rLimit.tokens()), "released tokens were parked")
+}
+
+func TestConcurrencyWithoutAdaptation(t *testing.T) {
+	assert.Equal(t, 3, NewRateLimiter(QueryUsers, WithMaxConcurrency(3)).Concurrency())
+	assert.Equal(t, 1, (&RateLimiter{}).Concurrency())
+}
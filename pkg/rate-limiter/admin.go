@@ -0,0 +1,148 @@
+package rate_limiter
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"time"
+)
+
+// adminPoll is how often the events stream of AdminHandler looks for the
+// limiters created meanwhile.
+const adminPoll = time.Second
+
+// EventStatus is the JSON form of an Event, as streamed by AdminHandler.
+type EventStatus struct {
+	Type     string            `json:"type"`
+	Api      string            `json:"api"`
+	At       time.Time         `json:"at"`
+	ResumeAt *time.Time        `json:"resume_at,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Pending  int               `json:"pending,omitempty"`
+}
+
+func eventStatus(event Event) EventStatus {
+	status := EventStatus{Type: event.Type.String(), Api: event.Api, At: event.At, Tags: event.Tags, Pending: event.Pending}
+	if !event.ResumeAt.IsZero() {
+		status.ResumeAt = &event.ResumeAt
+	}
+	if event.Err != nil {
+		status.Error = event.Err.Error()
+	}
+	return status
+}
+
+// AdminHandler returns an http.Handler letting the operators inspect and
+// steer the limiters of m in a running process, e.g. with the streamrl
+// command, to mount on an internal mux:
+//
+//	mux.Handle("/debug/ratelimiter/", rate_limiter.AdminHandler(manager))
+//
+// The last element of the path selects the action:
+//
+//   - GET status serves the Status of the limiters, as StatusHandler;
+//   - POST pause and POST resume Pause and Resume the limiters of the api
+//     query parameters, answering with their Status;
+//   - GET events streams the events of the limiters as JSON lines, see
+//     EventStatus, until the client disconnects.
+func AdminHandler(m *LimiterManager) http.Handler {
+	status := StatusHandler(m)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch action := path.Base(req.URL.Path); action {
+		case "status":
+			status.ServeHTTP(w, req)
+		case "pause", "resume":
+			if req.Method != http.MethodPost {
+				w.Header().Set("Allow", "POST")
+				http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+				return
+			}
+			apis := req.URL.Query()["api"]
+			if len(apis) == 0 {
+				http.Error(w, "missing api", http.StatusBadRequest)
+				return
+			}
+			statuses := make([]LimiterStatus, 0, len(apis))
+			for _, api := range apis {
+				r := m.For(GetStreamApiName(api))
+				if action == "pause" {
+					r.Pause()
+				} else {
+					r.Resume()
+				}
+				statuses = append(statuses, r.Status())
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(struct {
+				Limiters []LimiterStatus `json:"limiters"`
+			}{statuses})
+		case "events":
+			streamEvents(w, req, m)
+		default:
+			http.NotFound(w, req)
+		}
+	})
+}
+
+// streamEvents writes the events of the limiters of m, the ones of the api
+// query parameters only if any, until the client disconnects.
+func streamEvents(w http.ResponseWriter, req *http.Request, m *LimiterManager) {
+	apis := map[string]bool{}
+	for _, api := range req.URL.Query()["api"] {
+		apis[api] = true
+	}
+	events := make(chan Event, eventBuffer)
+	done := make(chan struct{})
+	subscribed := map[*RateLimiter]<-chan Event{}
+	defer func() {
+		close(done)
+		for r, ch := range subscribed {
+			r.unsubscribe(ch)
+		}
+	}()
+	subscribe := func() {
+		for _, r := range m.limiters.values() {
+			if _, ok := subscribed[r]; ok || (len(apis) > 0 && !apis[r.apiName]) {
+				continue
+			}
+			ch := r.Events()
+			subscribed[r] = ch
+			go func() {
+				for event := range ch {
+					select {
+					case events <- event:
+					case <-done:
+					}
+				}
+			}()
+		}
+	}
+
+	// subscribed before answering, not to miss the events following it
+	subscribe()
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	ticker := time.NewTicker(adminPoll)
+	defer ticker.Stop()
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case event := <-events:
+			if encoder.Encode(eventStatus(event)) != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		case <-ticker.C:
+			subscribe()
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
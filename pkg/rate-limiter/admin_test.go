@@ -0,0 +1,105 @@
+package rate_limiter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminHandlerPause(t *testing.T) {
+	manager := NewLimiterManager()
+	server := httptest.NewServer(AdminHandler(manager))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/debug/ratelimiter/pause?api=QueryUsers&api=QueryChannels", "", nil)
+	require.NoError(t, err)
+	var body struct {
+		Limiters []LimiterStatus `json:"limiters"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, body.Limiters, 2)
+	assert.True(t, body.Limiters[0].Paused)
+	assert.True(t, manager.For(QueryUsers).Paused())
+	assert.True(t, manager.For(QueryChannels).Paused())
+
+	resp, err = http.Post(server.URL+"/debug/ratelimiter/resume?api=QueryUsers", "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.False(t, manager.For(QueryUsers).Paused())
+	assert.True(t, manager.For(QueryChannels).Paused())
+
+	resp, err = http.Get(server.URL + "/debug/ratelimiter/status")
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	resp.Body.Close()
+	require.Len(t, body.Limiters, 2)
+	assert.Equal(t, string(QueryChannels), body.Limiters[0].Api)
+	assert.True(t, body.Limiters[0].Paused)
+	assert.False(t, body.Limiters[1].Paused)
+
+	for path, code := range map[string]int{
+		"/debug/ratelimiter/pause":   http.StatusBadRequest,
+		"/debug/ratelimiter/unknown": http.StatusNotFound,
+	} {
+		resp, err = http.Post(server.URL+path, "", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, code, resp.StatusCode, path)
+	}
+	resp, err = http.Get(server.URL + "/debug/ratelimiter/pause?api=QueryUsers")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestAdminHandlerEvents(t *testing.T) {
+	manager := NewLimiterManager()
+	users := manager.For(QueryUsers)
+	server := httptest.NewServer(AdminHandler(manager))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events?api=QueryUsers", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+	lines := bufio.NewScanner(resp.Body)
+
+	manager.For(QueryChannels).Pause() // filtered out
+	reset := time.Now().Add(time.Minute).Truncate(time.Second)
+	require.NoError(t, users.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: reset.Unix()}}, nil
+	}))
+
+	var events []EventStatus
+	for len(events) < 2 && lines.Scan() {
+		var event EventStatus
+		require.NoError(t, json.Unmarshal(lines.Bytes(), &event))
+		events = append(events, event)
+	}
+	require.Len(t, events, 2)
+	assert.Equal(t, "CallAdmitted", events[0].Type)
+	assert.Equal(t, string(QueryUsers), events[0].Api)
+	assert.Equal(t, "Blocked", events[1].Type)
+	require.NotNil(t, events[1].ResumeAt)
+	assert.True(t, reset.Equal(*events[1].ResumeAt))
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		users.events.mu.Lock()
+		defer users.events.mu.Unlock()
+		return len(users.events.subs) == 0
+	}, time.Second, time.Millisecond, "unsubscribed once disconnected")
+}
@@ -0,0 +1,124 @@
+package rate_limiter
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// notifyTimeout bounds how long a Notifier may take to deliver an alert.
+const notifyTimeout = 10 * time.Second
+
+// QuotaAlert reports the quota of an api used beyond a threshold of
+// WithUtilizationAlerts.
+type QuotaAlert struct {
+	Api string
+	// Threshold is the highest threshold crossed, e.g. 0.8.
+	Threshold float64
+	// Utilization is the share of the limit used, e.g. 0.83.
+	Utilization float64
+	Limit       int64
+	Remaining   int64
+	ResetAt     time.Time
+	At          time.Time
+}
+
+// Notifier delivers the alerts of WithUtilizationAlerts, e.g. to on-call. The
+// alerting package implements it for webhooks and Slack. Implementations must
+// be safe for concurrent use.
+type Notifier interface {
+	Notify(ctx context.Context, alert QuotaAlert) error
+}
+
+// NotifierFunc adapts a function to a Notifier.
+type NotifierFunc func(ctx context.Context, alert QuotaAlert) error
+
+func (f NotifierFunc) Notify(ctx context.Context, alert QuotaAlert) error {
+	return f(ctx, alert)
+}
+
+// WithUtilizationAlerts notifies notifier when the share of the limit used in
+// a window, as reported by the responses, crosses one of thresholds, e.g. 0.8
+// and 0.95, so that on-call is warned before the limiter starts blocking. Each
+// threshold fires once per window, only the highest one when several are
+// crossed at once. Alerts are delivered in the background, their failures
+// being logged. Thresholds outside (0, 1] are ignored.
+func WithUtilizationAlerts(notifier Notifier, thresholds ...float64) Option {
+	return func(r *RateLimiter) {
+		var valid []float64
+		for _, threshold := range thresholds {
+			if threshold > 0 && threshold <= 1 {
+				valid = append(valid, threshold)
+			}
+		}
+		if notifier == nil || len(valid) == 0 {
+			return
+		}
+		sort.Float64s(valid)
+		r.alerts = &utilizationAlerts{notifier: notifier, thresholds: valid}
+	}
+}
+
+// utilizationAlerts tracks the thresholds crossed in the current window.
+type utilizationAlerts struct {
+	notifier   Notifier
+	thresholds []float64 // sorted
+
+	mu    sync.Mutex
+	reset int64 // of the current window
+	fired int   // thresholds crossed in the current window
+}
+
+// crossed returns the highest threshold newly crossed by info, reporting false
+// when none is.
+func (a *utilizationAlerts) crossed(info *LimitInfo) (float64, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if info.Reset != a.reset {
+		a.reset, a.fired = info.Reset, 0
+	}
+	utilization := float64(info.Limit-info.Remaining) / float64(info.Limit)
+	crossed := sort.Search(len(a.thresholds), func(i int) bool {
+		return a.thresholds[i] > utilization
+	})
+	if crossed <= a.fired {
+		return 0, false
+	}
+	a.fired = crossed
+	return a.thresholds[crossed-1], true
+}
+
+// checkUtilization notifies the threshold of WithUtilizationAlerts crossed by
+// info, if any.
+func (r *RateLimiter) checkUtilization(ctx context.Context, logger Logger, info *LimitInfo) {
+	if r.alerts == nil || info == nil || info.Limit <= 0 {
+		return
+	}
+	threshold, ok := r.alerts.crossed(info)
+	if !ok {
+		return
+	}
+	alert := QuotaAlert{
+		Api:         r.apiName,
+		Threshold:   threshold,
+		Utilization: float64(info.Limit-info.Remaining) / float64(info.Limit),
+		Limit:       info.Limit,
+		Remaining:   info.Remaining,
+		ResetAt:     r.localReset(info.Reset),
+		At:          r.getClock().Now(),
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		defer cancel()
+		if err := r.alerts.notifier.Notify(ctx, alert); err != nil {
+			logAttrs(ctx, logger, slog.LevelWarn, "alert not delivered", []slog.Attr{
+				slog.String("api", r.apiName),
+				slog.Float64("threshold", threshold),
+				slog.String("error", err.Error()),
+			}, "Alert of %s at %.0f%% not delivered: %v\n", r.apiName, threshold*100, err)
+		}
+	}()
+}
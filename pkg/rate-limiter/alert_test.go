@@ -0,0 +1,89 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+// nextAlert returns the next alert of alerts, failing the test after a second.
+func nextAlert(t *testing.T, alerts <-chan QuotaAlert) QuotaAlert {
+	t.Helper()
+	select {
+	case alert := <-alerts:
+		return alert
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an alert")
+		return QuotaAlert{}
+	}
+}
+
+// alertsTo returns a Notifier sending the alerts to alerts.
+func alertsTo(alerts chan<- QuotaAlert) Notifier {
+	return NotifierFunc(func(_ context.Context, alert QuotaAlert) error {
+		alerts <- alert
+		return nil
+	})
+}
+
+func TestUtilizationAlerts(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	alerts := make(chan QuotaAlert, 10)
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithUtilizationAlerts(alertsTo(alerts), 0.95, 0.8, 1.5, 0))
+	call := func(remaining, reset int64) {
+		t.Helper()
+		require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 100, Remaining: remaining, Reset: reset}}, nil
+		}))
+	}
+
+	call(50, 1060)
+	call(15, 1060)
+	assert.Equal(t, QuotaAlert{
+		Api:         string(QueryUsers),
+		Threshold:   0.8,
+		Utilization: 0.85,
+		Limit:       100,
+		Remaining:   15,
+		ResetAt:     time.Unix(1060, 0),
+		At:          time.Unix(1000, 0),
+	}, nextAlert(t, alerts))
+
+	call(10, 1060)
+	call(3, 1060)
+	assert.Equal(t, 0.95, nextAlert(t, alerts).Threshold)
+	call(1, 1060)
+
+	// a new window fires again, only the highest threshold crossed
+	call(2, 1120)
+	alert := nextAlert(t, alerts)
+	assert.Equal(t, 0.95, alert.Threshold)
+	assert.Equal(t, time.Unix(1120, 0), alert.ResetAt)
+
+	assert.Empty(t, alerts, "each threshold fires once per window, 1.5 and 0 are ignored")
+}
+
+func TestUtilizationAlertsNotDelivered(t *testing.T) {
+	alerts := make(chan QuotaAlert, 1)
+	rLimit := NewRateLimiter(QueryUsers, WithUtilizationAlerts(NotifierFunc(func(_ context.Context, alert QuotaAlert) error {
+		alerts <- alert
+		return errors.New("unreachable")
+	}), 0.5))
+
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 5, Reset: time.Now().Add(time.Minute).Unix()}}, nil
+	}))
+	assert.Equal(t, 0.5, nextAlert(t, alerts).Threshold)
+}
+
+func TestUtilizationAlertsWithoutThresholds(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithUtilizationAlerts(alertsTo(nil), 2))
+	assert.Nil(t, rLimit.alerts)
+}
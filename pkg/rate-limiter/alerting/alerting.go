@@ -0,0 +1,91 @@
+// Package alerting delivers the quota alerts of
+// rate_limiter.WithUtilizationAlerts to a generic webhook or to Slack.
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// Webhook posts each alert as a JSON object to URL.
+type Webhook struct {
+	URL string
+	// Client performs the requests, http.DefaultClient when nil.
+	Client *http.Client
+}
+
+var _ rate_limiter.Notifier = (*Webhook)(nil)
+
+// webhookPayload is the JSON object posted by Webhook.
+type webhookPayload struct {
+	Api         string    `json:"api"`
+	Threshold   float64   `json:"threshold"`
+	Utilization float64   `json:"utilization"`
+	Limit       int64     `json:"limit"`
+	Remaining   int64     `json:"remaining"`
+	ResetAt     time.Time `json:"reset_at"`
+	At          time.Time `json:"at"`
+}
+
+func (w *Webhook) Notify(ctx context.Context, alert rate_limiter.QuotaAlert) error {
+	return post(ctx, w.Client, w.URL, webhookPayload{
+		Api:         alert.Api,
+		Threshold:   alert.Threshold,
+		Utilization: alert.Utilization,
+		Limit:       alert.Limit,
+		Remaining:   alert.Remaining,
+		ResetAt:     alert.ResetAt.UTC(),
+		At:          alert.At.UTC(),
+	})
+}
+
+// Slack posts each alert as a message to an incoming webhook of Slack.
+type Slack struct {
+	WebhookURL string
+	// Client performs the requests, http.DefaultClient when nil.
+	Client *http.Client
+}
+
+var _ rate_limiter.Notifier = (*Slack)(nil)
+
+func (s *Slack) Notify(ctx context.Context, alert rate_limiter.QuotaAlert) error {
+	return post(ctx, s.Client, s.WebhookURL, struct {
+		Text string `json:"text"`
+	}{Text: Message(alert)})
+}
+
+// Message returns the text of the Slack message of alert.
+func Message(alert rate_limiter.QuotaAlert) string {
+	return fmt.Sprintf(":warning: Stream quota of %s at %.0f%% (threshold %.0f%%): %d/%d calls left until %s",
+		alert.Api, alert.Utilization*100, alert.Threshold*100, alert.Remaining, alert.Limit, alert.ResetAt.UTC().Format(time.RFC3339))
+}
+
+func post(ctx context.Context, client *http.Client, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alerting: %s answered %s", url, resp.Status)
+	}
+	return nil
+}
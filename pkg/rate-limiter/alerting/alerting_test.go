@@ -0,0 +1,73 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+var testAlert = rate_limiter.QuotaAlert{
+	Api:         string(rate_limiter.QueryUsers),
+	Threshold:   0.8,
+	Utilization: 0.85,
+	Limit:       100,
+	Remaining:   15,
+	ResetAt:     time.Unix(1060, 0),
+	At:          time.Unix(1000, 0),
+}
+
+// receiver returns a server decoding the bodies posted to it into received.
+func receiver(t *testing.T, status int, received chan<- map[string]any) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		received <- body
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWebhook(t *testing.T) {
+	received := make(chan map[string]any, 1)
+	server := receiver(t, http.StatusOK, received)
+
+	require.NoError(t, (&Webhook{URL: server.URL}).Notify(context.Background(), testAlert))
+	assert.Equal(t, map[string]any{
+		"api":         "QueryUsers",
+		"threshold":   0.8,
+		"utilization": 0.85,
+		"limit":       float64(100),
+		"remaining":   float64(15),
+		"reset_at":    "1970-01-01T00:17:40Z",
+		"at":          "1970-01-01T00:16:40Z",
+	}, <-received)
+}
+
+func TestSlack(t *testing.T) {
+	received := make(chan map[string]any, 1)
+	server := receiver(t, http.StatusOK, received)
+
+	require.NoError(t, (&Slack{WebhookURL: server.URL}).Notify(context.Background(), testAlert))
+	assert.Equal(t, map[string]any{
+		"text": ":warning: Stream quota of QueryUsers at 85% (threshold 80%): 15/100 calls left until 1970-01-01T00:17:40Z",
+	}, <-received)
+}
+
+func TestNotifyFailure(t *testing.T) {
+	received := make(chan map[string]any, 1)
+	server := receiver(t, http.StatusInternalServerError, received)
+
+	err := (&Webhook{URL: server.URL}).Notify(context.Background(), testAlert)
+	assert.ErrorContains(t, err, "500 Internal Server Error")
+}
@@ -0,0 +1,46 @@
+package rate_limiter
+
+import (
+	"context"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+)
+
+// Future is the pending result of a call started by CallAsync.
+type Future struct {
+	done chan struct{}
+	resp *stream.Response
+	err  error
+}
+
+// CallAsync starts apiCall through the limiter in the background, as
+// CallApiWithContext does with ctx, and returns its Future right away.
+func (r *RateLimiter) CallAsync(ctx context.Context, apiCall GetStreamApiCaller) *Future {
+	f := &Future{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		f.err = r.CallApiWithContext(ctx, func() (*stream.Response, error) {
+			resp, err := apiCall()
+			f.resp = resp
+			return resp, err
+		})
+	}()
+	return f
+}
+
+// Done returns a channel closed once the call is over.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits until the call is over and returns its response, nil when the
+// api was not called, and error. It gives up with the ctx error once ctx is
+// done, leaving the call running.
+func (f *Future) Wait(ctx context.Context) (*stream.Response, error) {
+	select {
+	case <-f.done:
+		return f.resp, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,41 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallAsync(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	release := make(chan struct{})
+	future := rLimit.CallAsync(context.Background(), func() (*stream.Response, error) {
+		<-release
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 7}}, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := future.Wait(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	<-future.Done()
+	resp, err := future.Wait(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), resp.RateLimitInfo.Remaining)
+}
+
+func TestCallAsyncError(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	future := rLimit.CallAsync(context.Background(), func() (*stream.Response, error) {
+		return nil, assert.AnError
+	})
+	resp, err := future.Wait(context.Background())
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Nil(t, resp)
+}
@@ -0,0 +1,139 @@
+package rate_limiter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// AuditDecision is how the limiter handled a call, see AuditRecord.
+type AuditDecision int
+
+const (
+	// Admitted calls called the api without waiting.
+	Admitted AuditDecision = iota
+	// Delayed calls called the api once they waited for a slot, a reset, their
+	// Strategy or room among the calls in flight.
+	Delayed
+	// RejectedCall calls were turned down without calling the api, e.g. with
+	// an ErrRateLimited or an ErrWaitTimeout.
+	RejectedCall
+)
+
+func (d AuditDecision) String() string {
+	switch d {
+	case Admitted:
+		return "admitted"
+	case Delayed:
+		return "delayed"
+	case RejectedCall:
+		return "rejected"
+	default:
+		return fmt.Sprintf("AuditDecision(%d)", int(d))
+	}
+}
+
+// AuditRecord describes a call handled by a limiter, see WithAuditSink.
+type AuditRecord struct {
+	// At is when the call entered the limiter.
+	At  time.Time
+	Api string
+	// Tenant is the tenant of the limiter, see LimiterManager.ForTenant.
+	Tenant   string
+	Decision AuditDecision
+	// Wait is how long the call waited to be admitted, over all its attempts.
+	Wait time.Duration
+	// Blocked reports whether the call waited for the end of a block.
+	Blocked bool
+	// Attempts is how many times the api was called.
+	Attempts int
+	// Duration is how long the call took in the limiter, waits included.
+	Duration time.Duration
+	// Err is the error returned by the call, nil on success.
+	Err  error
+	Tags map[string]string
+}
+
+// AuditSink receives an AuditRecord per call of a limiter, see WithAuditSink,
+// e.g. the JSON-lines files of the auditlog package. Audit is called
+// synchronously as the call returns, and must be safe for concurrent use.
+type AuditSink interface {
+	Audit(record AuditRecord) error
+}
+
+// WithAuditSink records every call of the limiter, admitted, delayed or
+// rejected, into sink, e.g. to keep a record of why calls were delayed. The
+// calls are recorded by the limiter they were made on: the levels above it,
+// see WithGlobalLimiter, do not record them again. Sink failures are logged.
+func WithAuditSink(sink AuditSink) Option {
+	return func(r *RateLimiter) {
+		if sink != nil {
+			r.audit = sink
+		}
+	}
+}
+
+type levelKey struct{}
+
+// withLevel marks ctx as the context of a call of a child level.
+func withLevel(ctx context.Context) context.Context {
+	return context.WithValue(ctx, levelKey{}, true)
+}
+
+func fromLevel(ctx context.Context) bool {
+	child, _ := ctx.Value(levelKey{}).(bool)
+	return child
+}
+
+// auditing is the built-in interceptor of WithAuditSink, recording the calls
+// made on r into its sink.
+func (r *RateLimiter) auditing(next Caller) Caller {
+	return func(ctx context.Context, inv Invocation) error {
+		if fromLevel(ctx) {
+			return next(ctx, inv)
+		}
+		recorder := callRecorderFrom(ctx)
+		if recorder == nil {
+			recorder = &callRecorder{}
+			ctx = context.WithValue(ctx, callInfoKey{}, recorder)
+		}
+		attempts := 0
+		call := inv.call
+		inv.call = limitedFunc(func() (LimitInfo, bool, error) {
+			attempts++
+			return call.call()
+		})
+
+		clock := r.getClock()
+		start := clock.Now()
+		err := next(ctx, inv)
+		recorder.mu.Lock()
+		record := AuditRecord{
+			At:       start,
+			Api:      r.apiName,
+			Tenant:   r.tenant,
+			Wait:     recorder.info.QueuedFor,
+			Blocked:  recorder.info.Blocked,
+			Attempts: attempts,
+			Duration: clock.Now().Sub(start),
+			Err:      err,
+			Tags:     inv.Options.Tags,
+		}
+		delayed := recorder.info.Delayed
+		recorder.mu.Unlock()
+		switch {
+		case attempts == 0:
+			record.Decision = RejectedCall
+		case delayed:
+			record.Decision = Delayed
+		}
+		if auditErr := r.audit.Audit(record); auditErr != nil {
+			logAttrs(ctx, inv.logger, slog.LevelWarn, "audit failed", []slog.Attr{
+				slog.String("api", r.apiName),
+				slog.String("error", auditErr.Error()),
+			}, "Audit of a call of %s failed: %v\n", r.apiName, auditErr)
+		}
+		return err
+	}
+}
@@ -0,0 +1,132 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditRecorder is an AuditSink keeping the records audited.
+type auditRecorder struct {
+	mu      sync.Mutex
+	records []AuditRecord
+	err     error
+}
+
+func (a *auditRecorder) Audit(record AuditRecord) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.records = append(a.records, record)
+	return a.err
+}
+
+func (a *auditRecorder) get() []AuditRecord {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]AuditRecord(nil), a.records...)
+}
+
+func TestAuditAdmitted(t *testing.T) {
+	sink := &auditRecorder{}
+	rLimit := NewRateLimiter(QueryUsers, WithAuditSink(sink))
+
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), okCall, WithTags(map[string]string{"job": "sync"})))
+	errFailed := errors.New("failed")
+	require.ErrorIs(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return nil, errFailed
+	}), errFailed)
+
+	records := sink.get()
+	require.Len(t, records, 2)
+	assert.Equal(t, string(QueryUsers), records[0].Api)
+	assert.Equal(t, Admitted, records[0].Decision)
+	assert.Equal(t, 1, records[0].Attempts)
+	assert.NoError(t, records[0].Err)
+	assert.Equal(t, map[string]string{"job": "sync"}, records[0].Tags)
+	assert.False(t, records[0].At.IsZero())
+	assert.Equal(t, Admitted, records[1].Decision)
+	assert.ErrorIs(t, records[1].Err, errFailed)
+}
+
+func TestAuditDelayedAndRejected(t *testing.T) {
+	sink := &auditRecorder{}
+	rLimit := NewRateLimiter(QueryUsers, WithAuditSink(sink))
+
+	release := holdSlot(t, rLimit)
+	var limited ErrRateLimited
+	require.ErrorAs(t, rLimit.TryCallApi(okCall), &limited)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), okCall)
+	}()
+	require.Eventually(t, func() bool { return queued(rLimit) == 1 }, time.Second, time.Millisecond)
+	release()
+	require.NoError(t, receive(t, done))
+
+	records := sink.get()
+	require.Len(t, records, 3)
+	assert.Equal(t, RejectedCall, records[0].Decision)
+	assert.Equal(t, 0, records[0].Attempts)
+	assert.ErrorAs(t, records[0].Err, &limited)
+	assert.Equal(t, Admitted, records[1].Decision, "the call holding the slot")
+	assert.Equal(t, Delayed, records[2].Decision)
+	assert.Equal(t, 1, records[2].Attempts)
+	assert.Positive(t, records[2].Wait)
+}
+
+func TestAuditRetries(t *testing.T) {
+	sink := &auditRecorder{}
+	clock := &instantClock{now: time.Unix(1000, 0)}
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithAuditSink(sink),
+		WithRetry(RetryPolicy{MaxAttempts: 3, Backoff: ConstantBackoff(time.Second)}))
+
+	attempts := 0
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		if attempts++; attempts < 3 {
+			return nil, stream.Error{StatusCode: 503}
+		}
+		return &stream.Response{}, nil
+	}))
+	records := sink.get()
+	require.Len(t, records, 1)
+	assert.Equal(t, 3, records[0].Attempts)
+	assert.NoError(t, records[0].Err)
+	assert.Equal(t, 2*time.Second, records[0].Duration)
+}
+
+func TestAuditTenantOnce(t *testing.T) {
+	sink := &auditRecorder{}
+	manager := NewLimiterManager(WithAuditSink(sink), WithGlobalLimiter(WithAuditSink(sink)))
+
+	require.NoError(t, manager.ForTenant("acme", QueryUsers).CallApiWithContext(context.Background(), okCall))
+	records := sink.get()
+	require.Len(t, records, 1, "audited once through the levels")
+	assert.Equal(t, "acme", records[0].Tenant)
+	assert.Equal(t, string(QueryUsers), records[0].Api)
+
+	require.NoError(t, manager.For(QueryChannels).TryCallApi(okCall))
+	records = sink.get()
+	require.Len(t, records, 2)
+	assert.Empty(t, records[1].Tenant)
+}
+
+func TestAuditSinkFailureIsLogged(t *testing.T) {
+	sink := &auditRecorder{err: errors.New("disk full")}
+	rLimit := NewRateLimiter(QueryUsers, WithAuditSink(sink))
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), okCall), "the call is not failed")
+	assert.Len(t, sink.get(), 1)
+}
+
+func TestAuditDecisionString(t *testing.T) {
+	assert.Equal(t, "admitted", Admitted.String())
+	assert.Equal(t, "delayed", Delayed.String())
+	assert.Equal(t, "rejected", RejectedCall.String())
+	assert.Equal(t, "AuditDecision(7)", AuditDecision(7).String())
+}
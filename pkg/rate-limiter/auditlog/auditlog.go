@@ -0,0 +1,168 @@
+// Package auditlog writes the audit records of rate_limiter.WithAuditSink to
+// JSON-lines files, rotated by size.
+package auditlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// Config configures a FileWriter.
+type Config struct {
+	// MaxSize is the size in bytes beyond which the file is rotated, 0 never
+	// rotating it.
+	MaxSize int64
+	// MaxBackups is the number of rotated files kept, as <path>.1 for the
+	// most recent one up to <path>.<MaxBackups>, at least 1.
+	MaxBackups int
+	// Sync flushes the file to disk after each record.
+	Sync bool
+}
+
+// line is the JSON object written per record.
+type line struct {
+	At         time.Time         `json:"at"`
+	Api        string            `json:"api"`
+	Tenant     string            `json:"tenant,omitempty"`
+	Decision   string            `json:"decision"`
+	WaitMs     float64           `json:"wait_ms"`
+	Blocked    bool              `json:"blocked,omitempty"`
+	Attempts   int               `json:"attempts"`
+	DurationMs float64           `json:"duration_ms"`
+	Outcome    string            `json:"outcome"`
+	Error      string            `json:"error,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty"`
+}
+
+// FileWriter is a rate_limiter.AuditSink appending each record as a JSON
+// object on a line of its own to a file, only ever appended to, and moved to
+// a backup once it grows beyond its max size. It is safe for concurrent use.
+type FileWriter struct {
+	path   string
+	config Config
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+var _ rate_limiter.AuditSink = (*FileWriter)(nil)
+
+// NewFileWriter opens, or creates, the file at path to append the records to.
+func NewFileWriter(path string, config Config) (*FileWriter, error) {
+	config.MaxBackups = max(config.MaxBackups, 1)
+	w := &FileWriter{path: path, config: config}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *FileWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file, w.size = file, info.Size()
+	return nil
+}
+
+// Audit appends record to the file, rotating it first when the record would
+// take it beyond its max size.
+func (w *FileWriter) Audit(record rate_limiter.AuditRecord) error {
+	data, err := json.Marshal(toLine(record))
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	if w.config.MaxSize > 0 && w.size > 0 && w.size+int64(len(data)) > w.config.MaxSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := w.file.Write(data)
+	w.size += int64(n)
+	if err != nil {
+		return err
+	}
+	if w.config.Sync {
+		return w.file.Sync()
+	}
+	return nil
+}
+
+// rotate moves the file to the first backup, shifting the older ones and
+// dropping the oldest, and opens a new file. w.mu must be held.
+func (w *FileWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+	for i := w.config.MaxBackups - 1; i >= 1; i-- {
+		err := os.Rename(w.backup(i), w.backup(i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(w.path, w.backup(1)); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+func (w *FileWriter) backup(i int) string {
+	return fmt.Sprintf("%s.%d", w.path, i)
+}
+
+// Close closes the file, the records audited afterwards fail with
+// os.ErrClosed.
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func toLine(record rate_limiter.AuditRecord) line {
+	l := line{
+		At:         record.At.UTC(),
+		Api:        record.Api,
+		Tenant:     record.Tenant,
+		Decision:   record.Decision.String(),
+		WaitMs:     milliseconds(record.Wait),
+		Blocked:    record.Blocked,
+		Attempts:   record.Attempts,
+		DurationMs: milliseconds(record.Duration),
+		Outcome:    "ok",
+		Tags:       record.Tags,
+	}
+	if record.Err != nil {
+		l.Outcome = "error"
+		l.Error = record.Err.Error()
+	}
+	return l
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,136 @@
+package auditlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+var testRecord = rate_limiter.AuditRecord{
+	At:       time.Unix(1000, 0),
+	Api:      string(rate_limiter.QueryUsers),
+	Tenant:   "acme",
+	Decision: rate_limiter.Delayed,
+	Wait:     1500 * time.Millisecond,
+	Attempts: 1,
+	Duration: 2 * time.Second,
+	Tags:     map[string]string{"job": "sync"},
+}
+
+// readLines decodes the JSON lines of the file at path.
+func readLines(t *testing.T, path string) []map[string]any {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	var lines []map[string]any
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.NoError(t, scanner.Err())
+	return lines
+}
+
+func TestFileWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	w, err := NewFileWriter(path, Config{Sync: true})
+	require.NoError(t, err)
+
+	require.NoError(t, w.Audit(testRecord))
+	failed := testRecord
+	failed.Decision, failed.Tenant, failed.Tags = rate_limiter.RejectedCall, "", nil
+	failed.Err = errors.New("rate limited")
+	require.NoError(t, w.Audit(failed))
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []map[string]any{
+		{
+			"at":          "1970-01-01T00:16:40Z",
+			"api":         "QueryUsers",
+			"tenant":      "acme",
+			"decision":    "delayed",
+			"wait_ms":     1500.0,
+			"attempts":    1.0,
+			"duration_ms": 2000.0,
+			"outcome":     "ok",
+			"tags":        map[string]any{"job": "sync"},
+		},
+		{
+			"at":          "1970-01-01T00:16:40Z",
+			"api":         "QueryUsers",
+			"decision":    "rejected",
+			"wait_ms":     1500.0,
+			"attempts":    1.0,
+			"duration_ms": 2000.0,
+			"outcome":     "error",
+			"error":       "rate limited",
+		},
+	}, readLines(t, path))
+	assert.ErrorIs(t, w.Audit(testRecord), os.ErrClosed)
+}
+
+func TestFileWriterAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	for i := 0; i < 2; i++ {
+		w, err := NewFileWriter(path, Config{})
+		require.NoError(t, err)
+		require.NoError(t, w.Audit(testRecord))
+		require.NoError(t, w.Close())
+	}
+	assert.Len(t, readLines(t, path), 2)
+}
+
+func TestFileWriterRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	data, err := json.Marshal(toLine(testRecord))
+	require.NoError(t, err)
+	size := int64(len(data) + 1)
+
+	w, err := NewFileWriter(path, Config{MaxSize: 2 * size, MaxBackups: 2})
+	require.NoError(t, err)
+	for i := 0; i < 7; i++ {
+		record := testRecord
+		record.Attempts = i
+		require.NoError(t, w.Audit(record))
+	}
+	require.NoError(t, w.Close())
+
+	attempts := func(path string) []float64 {
+		var got []float64
+		for _, line := range readLines(t, path) {
+			got = append(got, line["attempts"].(float64))
+		}
+		return got
+	}
+	assert.Equal(t, []float64{6}, attempts(path))
+	assert.Equal(t, []float64{4, 5}, attempts(path+".1"))
+	assert.Equal(t, []float64{2, 3}, attempts(path+".2"))
+	assert.NoFileExists(t, path+".3", "beyond the backups kept")
+}
+
+func TestFileWriterSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	w, err := NewFileWriter(path, Config{})
+	require.NoError(t, err)
+	defer w.Close()
+
+	rLimit := rate_limiter.NewRateLimiter(rate_limiter.QueryUsers, rate_limiter.WithAuditSink(w))
+	require.NoError(t, rLimit.TryCallApi(func() (*stream.Response, error) {
+		return &stream.Response{}, nil
+	}))
+	lines := readLines(t, path)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "admitted", lines[0]["decision"])
+}
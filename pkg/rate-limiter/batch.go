@@ -0,0 +1,89 @@
+package rate_limiter
+
+import (
+	"context"
+	"sync"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+)
+
+// BatchResult is the outcome of a call of a batch run by CallAll.
+type BatchResult struct {
+	// Response is the response of the call, nil when it was not called.
+	Response *stream.Response
+	Err      error
+}
+
+// BatchOption configures CallAll.
+type BatchOption func(*batchConfig)
+
+type batchConfig struct {
+	parallelism int
+	stopOnError bool
+}
+
+// WithParallelism runs up to n calls of the batch at the same time, the
+// concurrency of the limiter by default. Values lower than 1 are ignored.
+func WithParallelism(n int) BatchOption {
+	return func(c *batchConfig) {
+		if n > 0 {
+			c.parallelism = n
+		}
+	}
+}
+
+// WithStopOnError gives up the calls not called yet once a call failed, their
+// result carrying context.Canceled.
+func WithStopOnError() BatchOption {
+	return func(c *batchConfig) {
+		c.stopOnError = true
+	}
+}
+
+// CallAll runs the batch of calls through the limiter, waiting for the slots
+// and the rate limit resets as CallApiWithContext does, and returns their
+// results in the order of calls. Once ctx is done, the calls not called yet
+// fail with the ctx error.
+func (r *RateLimiter) CallAll(ctx context.Context, calls []GetStreamApiCaller, opts ...BatchOption) []BatchResult {
+	config := batchConfig{parallelism: r.Concurrency()}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]BatchResult, len(calls))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(config.parallelism, len(calls)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i].Err = r.CallApiWithContext(ctx, func() (*stream.Response, error) {
+					resp, err := calls[i]()
+					results[i].Response = resp
+					return resp, err
+				})
+				if results[i].Err != nil && config.stopOnError {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	for i := range calls {
+		if ctx.Err() != nil {
+			results[i].Err = ctx.Err()
+			continue
+		}
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+		}
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,80 @@
+package rate_limiter
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallAll(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithMaxConcurrency(4))
+	var running, peak atomic.Int32
+	calls := make([]GetStreamApiCaller, 6)
+	for i := range calls {
+		remaining := int64(100 - i)
+		calls[i] = func() (*stream.Response, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			if remaining == 97 {
+				return nil, assert.AnError
+			}
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: remaining, Reset: time.Now().Add(time.Minute).Unix()}}, nil
+		}
+	}
+
+	results := rLimit.CallAll(context.Background(), calls, WithParallelism(2))
+	require.Len(t, results, 6)
+	for i, result := range results {
+		if i == 3 {
+			assert.ErrorIs(t, result.Err, assert.AnError)
+			assert.Nil(t, result.Response)
+			continue
+		}
+		require.NoError(t, result.Err)
+		assert.Equal(t, int64(100-i), result.Response.RateLimitInfo.Remaining)
+	}
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+func TestCallAllStopOnError(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	var called atomic.Int32
+	calls := make([]GetStreamApiCaller, 4)
+	for i := range calls {
+		calls[i] = func() (*stream.Response, error) {
+			called.Add(1)
+			return nil, assert.AnError
+		}
+	}
+
+	results := rLimit.CallAll(context.Background(), calls, WithStopOnError())
+	assert.Equal(t, int32(1), called.Load())
+	assert.ErrorIs(t, results[0].Err, assert.AnError)
+	for _, result := range results[1:] {
+		assert.ErrorIs(t, result.Err, context.Canceled)
+	}
+}
+
+func TestCallAllCanceled(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := rLimit.CallAll(ctx, []GetStreamApiCaller{func() (*stream.Response, error) {
+		t.Fatal("call run after ctx is done")
+		return nil, nil
+	}})
+	assert.ErrorIs(t, results[0].Err, context.Canceled)
+}
@@ -0,0 +1,193 @@
+package rate_limiter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+)
+
+// benchResponse returns a response always leaving plenty of calls.
+func benchResponse(reset int64) GetStreamApiCaller {
+	resp := &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 1 << 40, Remaining: 1 << 39, Reset: reset}}
+	return func() (*stream.Response, error) {
+		return resp, nil
+	}
+}
+
+// BenchmarkCallApi measures the admission of the calls of 1, 100 and 10k
+// concurrent callers sharing a limiter with as many slots, the callers being
+// started before the timer so that only the calls are measured.
+func BenchmarkCallApi(b *testing.B) {
+	for _, callers := range []int{1, 100, 10_000} {
+		b.Run(fmt.Sprintf("callers=%d", callers), func(b *testing.B) {
+			rLimit := NewRateLimiter(QueryUsers, WithMaxConcurrency(callers))
+			apiCall := benchResponse(time.Now().Add(time.Hour).Unix())
+			ctx := context.Background()
+
+			var (
+				wg    sync.WaitGroup
+				ready sync.WaitGroup
+				start = make(chan struct{})
+			)
+			perCaller := b.N / callers
+			for i := 0; i < callers; i++ {
+				n := perCaller
+				if i < b.N%callers {
+					n++
+				}
+				wg.Add(1)
+				ready.Add(1)
+				go func(n int) {
+					defer wg.Done()
+					ready.Done()
+					<-start
+					for j := 0; j < n; j++ {
+						if err := rLimit.CallApiWithContext(ctx, apiCall); err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				}(n)
+			}
+			ready.Wait()
+			b.ReportAllocs()
+			b.ResetTimer()
+			close(start)
+			wg.Wait()
+		})
+	}
+}
+
+// BenchmarkCallApiContended measures 100 callers contending for a single
+// slot, queued in turn.
+func BenchmarkCallApiContended(b *testing.B) {
+	rLimit := NewRateLimiter(QueryUsers)
+	apiCall := benchResponse(time.Now().Add(time.Hour).Unix())
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.SetParallelism(100)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := rLimit.CallApiWithContext(ctx, apiCall); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkTryCallApi(b *testing.B) {
+	rLimit := NewRateLimiter(QueryUsers)
+	apiCall := benchResponse(time.Now().Add(time.Hour).Unix())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := rLimit.TryCallApi(apiCall); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestCallApiAllocations checks that a call does not allocate while the quota
+// is plentiful, with tracing and logging disabled.
+func TestCallApiAllocations(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	apiCall := benchResponse(time.Now().Add(time.Hour).Unix())
+	ctx := context.Background()
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := rLimit.CallApiWithContext(ctx, apiCall); err != nil {
+			t.Fatal(err)
+		}
+	})
+	assert.Zero(t, allocs, "CallApiWithContext")
+	allocs = testing.AllocsPerRun(100, func() {
+		if err := rLimit.TryCallApi(apiCall); err != nil {
+			t.Fatal(err)
+		}
+	})
+	assert.Zero(t, allocs, "TryCallApi")
+}
+
+func BenchmarkLimiterManagerFor(b *testing.B) {
+	manager := NewLimiterManager()
+	manager.For(QueryUsers)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			manager.For(QueryUsers)
+		}
+	})
+}
+
+// BenchmarkLimiterManagerForTenant measures the lookups of the limiters of
+// 10k tenants, scaling with the cores, see -cpu.
+func BenchmarkLimiterManagerForTenant(b *testing.B) {
+	manager := NewLimiterManager(WithTenantIdleTTL(time.Hour))
+	tenants := make([]string, 10_000)
+	for i := range tenants {
+		tenants[i] = fmt.Sprintf("tenant-%d", i)
+		manager.ForTenant(tenants[i], QueryUsers)
+	}
+	var next atomic.Int64
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := int(next.Add(1)) * 7919
+		for pb.Next() {
+			manager.ForTenant(tenants[i%len(tenants)], QueryUsers)
+			i++
+		}
+	})
+}
+
+// TestStress runs many concurrent callers against a limiter with few slots
+// and a quota blocking it in turn, checking that every call returns.
+func TestStress(t *testing.T) {
+	if testing.Short() {
+		t.Skip("stress test")
+	}
+	rLimit := NewRateLimiter(QueryUsers, WithMaxConcurrency(8))
+	var (
+		mu        sync.Mutex
+		remaining int64 = 50
+	)
+	apiCall := func() (*stream.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		remaining--
+		info := &stream.RateLimitInfo{Limit: 50, Remaining: remaining, Reset: time.Now().Unix()}
+		if remaining <= 0 {
+			// the reset is already passed: blocks briefly, if at all
+			remaining = 50
+		}
+		return &stream.Response{RateLimitInfo: info}, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	const callers, calls = 200, 20
+	errs := make(chan error, callers*calls)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < calls; j++ {
+				errs <- rLimit.CallApiWithContext(ctx, apiCall)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
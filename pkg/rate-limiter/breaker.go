@@ -0,0 +1,166 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the api while the circuit
+// breaker enabled by WithCircuitBreaker is open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// CircuitBreakerConfig configures the circuit breaker, see WithCircuitBreaker.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures opening the circuit (default 5).
+	FailureThreshold int
+	// OpenDuration is how long the circuit stays open before probing the api again (default 30s).
+	OpenDuration time.Duration
+	// HalfOpenProbes is the number of probe calls allowed once the circuit is
+	// half-open, all of which must succeed to close it again (default 1).
+	HalfOpenProbes int
+	// IsFailure tells whether err counts as a failure, IsServerFailure when nil.
+	IsFailure func(err error) bool
+}
+
+// IsServerFailure reports whether err is a Stream server (5xx) error or a network error.
+func IsServerFailure(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if apiErr, ok := asStreamError(err); ok {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+type circuitBreaker struct {
+	config CircuitBreakerConfig
+
+	mu        sync.Mutex
+	state     circuitState
+	failures  int
+	openedAt  time.Time
+	probes    int // probes admitted while half-open
+	successes int // successful probes while half-open
+}
+
+func newCircuitBreaker(config CircuitBreakerConfig) *circuitBreaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	if config.OpenDuration <= 0 {
+		config.OpenDuration = 30 * time.Second
+	}
+	if config.HalfOpenProbes <= 0 {
+		config.HalfOpenProbes = 1
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = IsServerFailure
+	}
+	return &circuitBreaker{config: config}
+}
+
+// isOpen reports whether calls are currently rejected, without admitting a probe.
+func (b *circuitBreaker) isOpen(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.halfOpenAfter(now)
+	return b.state == circuitOpen || (b.state == circuitHalfOpen && b.probes >= b.config.HalfOpenProbes)
+}
+
+// allow admits a call, counting it as a probe while half-open.
+func (b *circuitBreaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.halfOpenAfter(now)
+	switch b.state {
+	case circuitOpen:
+		return false
+	case circuitHalfOpen:
+		if b.probes >= b.config.HalfOpenProbes {
+			return false
+		}
+		b.probes++
+	}
+	return true
+}
+
+// cancel gives back the probe admitted by allow for a call which did not
+// reach the api, e.g. turned down by another level, so that the circuit does
+// not stay half-open waiting for its outcome.
+func (b *circuitBreaker) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == circuitHalfOpen && b.probes > b.successes {
+		b.probes--
+	}
+}
+
+// record accounts the outcome of an admitted call and returns the new state
+// when it changed.
+func (b *circuitBreaker) record(now time.Time, err error) (circuitState, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	previous := b.state
+	failed := err != nil && b.config.IsFailure(err)
+
+	switch b.state {
+	case circuitClosed:
+		if !failed {
+			b.failures = 0
+			break
+		}
+		b.failures++
+		if b.failures >= b.config.FailureThreshold {
+			b.open(now)
+		}
+	case circuitHalfOpen:
+		if failed {
+			b.open(now)
+			break
+		}
+		b.successes++
+		if b.successes >= b.config.HalfOpenProbes {
+			b.state = circuitClosed
+			b.failures = 0
+		}
+	}
+	return b.state, b.state != previous
+}
+
+func (b *circuitBreaker) open(now time.Time) {
+	b.state = circuitOpen
+	b.openedAt = now
+	b.probes = 0
+	b.successes = 0
+}
+
+func (b *circuitBreaker) halfOpenAfter(now time.Time) {
+	if b.state == circuitOpen && now.Sub(b.openedAt) >= b.config.OpenDuration {
+		b.state = circuitHalfOpen
+	}
+}
@@ -0,0 +1,99 @@
+package rate_limiter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsServerFailure(t *testing.T) {
+	assert.True(t, IsServerFailure(stream.Error{StatusCode: http.StatusBadGateway}))
+	assert.True(t, IsServerFailure(&refusedError{}))
+	assert.False(t, IsServerFailure(stream.Error{StatusCode: http.StatusTooManyRequests}))
+	assert.False(t, IsServerFailure(context.Canceled))
+	assert.False(t, IsServerFailure(assert.AnError))
+}
+
+type refusedError struct{}
+
+func (*refusedError) Error() string   { return "connection refused" }
+func (*refusedError) Timeout() bool   { return false }
+func (*refusedError) Temporary() bool { return false }
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(1000, 0)
+	breaker := newCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2, OpenDuration: 10 * time.Second, HalfOpenProbes: 2})
+	serverErr := stream.Error{StatusCode: http.StatusServiceUnavailable}
+
+	assert.True(t, breaker.allow(now))
+	breaker.record(now, serverErr)
+	assert.True(t, breaker.allow(now))
+	breaker.record(now, nil)
+	assert.True(t, breaker.allow(now))
+	breaker.record(now, serverErr)
+	assert.True(t, breaker.allow(now))
+	state, changed := breaker.record(now, serverErr)
+	assert.Equal(t, circuitOpen, state)
+	assert.True(t, changed)
+
+	assert.True(t, breaker.isOpen(now))
+	assert.False(t, breaker.allow(now.Add(9*time.Second)))
+
+	halfOpen := now.Add(10 * time.Second)
+	assert.False(t, breaker.isOpen(halfOpen))
+	assert.True(t, breaker.allow(halfOpen))
+	assert.True(t, breaker.allow(halfOpen))
+	assert.False(t, breaker.allow(halfOpen))
+	assert.True(t, breaker.isOpen(halfOpen))
+
+	state, _ = breaker.record(halfOpen, nil)
+	assert.Equal(t, circuitHalfOpen, state)
+	state, changed = breaker.record(halfOpen, nil)
+	assert.Equal(t, circuitClosed, state)
+	assert.True(t, changed)
+}
+
+func TestCircuitBreakerProbeFailureReopens(t *testing.T) {
+	now := time.Unix(1000, 0)
+	breaker := newCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Second})
+
+	breaker.record(now, stream.Error{StatusCode: http.StatusInternalServerError})
+	later := now.Add(time.Second)
+	assert.True(t, breaker.allow(later))
+	state, _ := breaker.record(later, stream.Error{StatusCode: http.StatusInternalServerError})
+	assert.Equal(t, circuitOpen, state)
+	assert.False(t, breaker.allow(later))
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	clock := &instantClock{now: time.Unix(1000, 0)}
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 3,
+		OpenDuration:     time.Minute,
+	}))
+
+	calls := 0
+	failing := func() (resp *stream.Response, err error) {
+		calls++
+		return nil, stream.Error{StatusCode: http.StatusInternalServerError}
+	}
+	for i := 0; i < 3; i++ {
+		assert.NotErrorIs(t, rLimit.CallApiWithContext(context.Background(), failing), ErrCircuitOpen)
+	}
+	err := rLimit.CallApiWithContext(context.Background(), failing)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.EqualError(t, err, "circuit open on QueryUsers")
+	assert.ErrorIs(t, rLimit.TryCallApi(failing), ErrCircuitOpen)
+	assert.Equal(t, 3, calls)
+
+	clock.now = clock.now.Add(time.Minute)
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		calls++
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 10}}, nil
+	}))
+	assert.Equal(t, 4, calls)
+}
@@ -0,0 +1,205 @@
+package rate_limiter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BudgetPeriod is the period of a budget set by WithBudget. Periods are
+// calendar days and months in UTC.
+type BudgetPeriod int
+
+const (
+	Daily BudgetPeriod = iota
+	Monthly
+)
+
+func (p BudgetPeriod) String() string {
+	switch p {
+	case Daily:
+		return "daily"
+	case Monthly:
+		return "monthly"
+	default:
+		return fmt.Sprintf("BudgetPeriod(%d)", int(p))
+	}
+}
+
+// bounds returns the start and the end of the period holding now.
+func (p BudgetPeriod) bounds(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	if p == Monthly {
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	}
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// ErrBudgetExceeded is returned by the calls which would exceed a budget set
+// by WithBudget, without calling the api. ResetAt is the end of the period.
+type ErrBudgetExceeded struct {
+	ApiName string
+	Period  BudgetPeriod
+	Limit   int64
+	ResetAt time.Time
+}
+
+func (e ErrBudgetExceeded) Error() string {
+	return fmt.Sprintf("%s budget of %d calls exceeded on %s until %s", e.Period, e.Limit, e.ApiName, e.ResetAt.UTC().Format(time.RFC3339))
+}
+
+// BudgetStore is implemented by the Store counting the usage of the budgets of
+// WithBudget, like MemoryStore and the Store of the redisstore package, so
+// that the replicas sharing it share the budgets, and a persistent store keeps
+// them across restarts. Without it, the budgets are counted in process.
+type BudgetStore interface {
+	// AddUsage adds n, possibly negative, to the usage counted under key,
+	// dropping the counter at expiresAt, and returns the new usage.
+	AddUsage(ctx context.Context, key string, n int64, expiresAt time.Time) (int64, error)
+}
+
+type budget struct {
+	period BudgetPeriod
+	limit  int64
+}
+
+// WithBudget caps the calls of apiName to limit per period, e.g. 500000
+// QueryUsers per day, on top of the rate limits reported by Stream, for the
+// spend to stay predictable. The calls beyond the budget fail with
+// ErrBudgetExceeded instead of waiting for the next period; each call counts
+// its cost, see WithCost. A daily and a monthly budget may be combined. It
+// applies to the limiter of apiName only, e.g. through a LimiterManager;
+// limits lower than 1 are ignored.
+func WithBudget(apiName GetStreamApiName, period BudgetPeriod, limit int64) Option {
+	return func(r *RateLimiter) {
+		if limit < 1 || r.apiName != string(apiName) {
+			return
+		}
+		for i := range r.budgets {
+			if r.budgets[i].period == period {
+				r.budgets[i].limit = limit
+				return
+			}
+		}
+		r.budgets = append(r.budgets, budget{period: period, limit: limit})
+	}
+}
+
+// budgetSpend is the usage counted for a call against a budget.
+type budgetSpend struct {
+	key string
+	end time.Time
+	n   int64
+}
+
+// budgetKey returns the key of the usage of r in the period starting at start.
+func (r *RateLimiter) budgetKey(period BudgetPeriod, start time.Time) string {
+	layout := time.DateOnly
+	if period == Monthly {
+		layout = "2006-01"
+	}
+	return r.apiName + "/" + period.String() + "/" + start.Format(layout)
+}
+
+// budgetStore returns where the usage of the budgets is counted.
+func (r *RateLimiter) budgetStore() BudgetStore {
+	if store, ok := r.store.(BudgetStore); ok {
+		return store
+	}
+	return &r.usage
+}
+
+// spendBudgets counts a call of ctx against the budgets of r, returning what
+// refundBudgets takes back when the api is not called after all. It returns an
+// ErrBudgetExceeded, with nothing counted, when the call exceeds one of them.
+// The budgets are not enforced while their store is unavailable.
+func (r *RateLimiter) spendBudgets(ctx context.Context, logger Logger) ([]budgetSpend, error) {
+	if len(r.budgets) == 0 {
+		return nil, nil
+	}
+	store := r.budgetStore()
+	now := r.getClock().Now()
+	var spent []budgetSpend
+	for _, b := range r.budgets {
+		start, end := b.period.bounds(now)
+		spend := budgetSpend{key: r.budgetKey(b.period, start), end: end, n: int64(r.costFrom(ctx))}
+		used, err := store.AddUsage(ctx, spend.key, spend.n, spend.end)
+		if err != nil {
+			r.logStoreError(ctx, logger, err)
+			continue
+		}
+		spent = append(spent, spend)
+		if used > b.limit {
+			r.refundBudgets(ctx, logger, spent)
+			err := ErrBudgetExceeded{ApiName: r.apiName, Period: b.period, Limit: b.limit, ResetAt: end}
+			logAttrs(ctx, logger, slog.LevelWarn, "budget exceeded", []slog.Attr{
+				slog.String("api", r.apiName),
+				slog.String("period", b.period.String()),
+				slog.Int64("limit", b.limit),
+			}, "%s budget of %s exceeded, %d calls\n", b.period, r.apiName, b.limit)
+			trace.SpanFromContext(ctx).AddEvent("budget exceeded")
+			return nil, err
+		}
+	}
+	return spent, nil
+}
+
+// refundBudgets takes back the usage counted by spendBudgets.
+func (r *RateLimiter) refundBudgets(ctx context.Context, logger Logger, spent []budgetSpend) {
+	store := r.budgetStore()
+	for _, spend := range spent {
+		if _, err := store.AddUsage(ctx, spend.key, -spend.n, spend.end); err != nil {
+			r.logStoreError(ctx, logger, err)
+		}
+	}
+}
+
+// BudgetUsage returns the calls counted against the budget of period in the
+// current period, see WithBudget.
+func (r *RateLimiter) BudgetUsage(ctx context.Context, period BudgetPeriod) (int64, error) {
+	start, end := period.bounds(r.getClock().Now())
+	return r.budgetStore().AddUsage(ctx, r.budgetKey(period, start), 0, end)
+}
+
+// usageCounter counts the usage of the budgets in process, keeping the
+// counter of the current period of each budget only.
+type usageCounter struct {
+	mu     sync.Mutex
+	counts map[string]usageCount // by key without its period
+}
+
+// budgetOf returns the key of a usage counter without its period.
+func budgetOf(key string) string {
+	return key[:strings.LastIndex(key, "/")+1]
+}
+
+type usageCount struct {
+	key  string
+	used int64
+}
+
+var _ BudgetStore = (*usageCounter)(nil)
+
+func (c *usageCounter) AddUsage(ctx context.Context, key string, n int64, expiresAt time.Time) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]usageCount)
+	}
+	budget := budgetOf(key)
+	count := c.counts[budget]
+	if count.key != key {
+		// a new period
+		count = usageCount{key: key}
+	}
+	count.used = max(count.used+n, 0)
+	c.counts[budget] = count
+	return count.used, nil
+}
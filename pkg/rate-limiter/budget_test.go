@@ -0,0 +1,106 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestBudgetPeriodBounds(t *testing.T) {
+	now := time.Date(2026, time.October, 14, 23, 30, 0, 0, time.FixedZone("CET", 3600))
+	start, end := Daily.bounds(now)
+	assert.Equal(t, time.Date(2026, time.October, 14, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC), end)
+	start, end = Monthly.bounds(now)
+	assert.Equal(t, time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC), end)
+}
+
+func TestWithBudget(t *testing.T) {
+	clock := clocktest.NewFake(time.Date(2026, time.October, 14, 23, 59, 0, 0, time.UTC))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithBudget(QueryUsers, Daily, 2), WithBudget(UpdateUsers, Daily, 1))
+	calls := 0
+	apiCall := func() (*stream.Response, error) {
+		calls++
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 1000, Remaining: 1000, Reset: clock.Now().Unix() + 60}}, nil
+	}
+
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), apiCall))
+	require.NoError(t, rLimit.TryCallApi(apiCall))
+	var exceeded ErrBudgetExceeded
+	require.ErrorAs(t, rLimit.CallApiWithContext(context.Background(), apiCall), &exceeded)
+	assert.Equal(t, ErrBudgetExceeded{
+		ApiName: string(QueryUsers),
+		Period:  Daily,
+		Limit:   2,
+		ResetAt: time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC),
+	}, exceeded)
+	assert.Equal(t, 2, calls, "the api is not called")
+	used, err := rLimit.BudgetUsage(context.Background(), Daily)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), used)
+
+	clock.Advance(time.Minute)
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), apiCall), "a new day")
+	used, err = rLimit.BudgetUsage(context.Background(), Daily)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), used)
+}
+
+func TestBudgetCountsCost(t *testing.T) {
+	clock := clocktest.NewFake(time.Date(2026, time.October, 14, 12, 0, 0, 0, time.UTC))
+	rLimit := NewRateLimiter(UpdateUsers, WithClock(clock), WithBudget(UpdateUsers, Daily, 100), WithBudget(UpdateUsers, Monthly, 10))
+	apiCall := func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 1000, Remaining: 1000, Reset: clock.Now().Unix() + 60}}, nil
+	}
+
+	require.NoError(t, rLimit.CallWithCost(context.Background(), 4, apiCall))
+	require.NoError(t, rLimit.CallWithCost(context.Background(), 4, apiCall))
+	var exceeded ErrBudgetExceeded
+	require.ErrorAs(t, rLimit.CallWithCost(context.Background(), 4, apiCall), &exceeded)
+	assert.Equal(t, Monthly, exceeded.Period)
+
+	used, err := rLimit.BudgetUsage(context.Background(), Daily)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), used, "the daily budget is refunded")
+	used, err = rLimit.BudgetUsage(context.Background(), Monthly)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), used)
+	require.NoError(t, rLimit.CallWithCost(context.Background(), 2, apiCall), "within the budget left")
+}
+
+func TestBudgetSharedByStore(t *testing.T) {
+	store := NewMemoryStore()
+	clock := clocktest.NewFake(time.Date(2026, time.October, 14, 12, 0, 0, 0, time.UTC))
+	opts := []Option{WithStore(store), WithClock(clock), WithBudget(QueryUsers, Daily, 1)}
+	first := NewRateLimiter(QueryUsers, opts...)
+	second := NewRateLimiter(QueryUsers, opts...)
+	apiCall := func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 1000, Remaining: 1000, Reset: clock.Now().Unix() + 60}}, nil
+	}
+
+	require.NoError(t, first.CallApiWithContext(context.Background(), apiCall))
+	assert.ErrorAs(t, second.CallApiWithContext(context.Background(), apiCall), new(ErrBudgetExceeded))
+}
+
+func TestBudgetRefundedWhenNotCalled(t *testing.T) {
+	clock := clocktest.NewFake(time.Date(2026, time.October, 14, 12, 0, 0, 0, time.UTC))
+	endpoint := NewRateLimiter(QueryUsers, WithClock(clock), WithBudget(QueryUsers, Daily, 2))
+	bucket := NewRateLimiter("bucket", WithClock(clock), WithClientSide(), WithStrategy(NewTokenBucket(0, 1)))
+	limiter := Chain(endpoint, bucket)
+	apiCall := func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 1000, Remaining: 1000, Reset: clock.Now().Unix() + 60}}, nil
+	}
+
+	require.NoError(t, limiter.TryCallApi(apiCall))
+	assert.ErrorAs(t, limiter.TryCallApi(apiCall), new(ErrRateLimited), "turned down by the bucket")
+	used, err := endpoint.BudgetUsage(context.Background(), Daily)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), used)
+}
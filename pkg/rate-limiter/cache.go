@@ -0,0 +1,162 @@
+package rate_limiter
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+)
+
+// Cache stores the results of idempotent read calls by key, for a ttl, see
+// CallCached. It must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored under key, reporting false when there
+	// is none or it expired.
+	Get(key string) (any, bool)
+	// Set stores value under key for ttl.
+	Set(key string, value any, ttl time.Duration)
+}
+
+// CacheMetrics is implemented by the Metrics counting the lookups of
+// CallCached, like the Collector of the metrics package.
+type CacheMetrics interface {
+	// ObserveCacheHit counts a call of the wrapped api served by the cache.
+	ObserveCacheHit(api string)
+	// ObserveCacheMiss counts a call of the wrapped api not found in the
+	// cache, hence performed.
+	ObserveCacheMiss(api string)
+}
+
+// CallCached returns the result stored in cache under key when there is one,
+// without calling the api nor consuming a slot of r; otherwise it runs apiCall
+// through r as Call does and stores its result for ttl when it succeeded. The
+// keys are scoped by the api of r, so limiters of different apis may share a
+// cache. Only idempotent reads should be cached, keyed by everything telling
+// their results apart, e.g. the filter and sort of a query.
+func CallCached[T any](ctx context.Context, r *RateLimiter, cache Cache, key string, ttl time.Duration, apiCall GetStreamTypedApiCaller[T]) (T, error) {
+	key = r.apiName + "/" + key
+	metrics, _ := r.getMetrics().(CacheMetrics)
+	if value, ok := cache.Get(key); ok {
+		if result, ok := value.(T); ok {
+			if metrics != nil {
+				metrics.ObserveCacheHit(r.apiName)
+			}
+			r.getLogger(nil).Tracef("%s served from cache", r.apiName)
+			return result, nil
+		}
+	}
+	if metrics != nil {
+		metrics.ObserveCacheMiss(r.apiName)
+	}
+	result, err := Call(ctx, r, apiCall)
+	if err != nil {
+		return result, err
+	}
+	cache.Set(key, result, ttl)
+	return result, nil
+}
+
+// CachedQueryUsers is QueryUsers served from cache under key for ttl, see
+// CallCached.
+func (c *Client) CachedQueryUsers(ctx context.Context, cache Cache, key string, ttl time.Duration, q *stream.QueryOption, sorters ...*stream.SortOption) (*stream.QueryUsersResponse, error) {
+	return CallCached(ctx, c.manager.For(QueryUsers), cache, key, ttl, func() (*stream.QueryUsersResponse, *stream.Response, error) {
+		resp, err := c.client.QueryUsers(ctx, q, sorters...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp, &resp.Response, nil
+	})
+}
+
+// CachedQuery is Query served from cache under key for ttl, see CallCached.
+// The key is scoped by the channel.
+func (ch *Channel) CachedQuery(ctx context.Context, cache Cache, key string, ttl time.Duration, q *stream.QueryRequest) (*stream.QueryResponse, error) {
+	key = ch.channel.CID + "/" + key
+	return CallCached(ctx, ch.manager.For(QueryChannel), cache, key, ttl, func() (*stream.QueryResponse, *stream.Response, error) {
+		resp, err := ch.channel.Query(ctx, q)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp, &resp.Response, nil
+	})
+}
+
+// LRUCache is an in-memory Cache holding up to a capacity of entries, evicting
+// the least recently used one to make room for a new one. Expired entries are
+// dropped when looked up.
+type LRUCache struct {
+	capacity int
+	clock    Clock
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   list.List // most recently used first
+}
+
+type lruEntry struct {
+	key       string
+	value     any
+	expiresAt time.Time
+}
+
+var _ Cache = (*LRUCache)(nil)
+
+// NewLRUCache returns an LRUCache holding up to capacity entries, at least
+// one, expiring them on clock, the real one when nil.
+func NewLRUCache(capacity int, clock Clock) *LRUCache {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &LRUCache{
+		capacity: max(capacity, 1),
+		clock:    clock,
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *LRUCache) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if !c.clock.Now().Before(entry.expiresAt) {
+		c.removeLocked(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+func (c *LRUCache) Set(key string, value any, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expiresAt := c.clock.Now().Add(ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	if c.order.Len() > c.capacity {
+		c.removeLocked(c.order.Back())
+	}
+}
+
+// Len returns the number of entries held, expired ones included.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// removeLocked drops elem. c.mu must be held.
+func (c *LRUCache) removeLocked(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*lruEntry).key)
+}
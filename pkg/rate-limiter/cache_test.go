@@ -0,0 +1,103 @@
+package rate_limiter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestCallCached(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+	cache := NewLRUCache(10, clock)
+	calls := 0
+	apiCall := func() (int, *stream.Response, error) {
+		calls++
+		return calls, &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 9, Reset: clock.Now().Unix() + 60}}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		result, err := CallCached(context.Background(), rLimit, cache, "jane", time.Minute, apiCall)
+		require.NoError(t, err)
+		assert.Equal(t, 1, result)
+	}
+	assert.Equal(t, 1, calls, "served from cache")
+
+	result, err := CallCached(context.Background(), rLimit, cache, "john", time.Minute, apiCall)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result, "other key")
+
+	clock.Advance(time.Minute)
+	result, err = CallCached(context.Background(), rLimit, cache, "jane", time.Minute, apiCall)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result, "expired")
+}
+
+func TestCallCachedError(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	cache := NewLRUCache(10, nil)
+	_, err := CallCached(context.Background(), rLimit, cache, "jane", time.Minute, func() (int, *stream.Response, error) {
+		return 0, nil, assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Zero(t, cache.Len(), "errors are not cached")
+}
+
+func TestCallCachedScopedByApi(t *testing.T) {
+	cache := NewLRUCache(10, nil)
+	for i, apiName := range []GetStreamApiName{QueryUsers, QueryChannels} {
+		result, err := CallCached(context.Background(), NewRateLimiter(apiName), cache, "all", time.Minute, func() (int, *stream.Response, error) {
+			return i, &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 9}}, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, i, result)
+	}
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestLRUCacheEviction(t *testing.T) {
+	cache := NewLRUCache(2, nil)
+	cache.Set("a", 1, time.Minute)
+	cache.Set("b", 2, time.Minute)
+	_, ok := cache.Get("a")
+	require.True(t, ok)
+
+	// b is the least recently used
+	cache.Set("c", 3, time.Minute)
+	assert.Equal(t, 2, cache.Len())
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+	value, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	cache.Set("a", 4, time.Minute)
+	value, _ = cache.Get("a")
+	assert.Equal(t, 4, value, "overwritten")
+}
+
+func TestCachedQueryUsers(t *testing.T) {
+	calls := 0
+	client := newTestStreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_ = json.NewEncoder(w).Encode(map[string]any{"users": []map[string]string{{"id": "jane"}}})
+	})
+	wrapped := WrapClient(client)
+	cache := NewLRUCache(10, nil)
+
+	for i := 0; i < 2; i++ {
+		resp, err := wrapped.CachedQueryUsers(context.Background(), cache, "jane", time.Minute, &stream.QueryOption{Filter: map[string]any{"id": "jane"}})
+		require.NoError(t, err)
+		require.Len(t, resp.Users, 1)
+		assert.Equal(t, "jane", resp.Users[0].ID)
+	}
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,46 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// RateLimitedCaller is an api call of any client, e.g. of another Stream SDK,
+// returning the rate limit info of its response, nil when not reported.
+// throttled reports a call rejected for too many requests.
+type RateLimitedCaller func() (info *LimitInfo, throttled bool, err error)
+
+// errThrottled carries a call rejected for too many requests, and the error it
+// returned, through the limiter.
+type errThrottled struct {
+	err   error
+	cause error
+}
+
+func (e errThrottled) Error() string { return e.err.Error() }
+
+func (e errThrottled) Unwrap() error { return e.err }
+
+// CallRateLimited is like CallApiWithContext for calls not returning a
+// *stream.Response. A throttled call blocks the api like a Stream 429 error
+// and its own error, possibly nil, is returned.
+func (r *RateLimiter) CallRateLimited(ctx context.Context, apiCall RateLimitedCaller, opts ...CallOption) error {
+	err := r.run(ctx, Invocation{Options: ApplyCallOptions(opts...), call: limitedFunc(func() (LimitInfo, bool, error) {
+		reported, throttled, err := apiCall()
+		if throttled {
+			return LimitInfo{}, false, errThrottled{err: streamError(http.StatusTooManyRequests, "", reported), cause: err}
+		}
+		if err != nil || reported == nil {
+			// no quota reported, e.g. by an endpoint not rate limited, is
+			// accounted by the MissingInfoPolicy
+			return LimitInfo{}, false, err
+		}
+		return *reported, true, nil
+	})})
+	var throttled errThrottled
+	if errors.As(err, &throttled) {
+		return throttled.cause
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCall(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+
+	tests := []struct {
+		name      string
+		mockFn    GetStreamTypedApiCaller[*stream.QueryUsersResponse]
+		wantUsers int
+		wantError assert.ErrorAssertionFunc
+	}{
+		{
+			name: "Typed payload is returned",
+			mockFn: func() (*stream.QueryUsersResponse, *stream.Response, error) {
+				queryResp := &stream.QueryUsersResponse{
+					Users: []*stream.User{{ID: "a"}, {ID: "b"}},
+					Response: stream.Response{
+						RateLimitInfo: &stream.RateLimitInfo{Remaining: 10, Reset: time.Now().Unix()},
+					},
+				}
+				return queryResp, &queryResp.Response, nil
+			},
+			wantUsers: 2,
+			wantError: assert.NoError,
+		},
+		{
+			name: "Error returns zero value",
+			mockFn: func() (*stream.QueryUsersResponse, *stream.Response, error) {
+				return &stream.QueryUsersResponse{}, nil, assert.AnError
+			},
+			wantError: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rLimit := NewRateLimiter(QueryUsers, WithLogger(logger))
+
+			queryResp, err := Call(context.Background(), rLimit, tt.mockFn)
+			tt.wantError(t, err)
+			if tt.wantUsers == 0 {
+				assert.Nil(t, queryResp)
+				return
+			}
+			assert.Len(t, queryResp.Users, tt.wantUsers)
+		})
+	}
+}
+
+func TestCallRateLimited(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Unix()
+	rLimit := NewRateLimiter(QueryUsers)
+
+	err := rLimit.CallRateLimited(context.Background(), func() (*LimitInfo, bool, error) {
+		return &LimitInfo{Limit: 10, Remaining: 3, Reset: reset}, false, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), rLimit.Remaining())
+
+	// a throttled call returns its own error, nil here
+	err = rLimit.CallRateLimited(context.Background(), func() (*LimitInfo, bool, error) {
+		return &LimitInfo{Limit: 10, Reset: reset}, true, nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, rLimit.IsBlocked())
+	assert.Equal(t, time.Unix(reset, 0), rLimit.ResetAt())
+}
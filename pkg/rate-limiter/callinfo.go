@@ -0,0 +1,99 @@
+package rate_limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CallInfo reports how the limiter handled a call of CallWithInfo, e.g. to log
+// the latency it added to the call.
+type CallInfo struct {
+	// QueuedFor is how long the call waited to be admitted, over all its
+	// attempts and the levels it passed, see WithGlobalLimiter.
+	QueuedFor time.Duration
+	// Blocked reports whether the call waited for the end of a block, e.g. a
+	// rate limit reset.
+	Blocked bool
+	// Delayed reports whether the call waited to be admitted, for a slot, the
+	// end of a block, its Strategy or room among the calls in flight.
+	Delayed bool
+	// Attempts is how many times the api was called, more than once when
+	// retried, see WithRetry, 0 when it was not called.
+	Attempts int
+	// RemainingAfter is the remaining calls reported by the latest response,
+	// -1 when not reported.
+	RemainingAfter int64
+	// ResetAt is the reset reported by the latest response, the zero time
+	// when not reported.
+	ResetAt time.Time
+}
+
+// callRecorder collects the CallInfo of a call, updated by the levels it
+// passes.
+type callRecorder struct {
+	mu   sync.Mutex
+	info CallInfo
+}
+
+type callInfoKey struct{}
+
+func callRecorderFrom(ctx context.Context) *callRecorder {
+	recorder, _ := ctx.Value(callInfoKey{}).(*callRecorder)
+	return recorder
+}
+
+// recordQueued adds the time a call of ctx waited to be admitted.
+func recordQueued(ctx context.Context, wait time.Duration) {
+	if recorder := callRecorderFrom(ctx); recorder != nil {
+		recorder.mu.Lock()
+		recorder.info.QueuedFor += wait
+		recorder.mu.Unlock()
+	}
+}
+
+// recordBlocked marks a call of ctx as blocked.
+func recordBlocked(ctx context.Context) {
+	if recorder := callRecorderFrom(ctx); recorder != nil {
+		recorder.mu.Lock()
+		recorder.info.Blocked = true
+		recorder.mu.Unlock()
+	}
+}
+
+// recordDelayed marks a call of ctx as delayed.
+func recordDelayed(ctx context.Context) {
+	if recorder := callRecorderFrom(ctx); recorder != nil {
+		recorder.mu.Lock()
+		recorder.info.Delayed = true
+		recorder.mu.Unlock()
+	}
+}
+
+// CallWithInfo is like CallApiWithContext, also returning how the limiter
+// handled the call, whatever its error.
+func (r *RateLimiter) CallWithInfo(ctx context.Context, apiCall GetStreamApiCaller) (CallInfo, error) {
+	recorder := &callRecorder{info: CallInfo{RemainingAfter: -1}}
+	ctx = context.WithValue(ctx, callInfoKey{}, recorder)
+	call := streamCall(apiCall)
+	err := r.callApi(ctx, r.getLogger(nil), limitedFunc(func() (LimitInfo, bool, error) {
+		info, ok, err := call.call()
+		reported := orNil(info, ok)
+		if err != nil {
+			reported, _ = tooManyRequestsInfo(err, r.serverNow())
+		}
+		recorder.mu.Lock()
+		recorder.info.Attempts++
+		if reported != nil {
+			recorder.info.RemainingAfter = reported.Remaining
+			if reported.Reset > 0 {
+				recorder.info.ResetAt = r.localReset(reported.Reset)
+			}
+		}
+		recorder.mu.Unlock()
+		return info, ok, err
+	}))
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return recorder.info, err
+}
@@ -0,0 +1,76 @@
+package rate_limiter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestCallWithInfo(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+
+	info, err := rLimit.CallWithInfo(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1030}}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, CallInfo{Attempts: 1, RemainingAfter: 0, ResetAt: time.Unix(1030, 0)}, info)
+
+	done := make(chan CallInfo)
+	go func() {
+		info, err := rLimit.CallWithInfo(context.Background(), func() (*stream.Response, error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 9, Reset: 1090}}, nil
+		})
+		assert.NoError(t, err)
+		done <- info
+	}()
+	blockUntil(t, clock, 1)
+	require.Eventually(t, func() bool { return len(rLimit.tokens()) == 1 }, time.Second, time.Millisecond)
+	clock.Advance(30 * time.Second)
+	select {
+	case info = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the call")
+	}
+	assert.Equal(t, CallInfo{
+		QueuedFor:      30 * time.Second,
+		Blocked:        true,
+		Delayed:        true,
+		Attempts:       1,
+		RemainingAfter: 9,
+		ResetAt:        time.Unix(1090, 0),
+	}, info)
+}
+
+func TestCallWithInfoNotCalled(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	require.NoError(t, rLimit.Close(context.Background()))
+	info, err := rLimit.CallWithInfo(context.Background(), nil)
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.Equal(t, CallInfo{RemainingAfter: -1}, info)
+}
+
+func TestCallWithInfoRetried(t *testing.T) {
+	reset := time.Now().Unix() + 60
+	rLimit := NewRateLimiter(QueryUsers, WithRetry(RetryPolicy{MaxAttempts: 2, Backoff: ConstantBackoff(0)}))
+	attempts := 0
+	info, err := rLimit.CallWithInfo(context.Background(), func() (*stream.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, stream.Error{StatusCode: http.StatusServiceUnavailable}
+		}
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 100, Remaining: 50, Reset: reset}}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, info.Attempts)
+	assert.Equal(t, int64(50), info.RemainingAfter)
+	assert.Equal(t, time.Unix(reset, 0), info.ResetAt)
+	assert.False(t, info.Blocked)
+}
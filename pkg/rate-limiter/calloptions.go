@@ -0,0 +1,190 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CallOption adjusts a single call, e.g. of CallApiWithContext, without
+// building a limiter of its own for the call site.
+type CallOption func(*CallOptions)
+
+// CallOptions are the settings of a call set by its CallOption, for the
+// Limiter implementations, see ApplyCallOptions.
+type CallOptions struct {
+	// SkipLimit calls the api without waiting for the limiter, see SkipLimit.
+	SkipLimit bool
+	// Priority of the call, see WithPriority.
+	Priority Priority
+	// Cost of the call, 0 for the cost of the limiter, see WithCallCost.
+	Cost int
+	// Tags of the call, see WithTags.
+	Tags map[string]string
+}
+
+// ApplyCallOptions returns the settings of a call with opts.
+func ApplyCallOptions(opts ...CallOption) CallOptions {
+	if len(opts) == 0 {
+		// spares the allocation of o
+		return CallOptions{}
+	}
+	var o CallOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// SkipLimit calls the api right away, neither waiting for a slot nor for the
+// reset of a blocked api, e.g. for an urgent call accepting the risk of a 429.
+// It skips the limits of the limiter itself only: the slots, the reset, the
+// reservations of the Strategy, the Store, WithMaxInFlight and the faults of
+// WithFaultInjector. The budgets of WithBudget are still spent, the circuit
+// breaker still applies and the levels above, e.g. WithGlobalLimiter, are
+// tried, turning the call down without waiting. The rate limit reported by
+// its response is still recorded, blocking the other calls once exhausted.
+func SkipLimit() CallOption {
+	return func(o *CallOptions) {
+		o.SkipLimit = true
+	}
+}
+
+// WithPriority serves the call before the calls of lower priority while slots
+// are busy, as CallWithPriority does.
+func WithPriority(priority Priority) CallOption {
+	return func(o *CallOptions) {
+		o.Priority = priority
+	}
+}
+
+// WithCallCost makes the call consume cost of the remaining calls instead of
+// the cost of the limiter, as CallWithCost does. Costs lower than 1 are
+// ignored.
+func WithCallCost(cost int) CallOption {
+	return func(o *CallOptions) {
+		if cost > 0 {
+			o.Cost = cost
+		}
+	}
+}
+
+// WithTags labels the call with tags, set as attributes of its span and on the
+// CallAdmitted and Rejected events of the call. Tags of several WithTags are
+// merged.
+func WithTags(tags map[string]string) CallOption {
+	return func(o *CallOptions) {
+		if len(tags) == 0 {
+			return
+		}
+		if o.Tags == nil {
+			o.Tags = make(map[string]string, len(tags))
+		}
+		for k, v := range tags {
+			o.Tags[k] = v
+		}
+	}
+}
+
+type tagsKey struct{}
+
+// tagsFrom returns the tags of the call of ctx, nil when not set by WithTags.
+func tagsFrom(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(tagsKey{}).(map[string]string)
+	return tags
+}
+
+// withOptions returns ctx carrying the priority, cost and tags of o, when set.
+func withOptions(ctx context.Context, o CallOptions) context.Context {
+	if o.Priority != PriorityNormal {
+		ctx = withPriority(ctx, o.Priority)
+	}
+	if o.Cost > 0 {
+		ctx = withCost(ctx, o.Cost)
+	}
+	if o.Tags != nil {
+		ctx = context.WithValue(ctx, tagsKey{}, o.Tags)
+	}
+	return ctx
+}
+
+// traceTags sets tags on span.
+func traceTags(span trace.Span, tags map[string]string) {
+	if len(tags) == 0 || !span.IsRecording() {
+		return
+	}
+	attrs := make([]attribute.KeyValue, 0, len(tags))
+	for k, v := range tags {
+		attrs = append(attrs, attribute.String("getstream.tag."+k, v))
+	}
+	span.SetAttributes(attrs...)
+}
+
+// callUnlimited runs apiCall of a call skipping the limiter, recording the
+// rate limit reported as a preflight does. The call still spends the budgets
+// of WithBudget, passes the circuit breaker and is tried on the levels above,
+// see WithGlobalLimiter; its outcome is observed by the Strategy.
+func (r *RateLimiter) callUnlimited(ctx context.Context, logger Logger, apiCall limitedCall) error {
+	span := trace.SpanFromContext(ctx)
+	spent, err := r.spendBudgets(ctx, logger)
+	if err != nil {
+		traceError(span, err)
+		return err
+	}
+	if r.breaker != nil && !r.breaker.allow(r.getClock().Now()) {
+		r.refundBudgets(ctx, logger, spent)
+		err := r.errCircuitOpen()
+		traceError(span, err)
+		return err
+	}
+	r.emit(Event{Type: CallAdmitted, Tags: tagsFrom(ctx)})
+	calledAt := r.getClock().Now()
+	result, ok, err := r.safeCall(ctx, logger, r.throughParent(ctx, apiCall, true))
+	if err != nil && errors.As(err, new(*levelRejection)) {
+		// turned down by another level, the api was not called
+		r.refundBudgets(ctx, logger, spent)
+		if r.breaker != nil {
+			r.breaker.cancel()
+		}
+		traceError(span, err)
+		return err
+	}
+	r.recordCall(calledAt, err)
+	r.recordOutcome(ctx, logger, err)
+	if r.clientSide {
+		r.observe(calledAt, nil)
+		if r.strategy != nil {
+			r.strategy.Observe(r.getClock().Now(), nil, err)
+		}
+		return err
+	}
+	if err != nil {
+		info, tooManyRequests := tooManyRequestsInfo(err, r.serverNow())
+		if r.strategy != nil {
+			r.strategy.Observe(r.getClock().Now(), info.clone(), err)
+		}
+		if !tooManyRequests {
+			return err
+		}
+		r.observe(calledAt, info)
+		r.observeStore(ctx, logger, info)
+		r.block(ctx, logger, info.Reset)
+		return r.rateLimitedError(err, r.getResetAt())
+	}
+	info := orNil(result, ok)
+	r.observe(calledAt, info)
+	if r.strategy != nil {
+		r.strategy.Observe(r.getClock().Now(), info.clone(), nil)
+	}
+	if info == nil {
+		return nil
+	}
+	r.observeStore(ctx, logger, info)
+	traceRateLimitInfo(span, info)
+	if r.exhausted(info.Remaining, r.costFrom(ctx)) {
+		r.block(ctx, logger, info.Reset)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package rate_limiter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestApplyCallOptions(t *testing.T) {
+	o := ApplyCallOptions(
+		SkipLimit(),
+		WithPriority(PriorityHigh),
+		WithCallCost(3),
+		WithCallCost(0),
+		WithTags(map[string]string{"job": "sync"}),
+		WithTags(map[string]string{"tenant": "acme"}),
+	)
+	assert.Equal(t, CallOptions{
+		SkipLimit: true,
+		Priority:  PriorityHigh,
+		Cost:      3,
+		Tags:      map[string]string{"job": "sync", "tenant": "acme"},
+	}, o)
+}
+
+func TestSkipLimit(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1060}}, nil
+	}))
+	require.True(t, rLimit.IsBlocked())
+
+	// called right away, although blocked, its response recorded
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1120}}, nil
+	}, SkipLimit()))
+	assert.Equal(t, time.Unix(1120, 0), rLimit.getResetAt(), "later reset")
+
+	err := rLimit.TryCallApi(func() (*stream.Response, error) {
+		return nil, stream.Error{StatusCode: 429}
+	}, SkipLimit())
+	assert.ErrorIs(t, err, ErrRateLimited{})
+}
+
+func TestSkipLimitBlocksOnceExhausted(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	reset := time.Now().Add(time.Minute).Unix()
+	require.NoError(t, rLimit.TryCallApi(func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: reset}}, nil
+	}, SkipLimit()))
+	assert.True(t, rLimit.IsBlocked())
+	assert.Zero(t, rLimit.Remaining())
+}
+
+func TestSkipLimitSpendsBudgets(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithBudget(QueryUsers, Daily, 1))
+	ok := func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 9}}, nil
+	}
+	require.NoError(t, rLimit.TryCallApi(ok, SkipLimit()))
+	var exceeded ErrBudgetExceeded
+	require.ErrorAs(t, rLimit.TryCallApi(func() (*stream.Response, error) {
+		t.Error("called beyond the budget")
+		return nil, nil
+	}, SkipLimit()), &exceeded)
+	used, err := rLimit.BudgetUsage(context.Background(), Daily)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), used)
+}
+
+func TestSkipLimitTriesLevelsAbove(t *testing.T) {
+	manager := NewLimiterManager(WithBudget(QueryUsers, Daily, 5), WithGlobalLimiter(WithMaxConcurrency(1)))
+	release := holdSlot(t, manager.Global())
+	rLimit := manager.For(QueryUsers)
+
+	var limited ErrRateLimited
+	require.ErrorAs(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		t.Error("called beyond the global concurrency")
+		return nil, nil
+	}, SkipLimit()), &limited)
+	assert.Equal(t, string(GlobalApiName), limited.ApiName, "turned down without waiting")
+	used, err := rLimit.BudgetUsage(context.Background(), Daily)
+	require.NoError(t, err)
+	assert.Zero(t, used, "refunded")
+
+	release()
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 9}}, nil
+	}, SkipLimit()))
+	assert.Zero(t, inFlightOf(manager.Global()))
+}
+
+func TestSkipLimitCircuitBreaker(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1}))
+	calls := 0
+	failing := func() (*stream.Response, error) {
+		calls++
+		return nil, stream.Error{StatusCode: http.StatusInternalServerError}
+	}
+	assert.NotErrorIs(t, rLimit.TryCallApi(failing, SkipLimit()), ErrCircuitOpen)
+	assert.ErrorIs(t, rLimit.TryCallApi(failing, SkipLimit()), ErrCircuitOpen)
+	assert.Equal(t, 1, calls)
+}
+
+func TestWithCallCost(t *testing.T) {
+	rLimit := NewRateLimiter(UpdateUsers)
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+			<-release
+			return &stream.Response{}, nil
+		}, WithCallCost(3))
+	}()
+	require.Eventually(t, func() bool { return inFlightOf(rLimit) == 3 }, time.Second, time.Millisecond)
+	close(release)
+	require.NoError(t, receive(t, done))
+	assert.Zero(t, inFlightOf(rLimit))
+}
+
+func TestWithPriority(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	releaseSlot := holdSlot(t, rLimit)
+
+	order := make(chan Priority, 2)
+	done := make(chan error, 2)
+	for i, priority := range []Priority{PriorityLow, PriorityHigh} {
+		priority := priority
+		go func() {
+			done <- rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+				order <- priority
+				return &stream.Response{}, nil
+			}, WithPriority(priority))
+		}()
+		n := i + 1
+		require.Eventually(t, func() bool { return queued(rLimit) == n }, time.Second, time.Millisecond)
+	}
+
+	releaseSlot()
+	require.NoError(t, receive(t, done))
+	require.NoError(t, receive(t, done))
+	assert.Equal(t, PriorityHigh, <-order)
+	assert.Equal(t, PriorityLow, <-order)
+}
+
+func TestWithTags(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	rLimit := NewRateLimiter(QueryUsers, WithTracerProvider(provider))
+	events := rLimit.Events()
+	tags := map[string]string{"tenant": "acme"}
+
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{}, nil
+	}, WithTags(tags)))
+	event := <-events
+	assert.Equal(t, CallAdmitted, event.Type)
+	assert.Equal(t, tags, event.Tags)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("getstream.tag.tenant", "acme"))
+}
+
+func TestChainPassesCallOptions(t *testing.T) {
+	a := NewRateLimiter("a")
+	b := NewRateLimiter("b")
+	limiter := Chain(a, b)
+	reset := time.Now().Add(time.Minute).Unix()
+	require.NoError(t, b.TryCallApi(func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: reset}}, nil
+	}))
+
+	called := false
+	assert.NoError(t, limiter.TryCallApi(func() (*stream.Response, error) {
+		called = true
+		return &stream.Response{}, nil
+	}, SkipLimit()))
+	assert.True(t, called, "b skipped")
+}
@@ -0,0 +1,213 @@
+// Package cassette records the rate limit info of real Stream responses to a
+// JSON cassette and replays it through a fake caller, so that the behaviour
+// of the limiter against real-world traffic can be regression-tested offline.
+package cassette
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// ErrEnd is returned by Replayer.Call once every interaction was replayed.
+var ErrEnd = errors.New("cassette: no interaction left")
+
+// Interaction is a recorded call.
+type Interaction struct {
+	// OffsetMs is when the call was made, in milliseconds since the first
+	// call of the cassette.
+	OffsetMs  int64  `json:"offset_ms"`
+	RateLimit *Quota `json:"rate_limit,omitempty"`
+	Error     *Error `json:"error,omitempty"`
+}
+
+// Quota is the rate limit info of a response, its reset recorded relatively to
+// the call so that it can be replayed at any time.
+type Quota struct {
+	Limit     int64 `json:"limit"`
+	Remaining int64 `json:"remaining"`
+	// ResetIn is the reset in seconds after the call.
+	ResetIn int64 `json:"reset_in"`
+}
+
+// Error is the stream.Error of a failed call.
+type Error struct {
+	StatusCode int    `json:"status_code"`
+	Code       int    `json:"code,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+// Cassette is a sequence of recorded calls.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Load reads a cassette written by Save.
+func Load(r io.Reader) (*Cassette, error) {
+	var c Cassette
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// LoadFile reads the cassette of the file at path.
+func LoadFile(path string) (*Cassette, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Save writes c as indented JSON.
+func (c *Cassette) Save(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(c)
+}
+
+// SaveFile writes c to the file at path, replacing it.
+func (c *Cassette) SaveFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := c.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Recorder records the calls wrapped by Wrap. It is safe for concurrent use.
+type Recorder struct {
+	clock rate_limiter.Clock
+
+	mu       sync.Mutex
+	start    time.Time
+	cassette Cassette
+}
+
+// NewRecorder returns a Recorder reading the time from clock, or from the
+// system clock when clock is nil.
+func NewRecorder(clock rate_limiter.Clock) *Recorder {
+	return &Recorder{clock: clock}
+}
+
+func (r *Recorder) now() time.Time {
+	if r.clock == nil {
+		return time.Now()
+	}
+	return r.clock.Now()
+}
+
+// Wrap returns apiCall recording its rate limit info, or its error, on each
+// call.
+func (r *Recorder) Wrap(apiCall rate_limiter.GetStreamApiCaller) rate_limiter.GetStreamApiCaller {
+	return func() (*stream.Response, error) {
+		calledAt := r.now()
+		resp, err := apiCall()
+		r.record(calledAt, resp, err)
+		return resp, err
+	}
+}
+
+func (r *Recorder) record(calledAt time.Time, resp *stream.Response, err error) {
+	interaction := Interaction{}
+	var info *stream.RateLimitInfo
+	if err != nil {
+		var apiErr stream.Error
+		if errors.As(err, &apiErr) {
+			interaction.Error = &Error{StatusCode: apiErr.StatusCode, Code: apiErr.Code, Message: apiErr.Message}
+			info = apiErr.RateLimit
+		} else {
+			interaction.Error = &Error{Message: err.Error()}
+		}
+	} else if resp != nil {
+		info = resp.RateLimitInfo
+	}
+	if info != nil {
+		interaction.RateLimit = &Quota{Limit: info.Limit, Remaining: info.Remaining, ResetIn: info.Reset - calledAt.Unix()}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.start.IsZero() {
+		r.start = calledAt
+	}
+	interaction.OffsetMs = calledAt.Sub(r.start).Milliseconds()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+}
+
+// Cassette returns a copy of the calls recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Cassette{Interactions: append([]Interaction(nil), r.cassette.Interactions...)}
+}
+
+// Replayer replays the interactions of a cassette in order, one per Call. It
+// is safe for concurrent use.
+type Replayer struct {
+	cassette *Cassette
+	clock    rate_limiter.Clock
+
+	mu   sync.Mutex
+	next int
+}
+
+// NewReplayer returns a Replayer of c, shifting the resets relatively to the
+// time of clock, or of the system clock when clock is nil, e.g. the
+// clocktest.Fake given to the limiter.
+func NewReplayer(c *Cassette, clock rate_limiter.Clock) *Replayer {
+	return &Replayer{cassette: c, clock: clock}
+}
+
+// Call is a rate_limiter.GetStreamApiCaller returning the response, or the
+// error, of the next interaction, ErrEnd once all of them were replayed.
+func (p *Replayer) Call() (*stream.Response, error) {
+	p.mu.Lock()
+	if p.next >= len(p.cassette.Interactions) {
+		p.mu.Unlock()
+		return nil, ErrEnd
+	}
+	interaction := p.cassette.Interactions[p.next]
+	p.next++
+	p.mu.Unlock()
+
+	now := time.Now()
+	if p.clock != nil {
+		now = p.clock.Now()
+	}
+	var info *stream.RateLimitInfo
+	if quota := interaction.RateLimit; quota != nil {
+		info = &stream.RateLimitInfo{Limit: quota.Limit, Remaining: quota.Remaining, Reset: now.Unix() + quota.ResetIn}
+	}
+	if e := interaction.Error; e != nil {
+		if e.StatusCode == 0 {
+			return nil, errors.New(e.Message)
+		}
+		return nil, stream.Error{StatusCode: e.StatusCode, Code: e.Code, Message: e.Message, RateLimit: info}
+	}
+	return &stream.Response{RateLimitInfo: info}, nil
+}
+
+// Next returns the offset of the next interaction to replay, e.g. to move a
+// fake clock along the recorded traffic, reporting false when none is left.
+func (p *Replayer) Next() (time.Duration, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.next >= len(p.cassette.Interactions) {
+		return 0, false
+	}
+	return time.Duration(p.cassette.Interactions[p.next].OffsetMs) * time.Millisecond, true
+}
@@ -0,0 +1,115 @@
+package cassette_test
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/cassette"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	recorder := cassette.NewRecorder(clock)
+	responses := []func() (*stream.Response, error){
+		func() (*stream.Response, error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 9, Reset: 1060}}, nil
+		},
+		func() (*stream.Response, error) {
+			return nil, stream.Error{StatusCode: 500, Code: 16, Message: "internal"}
+		},
+		func() (*stream.Response, error) { return &stream.Response{}, nil },
+		func() (*stream.Response, error) { return nil, errors.New("connection reset") },
+	}
+	for _, respond := range responses {
+		_, _ = recorder.Wrap(respond)()
+		clock.Advance(time.Second)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, recorder.Cassette().Save(&buf))
+	loaded, err := cassette.Load(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, &cassette.Cassette{Interactions: []cassette.Interaction{
+		{OffsetMs: 0, RateLimit: &cassette.Quota{Limit: 10, Remaining: 9, ResetIn: 60}},
+		{OffsetMs: 1000, Error: &cassette.Error{StatusCode: 500, Code: 16, Message: "internal"}},
+		{OffsetMs: 2000},
+		{OffsetMs: 3000, Error: &cassette.Error{Message: "connection reset"}},
+	}}, loaded)
+
+	replayer := cassette.NewReplayer(loaded, clocktest.NewFake(time.Unix(5000, 0)))
+	next, ok := replayer.Next()
+	assert.True(t, ok)
+	assert.Zero(t, next)
+	resp, err := replayer.Call()
+	require.NoError(t, err)
+	assert.Equal(t, &stream.RateLimitInfo{Limit: 10, Remaining: 9, Reset: 5060}, resp.RateLimitInfo, "reset shifted")
+	_, err = replayer.Call()
+	assert.Equal(t, stream.Error{StatusCode: 500, Code: 16, Message: "internal"}, err)
+	resp, err = replayer.Call()
+	require.NoError(t, err)
+	assert.Nil(t, resp.RateLimitInfo)
+	_, err = replayer.Call()
+	assert.EqualError(t, err, "connection reset")
+
+	_, ok = replayer.Next()
+	assert.False(t, ok)
+	_, err = replayer.Call()
+	assert.ErrorIs(t, err, cassette.ErrEnd)
+}
+
+// TestReplayQueryUsers replays through a limiter a window exhausted by a
+// burst, followed by a 429 of the server, its window not reset yet.
+func TestReplayQueryUsers(t *testing.T) {
+	recorded, err := cassette.LoadFile(filepath.Join("testdata", "query_users.json"))
+	require.NoError(t, err)
+	start := time.Unix(1000, 0)
+	clock := clocktest.NewFake(start)
+	replayer := cassette.NewReplayer(recorded, clock)
+	rLimit := rate_limiter.NewRateLimiter(rate_limiter.QueryUsers, rate_limiter.WithClock(clock))
+
+	call := func() error {
+		t.Helper()
+		next, ok := replayer.Next()
+		require.True(t, ok)
+		clock.Set(start.Add(next))
+		return rLimit.TryCallApi(replayer.Call)
+	}
+	require.NoError(t, call())
+	assert.Equal(t, int64(2), rLimit.Remaining())
+	require.NoError(t, call())
+	assert.True(t, rLimit.IsBlocked())
+	assert.Equal(t, time.Unix(1039, 0), rLimit.ResetAt())
+
+	// the call made right after the reset is rejected by Stream, blocking
+	// the api one more second
+	err = call()
+	var rateLimited rate_limiter.ErrRateLimited
+	require.ErrorAs(t, err, &rateLimited)
+	assert.Equal(t, 429, rateLimited.Err.(stream.Error).StatusCode)
+	assert.True(t, rLimit.IsBlocked())
+	assert.Equal(t, time.Unix(1040, 0), rLimit.ResetAt())
+
+	require.NoError(t, call())
+	assert.False(t, rLimit.IsBlocked())
+	assert.Equal(t, int64(2), rLimit.Remaining())
+	_, ok := replayer.Next()
+	assert.False(t, ok)
+}
+
+func TestSaveFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	c := &cassette.Cassette{Interactions: []cassette.Interaction{{OffsetMs: 5, RateLimit: &cassette.Quota{Limit: 1}}}}
+	require.NoError(t, c.SaveFile(path))
+	loaded, err := cassette.LoadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, c, loaded)
+}
@@ -0,0 +1,135 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+)
+
+// Limiter runs api calls within its limits, like RateLimiter, see Chain.
+type Limiter interface {
+	// CallApiWithContext runs apiCall once admitted, waiting until then or
+	// until ctx is done.
+	CallApiWithContext(ctx context.Context, apiCall GetStreamApiCaller, opts ...CallOption) error
+	// TryCallApi runs apiCall only if admitted right away, otherwise it
+	// returns an error, e.g. ErrRateLimited, without calling it.
+	TryCallApi(apiCall GetStreamApiCaller, opts ...CallOption) error
+}
+
+var _ Limiter = (*RateLimiter)(nil)
+
+// WithClientSide makes the limiter ignore the rate limits reported by Stream,
+// shaping the calls with its concurrency and Strategy only, e.g. a TokenBucket
+// chained with the limiter of an endpoint, see Chain.
+func WithClientSide() Option {
+	return func(r *RateLimiter) {
+		r.clientSide = true
+	}
+}
+
+// limiterRanks numbers the limiters in creation order, the order Chain
+// acquires them in.
+var limiterRanks atomic.Uint64
+
+// order returns the rank of r, numbering it on first use.
+func (r *RateLimiter) order() uint64 {
+	if rank := r.rank.Load(); rank != 0 {
+		return rank
+	}
+	r.rank.CompareAndSwap(0, limiterRanks.Add(1))
+	return r.rank.Load()
+}
+
+type chain struct {
+	limiters []Limiter
+}
+
+// Chain returns a Limiter admitting a call once each of limiters admits it,
+// e.g. a client-side TokenBucket and the limiter of an endpoint, the response
+// being observed by all of them. The limiters are acquired one after the
+// other, each held until the call returns; when one of them turns the call
+// down, e.g. as the context is done, the ones already acquired are rolled
+// back, released without accounting a call, see StrategyCanceler. Whatever the order they are
+// given in, the RateLimiter are acquired in the order they were created, the
+// chains given being flattened, so that chains sharing limiters cannot
+// deadlock each other; other Limiter implementations are acquired first, in
+// the order given.
+func Chain(limiters ...Limiter) Limiter {
+	var (
+		others []Limiter
+		ranked []*RateLimiter
+		seen   = make(map[*RateLimiter]bool)
+	)
+	var add func(limiters []Limiter)
+	add = func(limiters []Limiter) {
+		for _, limiter := range limiters {
+			switch limiter := limiter.(type) {
+			case *chain:
+				add(limiter.limiters)
+			case *RateLimiter:
+				// acquiring the same limiter twice would deadlock
+				if !seen[limiter] {
+					seen[limiter] = true
+					ranked = append(ranked, limiter)
+				}
+			default:
+				others = append(others, limiter)
+			}
+		}
+	}
+	add(limiters)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].order() < ranked[j].order()
+	})
+	for _, r := range ranked {
+		others = append(others, r)
+	}
+	return &chain{limiters: others}
+}
+
+func (c *chain) CallApiWithContext(ctx context.Context, apiCall GetStreamApiCaller, opts ...CallOption) error {
+	return c.call(apiCall, func(limiter Limiter, apiCall GetStreamApiCaller) error {
+		return limiter.CallApiWithContext(ctx, apiCall, opts...)
+	})
+}
+
+func (c *chain) TryCallApi(apiCall GetStreamApiCaller, opts ...CallOption) error {
+	return c.call(apiCall, func(limiter Limiter, apiCall GetStreamApiCaller) error {
+		return limiter.TryCallApi(apiCall, opts...)
+	})
+}
+
+// call runs apiCall through each limiter of the chain, from the first one,
+// with through.
+func (c *chain) call(apiCall GetStreamApiCaller, through func(Limiter, GetStreamApiCaller) error) error {
+	var (
+		resp   *stream.Response
+		called bool
+	)
+	next := func() (*stream.Response, error) {
+		called = true
+		var err error
+		resp, err = apiCall()
+		return resp, err
+	}
+	for i := len(c.limiters) - 1; i >= 0; i-- {
+		limiter, inner := c.limiters[i], next
+		next = func() (*stream.Response, error) {
+			err := through(limiter, inner)
+			if err != nil && !called {
+				// not a failed call for the limiters already acquired, marked
+				// once by the innermost level turning it down
+				if errors.As(err, new(*levelRejection)) {
+					return nil, err
+				}
+				return nil, &levelRejection{err: err}
+			}
+			return resp, err
+		}
+	}
+	_, err := next()
+	return err
+}
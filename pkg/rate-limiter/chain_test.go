@@ -0,0 +1,166 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func okResponse() (*stream.Response, error) {
+	return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 1000, Reset: time.Now().Unix() + 60}}, nil
+}
+
+func TestChain(t *testing.T) {
+	bucket := NewRateLimiter("bucket", WithClientSide(), WithStrategy(NewTokenBucket(0, 1)))
+	endpoint := NewRateLimiter(QueryUsers)
+	limiter := Chain(bucket, endpoint)
+
+	require.NoError(t, limiter.CallApiWithContext(context.Background(), okResponse))
+	assert.Equal(t, int64(1000), endpoint.Remaining())
+	assert.Equal(t, int64(-1), bucket.Remaining(), "client side only")
+
+	var errored []error
+	endpoint.OnError(func(api string, err error) {
+		errored = append(errored, err)
+	})
+	var limited ErrRateLimited
+	require.ErrorAs(t, limiter.TryCallApi(func() (*stream.Response, error) {
+		t.Error("called beyond the bucket")
+		return nil, nil
+	}), &limited)
+	assert.Equal(t, "bucket", limited.ApiName)
+	assert.Empty(t, errored)
+}
+
+func TestChainError(t *testing.T) {
+	a, b := NewRateLimiter("a"), NewRateLimiter("b")
+	limiter := Chain(a, b)
+	assert.ErrorIs(t, limiter.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return nil, assert.AnError
+	}), assert.AnError)
+	// both released
+	require.NoError(t, a.TryCallApi(okResponse))
+	require.NoError(t, b.TryCallApi(okResponse))
+}
+
+func TestChainRollsBack(t *testing.T) {
+	a, b := NewRateLimiter("a"), NewRateLimiter("b")
+	release := holdSlot(t, b)
+	defer release()
+	var errored []error
+	a.OnError(func(api string, err error) {
+		errored = append(errored, err)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Chain(a, b).CallApiWithContext(ctx, func() (*stream.Response, error) {
+			t.Error("called while b is busy")
+			return nil, nil
+		})
+	}()
+	require.Eventually(t, func() bool { return queued(b) == 1 }, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, receive(t, done), context.Canceled)
+	// a was released without accounting a call
+	require.NoError(t, a.TryCallApi(okResponse))
+	assert.Empty(t, errored)
+}
+
+func TestChainMarksRejectionOnce(t *testing.T) {
+	a, b, c := NewRateLimiter("a"), NewRateLimiter("b"), NewRateLimiter("c")
+	release := holdSlot(t, c)
+	defer release()
+
+	err := Chain(a, b, c).TryCallApi(okResponse)
+	var limited ErrRateLimited
+	require.ErrorAs(t, err, &limited)
+	assert.Equal(t, "c", limited.ApiName)
+	var rejection *levelRejection
+	require.ErrorAs(t, err, &rejection)
+	assert.False(t, errors.As(rejection.err, new(*levelRejection)), "wrapped once")
+	require.NoError(t, a.TryCallApi(okResponse))
+	require.NoError(t, b.TryCallApi(okResponse))
+}
+
+// passLimiter is a Limiter admitting every call.
+type passLimiter struct{}
+
+func (*passLimiter) CallApiWithContext(ctx context.Context, apiCall GetStreamApiCaller, opts ...CallOption) error {
+	_, err := apiCall()
+	return err
+}
+
+func (*passLimiter) TryCallApi(apiCall GetStreamApiCaller, opts ...CallOption) error {
+	_, err := apiCall()
+	return err
+}
+
+func TestChainCancelsStrategies(t *testing.T) {
+	bucket := NewTokenBucket(0, 2)
+	outer := NewRateLimiter("outer", WithClientSide(), WithStrategy(bucket))
+	inner := NewRateLimiter("inner", WithClientSide(), WithStrategy(NewTokenBucket(0, 1)))
+	limiter := Chain(outer, inner)
+	require.NoError(t, inner.TryCallApi(okResponse))
+
+	var limited ErrRateLimited
+	require.ErrorAs(t, limiter.TryCallApi(okResponse), &limited)
+	assert.Equal(t, "inner", limited.ApiName)
+	assert.Equal(t, 2.0, bucket.tokens, "the outer tokens are given back")
+	require.NoError(t, outer.TryCallApi(okResponse))
+	require.NoError(t, outer.TryCallApi(okResponse))
+}
+
+func TestChainOrder(t *testing.T) {
+	a, b, c := NewRateLimiter("a"), NewRateLimiter("b"), NewRateLimiter("c")
+	other := &passLimiter{}
+	limiter := Chain(c, other, Chain(b, a), c).(*chain)
+	assert.Equal(t, []Limiter{other, a, b, c}, limiter.limiters, "creation order, without duplicates")
+}
+
+func TestChainOrdersDoNotDeadlock(t *testing.T) {
+	a, b := NewRateLimiter("a"), NewRateLimiter("b")
+	chains := []Limiter{Chain(a, b), Chain(b, a)}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(limiter Limiter) {
+			defer wg.Done()
+			assert.NoError(t, limiter.CallApiWithContext(context.Background(), okResponse))
+		}(chains[i%2])
+	}
+	idle := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(idle)
+	}()
+	select {
+	case <-idle:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock")
+	}
+}
+
+func TestChainNested(t *testing.T) {
+	a, b, c := NewRateLimiter("a"), NewRateLimiter("b"), NewRateLimiter("c")
+	require.NoError(t, Chain(Chain(a, b), c).CallApiWithContext(context.Background(), okResponse))
+	for _, r := range []*RateLimiter{a, b, c} {
+		assert.Equal(t, int64(1000), r.Remaining(), r.apiName)
+	}
+}
+
+func TestEmptyChain(t *testing.T) {
+	called := false
+	require.NoError(t, Chain().CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		called = true
+		return nil, nil
+	}))
+	assert.True(t, called)
+}
@@ -0,0 +1,61 @@
+package rate_limiter
+
+import (
+	"context"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+)
+
+// MaxUsersPerRequest is the largest number of users Stream accepts in a single
+// QueryUsers or UpsertUsers request.
+const MaxUsersPerRequest = 100
+
+// chunks splits items into chunks of up to size items.
+func chunks[T any](items []T, size int) [][]T {
+	var chunks [][]T
+	for len(items) > size {
+		chunks = append(chunks, items[:size:size])
+		items = items[size:]
+	}
+	if len(items) > 0 {
+		chunks = append(chunks, items)
+	}
+	return chunks
+}
+
+// QueryUsersByID queries the users of ids, however many, by chunks of
+// MaxUsersPerRequest ids called one after the other through the limiter of
+// QueryUsers, hence paced as its other calls. On failure, the users found by
+// the previous chunks are returned alongside the error.
+func (c *Client) QueryUsersByID(ctx context.Context, ids []string) ([]*stream.User, error) {
+	users := make([]*stream.User, 0, len(ids))
+	for _, chunk := range chunks(ids, MaxUsersPerRequest) {
+		resp, err := c.QueryUsers(ctx, &stream.QueryOption{
+			Filter: map[string]interface{}{"id": map[string]interface{}{"$in": chunk}},
+			Limit:  len(chunk),
+		})
+		if err != nil {
+			return users, err
+		}
+		users = append(users, resp.Users...)
+	}
+	return users, nil
+}
+
+// UpsertUsersInChunks upserts users, however many, by chunks of
+// MaxUsersPerRequest users called one after the other through the limiter of
+// UpdateUsers, and returns the upserted users by ID. On failure, the users
+// upserted by the previous chunks are returned alongside the error.
+func (c *Client) UpsertUsersInChunks(ctx context.Context, users []*stream.User) (map[string]*stream.User, error) {
+	upserted := make(map[string]*stream.User, len(users))
+	for _, chunk := range chunks(users, MaxUsersPerRequest) {
+		resp, err := c.UpsertUsers(ctx, chunk...)
+		if err != nil {
+			return upserted, err
+		}
+		for id, user := range resp.Users {
+			upserted[id] = user
+		}
+	}
+	return upserted, nil
+}
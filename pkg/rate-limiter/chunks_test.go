@@ -0,0 +1,89 @@
+package rate_limiter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunks(t *testing.T) {
+	assert.Nil(t, chunks([]int{}, 2))
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, chunks([]int{1, 2, 3, 4, 5}, 2))
+	assert.Equal(t, [][]int{{1, 2}}, chunks([]int{1, 2}, 2))
+}
+
+// userIDs returns n user IDs.
+func userIDs(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprint("user-", i)
+	}
+	return ids
+}
+
+func TestQueryUsersByID(t *testing.T) {
+	var sizes []int
+	client := newTestStreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var query struct {
+			Filter struct {
+				ID struct {
+					In []string `json:"$in"`
+				} `json:"id"`
+			} `json:"filter_conditions"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(r.URL.Query().Get("payload")), &query))
+		sizes = append(sizes, len(query.Filter.ID.In))
+		w.Header().Set(stream.HeaderRateRemaining, "50")
+		w.Header().Set(stream.HeaderRateReset, strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+		users := make([]map[string]string, len(query.Filter.ID.In))
+		for i, id := range query.Filter.ID.In {
+			users[i] = map[string]string{"id": id}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"users": users})
+	})
+
+	users, err := WrapClient(client).QueryUsersByID(context.Background(), userIDs(250))
+	require.NoError(t, err)
+	assert.Equal(t, []int{100, 100, 50}, sizes)
+	require.Len(t, users, 250)
+	assert.Equal(t, "user-249", users[249].ID)
+}
+
+func TestUpsertUsersInChunks(t *testing.T) {
+	requests := 0
+	client := newTestStreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var upsert struct {
+			Users map[string]map[string]any `json:"users"`
+		}
+		require.NoError(t, json.Unmarshal(body, &upsert))
+		if requests == 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"message":"boom"}`))
+			return
+		}
+		w.Header().Set(stream.HeaderRateRemaining, "50")
+		w.Header().Set(stream.HeaderRateReset, strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+		_ = json.NewEncoder(w).Encode(map[string]any{"users": upsert.Users})
+	})
+	users := make([]*stream.User, 0, 250)
+	for _, id := range userIDs(250) {
+		users = append(users, &stream.User{ID: id})
+	}
+
+	upserted, err := WrapClient(client).UpsertUsersInChunks(context.Background(), users)
+	assert.Error(t, err)
+	assert.Equal(t, 3, requests)
+	assert.Len(t, upserted, 200)
+}
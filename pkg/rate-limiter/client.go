@@ -0,0 +1,66 @@
+package rate_limiter
+
+import (
+	"context"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+)
+
+//go:generate go run ./internal/genwrappers -o client_gen.go
+
+// Client exposes the operations of a stream.Client, each of them rate limited
+// by the limiter of its endpoint, so that call sites do not have to wrap every
+// call in a closure. Its methods are generated from the ones of stream.Client
+// taking a context and returning a response.
+type Client struct {
+	client  *stream.Client
+	manager *LimiterManager
+}
+
+// WrapClient returns a Client calling client through limiters built with
+// opts, one per endpoint.
+func WrapClient(client *stream.Client, opts ...Option) *Client {
+	return &Client{client: client, manager: NewLimiterManager(opts...)}
+}
+
+// Stream returns the wrapped stream.Client, e.g. for the operations not
+// exposed by Client, which are not rate limited.
+func (c *Client) Stream() *stream.Client {
+	return c.client
+}
+
+// Manager returns the LimiterManager holding the limiters of the endpoints,
+// e.g. to inspect, preflight or close them.
+func (c *Client) Manager() *LimiterManager {
+	return c.manager
+}
+
+// SendMessage sends message to ch, which must have been obtained through the
+// wrapped client, e.g. from CreateChannel or stream.Client.Channel.
+func (c *Client) SendMessage(ctx context.Context, ch *stream.Channel, message *stream.Message, userID string, options ...stream.SendMessageOption) (*stream.MessageResponse, error) {
+	return Call(ctx, c.manager.For(SendMessage), func() (*stream.MessageResponse, *stream.Response, error) {
+		resp, err := ch.SendMessage(ctx, message, userID, options...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp, &resp.Response, nil
+	})
+}
+
+// Channel exposes the operations of a stream.Channel, each of them rate
+// limited by the limiter of its endpoint, like Client.
+type Channel struct {
+	channel *stream.Channel
+	manager *LimiterManager
+}
+
+// WrapChannel returns a Channel calling ch, which must have been obtained
+// through the wrapped client, through the limiters of c.
+func (c *Client) WrapChannel(ch *stream.Channel) *Channel {
+	return &Channel{channel: ch, manager: c.manager}
+}
+
+// Stream returns the wrapped stream.Channel.
+func (ch *Channel) Stream() *stream.Channel {
+	return ch.channel
+}
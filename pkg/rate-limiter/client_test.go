@@ -0,0 +1,93 @@
+package rate_limiter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStreamClient returns a stream.Client talking to handler.
+func newTestStreamClient(t *testing.T, handler http.HandlerFunc) *stream.Client {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client, err := stream.NewClient("key", "secret")
+	require.NoError(t, err)
+	client.BaseURL = srv.URL
+	return client
+}
+
+func TestWrapClient(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Unix()
+	client := newTestStreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		remaining := "10"
+		if strings.HasPrefix(r.URL.Path, "/messages") {
+			remaining = "5"
+		}
+		w.Header().Set(stream.HeaderRateLimit, "20")
+		w.Header().Set(stream.HeaderRateRemaining, remaining)
+		w.Header().Set(stream.HeaderRateReset, strconv.FormatInt(reset, 10))
+		if r.URL.Path == "/users" {
+			_, _ = w.Write([]byte(`{"users":[{"id":"jane"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	})
+	wrapped := WrapClient(client, WithMaxConcurrency(2))
+	assert.Same(t, client, wrapped.Stream())
+
+	users, err := wrapped.QueryUsers(context.Background(), &stream.QueryOption{Filter: map[string]interface{}{"id": "jane"}})
+	require.NoError(t, err)
+	require.Len(t, users.Users, 1)
+	assert.Equal(t, "jane", users.Users[0].ID)
+
+	_, err = wrapped.DeleteMessage(context.Background(), "message-id")
+	require.NoError(t, err)
+
+	// each endpoint has its own limiter
+	assert.Equal(t, int64(10), wrapped.Manager().For(QueryUsers).Remaining())
+	assert.Equal(t, int64(5), wrapped.Manager().For(DeleteMessage).Remaining())
+	assert.Equal(t, int64(-1), wrapped.Manager().For(QueryChannels).Remaining())
+	assert.Equal(t, 2, wrapped.Manager().For(QueryUsers).Concurrency())
+}
+
+func TestWrapClientError(t *testing.T) {
+	client := newTestStreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(stream.HeaderRateReset, strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"code":9,"message":"Too many requests","StatusCode":429}`))
+	})
+	wrapped := WrapClient(client)
+
+	_, err := wrapped.GetMessage(context.Background(), "message-id")
+	assert.Error(t, err)
+	assert.True(t, wrapped.Manager().For(GetMessage).IsBlocked())
+	assert.False(t, wrapped.Manager().For(UpdateMessage).IsBlocked())
+}
+
+func TestWrapChannel(t *testing.T) {
+	client := newTestStreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(stream.HeaderRateRemaining, "7")
+		w.Header().Set(stream.HeaderRateReset, strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+		_, _ = w.Write([]byte(`{"message":{"id":"message-id","text":"hello"}}`))
+	})
+	wrapped := WrapClient(client)
+	ch := wrapped.WrapChannel(client.Channel("messaging", "general"))
+	assert.Equal(t, "general", ch.Stream().ID)
+
+	resp, err := ch.SendMessage(context.Background(), &stream.Message{Text: "hello"}, "jane")
+	require.NoError(t, err)
+	assert.Equal(t, "message-id", resp.Message.ID)
+	assert.Equal(t, int64(7), wrapped.Manager().For(SendMessage).Remaining())
+
+	_, err = ch.Delete(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), wrapped.Manager().For("DeleteChannel").Remaining(), "named after the endpoint")
+}
@@ -0,0 +1,61 @@
+package rate_limiter
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock abstracts the time source used by a RateLimiter.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// TimerClock is a Clock able to schedule calls, like time.AfterFunc, returning
+// a func stopping the call, which reports false if the call already ran or was
+// stopped, like context.AfterFunc. The limiter schedules the end of a block
+// with it, so that the block can be cancelled or rescheduled; other clocks get
+// a goroutine waiting on After.
+type TimerClock interface {
+	Clock
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
+
+// afterFunc calls f once d elapsed on clock, returning a func stopping it.
+func afterFunc(clock Clock, d time.Duration, f func()) func() bool {
+	if clock, ok := clock.(TimerClock); ok {
+		return clock.AfterFunc(d, f)
+	}
+	var done atomic.Bool
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-clock.After(d):
+			if done.CompareAndSwap(false, true) {
+				f()
+			}
+		case <-stop:
+		}
+	}()
+	return func() bool {
+		if !done.CompareAndSwap(false, true) {
+			return false
+		}
+		close(stop)
+		return true
+	}
+}
@@ -0,0 +1,35 @@
+package rate_limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+// afterClock hides the AfterFunc method of its clock.
+type afterClock struct {
+	Clock
+}
+
+func TestAfterFuncWithoutTimerClock(t *testing.T) {
+	fake := clocktest.NewFake(time.Unix(1000, 0))
+	clock := afterClock{fake}
+
+	fired := make(chan struct{})
+	afterFunc(clock, time.Second, func() { close(fired) })
+	blockUntil(t, fake, 1)
+	fake.Advance(time.Second)
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("not fired")
+	}
+
+	stop := afterFunc(clock, time.Second, func() { t.Error("fired after stop") })
+	assert.True(t, stop())
+	assert.False(t, stop(), "already stopped")
+	fake.Advance(time.Second)
+}
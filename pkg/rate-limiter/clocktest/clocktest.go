@@ -0,0 +1,170 @@
+// Package clocktest provides a fake clock to test the rate limiter blocking
+// behaviour instantly and deterministically, see rate_limiter.WithClock.
+package clocktest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type waiter struct {
+	deadline time.Time
+	c        chan time.Time // fired by After waiters
+	f        func()         // called by AfterFunc waiters
+}
+
+// Fake is a manually driven clock: time only moves on Advance or Set,
+// firing the channels returned by After and the functions scheduled by
+// AfterFunc whose deadline is reached.
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*waiter
+}
+
+// NewFake returns a Fake clock set at now.
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := make(chan time.Time, 1)
+	if d <= 0 {
+		c <- f.now
+		return c
+	}
+	f.add(&waiter{deadline: f.now.Add(d), c: c})
+	return c
+}
+
+// AfterFunc schedules fn once the clock is moved d forward. fn is called by
+// Advance or Set, before they return, and right away when d is not positive.
+func (f *Fake) AfterFunc(d time.Duration, fn func()) (stop func() bool) {
+	if d <= 0 {
+		fn()
+		return func() bool { return false }
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := &waiter{deadline: f.now.Add(d), f: fn}
+	f.add(w)
+	return func() bool { return f.remove(w) }
+}
+
+func (f *Fake) add(w *waiter) {
+	f.waiters = append(f.waiters, w)
+	sort.SliceStable(f.waiters, func(i, j int) bool {
+		return f.waiters[i].deadline.Before(f.waiters[j].deadline)
+	})
+	f.cond.Broadcast()
+}
+
+// remove cancels the pending waiter w, reporting false if it already fired.
+func (f *Fake) remove(w *waiter) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, pending := range f.waiters {
+		if pending == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			f.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+// Advance moves the clock forward by d.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	now := f.now.Add(d)
+	f.mu.Unlock()
+	f.Set(now)
+}
+
+// Set moves the clock to now, firing every waiter whose deadline is reached.
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	f.now = now
+	var funcs []func()
+	fired := 0
+	for _, w := range f.waiters {
+		if w.deadline.After(now) {
+			break
+		}
+		if w.f != nil {
+			funcs = append(funcs, w.f)
+		} else {
+			w.c <- now
+		}
+		fired++
+	}
+	f.waiters = f.waiters[fired:]
+	f.cond.Broadcast()
+	f.mu.Unlock()
+
+	// called without the lock, as they may use the clock
+	for _, fn := range funcs {
+		fn()
+	}
+}
+
+// Waiters returns the number of pending After and AfterFunc calls.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil blocks until at least n After or AfterFunc calls are pending, e.g. until the
+// limiter started waiting for a rate limit reset.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// BlockUntilContext is BlockUntil returning ctx.Err() when ctx is done first,
+// so that a test does not hang when the limiter never waits.
+func (f *Fake) BlockUntilContext(ctx context.Context, n int) error {
+	stop := context.AfterFunc(ctx, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.cond.Broadcast()
+	})
+	defer stop()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		f.cond.Wait()
+	}
+	return nil
+}
+
+// NextDeadline returns the earliest pending deadline, false when none is pending.
+func (f *Fake) NextDeadline() (time.Time, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.waiters) == 0 {
+		return time.Time{}, false
+	}
+	return f.waiters[0].deadline, true
+}
@@ -0,0 +1,94 @@
+package clocktest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+var _ rate_limiter.TimerClock = (*clocktest.Fake)(nil)
+
+func TestFake(t *testing.T) {
+	start := time.Unix(1000, 0)
+	clock := clocktest.NewFake(start)
+
+	immediate := clock.After(0)
+	assert.Equal(t, start, <-immediate)
+
+	later := clock.After(2 * time.Second)
+	sooner := clock.After(time.Second)
+	assert.Equal(t, 2, clock.Waiters())
+	deadline, ok := clock.NextDeadline()
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(time.Second), deadline)
+
+	clock.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Second), <-sooner)
+	assert.Equal(t, 1, clock.Waiters())
+	select {
+	case <-later:
+		t.Fatal("fired before its deadline")
+	default:
+	}
+
+	clock.Set(start.Add(time.Minute))
+	assert.Equal(t, start.Add(time.Minute), <-later)
+	assert.Equal(t, start.Add(time.Minute), clock.Now())
+	_, ok = clock.NextDeadline()
+	assert.False(t, ok)
+}
+
+func TestFakeBlockUntilContext(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, clock.BlockUntilContext(ctx, 1), context.DeadlineExceeded)
+
+	clock.After(time.Second)
+	assert.NoError(t, clock.BlockUntilContext(context.Background(), 1))
+}
+
+func TestFakeDrivesRateLimiter(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := rate_limiter.NewRateLimiter(rate_limiter.QueryUsers, rate_limiter.WithClock(clock))
+
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Reset: 1060}}, nil
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, clock.BlockUntilContext(ctx, 1))
+	assert.True(t, rLimit.IsBlocked())
+
+	clock.Advance(59 * time.Second)
+	assert.True(t, rLimit.IsBlocked())
+
+	clock.Advance(time.Second)
+	assert.Eventually(t, func() bool { return !rLimit.IsBlocked() }, time.Second, time.Millisecond)
+}
+
+func TestFakeAfterFunc(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+
+	fired := 0
+	clock.AfterFunc(time.Second, func() { fired++ })
+	stop := clock.AfterFunc(2*time.Second, func() { t.Error("fired after stop") })
+	assert.Equal(t, 2, clock.Waiters())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, 1, fired, "fired before Advance returns")
+	assert.True(t, stop())
+	assert.False(t, stop())
+	assert.Equal(t, 0, clock.Waiters())
+	clock.Advance(time.Second)
+
+	clock.AfterFunc(0, func() { fired++ })
+	assert.Equal(t, 2, fired)
+}
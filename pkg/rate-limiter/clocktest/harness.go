@@ -0,0 +1,124 @@
+package clocktest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Completion is a goroutine of a Harness returning.
+type Completion struct {
+	Name string
+	// At is the time of the clock when it returned.
+	At  time.Time
+	Err error
+}
+
+// Harness runs named goroutines, e.g. limited calls, against a Fake clock,
+// recording in which order and at which virtual time they return, so that a
+// test can assert the exact unblock ordering: Step moves the clock to the next
+// deadline only, firing its timers before returning, and WaitDone waits for
+// the goroutines released.
+type Harness struct {
+	*Fake
+
+	mu          sync.Mutex
+	changed     chan struct{} // closed and replaced on each completion
+	running     map[string]bool
+	completions []Completion
+}
+
+// NewHarness returns a Harness with a Fake clock set at now.
+func NewHarness(now time.Time) *Harness {
+	return &Harness{Fake: NewFake(now), changed: make(chan struct{}), running: make(map[string]bool)}
+}
+
+// Go runs f on a goroutine named name, recording its Completion once it
+// returns. Names must be unique.
+func (h *Harness) Go(name string, f func() error) {
+	h.mu.Lock()
+	if _, ok := h.running[name]; ok {
+		h.mu.Unlock()
+		panic(fmt.Sprintf("clocktest: goroutine %q already run", name))
+	}
+	h.running[name] = true
+	h.mu.Unlock()
+
+	go func() {
+		err := f()
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.running[name] = false
+		h.completions = append(h.completions, Completion{Name: name, At: h.Now(), Err: err})
+		close(h.changed)
+		h.changed = make(chan struct{})
+	}()
+}
+
+// Step moves the clock to the next pending deadline, firing the waiters due
+// then, and returns it; it reports false, leaving the clock as is, when none
+// is pending.
+func (h *Harness) Step() (time.Time, bool) {
+	deadline, ok := h.NextDeadline()
+	if !ok {
+		return time.Time{}, false
+	}
+	h.Set(deadline)
+	return deadline, true
+}
+
+// WaitDone waits until the goroutines of names returned, returning ctx.Err()
+// when ctx is done first.
+func (h *Harness) WaitDone(ctx context.Context, names ...string) error {
+	for {
+		h.mu.Lock()
+		changed := h.changed
+		done := true
+		for _, name := range names {
+			if running, ok := h.running[name]; !ok || running {
+				done = false
+				break
+			}
+		}
+		h.mu.Unlock()
+		if done {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Completions returns the goroutines returned so far, in order.
+func (h *Harness) Completions() []Completion {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Completion(nil), h.completions...)
+}
+
+// Order returns the names of the goroutines returned so far, in order.
+func (h *Harness) Order() []string {
+	var names []string
+	for _, completion := range h.Completions() {
+		names = append(names, completion.Name)
+	}
+	return names
+}
+
+// AssertOrder reports whether the goroutines returned so far are names, in
+// this order, failing t otherwise.
+func (h *Harness) AssertOrder(t testing.TB, names ...string) bool {
+	t.Helper()
+	got := h.Order()
+	if !slices.Equal(got, names) {
+		t.Errorf("goroutines returned in order %q, want %q", got, names)
+		return false
+	}
+	return true
+}
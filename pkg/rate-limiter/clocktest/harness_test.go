@@ -0,0 +1,72 @@
+package clocktest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestHarnessUnblockOrder(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	h := clocktest.NewHarness(time.Unix(1000, 0))
+	manager := rate_limiter.NewLimiterManager(rate_limiter.WithClock(h))
+	resets := map[rate_limiter.GetStreamApiName]int64{
+		rate_limiter.QueryUsers:    1030,
+		rate_limiter.QueryChannel:  1010,
+		rate_limiter.CreateChannel: 1020,
+	}
+	for api, reset := range resets {
+		limiter := manager.For(api)
+		require.NoError(t, limiter.CallApiWithContext(ctx, func() (*stream.Response, error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: reset}}, nil
+		}))
+		h.Go(string(api), func() error { return limiter.WaitUntilAvailable(ctx) })
+	}
+
+	for _, api := range []string{"QueryChannel", "CreateChannel", "QueryUsers"} {
+		_, ok := h.Step()
+		require.True(t, ok)
+		require.NoError(t, h.WaitDone(ctx, api))
+	}
+	_, ok := h.Step()
+	assert.False(t, ok, "no timer left")
+
+	h.AssertOrder(t, "QueryChannel", "CreateChannel", "QueryUsers")
+	assert.Equal(t, []clocktest.Completion{
+		{Name: "QueryChannel", At: time.Unix(1010, 0)},
+		{Name: "CreateChannel", At: time.Unix(1020, 0)},
+		{Name: "QueryUsers", At: time.Unix(1030, 0)},
+	}, h.Completions())
+}
+
+func TestHarnessWaitDone(t *testing.T) {
+	h := clocktest.NewHarness(time.Unix(1000, 0))
+	errDone := errors.New("done")
+	h.Go("after", func() error {
+		<-h.After(time.Minute)
+		return errDone
+	})
+	h.BlockUntil(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, h.WaitDone(ctx, "after"), context.DeadlineExceeded)
+	assert.ErrorIs(t, h.WaitDone(ctx, "unknown"), context.DeadlineExceeded)
+
+	deadline, ok := h.Step()
+	assert.True(t, ok)
+	assert.Equal(t, time.Unix(1060, 0), deadline)
+	require.NoError(t, h.WaitDone(context.Background(), "after"))
+	assert.Equal(t, []clocktest.Completion{{Name: "after", At: time.Unix(1060, 0), Err: errDone}}, h.Completions())
+
+	assert.Panics(t, func() { h.Go("after", nil) })
+}
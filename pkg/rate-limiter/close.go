@@ -0,0 +1,132 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrClosed is returned by the calls made after Close, and by the calls still
+// waiting for a slot or for the rate limit reset when Close is called.
+var ErrClosed = errors.New("rate limiter closed")
+
+// closing returns the channel closed by Close.
+func (r *RateLimiter) closing() chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closingLocked()
+}
+
+func (r *RateLimiter) closingLocked() chan struct{} {
+	if r.done == nil {
+		r.done = make(chan struct{})
+	}
+	return r.done
+}
+
+// enter registers a call, failing once the limiter is closed or draining.
+func (r *RateLimiter) enter() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return r.errClosed()
+	}
+	if r.draining {
+		return r.errDraining()
+	}
+	r.calls++
+	return nil
+}
+
+// leave unregisters a call registered by enter.
+func (r *RateLimiter) leave() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls--
+	if r.calls == 0 && r.idle != nil {
+		close(r.idle)
+		r.idle = nil
+	}
+	if r.left != nil {
+		close(r.left)
+		r.left = nil
+	}
+}
+
+// shutdown stops accepting calls, cancels the waiting ones and stops the reset
+// timer, returning a channel closed once no call is in flight.
+func (r *RateLimiter) shutdown() <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.closed {
+		r.closed = true
+		close(r.closingLocked())
+		r.stopResetLocked()
+		r.closeEvents()
+	}
+	idle := make(chan struct{})
+	if r.calls == 0 {
+		close(idle)
+		return idle
+	}
+	if r.idle == nil {
+		r.idle = make(chan struct{})
+	}
+	return r.idle
+}
+
+// Close stops accepting new calls, which fail with ErrClosed like the calls
+// still waiting for a slot or for the rate limit reset, and stops the reset
+// timer. It then waits for the calls in flight to complete, returning
+// ctx.Err() if ctx is done first. Close can be called several times.
+func (r *RateLimiter) Close(ctx context.Context) error {
+	idle := r.shutdown()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *RateLimiter) errClosed() error {
+	return fmt.Errorf("%w: %s", ErrClosed, r.apiName)
+}
+
+// Close closes every limiter of the manager, as RateLimiter.Close, waiting for
+// the calls in flight of all of them. Limiters requested afterwards are closed
+// as well.
+func (m *LimiterManager) Close(ctx context.Context) error {
+	return waitIdle(ctx, m.shutdown())
+}
+
+// shutdown shuts every limiter of the manager down, returning the channels
+// closed once they have no call in flight.
+func (m *LimiterManager) shutdown() []<-chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	idle := make([]<-chan struct{}, 0, m.limiters.len()+m.tenantLimiters.len())
+	m.limiters.each(func(_ GetStreamApiName, r *RateLimiter) {
+		idle = append(idle, r.shutdown())
+	})
+	m.tenantLimiters.each(func(_ tenantKey, t *tenantLimiter) {
+		idle = append(idle, t.r.shutdown())
+	})
+	if m.global != nil {
+		idle = append(idle, m.global.shutdown())
+	}
+	return idle
+}
+
+// waitIdle waits for every idle channel to be closed, or for ctx to be done.
+func waitIdle(ctx context.Context, idle []<-chan struct{}) error {
+	for _, done := range idle {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
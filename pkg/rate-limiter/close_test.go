@@ -0,0 +1,101 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestClose(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	assert.NoError(t, rLimit.Close(context.Background()))
+	assert.NoError(t, rLimit.Close(context.Background()), "closing twice")
+
+	apiCall := func() (resp *stream.Response, err error) {
+		t.Error("call invoked after Close")
+		return nil, nil
+	}
+	err := rLimit.CallApiWithContext(context.Background(), apiCall)
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.EqualError(t, err, "rate limiter closed: QueryUsers")
+	assert.ErrorIs(t, rLimit.TryCallApi(apiCall), ErrClosed)
+	assert.NoError(t, (&RateLimiter{}).Close(context.Background()))
+}
+
+func TestCloseCancelsWaitingCalls(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+	assert.NoError(t, rLimit.TryCallApi(func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 1, Remaining: 0, Reset: 1060}}, nil
+	}))
+	blockUntil(t, clock, 1)
+
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			done <- rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+				t.Error("waiting call invoked after Close")
+				return nil, nil
+			})
+		}()
+	}
+	// one call waits for the reset with the token, the other one is queued
+	assert.Eventually(t, func() bool { return queued(rLimit) == 1 }, time.Second, time.Millisecond)
+
+	assert.NoError(t, rLimit.Close(context.Background()))
+	for i := 0; i < 2; i++ {
+		assert.ErrorIs(t, receive(t, done), ErrClosed)
+	}
+	assert.Equal(t, 0, len(rLimit.tokens()), "tokens released")
+}
+
+func TestCloseWaitsForCallsInFlight(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	releaseSlot := holdSlot(t, rLimit)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, rLimit.Close(ctx), context.DeadlineExceeded)
+
+	closed := make(chan error, 1)
+	go func() { closed <- rLimit.Close(context.Background()) }()
+	select {
+	case <-closed:
+		t.Fatal("Close returned with a call in flight")
+	case <-time.After(10 * time.Millisecond):
+	}
+	releaseSlot()
+	assert.NoError(t, receive(t, closed))
+}
+
+func TestLimiterManagerClose(t *testing.T) {
+	manager := NewLimiterManager()
+	rLimit := manager.For(QueryUsers)
+	assert.NoError(t, manager.Close(context.Background()))
+
+	apiCall := func() (resp *stream.Response, err error) {
+		t.Error("call invoked after Close")
+		return nil, nil
+	}
+	assert.ErrorIs(t, rLimit.TryCallApi(apiCall), ErrClosed)
+	assert.ErrorIs(t, manager.For(QueryChannel).TryCallApi(apiCall), ErrClosed, "limiters created after Close")
+}
+
+func TestCloseStopsResetTimer(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+	rLimit.OnResumed(func(string) { t.Error("resumed after Close") })
+	assert.NoError(t, rLimit.TryCallApi(func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 1, Remaining: 0, Reset: 1060}}, nil
+	}))
+	assert.Equal(t, 1, clock.Waiters())
+
+	assert.NoError(t, rLimit.Close(context.Background()))
+	assert.Equal(t, 0, clock.Waiters(), "timer stopped")
+	clock.Advance(time.Minute)
+}
@@ -0,0 +1,118 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+)
+
+// errSharedCallPanicked is returned to the callers sharing a call which
+// panicked, the panic being raised in the caller performing it.
+var errSharedCallPanicked = errors.New("shared call panicked")
+
+// Coalescer shares one call among the concurrent callers of the same key, like
+// golang.org/x/sync/singleflight, see CallShared. The zero value is usable.
+type Coalescer struct {
+	mu    sync.Mutex
+	calls map[string]*sharedCall
+}
+
+type sharedCall struct {
+	done    chan struct{}
+	waiters int // callers joining the call
+	value   any
+	err     error
+}
+
+// CallShared runs apiCall through r as Call does, unless a call of the same
+// key is already in flight through c, in which case it waits for that call and
+// returns its result and error, so that the concurrent callers of an identical
+// read consume a single slot and quota unit. The keys are scoped by the api of
+// r. The result is shared by the callers and must not be modified. A caller
+// gives up waiting once its ctx is done; when the call performing it failed as
+// its own context was done, the callers waiting perform it again instead.
+func CallShared[T any](ctx context.Context, r *RateLimiter, c *Coalescer, key string, apiCall GetStreamTypedApiCaller[T]) (T, error) {
+	value, err := c.do(ctx, r.apiName+"/"+key, func() (any, error) {
+		return Call(ctx, r, apiCall)
+	}, r.getLogger(nil))
+	result, _ := value.(T)
+	return result, err
+}
+
+// SharedQueryUsers is QueryUsers shared among the concurrent callers of key,
+// see CallShared.
+func (c *Client) SharedQueryUsers(ctx context.Context, group *Coalescer, key string, q *stream.QueryOption, sorters ...*stream.SortOption) (*stream.QueryUsersResponse, error) {
+	return CallShared(ctx, c.manager.For(QueryUsers), group, key, func() (*stream.QueryUsersResponse, *stream.Response, error) {
+		resp, err := c.client.QueryUsers(ctx, q, sorters...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp, &resp.Response, nil
+	})
+}
+
+// SharedQuery is Query shared among the concurrent callers of key, see
+// CallShared. The key is scoped by the channel.
+func (ch *Channel) SharedQuery(ctx context.Context, group *Coalescer, key string, q *stream.QueryRequest) (*stream.QueryResponse, error) {
+	return CallShared(ctx, ch.manager.For(QueryChannel), group, ch.channel.CID+"/"+key, func() (*stream.QueryResponse, *stream.Response, error) {
+		resp, err := ch.channel.Query(ctx, q)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp, &resp.Response, nil
+	})
+}
+
+// InFlight returns the number of calls currently shared through c.
+func (c *Coalescer) InFlight() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.calls)
+}
+
+func (c *Coalescer) do(ctx context.Context, key string, fn func() (any, error), logger Logger) (any, error) {
+	for {
+		c.mu.Lock()
+		if call, ok := c.calls[key]; ok {
+			call.waiters++
+			c.mu.Unlock()
+			logger.Tracef("%s joining the call in flight", key)
+			select {
+			case <-call.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			if isContextError(call.err) && ctx.Err() == nil {
+				// the caller performing the call gave up
+				continue
+			}
+			return call.value, call.err
+		}
+		if c.calls == nil {
+			c.calls = make(map[string]*sharedCall)
+		}
+		call := &sharedCall{done: make(chan struct{}), err: errSharedCallPanicked}
+		c.calls[key] = call
+		c.mu.Unlock()
+
+		c.perform(key, call, fn)
+		return call.value, call.err
+	}
+}
+
+// perform runs fn as call, releasing its waiters even when fn panics.
+func (c *Coalescer) perform(key string, call *sharedCall, fn func() (any, error)) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+		close(call.done)
+	}()
+	call.value, call.err = fn()
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
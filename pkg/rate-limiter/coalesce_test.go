@@ -0,0 +1,136 @@
+package rate_limiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitersOf returns the callers joining the call of key in flight through c.
+func waitersOf(c *Coalescer, key string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if call, ok := c.calls[key]; ok {
+		return call.waiters
+	}
+	return 0
+}
+
+func TestCallShared(t *testing.T) {
+	rLimit := NewRateLimiter(QueryChannels, WithMaxConcurrency(10))
+	var group Coalescer
+	release := make(chan struct{})
+	calls := 0
+	apiCall := func() (string, *stream.Response, error) {
+		calls++
+		<-release
+		return "channels", &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 9, Reset: time.Now().Unix() + 60}}, nil
+	}
+
+	const callers = 5
+	var wg sync.WaitGroup
+	results := make([]string, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result, err := CallShared(context.Background(), rLimit, &group, "messaging", apiCall)
+			assert.NoError(t, err)
+			results[i] = result
+		}(i)
+	}
+	require.Eventually(t, func() bool {
+		return waitersOf(&group, "QueryChannels/messaging") == callers-1
+	}, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, []string{"channels", "channels", "channels", "channels", "channels"}, results)
+	assert.Zero(t, group.InFlight())
+}
+
+func TestCallSharedError(t *testing.T) {
+	rLimit := NewRateLimiter(QueryChannels)
+	var group Coalescer
+	_, err := CallShared(context.Background(), rLimit, &group, "messaging", func() (string, *stream.Response, error) {
+		return "", nil, assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Zero(t, group.InFlight(), "errors are not kept")
+}
+
+func TestCallSharedWaiterCancelled(t *testing.T) {
+	rLimit := NewRateLimiter(QueryChannels, WithMaxConcurrency(2))
+	var group Coalescer
+	release := make(chan struct{})
+	defer close(release)
+	go func() {
+		_, _ = CallShared(context.Background(), rLimit, &group, "messaging", func() (string, *stream.Response, error) {
+			<-release
+			return "", &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 9}}, nil
+		})
+	}()
+	require.Eventually(t, func() bool { return group.InFlight() == 1 }, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := CallShared(ctx, rLimit, &group, "messaging", func() (string, *stream.Response, error) {
+			t.Error("shared call performed twice")
+			return "", nil, nil
+		})
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return waitersOf(&group, "QueryChannels/messaging") == 1 }, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, receive(t, done), context.Canceled)
+}
+
+func TestCallSharedLeaderCancelled(t *testing.T) {
+	rLimit := NewRateLimiter(QueryChannels, WithMaxConcurrency(2))
+	var group Coalescer
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	leader := make(chan error)
+	go func() {
+		_, err := CallShared(ctx, rLimit, &group, "messaging", func() (string, *stream.Response, error) {
+			close(started)
+			<-ctx.Done()
+			return "", nil, ctx.Err()
+		})
+		leader <- err
+	}()
+	<-started
+
+	done := make(chan error)
+	var result string
+	go func() {
+		var err error
+		result, err = CallShared(context.Background(), rLimit, &group, "messaging", func() (string, *stream.Response, error) {
+			return "channels", &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 9}}, nil
+		})
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return waitersOf(&group, "QueryChannels/messaging") == 1 }, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, receive(t, leader), context.Canceled)
+	// the waiter performed the call once the leader gave up
+	require.NoError(t, receive(t, done))
+	assert.Equal(t, "channels", result)
+}
+
+func TestCallSharedPanic(t *testing.T) {
+	rLimit := NewRateLimiter(QueryChannels)
+	var group Coalescer
+	_, err := CallShared(context.Background(), rLimit, &group, "messaging", func() (string, *stream.Response, error) {
+		panic("boom")
+	})
+	assert.ErrorIs(t, err, ErrCallPanicked, "recovered by the limiter")
+	assert.Zero(t, group.InFlight())
+}
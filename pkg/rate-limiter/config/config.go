@@ -0,0 +1,475 @@
+// Package config builds a rate_limiter.LimiterManager from a declarative YAML
+// or JSON file, so that the limiters can be tuned without recompiling:
+//
+//	defaults:
+//	  concurrency: 4
+//	  max_wait: 30s
+//	  retry:
+//	    max_attempts: 3
+//	    backoff: exponential
+//	    initial_delay: 100ms
+//	    max_delay: 5s
+//	endpoints:
+//	  QueryUsers:
+//	    concurrency: 8
+//	    headroom: 20
+//	endpoint_buckets: true
+//	store:
+//	  type: redis
+//	  addr: localhost:6379
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gopkg.in/yaml.v3"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/etcdstore"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/filestore"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/redisstore"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/slogadapter"
+)
+
+// The backoffs of Retry.
+const (
+	BackoffConstant    = "constant"
+	BackoffExponential = "exponential"
+)
+
+// The priorities of Limits.
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+)
+
+var priorities = map[string]rate_limiter.Priority{
+	"":             rate_limiter.PriorityNormal,
+	PriorityLow:    rate_limiter.PriorityLow,
+	PriorityNormal: rate_limiter.PriorityNormal,
+	PriorityHigh:   rate_limiter.PriorityHigh,
+}
+
+// The stores of Store.
+const (
+	StoreMemory = "memory"
+	StoreRedis  = "redis"
+	StoreFile   = "file"
+	StoreEtcd   = "etcd"
+)
+
+// Config describes the limiters of a LimiterManager.
+type Config struct {
+	// Defaults are the settings of every limiter.
+	Defaults Limits `json:"defaults" yaml:"defaults"`
+	// Endpoints override the defaults per api name, or per bucket, see
+	// rate_limiter.WithApiOptions.
+	Endpoints map[string]Limits `json:"endpoints" yaml:"endpoints"`
+	// EndpointBuckets shares the limiters of the endpoints counting against
+	// the same rate limit, see rate_limiter.WithEndpointBuckets.
+	EndpointBuckets bool  `json:"endpoint_buckets" yaml:"endpoint_buckets"`
+	Store           Store `json:"store" yaml:"store"`
+	// LogLevel logs the messages of the limiters from this level, trace,
+	// debug, info, warn or error, as JSON to the standard error. No logs when
+	// empty.
+	LogLevel string `json:"log_level" yaml:"log_level"`
+}
+
+// Limits are the settings of a limiter, the unset ones keeping their default.
+type Limits struct {
+	// Concurrency is the calls in flight at the same time, see
+	// rate_limiter.WithMaxConcurrency.
+	Concurrency int `json:"concurrency" yaml:"concurrency"`
+	// Headroom is the calls of a window left to the other services, see
+	// rate_limiter.WithHeadroom.
+	Headroom *int `json:"headroom" yaml:"headroom"`
+	// MaxWait bounds the wait of a call, see rate_limiter.WithMaxWait.
+	MaxWait Duration `json:"max_wait" yaml:"max_wait"`
+	// MaxQueueDepth bounds the callers waiting for a slot, see
+	// rate_limiter.WithMaxQueueDepth.
+	MaxQueueDepth int `json:"max_queue_depth" yaml:"max_queue_depth"`
+	// Priority is PriorityLow, PriorityNormal or PriorityHigh, see
+	// rate_limiter.WithDefaultPriority.
+	Priority string `json:"priority" yaml:"priority"`
+	Retry    *Retry `json:"retry" yaml:"retry"`
+	// Schedule throttles the calls by time of day, see
+	// rate_limiter.WithSchedule. The schedule of an endpoint replaces the
+	// default one, an empty one unthrottling the endpoint.
+	Schedule []Throttle `json:"schedule" yaml:"schedule"`
+}
+
+// Throttle is a rule of the schedule of a limiter, see
+// rate_limiter.ThrottleRule.
+type Throttle struct {
+	// When is the period of the rule, e.g. "Mon-Fri 09:00-18:00", see
+	// rate_limiter.ParseThrottleRule.
+	When string `json:"when" yaml:"when"`
+	// Rate is the calls per second during the period, none for quiet hours.
+	Rate float64 `json:"rate" yaml:"rate"`
+	// Timezone is the IANA time zone of When, e.g. Europe/Rome (default UTC).
+	Timezone string `json:"timezone" yaml:"timezone"`
+}
+
+func (t Throttle) rule() (rate_limiter.ThrottleRule, error) {
+	rule, err := rate_limiter.ParseThrottleRule(t.When, t.Rate)
+	if err != nil {
+		return rate_limiter.ThrottleRule{}, err
+	}
+	if t.Timezone != "" {
+		if rule.Location, err = time.LoadLocation(t.Timezone); err != nil {
+			return rate_limiter.ThrottleRule{}, fmt.Errorf("schedule %q: %w", t.When, err)
+		}
+	}
+	return rule, nil
+}
+
+// Retry is the retry policy of a limiter, see rate_limiter.WithRetry.
+type Retry struct {
+	MaxAttempts int `json:"max_attempts" yaml:"max_attempts"`
+	// Backoff is BackoffConstant, waiting InitialDelay between attempts, or
+	// BackoffExponential, doubling it up to MaxDelay. No delay when empty.
+	Backoff      string   `json:"backoff" yaml:"backoff"`
+	InitialDelay Duration `json:"initial_delay" yaml:"initial_delay"`
+	MaxDelay     Duration `json:"max_delay" yaml:"max_delay"`
+}
+
+// Store is the backend keeping the rate limit state, see
+// rate_limiter.WithStore.
+type Store struct {
+	// Type is StoreMemory, the default, StoreRedis, StoreEtcd or StoreFile.
+	Type string `json:"type" yaml:"type"`
+	// Addr is the address of redis, e.g. localhost:6379, or the endpoint of
+	// etcd, e.g. http://etcd:2379.
+	Addr     string `json:"addr" yaml:"addr"`
+	Password string `json:"password" yaml:"password"`
+	DB       int    `json:"db" yaml:"db"`
+	// KeyPrefix replaces redisstore.DefaultKeyPrefix, or
+	// etcdstore.DefaultKeyPrefix, when set.
+	KeyPrefix string `json:"key_prefix" yaml:"key_prefix"`
+	// Path is the file of StoreFile, see filestore.Open.
+	Path string `json:"path" yaml:"path"`
+}
+
+// Duration is a time.Duration written as a string, e.g. "1m30s".
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Load reads the config of the file at path, decoded as JSON when its
+// extension is .json and as YAML otherwise.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parse(path, data)
+}
+
+// parse decodes data, the content of the file at path, see Load.
+func parse(path string, data []byte) (*Config, error) {
+	var (
+		c   *Config
+		err error
+	)
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		c, err = ParseJSON(data)
+	} else {
+		c, err = ParseYAML(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// ParseYAML decodes and validates a YAML config. Unknown fields are errors.
+func ParseYAML(data []byte) (*Config, error) {
+	var c Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ParseJSON decodes and validates a JSON config. Unknown fields are errors.
+func ParseJSON(data []byte) (*Config, error) {
+	var c Config
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&c); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Validate reports the invalid settings of c.
+func (c *Config) Validate() error {
+	errs := []error{c.Defaults.validate("defaults")}
+	for _, name := range c.endpoints() {
+		errs = append(errs, c.Endpoints[name].validate("endpoints."+name))
+	}
+	switch c.Store.Type {
+	case "", StoreMemory:
+	case StoreRedis:
+		if c.Store.Addr == "" {
+			errs = append(errs, errors.New("store: redis without addr"))
+		}
+	case StoreEtcd:
+		if c.Store.Addr == "" {
+			errs = append(errs, errors.New("store: etcd without addr"))
+		}
+	case StoreFile:
+		if c.Store.Path == "" {
+			errs = append(errs, errors.New("store: file without path"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("store: unknown type %q", c.Store.Type))
+	}
+	if _, err := parseLevel(c.LogLevel); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// parseLevel returns the slog level of the LogLevel of a Config, 0 when
+// empty.
+func parseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "":
+		return 0, nil
+	case "trace":
+		return rate_limiter.LevelTrace, nil
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return 0, fmt.Errorf("log_level: unknown level %q", level)
+	}
+	return l, nil
+}
+
+// endpoints returns the names of the endpoints of c, sorted.
+func (c *Config) endpoints() []string {
+	names := make([]string, 0, len(c.Endpoints))
+	for name := range c.Endpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (l Limits) validate(path string) error {
+	var errs []error
+	if l.Concurrency < 0 {
+		errs = append(errs, fmt.Errorf("%s: negative concurrency %d", path, l.Concurrency))
+	}
+	if l.Headroom != nil && *l.Headroom < 0 {
+		errs = append(errs, fmt.Errorf("%s: negative headroom %d", path, *l.Headroom))
+	}
+	if l.MaxWait < 0 {
+		errs = append(errs, fmt.Errorf("%s: negative max_wait %v", path, time.Duration(l.MaxWait)))
+	}
+	if l.MaxQueueDepth < 0 {
+		errs = append(errs, fmt.Errorf("%s: negative max_queue_depth %d", path, l.MaxQueueDepth))
+	}
+	if _, ok := priorities[l.Priority]; !ok {
+		errs = append(errs, fmt.Errorf("%s: unknown priority %q", path, l.Priority))
+	}
+	if l.Retry != nil {
+		if l.Retry.MaxAttempts < 1 {
+			errs = append(errs, fmt.Errorf("%s: retry max_attempts %d lower than 1", path, l.Retry.MaxAttempts))
+		}
+		switch l.Retry.Backoff {
+		case "", BackoffConstant, BackoffExponential:
+		default:
+			errs = append(errs, fmt.Errorf("%s: unknown retry backoff %q", path, l.Retry.Backoff))
+		}
+	}
+	for _, throttle := range l.Schedule {
+		if _, err := throttle.rule(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Options returns the options of the limiters described by c.
+func (c *Config) Options() ([]rate_limiter.Option, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	opts := c.Defaults.options()
+	for _, name := range c.endpoints() {
+		opts = append(opts, rate_limiter.WithApiOptions(rate_limiter.GetStreamApiName(name), c.Endpoints[name].options()...))
+	}
+	if c.EndpointBuckets {
+		opts = append(opts, rate_limiter.WithEndpointBuckets())
+	}
+	if c.Store.Type == StoreRedis {
+		client := redis.NewClient(&redis.Options{
+			Addr:     c.Store.Addr,
+			Password: c.Store.Password,
+			DB:       c.Store.DB,
+		})
+		var storeOpts []redisstore.Option
+		if c.Store.KeyPrefix != "" {
+			storeOpts = append(storeOpts, redisstore.WithKeyPrefix(c.Store.KeyPrefix))
+		}
+		opts = append(opts, rate_limiter.WithStore(redisstore.New(client, storeOpts...)))
+	}
+	if c.Store.Type == StoreEtcd {
+		var storeOpts []etcdstore.Option
+		if c.Store.KeyPrefix != "" {
+			storeOpts = append(storeOpts, etcdstore.WithKeyPrefix(c.Store.KeyPrefix))
+		}
+		opts = append(opts, rate_limiter.WithStore(etcdstore.New(etcdstore.NewGateway(c.Store.Addr, nil), storeOpts...)))
+	}
+	if c.Store.Type == StoreFile {
+		store, err := filestore.Open(c.Store.Path)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, rate_limiter.WithStore(store))
+	}
+	if c.LogLevel != "" {
+		level, _ := parseLevel(c.LogLevel)
+		handler := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+		opts = append(opts, rate_limiter.WithLogger(slogadapter.New(slog.New(handler))))
+	}
+	return opts, nil
+}
+
+// NewManager returns a LimiterManager building its limiters as described by
+// c, then with opts, e.g. for a logger or metrics.
+func (c *Config) NewManager(opts ...rate_limiter.Option) (*rate_limiter.LimiterManager, error) {
+	configured, err := c.Options()
+	if err != nil {
+		return nil, err
+	}
+	return rate_limiter.NewLimiterManager(append(configured, opts...)...), nil
+}
+
+func (l Limits) options() []rate_limiter.Option {
+	var opts []rate_limiter.Option
+	if l.Concurrency > 0 {
+		opts = append(opts, rate_limiter.WithMaxConcurrency(l.Concurrency))
+	}
+	if l.Headroom != nil {
+		opts = append(opts, rate_limiter.WithHeadroom(*l.Headroom))
+	}
+	if l.MaxWait > 0 {
+		opts = append(opts, rate_limiter.WithMaxWait(time.Duration(l.MaxWait)))
+	}
+	if l.MaxQueueDepth > 0 {
+		opts = append(opts, rate_limiter.WithMaxQueueDepth(l.MaxQueueDepth))
+	}
+	if l.Priority != "" {
+		opts = append(opts, rate_limiter.WithDefaultPriority(priorities[l.Priority]))
+	}
+	if l.Retry != nil {
+		opts = append(opts, rate_limiter.WithRetry(l.Retry.policy()))
+	}
+	if l.Schedule != nil {
+		rules := make([]rate_limiter.ThrottleRule, 0, len(l.Schedule))
+		for _, throttle := range l.Schedule {
+			rule, _ := throttle.rule()
+			rules = append(rules, rule)
+		}
+		opts = append(opts, rate_limiter.WithSchedule(rules...))
+	}
+	return opts
+}
+
+// ManagerConfig returns the settings of the limiters described by c which can
+// be changed at runtime, see rate_limiter.LimiterManager.ApplyConfig: the
+// retry policies, the endpoint buckets and the store are only set by Options.
+func (c *Config) ManagerConfig() rate_limiter.ManagerConfig {
+	cfg := rate_limiter.ManagerConfig{
+		Defaults: c.Defaults.settings(),
+		Apis:     make(map[rate_limiter.GetStreamApiName]rate_limiter.Settings, len(c.Endpoints)),
+	}
+	for name, limits := range c.Endpoints {
+		cfg.Apis[rate_limiter.GetStreamApiName(name)] = c.Defaults.merge(limits).settings()
+	}
+	return cfg
+}
+
+// merge returns l overridden by the settings set in override.
+func (l Limits) merge(override Limits) Limits {
+	if override.Concurrency > 0 {
+		l.Concurrency = override.Concurrency
+	}
+	if override.Headroom != nil {
+		l.Headroom = override.Headroom
+	}
+	if override.MaxWait > 0 {
+		l.MaxWait = override.MaxWait
+	}
+	if override.MaxQueueDepth > 0 {
+		l.MaxQueueDepth = override.MaxQueueDepth
+	}
+	if override.Priority != "" {
+		l.Priority = override.Priority
+	}
+	if override.Retry != nil {
+		l.Retry = override.Retry
+	}
+	if override.Schedule != nil {
+		l.Schedule = override.Schedule
+	}
+	return l
+}
+
+func (l Limits) settings() rate_limiter.Settings {
+	s := rate_limiter.Settings{
+		MaxConcurrency: l.Concurrency,
+		MaxWait:        time.Duration(l.MaxWait),
+		MaxQueueDepth:  l.MaxQueueDepth,
+		Priority:       priorities[l.Priority],
+	}
+	if l.Headroom != nil {
+		s.Headroom = *l.Headroom
+	}
+	return s
+}
+
+func (r Retry) policy() rate_limiter.RetryPolicy {
+	policy := rate_limiter.RetryPolicy{MaxAttempts: r.MaxAttempts}
+	switch r.Backoff {
+	case BackoffConstant:
+		policy.Backoff = rate_limiter.ConstantBackoff(time.Duration(r.InitialDelay))
+	case BackoffExponential:
+		policy.Backoff = rate_limiter.ExponentialBackoff(time.Duration(r.InitialDelay), time.Duration(r.MaxDelay))
+	}
+	return policy
+}
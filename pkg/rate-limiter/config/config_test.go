@@ -0,0 +1,327 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+const testYAML = `
+defaults:
+  concurrency: 2
+  headroom: 5
+  max_wait: 30s
+  max_queue_depth: 1
+  retry:
+    max_attempts: 3
+    backoff: exponential
+    initial_delay: 100ms
+    max_delay: 2s
+endpoints:
+  QueryUsers:
+    concurrency: 8
+    headroom: 0
+endpoint_buckets: true
+store:
+  type: memory
+`
+
+const testJSON = `{
+  "defaults": {"concurrency": 2, "headroom": 5, "max_wait": "30s", "max_queue_depth": 1,
+    "retry": {"max_attempts": 3, "backoff": "exponential", "initial_delay": "100ms", "max_delay": "2s"}},
+  "endpoints": {"QueryUsers": {"concurrency": 8, "headroom": 0}},
+  "endpoint_buckets": true,
+  "store": {"type": "memory"}
+}`
+
+func intPtr(n int) *int {
+	return &n
+}
+
+var testConfig = &Config{
+	Defaults: Limits{
+		Concurrency:   2,
+		Headroom:      intPtr(5),
+		MaxWait:       Duration(30 * time.Second),
+		MaxQueueDepth: 1,
+		Retry: &Retry{
+			MaxAttempts:  3,
+			Backoff:      BackoffExponential,
+			InitialDelay: Duration(100 * time.Millisecond),
+			MaxDelay:     Duration(2 * time.Second),
+		},
+	},
+	Endpoints: map[string]Limits{
+		"QueryUsers": {Concurrency: 8, Headroom: intPtr(0)},
+	},
+	EndpointBuckets: true,
+	Store:           Store{Type: StoreMemory},
+}
+
+func TestParse(t *testing.T) {
+	c, err := ParseYAML([]byte(testYAML))
+	require.NoError(t, err)
+	assert.Equal(t, testConfig, c)
+
+	c, err = ParseJSON([]byte(testJSON))
+	require.NoError(t, err)
+	assert.Equal(t, testConfig, c)
+
+	c, err = ParseYAML(nil)
+	require.NoError(t, err, "empty config")
+	assert.Equal(t, &Config{}, c)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{"limits.yaml": testYAML, "limits.json": testJSON} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+		c, err := Load(path)
+		require.NoError(t, err, name)
+		assert.Equal(t, testConfig, c, name)
+	}
+
+	_, err := Load(filepath.Join(dir, "missing.yaml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{
+			name:    "Unknown field",
+			yaml:    "defaults:\n  concurency: 2\n",
+			wantErr: "field concurency not found",
+		},
+		{
+			name:    "Invalid duration",
+			yaml:    "defaults:\n  max_wait: soon\n",
+			wantErr: "invalid duration",
+		},
+		{
+			name:    "Negative values",
+			yaml:    "defaults:\n  concurrency: -1\nendpoints:\n  QueryUsers:\n    max_queue_depth: -2\n",
+			wantErr: "defaults: negative concurrency -1\nendpoints.QueryUsers: negative max_queue_depth -2",
+		},
+		{
+			name:    "Invalid retry",
+			yaml:    "defaults:\n  retry:\n    max_attempts: 0\n    backoff: linear\n",
+			wantErr: "defaults: retry max_attempts 0 lower than 1\ndefaults: unknown retry backoff \"linear\"",
+		},
+		{
+			name:    "Unknown priority",
+			yaml:    "endpoints:\n  QueryUsers:\n    priority: urgent\n",
+			wantErr: "endpoints.QueryUsers: unknown priority \"urgent\"",
+		},
+		{
+			name:    "Invalid schedule",
+			yaml:    "endpoints:\n  QueryUsers:\n    schedule:\n      - when: Mon-Fri 9h-18h\n      - when: 22:00-06:00\n        timezone: Mars/Olympus\n",
+			wantErr: "endpoints.QueryUsers: schedule \"Mon-Fri 9h-18h\": want [days] HH:MM-HH:MM\nendpoints.QueryUsers: schedule \"22:00-06:00\": unknown time zone Mars/Olympus",
+		},
+		{
+			name:    "Unknown log level",
+			yaml:    "log_level: verbose\n",
+			wantErr: "log_level: unknown level \"verbose\"",
+		},
+		{
+			name:    "Unknown store",
+			yaml:    "store:\n  type: memcached\n",
+			wantErr: "store: unknown type \"memcached\"",
+		},
+		{
+			name:    "Redis without addr",
+			yaml:    "store:\n  type: redis\n",
+			wantErr: "store: redis without addr",
+		},
+		{
+			name:    "Etcd without addr",
+			yaml:    "store:\n  type: etcd\n",
+			wantErr: "store: etcd without addr",
+		},
+		{
+			name:    "File without path",
+			yaml:    "store:\n  type: file\n",
+			wantErr: "store: file without path",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseYAML([]byte(tt.yaml))
+			assert.Nil(t, c)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	_, err := ParseJSON([]byte(`{"store": {"typ": "redis"}}`))
+	assert.ErrorContains(t, err, "unknown field \"typ\"")
+}
+
+// hold holds n calls of r in flight until the returned func is called.
+func hold(t *testing.T, r *rate_limiter.RateLimiter, n int) func() {
+	inFlight := make(chan struct{}, n)
+	release := make(chan struct{})
+	done := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			done <- r.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+				inFlight <- struct{}{}
+				<-release
+				return &stream.Response{}, nil
+			})
+		}()
+		<-inFlight
+	}
+	return func() {
+		close(release)
+		for i := 0; i < n; i++ {
+			assert.NoError(t, <-done)
+		}
+	}
+}
+
+func okCall() (*stream.Response, error) {
+	return &stream.Response{}, nil
+}
+
+func TestNewManager(t *testing.T) {
+	manager, err := testConfig.NewManager()
+	require.NoError(t, err)
+
+	release := hold(t, manager.For(rate_limiter.QueryUsers), 8)
+	var limited rate_limiter.ErrRateLimited
+	assert.ErrorAs(t, manager.For(rate_limiter.QueryUsers).TryCallApi(okCall), &limited, "concurrency of the endpoint")
+	release()
+
+	queryChannels := manager.For(rate_limiter.QueryChannels)
+	release = hold(t, queryChannels, 2)
+	assert.ErrorAs(t, queryChannels.TryCallApi(okCall), &limited, "default concurrency")
+
+	queued := make(chan error, 1)
+	go func() {
+		queued <- queryChannels.CallApiWithContext(context.Background(), okCall)
+	}()
+	require.Eventually(t, func() bool { return queryChannels.QueueDepth() == 1 }, time.Second, time.Millisecond)
+	assert.ErrorIs(t, queryChannels.CallApiWithContext(context.Background(), okCall), rate_limiter.ErrQueueFull)
+	release()
+	assert.NoError(t, <-queued)
+
+	assert.Same(t, manager.For(rate_limiter.UpdateChannel), manager.For(rate_limiter.RemoveMembers), "endpoint buckets")
+}
+
+func TestNewManagerRetries(t *testing.T) {
+	c := &Config{Defaults: Limits{Retry: &Retry{MaxAttempts: 3}}}
+	manager, err := c.NewManager()
+	require.NoError(t, err)
+
+	attempts := 0
+	require.NoError(t, manager.For(rate_limiter.QueryUsers).CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		if attempts++; attempts < 3 {
+			return nil, stream.Error{StatusCode: 503}
+		}
+		return &stream.Response{}, nil
+	}))
+	assert.Equal(t, 3, attempts)
+}
+
+func TestNewManagerSchedule(t *testing.T) {
+	c, err := ParseYAML([]byte(`
+defaults:
+  schedule:
+    - when: 00:00-06:00
+endpoints:
+  QueryUsers:
+    schedule: []
+  SendMessage:
+    schedule:
+      - when: Mon 09:00-18:00
+        rate: 1
+        timezone: UTC
+`))
+	require.NoError(t, err)
+	clock := clocktest.NewFake(time.Date(2026, 10, 12, 3, 0, 0, 0, time.UTC))
+	manager, err := c.NewManager(rate_limiter.WithClock(clock))
+	require.NoError(t, err)
+
+	var limited rate_limiter.ErrRateLimited
+	require.ErrorAs(t, manager.For(rate_limiter.QueryChannels).TryCallApi(okCall), &limited, "quiet hours")
+	assert.Equal(t, time.Date(2026, 10, 12, 6, 0, 0, 0, time.UTC), limited.ResetAt)
+	assert.NoError(t, manager.For(rate_limiter.QueryUsers).TryCallApi(okCall), "unthrottled endpoint")
+	assert.NoError(t, manager.For(rate_limiter.SendMessage).TryCallApi(okCall), "schedule of the endpoint")
+
+	clock.Advance(7 * time.Hour)
+	sendMessage := manager.For(rate_limiter.SendMessage)
+	assert.NoError(t, sendMessage.TryCallApi(okCall))
+	require.ErrorAs(t, sendMessage.TryCallApi(okCall), &limited, "a call per second")
+	assert.Equal(t, clock.Now().Add(time.Second), limited.ResetAt)
+}
+
+func TestNewManagerRedisStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	c := &Config{Store: Store{Type: StoreRedis, Addr: server.Addr(), KeyPrefix: "app:"}}
+	manager, err := c.NewManager()
+	require.NoError(t, err)
+
+	require.NoError(t, manager.For(rate_limiter.QueryUsers).CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 5, Reset: time.Now().Add(time.Minute).Unix()}}, nil
+	}))
+	assert.Equal(t, []string{"app:QueryUsers"}, server.Keys())
+}
+
+func TestNewManagerFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	c := &Config{Store: Store{Type: StoreFile, Path: path}}
+	manager, err := c.NewManager()
+	require.NoError(t, err)
+
+	require.NoError(t, manager.For(rate_limiter.QueryUsers).CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 5, Reset: time.Now().Add(time.Minute).Unix()}}, nil
+	}))
+	assert.Eventually(t, func() bool {
+		data, _ := os.ReadFile(path)
+		return strings.Contains(string(data), `"QueryUsers"`)
+	}, 5*time.Second, 10*time.Millisecond, "written within filestore.DefaultFlushInterval")
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	c.Store.Path = path
+	_, err = c.NewManager()
+	assert.ErrorContains(t, err, "filestore: invalid")
+}
+
+func TestNewManagerInvalid(t *testing.T) {
+	c := &Config{Defaults: Limits{Concurrency: -1}}
+	_, err := c.NewManager()
+	assert.ErrorContains(t, err, "negative concurrency")
+}
+
+func TestManagerConfig(t *testing.T) {
+	c := &Config{
+		Defaults: Limits{Concurrency: 2, Headroom: intPtr(5), MaxWait: Duration(time.Second), Priority: PriorityLow},
+		Endpoints: map[string]Limits{
+			"QueryUsers":    {Concurrency: 8, Headroom: intPtr(0), Priority: PriorityHigh},
+			"QueryChannels": {MaxQueueDepth: 10},
+		},
+	}
+	assert.Equal(t, rate_limiter.ManagerConfig{
+		Defaults: rate_limiter.Settings{MaxConcurrency: 2, Headroom: 5, MaxWait: time.Second, Priority: rate_limiter.PriorityLow},
+		Apis: map[rate_limiter.GetStreamApiName]rate_limiter.Settings{
+			rate_limiter.QueryUsers:    {MaxConcurrency: 8, Headroom: 0, MaxWait: time.Second, Priority: rate_limiter.PriorityHigh},
+			rate_limiter.QueryChannels: {MaxConcurrency: 2, Headroom: 5, MaxWait: time.Second, MaxQueueDepth: 10, Priority: rate_limiter.PriorityLow},
+		},
+	}, c.ManagerConfig())
+}
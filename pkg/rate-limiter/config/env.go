@@ -0,0 +1,119 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// The environment variables read by FromEnv, the unset ones keeping the
+// default of the limiters.
+const (
+	// EnvFile is the path of a config file, see Load, the other variables
+	// overriding its settings.
+	EnvFile = "STREAM_RL_CONFIG_FILE"
+	// EnvMaxConcurrency is the concurrency of every limiter, e.g. 4.
+	EnvMaxConcurrency = "STREAM_RL_MAX_CONCURRENCY"
+	// EnvMaxWait is the max wait of the calls, e.g. 30s.
+	EnvMaxWait = "STREAM_RL_MAX_WAIT"
+	// EnvHeadroom is the headroom of every limiter, e.g. 10.
+	EnvHeadroom = "STREAM_RL_HEADROOM"
+	// EnvMaxQueueDepth is the queue depth of every limiter, e.g. 100.
+	EnvMaxQueueDepth = "STREAM_RL_MAX_QUEUE_DEPTH"
+	// EnvEndpointBuckets shares the limiters of the endpoints counting
+	// against the same rate limit when true.
+	EnvEndpointBuckets = "STREAM_RL_ENDPOINT_BUCKETS"
+	// EnvRedisAddr shares the rate limit state through the redis server at
+	// this address, e.g. localhost:6379.
+	EnvRedisAddr      = "STREAM_RL_REDIS_ADDR"
+	EnvRedisPassword  = "STREAM_RL_REDIS_PASSWORD"
+	EnvRedisDB        = "STREAM_RL_REDIS_DB"
+	EnvRedisKeyPrefix = "STREAM_RL_REDIS_KEY_PREFIX"
+	// EnvStateFile persists the rate limit state to the file at this path
+	// across restarts, see the filestore package.
+	EnvStateFile = "STREAM_RL_STATE_FILE"
+	// EnvLogLevel is the level of the logs, see Config.LogLevel, e.g. warn.
+	EnvLogLevel = "STREAM_RL_LOG_LEVEL"
+)
+
+// FromEnv returns the config described by the STREAM_RL_* environment
+// variables, for deployments configured through the environment alone. Empty
+// variables are ignored.
+func FromEnv() (*Config, error) {
+	c := &Config{}
+	if path := os.Getenv(EnvFile); path != "" {
+		loaded, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		c = loaded
+	}
+
+	var errs []error
+	envInt := func(name string, set func(int)) {
+		if value := os.Getenv(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid integer %q", name, value))
+				return
+			}
+			set(n)
+		}
+	}
+	envInt(EnvMaxConcurrency, func(n int) { c.Defaults.Concurrency = n })
+	envInt(EnvHeadroom, func(n int) { c.Defaults.Headroom = &n })
+	envInt(EnvMaxQueueDepth, func(n int) { c.Defaults.MaxQueueDepth = n })
+	envInt(EnvRedisDB, func(n int) { c.Store.DB = n })
+	if value := os.Getenv(EnvMaxWait); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid duration %q", EnvMaxWait, value))
+		}
+		c.Defaults.MaxWait = Duration(d)
+	}
+	if value := os.Getenv(EnvEndpointBuckets); value != "" {
+		buckets, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid boolean %q", EnvEndpointBuckets, value))
+		}
+		c.EndpointBuckets = buckets
+	}
+	if addr := os.Getenv(EnvRedisAddr); addr != "" {
+		c.Store.Type = StoreRedis
+		c.Store.Addr = addr
+	}
+	if path := os.Getenv(EnvStateFile); path != "" {
+		c.Store.Type = StoreFile
+		c.Store.Path = path
+	}
+	if password := os.Getenv(EnvRedisPassword); password != "" {
+		c.Store.Password = password
+	}
+	if prefix := os.Getenv(EnvRedisKeyPrefix); prefix != "" {
+		c.Store.KeyPrefix = prefix
+	}
+	if level := os.Getenv(EnvLogLevel); level != "" {
+		c.LogLevel = level
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewFromEnv returns a LimiterManager building its limiters as described by
+// the environment, see FromEnv, then with opts.
+func NewFromEnv(opts ...rate_limiter.Option) (*rate_limiter.LimiterManager, error) {
+	c, err := FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return c.NewManager(opts...)
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(EnvMaxConcurrency, "4")
+	t.Setenv(EnvMaxWait, "30s")
+	t.Setenv(EnvHeadroom, "0")
+	t.Setenv(EnvMaxQueueDepth, "100")
+	t.Setenv(EnvEndpointBuckets, "true")
+	t.Setenv(EnvRedisAddr, "localhost:6379")
+	t.Setenv(EnvRedisPassword, "secret")
+	t.Setenv(EnvRedisDB, "2")
+	t.Setenv(EnvRedisKeyPrefix, "app:")
+	t.Setenv(EnvLogLevel, "warn")
+
+	c, err := FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, &Config{
+		Defaults: Limits{
+			Concurrency:   4,
+			Headroom:      intPtr(0),
+			MaxWait:       Duration(30 * time.Second),
+			MaxQueueDepth: 100,
+		},
+		EndpointBuckets: true,
+		Store:           Store{Type: StoreRedis, Addr: "localhost:6379", Password: "secret", DB: 2, KeyPrefix: "app:"},
+		LogLevel:        "warn",
+	}, c)
+}
+
+func TestFromEnvDefaults(t *testing.T) {
+	c, err := FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, &Config{}, c)
+}
+
+func TestFromEnvOverridesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limits.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testYAML), 0o600))
+	t.Setenv(EnvFile, path)
+	t.Setenv(EnvMaxConcurrency, "6")
+
+	c, err := FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, 6, c.Defaults.Concurrency)
+	assert.Equal(t, testConfig.Endpoints, c.Endpoints, "settings of the file")
+}
+
+func TestFromEnvErrors(t *testing.T) {
+	t.Setenv(EnvMaxConcurrency, "many")
+	t.Setenv(EnvMaxWait, "soon")
+	t.Setenv(EnvEndpointBuckets, "maybe")
+	_, err := FromEnv()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `STREAM_RL_MAX_CONCURRENCY: invalid integer "many"`)
+	assert.Contains(t, err.Error(), `STREAM_RL_MAX_WAIT: invalid duration "soon"`)
+	assert.Contains(t, err.Error(), `STREAM_RL_ENDPOINT_BUCKETS: invalid boolean "maybe"`)
+
+	t.Setenv(EnvMaxConcurrency, "-1")
+	t.Setenv(EnvMaxWait, "")
+	t.Setenv(EnvEndpointBuckets, "")
+	_, err = FromEnv()
+	assert.ErrorContains(t, err, "negative concurrency")
+
+	t.Setenv(EnvMaxConcurrency, "")
+	t.Setenv(EnvLogLevel, "verbose")
+	_, err = FromEnv()
+	assert.ErrorContains(t, err, `log_level: unknown level "verbose"`)
+}
+
+func TestNewFromEnv(t *testing.T) {
+	server := miniredis.RunT(t)
+	t.Setenv(EnvMaxConcurrency, "3")
+	t.Setenv(EnvRedisAddr, server.Addr())
+	t.Setenv(EnvLogLevel, "trace")
+
+	manager, err := NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, 3, manager.For(rate_limiter.QueryUsers).Concurrency())
+}
+
+func TestFromEnvStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	t.Setenv(EnvStateFile, path)
+
+	c, err := FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, Store{Type: StoreFile, Path: path}, c.Store)
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"time"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// Watch applies the config of the file at path to manager whenever the file
+// changes from now on, checking it every interval in the background until ctx
+// is done, see rate_limiter.LimiterManager.ApplyConfig. A file failing to
+// load, e.g. invalid, leaves the settings unchanged and is reported to
+// onError, if not nil, once per failure.
+func Watch(ctx context.Context, path string, manager *rate_limiter.LimiterManager, interval time.Duration, onError func(error)) {
+	// the content the manager was built from
+	current, _ := os.ReadFile(path)
+	var failed string
+	reload := func() {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			if bytes.Equal(data, current) {
+				return
+			}
+			current = data
+			var c *Config
+			if c, err = parse(path, data); err == nil {
+				manager.ApplyConfig(c.ManagerConfig())
+				failed = ""
+				return
+			}
+		}
+		if onError != nil && err.Error() != failed {
+			onError(err)
+		}
+		failed = err.Error()
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				reload()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limits.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("defaults:\n  concurrency: 2\n"), 0o600))
+	c, err := Load(path)
+	require.NoError(t, err)
+	manager, err := c.NewManager()
+	require.NoError(t, err)
+	queryUsers := manager.For(rate_limiter.QueryUsers)
+	require.Equal(t, 2, queryUsers.Concurrency())
+
+	var (
+		mu       sync.Mutex
+		failures []error
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	Watch(ctx, path, manager, time.Millisecond, func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failures = append(failures, err)
+	})
+	reported := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(failures)
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte("defaults:\n  concurrency: 2\nendpoints:\n  QueryUsers:\n    concurrency: 8\n"), 0o600))
+	require.Eventually(t, func() bool { return queryUsers.Concurrency() == 8 }, time.Second, time.Millisecond)
+	assert.Equal(t, 2, manager.For(rate_limiter.QueryChannels).Concurrency())
+
+	require.NoError(t, os.WriteFile(path, []byte("defaults:\n  concurrency: -1\n"), 0o600))
+	require.Eventually(t, func() bool { return reported() > 0 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 1, reported(), "reported once")
+	assert.ErrorContains(t, failures[0], "negative concurrency")
+	assert.Equal(t, 8, queryUsers.Concurrency(), "settings unchanged")
+
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, os.WriteFile(path, []byte("defaults:\n  concurrency: 3\n"), 0o600))
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 8, queryUsers.Concurrency(), "no longer watched")
+}
@@ -0,0 +1,92 @@
+package rate_limiter
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// CooldownConfig configures WithCooldown.
+type CooldownConfig struct {
+	// Errors is the number of failures within Window starting a cooldown
+	// (default 5).
+	Errors int
+	// Window is the period over which the failures are counted (default 1m).
+	Window time.Duration
+	// Quiet is how long the calls are held once cooling down (default 30s).
+	Quiet time.Duration
+	// IsFailure tells whether err counts as a failure, IsServerFailure when nil.
+	IsFailure func(err error) bool
+}
+
+// cooldown counts the failures of a limiter within the window of its config.
+type cooldown struct {
+	config CooldownConfig
+
+	mu       sync.Mutex
+	failures []time.Time // sorted
+}
+
+// WithCooldown holds every call of the api for a quiet period after a burst of
+// failures, even though quota remains, so that the budget is not burnt into a
+// failing endpoint: once config.Errors calls failed within config.Window, the
+// calls wait as for a rate limit reset until config.Quiet elapsed, while
+// TryCallApi returns an ErrRateLimited with its end. Unlike
+// WithCircuitBreaker, the calls are delayed rather than turned down.
+func WithCooldown(config CooldownConfig) Option {
+	return func(r *RateLimiter) {
+		if config.Errors <= 0 {
+			config.Errors = 5
+		}
+		if config.Window <= 0 {
+			config.Window = time.Minute
+		}
+		if config.Quiet <= 0 {
+			config.Quiet = 30 * time.Second
+		}
+		if config.IsFailure == nil {
+			config.IsFailure = IsServerFailure
+		}
+		r.cooldown = &cooldown{config: config}
+	}
+}
+
+// record accounts the outcome of a call at now, reporting whether it starts a
+// cooldown.
+func (c *cooldown) record(now time.Time, err error) bool {
+	if err == nil || !c.config.IsFailure(err) {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expired := 0
+	for expired < len(c.failures) && !c.failures[expired].After(now.Add(-c.config.Window)) {
+		expired++
+	}
+	c.failures = append(c.failures[expired:], now)
+	if len(c.failures) < c.config.Errors {
+		return false
+	}
+	c.failures = c.failures[:0]
+	return true
+}
+
+// coolDown holds the calls of r for the quiet period of WithCooldown, if set,
+// once err completes a burst of failures.
+func (r *RateLimiter) coolDown(ctx context.Context, logger Logger, err error) {
+	if r.cooldown == nil {
+		return
+	}
+	now := r.getClock().Now()
+	if !r.cooldown.record(now, err) {
+		return
+	}
+	quiet := r.cooldown.config.Quiet
+	logAttrs(ctx, logger, slog.LevelWarn, "cooling down after errors", []slog.Attr{
+		slog.String("api", r.apiName),
+		slog.Int("errors", r.cooldown.config.Errors),
+		slog.Duration("quiet", quiet),
+	}, "Cooling down %s for %v after %d errors\n", r.apiName, quiet, r.cooldown.config.Errors)
+	r.blockFor(ctx, logger, now.Add(quiet), quiet)
+}
@@ -0,0 +1,77 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestCooldownWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c := &cooldown{config: CooldownConfig{Errors: 3, Window: time.Minute, IsFailure: IsServerFailure}}
+	serverError := stream.Error{StatusCode: http.StatusInternalServerError}
+
+	assert.False(t, c.record(now, serverError))
+	assert.False(t, c.record(now.Add(10*time.Second), serverError))
+	assert.False(t, c.record(now.Add(20*time.Second), stream.Error{StatusCode: http.StatusBadRequest}), "not a failure")
+	assert.False(t, c.record(now.Add(20*time.Second), nil))
+	assert.False(t, c.record(now.Add(time.Minute), serverError), "the first failure left the window")
+	assert.True(t, c.record(now.Add(65*time.Second), serverError))
+	assert.Empty(t, c.failures, "counted afresh after a cooldown")
+}
+
+func TestWithCooldown(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithCooldown(CooldownConfig{
+		Errors: 2,
+		Quiet:  30 * time.Second,
+	}))
+	failing := func() (*stream.Response, error) {
+		return nil, stream.Error{StatusCode: http.StatusServiceUnavailable}
+	}
+	plenty := func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 100, Remaining: 99, Reset: 1060}}, nil
+	}
+
+	assert.Error(t, rLimit.CallApiWithContext(context.Background(), failing))
+	assert.False(t, rLimit.IsBlocked())
+	assert.Error(t, rLimit.CallApiWithContext(context.Background(), failing))
+	assert.True(t, rLimit.IsBlocked(), "cooling down with quota left")
+
+	var rateLimited ErrRateLimited
+	require.ErrorAs(t, rLimit.TryCallApi(plenty), &rateLimited)
+	assert.Equal(t, time.Unix(1030, 0), rateLimited.ResetAt)
+
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), plenty)
+	}()
+	blockUntil(t, clock, 1)
+	clock.Advance(30 * time.Second)
+	assert.NoError(t, receive(t, done))
+	assert.False(t, rLimit.IsBlocked())
+}
+
+func TestWithCooldownIsFailure(t *testing.T) {
+	errCustom := errors.New("custom")
+	rLimit := NewRateLimiter(QueryUsers, WithCooldown(CooldownConfig{
+		Errors:    1,
+		IsFailure: func(err error) bool { return errors.Is(err, errCustom) },
+	}))
+	assert.Error(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return nil, stream.Error{StatusCode: http.StatusInternalServerError}
+	}))
+	assert.False(t, rLimit.IsBlocked())
+	assert.Error(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return nil, errCustom
+	}))
+	assert.True(t, rLimit.IsBlocked())
+}
@@ -0,0 +1,54 @@
+package rate_limiter
+
+import (
+	"context"
+	"time"
+)
+
+// CostStore is implemented by the Store able to consume several of the
+// remaining calls at once, for the calls of a cost higher than 1, see
+// CallWithCost. Other stores consume a single call per call.
+type CostStore interface {
+	Store
+	// TakeN is Take consuming n of the remaining calls.
+	TakeN(ctx context.Context, api string, now time.Time, n int) (blockedUntil time.Time, err error)
+}
+
+type costKey struct{}
+
+func withCost(ctx context.Context, cost int) context.Context {
+	return context.WithValue(ctx, costKey{}, cost)
+}
+
+// costFrom returns the cost of the call of ctx, the cost of the limiter, see
+// WithCost, when not set by CallWithCost.
+func (r *RateLimiter) costFrom(ctx context.Context) int {
+	if cost, ok := ctx.Value(costKey{}).(int); ok && cost > 0 {
+		return cost
+	}
+	return max(r.cost, 1)
+}
+
+// WithCost sets the cost of the calls of apiName, how many of the remaining
+// calls each of them consumes, 1 by default, for the endpoints accounting some
+// operations as several calls, e.g. bulk upserts. It applies to the limiter
+// of apiName only, e.g. through a LimiterManager; values lower than 1 are
+// ignored.
+func WithCost(apiName GetStreamApiName, cost int) Option {
+	return func(r *RateLimiter) {
+		if cost > 0 && r.apiName == string(apiName) {
+			r.cost = cost
+		}
+	}
+}
+
+// CallWithCost is like CallApiWithContext for a call consuming cost of the
+// remaining calls, instead of the default cost of the limiter: it waits until
+// the remaining calls cover it on top of the calls in flight, and counts cost
+// calls in flight until it returns. Costs lower than 1 are ignored.
+func (r *RateLimiter) CallWithCost(ctx context.Context, cost int, apiCall GetStreamApiCaller) error {
+	if cost > 0 {
+		ctx = withCost(ctx, cost)
+	}
+	return r.callApi(ctx, r.getLogger(nil), streamCall(apiCall))
+}
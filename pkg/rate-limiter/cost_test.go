@@ -0,0 +1,74 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCost(t *testing.T) {
+	ctx := context.Background()
+	opts := []Option{WithCost(UpdateUsers, 5), WithCost(QueryUsers, 0)}
+	assert.Equal(t, 5, NewRateLimiter(UpdateUsers, opts...).costFrom(ctx))
+	assert.Equal(t, 1, NewRateLimiter(QueryUsers, opts...).costFrom(ctx), "ignored")
+	assert.Equal(t, 1, NewRateLimiter(QueryChannels, opts...).costFrom(ctx), "other endpoint")
+	assert.Equal(t, 3, NewRateLimiter(UpdateUsers, opts...).costFrom(withCost(ctx, 3)))
+}
+
+func TestCallWithCostWaitsForRoom(t *testing.T) {
+	rLimit := NewRateLimiter(UpdateUsers, WithMaxConcurrency(2))
+	reset := time.Now().Add(time.Minute).Unix()
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 20, Remaining: 10, Reset: reset}}, nil
+	}))
+
+	release := make(chan struct{})
+	held := make(chan error)
+	go func() {
+		held <- rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+			<-release
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 20, Remaining: 9, Reset: reset}}, nil
+		})
+	}()
+	require.Eventually(t, func() bool { return inFlightOf(rLimit) == 1 }, time.Second, time.Millisecond)
+
+	// 10 calls left, 1 in flight: no room for a call costing 10
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallWithCost(context.Background(), 10, func() (*stream.Response, error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 20, Remaining: 0, Reset: reset}}, nil
+		})
+	}()
+	select {
+	case <-done:
+		t.Fatal("admitted beyond the calls left")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Equal(t, 1, inFlightOf(rLimit))
+
+	close(release)
+	require.NoError(t, receive(t, held))
+	require.NoError(t, receive(t, done))
+	assert.Zero(t, inFlightOf(rLimit))
+	assert.True(t, rLimit.IsBlocked())
+}
+
+func TestCallWithCostConsumesStore(t *testing.T) {
+	store := NewMemoryStore()
+	rLimit := NewRateLimiter(UpdateUsers, WithStore(store))
+	reset := time.Now().Add(time.Minute).Unix()
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 20, Remaining: 10, Reset: reset}}, nil
+	}))
+	assert.ErrorIs(t, rLimit.CallWithCost(context.Background(), 4, func() (*stream.Response, error) {
+		return nil, assert.AnError
+	}), assert.AnError)
+	state, err := store.Load(context.Background(), string(UpdateUsers))
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), state.Remaining)
+	assert.Zero(t, inFlightOf(rLimit))
+}
@@ -0,0 +1,102 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ErrDraining is returned by the calls made after Drain.
+var ErrDraining = errors.New("rate limiter draining")
+
+// Drain stops admitting new calls, which fail with ErrDraining, while the calls
+// already made, queued for a slot, waiting for the rate limit reset or in
+// flight, go on until they return, e.g. so that a rolling deployment does not
+// abandon the calls which already waited through a reset window. It then waits
+// for them, returning ctx.Err() if ctx is done first, and reports the progress
+// with a Draining event, and a log, whenever a call returns, then with a Drained
+// event. Unlike Close, the limiter keeps its reset timer and can be closed
+// afterwards. Drain can be called several times.
+func (r *RateLimiter) Drain(ctx context.Context) error {
+	logger := r.getLogger(nil)
+	r.startDrain()
+
+	for reported := -1; ; {
+		r.mu.Lock()
+		pending := r.calls
+		var left chan struct{}
+		if pending > 0 {
+			if r.left == nil {
+				r.left = make(chan struct{})
+			}
+			left = r.left
+		}
+		r.mu.Unlock()
+
+		if pending == 0 {
+			logAttrs(ctx, logger, slog.LevelInfo, "api drained", []slog.Attr{
+				slog.String("api", r.apiName),
+			}, "Drained api %s\n", r.apiName)
+			r.emit(Event{Type: Drained})
+			return nil
+		}
+		if pending != reported {
+			reported = pending
+			logAttrs(ctx, logger, slog.LevelInfo, "draining api", []slog.Attr{
+				slog.String("api", r.apiName),
+				slog.Int("pending", pending),
+			}, "Draining api %s: %d calls pending\n", r.apiName, pending)
+			r.emit(Event{Type: Draining, Pending: pending})
+		}
+		select {
+		case <-left:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Draining reports whether the limiter is draining, see Drain.
+func (r *RateLimiter) Draining() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.draining
+}
+
+func (r *RateLimiter) errDraining() error {
+	return fmt.Errorf("%w: %s", ErrDraining, r.apiName)
+}
+
+// Drain drains every limiter of the manager, as RateLimiter.Drain, waiting for
+// the calls of all of them. Limiters requested afterwards are draining as
+// well.
+func (m *LimiterManager) Drain(ctx context.Context) error {
+	m.mu.Lock()
+	m.draining = true
+	limiters := m.limiters.values()
+	m.tenantLimiters.each(func(_ tenantKey, t *tenantLimiter) {
+		limiters = append(limiters, t.r)
+	})
+	if m.global != nil {
+		limiters = append(limiters, m.global)
+	}
+	for _, r := range limiters {
+		r.startDrain()
+	}
+	m.mu.Unlock()
+
+	for _, r := range limiters {
+		if err := r.Drain(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startDrain stops admitting new calls, as Drain, without waiting.
+func (r *RateLimiter) startDrain() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.draining = true
+}
@@ -0,0 +1,99 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrain(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	events := rLimit.Events()
+	releaseSlot := holdSlot(t, rLimit)
+
+	queuedCall := make(chan error, 1)
+	go func() {
+		queuedCall <- rLimit.CallApiWithContext(context.Background(), okCall)
+	}()
+	require.Eventually(t, func() bool { return queued(rLimit) == 1 }, time.Second, time.Millisecond)
+	<-events // CallAdmitted of the slot held
+
+	drained := make(chan error, 1)
+	go func() { drained <- rLimit.Drain(context.Background()) }()
+	event := <-events
+	assert.Equal(t, Draining, event.Type)
+	assert.Equal(t, 2, event.Pending)
+	assert.True(t, rLimit.Draining())
+
+	err := rLimit.CallApiWithContext(context.Background(), okCall)
+	assert.ErrorIs(t, err, ErrDraining)
+	assert.EqualError(t, err, "rate limiter draining: QueryUsers")
+	assert.ErrorIs(t, rLimit.TryCallApi(okCall), ErrDraining)
+	assert.ErrorIs(t, rLimit.Ready(), ErrDraining)
+
+	releaseSlot()
+	require.NoError(t, receive(t, queuedCall), "queued call served")
+	require.NoError(t, receive(t, drained))
+
+	var types []EventType
+	for len(events) > 0 {
+		types = append(types, (<-events).Type)
+	}
+	assert.Contains(t, types, CallAdmitted, "of the queued call")
+	assert.Equal(t, Drained, types[len(types)-1])
+
+	assert.NoError(t, rLimit.Drain(context.Background()), "draining twice")
+	assert.NoError(t, rLimit.Close(context.Background()))
+}
+
+func TestDrainTimeout(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	releaseSlot := holdSlot(t, rLimit)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, rLimit.Drain(ctx), context.DeadlineExceeded)
+	releaseSlot()
+	assert.NoError(t, rLimit.Drain(context.Background()))
+}
+
+func TestLimiterManagerDrain(t *testing.T) {
+	manager := NewLimiterManager(WithGlobalLimiter(WithMaxConcurrency(10)))
+	queryUsers := manager.For(QueryUsers)
+	releaseSlot := holdSlot(t, queryUsers)
+	tenant := manager.ForTenant("acme", QueryChannels)
+
+	drained := make(chan error, 1)
+	go func() { drained <- manager.Drain(context.Background()) }()
+	require.Eventually(t, queryUsers.Draining, time.Second, time.Millisecond)
+	assert.ErrorIs(t, tenant.CallApiWithContext(context.Background(), okCall), ErrDraining)
+	assert.ErrorIs(t, manager.For(UpdateUsers).TryCallApi(okCall), ErrDraining, "limiter requested afterwards")
+	assert.ErrorIs(t, manager.Ready(), ErrDraining)
+
+	select {
+	case <-drained:
+		t.Fatal("Drain returned with a call in flight")
+	case <-time.After(10 * time.Millisecond):
+	}
+	releaseSlot()
+	assert.NoError(t, receive(t, drained))
+}
+
+func TestDrainKeepsCallsBlockedByReset(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: time.Now().Add(time.Second).Unix()}}, nil
+	}))
+	waiting := make(chan error, 1)
+	go func() {
+		waiting <- rLimit.CallApiWithContext(context.Background(), okCall)
+	}()
+	require.Eventually(t, func() bool { return len(rLimit.tokens()) == 1 }, time.Second, time.Millisecond)
+
+	require.NoError(t, rLimit.Drain(context.Background()))
+	assert.NoError(t, receive(t, waiting), "served after the reset")
+}
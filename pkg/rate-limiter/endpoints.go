@@ -0,0 +1,275 @@
+package rate_limiter
+
+import "sort"
+
+// The Stream Chat endpoints, named as in the rate limits reported by Stream,
+// plus the aliases of the stream-chat-go methods calling an endpoint under
+// another name, see Endpoint.Bucket.
+
+// Channels endpoints
+const (
+	CreateChannel        GetStreamApiName = "CreateChannel"
+	QueryChannel         GetStreamApiName = "QueryChannel"
+	GetOrCreateChannel   GetStreamApiName = "GetOrCreateChannel"
+	RefreshState         GetStreamApiName = "RefreshState"
+	QueryChannels        GetStreamApiName = "QueryChannels"
+	UpdateChannel        GetStreamApiName = "UpdateChannel"
+	UpdateChannelPartial GetStreamApiName = "UpdateChannelPartial"
+	DeleteChannel        GetStreamApiName = "DeleteChannel"
+	DeleteChannels       GetStreamApiName = "DeleteChannels"
+	TruncateChannel      GetStreamApiName = "TruncateChannel"
+	HideChannel          GetStreamApiName = "HideChannel"
+	ShowChannel          GetStreamApiName = "ShowChannel"
+	MarkRead             GetStreamApiName = "MarkRead"
+	MarkAllRead          GetStreamApiName = "MarkAllRead"
+	SendEvent            GetStreamApiName = "SendEvent"
+	SendFile             GetStreamApiName = "SendFile"
+	UploadFile           GetStreamApiName = "UploadFile"
+	SendImage            GetStreamApiName = "SendImage"
+	UploadImage          GetStreamApiName = "UploadImage"
+	DeleteFile           GetStreamApiName = "DeleteFile"
+	DeleteImage          GetStreamApiName = "DeleteImage"
+)
+
+// Members endpoints
+const (
+	QueryMembers     GetStreamApiName = "QueryMembers"
+	AddMembers       GetStreamApiName = "AddMembers"
+	RemoveMembers    GetStreamApiName = "RemoveMembers"
+	InviteMembers    GetStreamApiName = "InviteMembers"
+	AcceptInvite     GetStreamApiName = "AcceptInvite"
+	RejectInvite     GetStreamApiName = "RejectInvite"
+	AddModerators    GetStreamApiName = "AddModerators"
+	DemoteModerators GetStreamApiName = "DemoteModerators"
+	AssignRole       GetStreamApiName = "AssignRole"
+)
+
+// Messages endpoints
+const (
+	SendMessage          GetStreamApiName = "SendMessage"
+	GetMessage           GetStreamApiName = "GetMessage"
+	GetMessages          GetStreamApiName = "GetMessages"
+	GetManyMessages      GetStreamApiName = "GetManyMessages"
+	UpdateMessage        GetStreamApiName = "UpdateMessage"
+	PartialUpdateMessage GetStreamApiName = "PartialUpdateMessage"
+	UpdateMessagePartial GetStreamApiName = "UpdateMessagePartial"
+	PinMessage           GetStreamApiName = "PinMessage"
+	UnPinMessage         GetStreamApiName = "UnPinMessage"
+	DeleteMessage        GetStreamApiName = "DeleteMessage"
+	CommitMessage        GetStreamApiName = "CommitMessage"
+	TranslateMessage     GetStreamApiName = "TranslateMessage"
+	GetReplies           GetStreamApiName = "GetReplies"
+	SendAction           GetStreamApiName = "SendAction"
+	RunMessageAction     GetStreamApiName = "RunMessageAction"
+	Search               GetStreamApiName = "Search"
+)
+
+// Reactions endpoints
+const (
+	SendReaction   GetStreamApiName = "SendReaction"
+	DeleteReaction GetStreamApiName = "DeleteReaction"
+	GetReactions   GetStreamApiName = "GetReactions"
+)
+
+// Users endpoints
+const (
+	QueryUsers          GetStreamApiName = "QueryUsers"
+	UpdateUsers         GetStreamApiName = "UpdateUsers"
+	UpdateUsersPartial  GetStreamApiName = "UpdateUsersPartial"
+	CreateGuestUser     GetStreamApiName = "CreateGuestUser"
+	DeleteUser          GetStreamApiName = "DeleteUser"
+	DeleteUsers         GetStreamApiName = "DeleteUsers"
+	DeactivateUser      GetStreamApiName = "DeactivateUser"
+	DeactivateUsers     GetStreamApiName = "DeactivateUsers"
+	ReactivateUser      GetStreamApiName = "ReactivateUser"
+	ReactivateUsers     GetStreamApiName = "ReactivateUsers"
+	RevokeUserToken     GetStreamApiName = "RevokeUserToken"
+	RevokeUsersTokens   GetStreamApiName = "RevokeUsersTokens"
+	SendUserCustomEvent GetStreamApiName = "SendUserCustomEvent"
+)
+
+// Moderation endpoints
+const (
+	BanUser           GetStreamApiName = "BanUser"
+	ShadowBan         GetStreamApiName = "ShadowBan"
+	UnBanUser         GetStreamApiName = "UnBanUser"
+	QueryBannedUsers  GetStreamApiName = "QueryBannedUsers"
+	FlagMessage       GetStreamApiName = "FlagMessage"
+	FlagUser          GetStreamApiName = "FlagUser"
+	Flag              GetStreamApiName = "Flag"
+	MuteUser          GetStreamApiName = "MuteUser"
+	MuteUsers         GetStreamApiName = "MuteUsers"
+	Mute              GetStreamApiName = "Mute"
+	UnmuteUser        GetStreamApiName = "UnmuteUser"
+	UnmuteUsers       GetStreamApiName = "UnmuteUsers"
+	Unmute            GetStreamApiName = "Unmute"
+	MuteChannel       GetStreamApiName = "MuteChannel"
+	UnmuteChannel     GetStreamApiName = "UnmuteChannel"
+	QueryMessageFlags GetStreamApiName = "QueryMessageFlags"
+	QueryFlagReports  GetStreamApiName = "QueryFlagReports"
+	ReviewFlagReport  GetStreamApiName = "ReviewFlagReport"
+)
+
+// Devices endpoints
+const (
+	AddDevice    GetStreamApiName = "AddDevice"
+	DeleteDevice GetStreamApiName = "DeleteDevice"
+	GetDevices   GetStreamApiName = "GetDevices"
+)
+
+// Exports endpoints
+const (
+	ExportChannels        GetStreamApiName = "ExportChannels"
+	GetExportChannelsTask GetStreamApiName = "GetExportChannelsTask"
+	ExportUser            GetStreamApiName = "ExportUser"
+	GetTask               GetStreamApiName = "GetTask"
+)
+
+// Imports endpoints
+const (
+	CreateImportURL GetStreamApiName = "CreateImportURL"
+	CreateImport    GetStreamApiName = "CreateImport"
+	GetImport       GetStreamApiName = "GetImport"
+	ListImports     GetStreamApiName = "ListImports"
+)
+
+// Push endpoints
+const (
+	CheckPush          GetStreamApiName = "CheckPush"
+	CheckSqs           GetStreamApiName = "CheckSqs"
+	UpsertPushProvider GetStreamApiName = "UpsertPushProvider"
+	DeletePushProvider GetStreamApiName = "DeletePushProvider"
+	ListPushProviders  GetStreamApiName = "ListPushProviders"
+)
+
+// App endpoints
+const (
+	GetAppSettings    GetStreamApiName = "GetAppSettings"
+	UpdateAppSettings GetStreamApiName = "UpdateAppSettings"
+	RevokeTokens      GetStreamApiName = "RevokeTokens"
+	CreateChannelType GetStreamApiName = "CreateChannelType"
+	GetChannelType    GetStreamApiName = "GetChannelType"
+	ListChannelTypes  GetStreamApiName = "ListChannelTypes"
+	UpdateChannelType GetStreamApiName = "UpdateChannelType"
+	DeleteChannelType GetStreamApiName = "DeleteChannelType"
+	CreateCommand     GetStreamApiName = "CreateCommand"
+	GetCommand        GetStreamApiName = "GetCommand"
+	ListCommands      GetStreamApiName = "ListCommands"
+	UpdateCommand     GetStreamApiName = "UpdateCommand"
+	DeleteCommand     GetStreamApiName = "DeleteCommand"
+	CreateBlocklist   GetStreamApiName = "CreateBlocklist"
+	GetBlocklist      GetStreamApiName = "GetBlocklist"
+	ListBlocklists    GetStreamApiName = "ListBlocklists"
+	UpdateBlocklist   GetStreamApiName = "UpdateBlocklist"
+	DeleteBlocklist   GetStreamApiName = "DeleteBlocklist"
+)
+
+// EndpointCategory groups the endpoints by the resource they act on.
+type EndpointCategory string
+
+const (
+	CategoryChannels   EndpointCategory = "channels"
+	CategoryMembers    EndpointCategory = "members"
+	CategoryMessages   EndpointCategory = "messages"
+	CategoryReactions  EndpointCategory = "reactions"
+	CategoryUsers      EndpointCategory = "users"
+	CategoryModeration EndpointCategory = "moderation"
+	CategoryDevices    EndpointCategory = "devices"
+	CategoryExports    EndpointCategory = "exports"
+	CategoryImports    EndpointCategory = "imports"
+	CategoryPush       EndpointCategory = "push"
+	CategoryApp        EndpointCategory = "app"
+)
+
+// Endpoint describes a Stream Chat endpoint.
+type Endpoint struct {
+	Name     GetStreamApiName
+	Category EndpointCategory
+	// Bucket is the endpoint whose server side rate limit the calls count
+	// against: Name itself, unless Name is an alias sharing the quota of
+	// another endpoint, e.g. AddMembers updates the channel and counts against
+	// UpdateChannel.
+	Bucket GetStreamApiName
+}
+
+var endpoints = func() map[GetStreamApiName]Endpoint {
+	m := make(map[GetStreamApiName]Endpoint)
+	add := func(category EndpointCategory, names []GetStreamApiName, buckets map[GetStreamApiName]GetStreamApiName) {
+		for _, name := range names {
+			bucket, ok := buckets[name]
+			if !ok {
+				bucket = name
+			}
+			m[name] = Endpoint{Name: name, Category: category, Bucket: bucket}
+		}
+	}
+	add(CategoryChannels, []GetStreamApiName{CreateChannel, QueryChannel, GetOrCreateChannel, RefreshState, QueryChannels, UpdateChannel, UpdateChannelPartial, DeleteChannel, DeleteChannels, TruncateChannel, HideChannel, ShowChannel, MarkRead, MarkAllRead, SendEvent, SendFile, UploadFile, SendImage, UploadImage, DeleteFile, DeleteImage}, map[GetStreamApiName]GetStreamApiName{
+		CreateChannel: GetOrCreateChannel,
+		QueryChannel:  GetOrCreateChannel,
+		RefreshState:  GetOrCreateChannel,
+		SendFile:      UploadFile,
+		SendImage:     UploadImage,
+	})
+	add(CategoryMembers, []GetStreamApiName{QueryMembers, AddMembers, RemoveMembers, InviteMembers, AcceptInvite, RejectInvite, AddModerators, DemoteModerators, AssignRole}, map[GetStreamApiName]GetStreamApiName{
+		AddMembers:       UpdateChannel,
+		RemoveMembers:    UpdateChannel,
+		InviteMembers:    UpdateChannel,
+		AcceptInvite:     UpdateChannel,
+		RejectInvite:     UpdateChannel,
+		AddModerators:    UpdateChannel,
+		DemoteModerators: UpdateChannel,
+		AssignRole:       UpdateChannel,
+	})
+	add(CategoryMessages, []GetStreamApiName{SendMessage, GetMessage, GetMessages, GetManyMessages, UpdateMessage, PartialUpdateMessage, UpdateMessagePartial, PinMessage, UnPinMessage, DeleteMessage, CommitMessage, TranslateMessage, GetReplies, SendAction, RunMessageAction, Search}, map[GetStreamApiName]GetStreamApiName{
+		GetMessages:          GetManyMessages,
+		PartialUpdateMessage: UpdateMessagePartial,
+		PinMessage:           UpdateMessagePartial,
+		UnPinMessage:         UpdateMessagePartial,
+		SendAction:           RunMessageAction,
+	})
+	add(CategoryReactions, []GetStreamApiName{SendReaction, DeleteReaction, GetReactions}, nil)
+	add(CategoryUsers, []GetStreamApiName{QueryUsers, UpdateUsers, UpdateUsersPartial, CreateGuestUser, DeleteUser, DeleteUsers, DeactivateUser, DeactivateUsers, ReactivateUser, ReactivateUsers, RevokeUserToken, RevokeUsersTokens, SendUserCustomEvent}, map[GetStreamApiName]GetStreamApiName{
+		RevokeUserToken:   UpdateUsersPartial,
+		RevokeUsersTokens: UpdateUsersPartial,
+	})
+	add(CategoryModeration, []GetStreamApiName{BanUser, ShadowBan, UnBanUser, QueryBannedUsers, FlagMessage, FlagUser, Flag, MuteUser, MuteUsers, Mute, UnmuteUser, UnmuteUsers, Unmute, MuteChannel, UnmuteChannel, QueryMessageFlags, QueryFlagReports, ReviewFlagReport}, map[GetStreamApiName]GetStreamApiName{
+		ShadowBan:   BanUser,
+		FlagMessage: Flag,
+		FlagUser:    Flag,
+		MuteUser:    Mute,
+		MuteUsers:   Mute,
+		UnmuteUser:  Unmute,
+		UnmuteUsers: Unmute,
+	})
+	add(CategoryDevices, []GetStreamApiName{AddDevice, DeleteDevice, GetDevices}, nil)
+	add(CategoryExports, []GetStreamApiName{ExportChannels, GetExportChannelsTask, ExportUser, GetTask}, nil)
+	add(CategoryImports, []GetStreamApiName{CreateImportURL, CreateImport, GetImport, ListImports}, nil)
+	add(CategoryPush, []GetStreamApiName{CheckPush, CheckSqs, UpsertPushProvider, DeletePushProvider, ListPushProviders}, nil)
+	add(CategoryApp, []GetStreamApiName{GetAppSettings, UpdateAppSettings, RevokeTokens, CreateChannelType, GetChannelType, ListChannelTypes, UpdateChannelType, DeleteChannelType, CreateCommand, GetCommand, ListCommands, UpdateCommand, DeleteCommand, CreateBlocklist, GetBlocklist, ListBlocklists, UpdateBlocklist, DeleteBlocklist}, nil)
+	return m
+}()
+
+// Endpoints returns every known endpoint, sorted by name.
+func Endpoints() []Endpoint {
+	all := make([]Endpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		all = append(all, endpoint)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// LookupEndpoint returns the description of the endpoint apiName.
+func LookupEndpoint(apiName GetStreamApiName) (Endpoint, bool) {
+	endpoint, ok := endpoints[apiName]
+	return endpoint, ok
+}
+
+// Bucket returns the endpoint whose rate limit the calls of n count against,
+// n itself for unknown endpoints.
+func (n GetStreamApiName) Bucket() GetStreamApiName {
+	if endpoint, ok := endpoints[n]; ok {
+		return endpoint.Bucket
+	}
+	return n
+}
@@ -0,0 +1,28 @@
+package rate_limiter
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpoints(t *testing.T) {
+	all := Endpoints()
+	assert.True(t, sort.SliceIsSorted(all, func(i, j int) bool { return all[i].Name < all[j].Name }))
+	for _, endpoint := range all {
+		bucket, ok := LookupEndpoint(endpoint.Bucket)
+		if assert.True(t, ok, "bucket %s of %s", endpoint.Bucket, endpoint.Name) {
+			assert.Equal(t, bucket.Name, bucket.Bucket, "bucket %s is shared itself", bucket.Name)
+		}
+	}
+
+	endpoint, ok := LookupEndpoint(AddMembers)
+	assert.True(t, ok)
+	assert.Equal(t, Endpoint{Name: AddMembers, Category: CategoryMembers, Bucket: UpdateChannel}, endpoint)
+	assert.Equal(t, QueryUsers, QueryUsers.Bucket())
+	assert.Equal(t, GetOrCreateChannel, CreateChannel.Bucket())
+	assert.Equal(t, GetStreamApiName("Unknown"), GetStreamApiName("Unknown").Bucket())
+	_, ok = LookupEndpoint("Unknown")
+	assert.False(t, ok)
+}
@@ -0,0 +1,64 @@
+package rate_limiter
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultRateLimitWindow is how long an api is blocked after a 429 error not
+// carrying any rate limit info, i.e. the Stream rate limit window.
+const defaultRateLimitWindow = time.Minute
+
+// ErrWaitTimeout is returned when a call waited longer than the duration set
+// by WithMaxWait to be admitted.
+var ErrWaitTimeout = errors.New("wait timeout")
+
+// ErrRateLimited is returned by TryCallApi when the api slot is not
+// available, and by the calls rejected by Stream for too many requests, then
+// wrapping the error of the SDK as Err. ResetAt is set when the api is blocked
+// until a rate limit reset. errors.Is(err, ErrRateLimited{}) reports any of
+// them.
+type ErrRateLimited struct {
+	ApiName string
+	ResetAt time.Time
+	Err     error
+}
+
+func (e ErrRateLimited) Error() string {
+	msg := fmt.Sprintf("rate limited on %s", e.ApiName)
+	if !e.ResetAt.IsZero() {
+		msg += " until " + e.ResetAt.UTC().Format(time.RFC3339)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e ErrRateLimited) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an ErrRateLimited without any field set, or
+// equal to e but for Err.
+func (e ErrRateLimited) Is(target error) bool {
+	t, ok := target.(ErrRateLimited)
+	if !ok {
+		return false
+	}
+	if t.ApiName == "" && t.ResetAt.IsZero() {
+		return true
+	}
+	return t.ApiName == e.ApiName && t.ResetAt.Equal(e.ResetAt)
+}
+
+// rateLimitedError wraps err, rejected by Stream for too many requests until
+// resetAt, into an ErrRateLimited, unless a level below already did.
+func (r *RateLimiter) rateLimitedError(err error, resetAt time.Time) error {
+	var rateLimited ErrRateLimited
+	if errors.As(err, &rateLimited) {
+		return err
+	}
+	return ErrRateLimited{ApiName: r.apiName, ResetAt: resetAt, Err: err}
+}
@@ -0,0 +1,143 @@
+package rate_limiter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrRateLimited(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     ErrRateLimited
+		wantMsg string
+	}{
+		{
+			name:    "Busy slot",
+			err:     ErrRateLimited{ApiName: string(QueryUsers)},
+			wantMsg: "rate limited on QueryUsers",
+		},
+		{
+			name:    "Blocked until reset",
+			err:     ErrRateLimited{ApiName: string(QueryUsers), ResetAt: time.Unix(0, 0)},
+			wantMsg: "rate limited on QueryUsers until 1970-01-01T00:00:00Z",
+		},
+		{
+			name:    "Too many requests",
+			err:     ErrRateLimited{ApiName: string(QueryUsers), ResetAt: time.Unix(0, 0), Err: assert.AnError},
+			wantMsg: "rate limited on QueryUsers until 1970-01-01T00:00:00Z: " + assert.AnError.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, tt.err, tt.wantMsg)
+		})
+	}
+}
+
+func TestErrRateLimitedIs(t *testing.T) {
+	err := fmt.Errorf("call: %w", ErrRateLimited{ApiName: string(QueryUsers), ResetAt: time.Unix(1060, 0), Err: assert.AnError})
+	assert.ErrorIs(t, err, ErrRateLimited{})
+	assert.ErrorIs(t, err, ErrRateLimited{ApiName: string(QueryUsers), ResetAt: time.Unix(1060, 0)})
+	assert.NotErrorIs(t, err, ErrRateLimited{ApiName: string(QueryChannels)})
+	assert.ErrorIs(t, err, assert.AnError, "wraps the error of the SDK")
+}
+
+func TestSentinelErrors(t *testing.T) {
+	reset := time.Now().Unix() + 60
+	throttled := func() (*stream.Response, error) {
+		return nil, stream.Error{StatusCode: http.StatusTooManyRequests, RateLimit: &stream.RateLimitInfo{Limit: 60, Reset: reset}}
+	}
+
+	t.Run("Too many requests", func(t *testing.T) {
+		err := NewRateLimiter(QueryUsers).CallApiWithContext(context.Background(), throttled)
+		var rateLimited ErrRateLimited
+		require.ErrorAs(t, err, &rateLimited)
+		assert.Equal(t, time.Unix(reset, 0), rateLimited.ResetAt)
+		var apiErr stream.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	})
+	t.Run("Busy slot", func(t *testing.T) {
+		rLimit := NewRateLimiter(QueryUsers)
+		release := holdSlot(t, rLimit)
+		defer release()
+		assert.ErrorIs(t, rLimit.TryCallApi(nil), ErrRateLimited{})
+	})
+	t.Run("Wait timeout", func(t *testing.T) {
+		rLimit := NewRateLimiter(QueryUsers, WithMaxWait(time.Millisecond))
+		release := holdSlot(t, rLimit)
+		defer release()
+		assert.ErrorIs(t, rLimit.CallApiWithContext(context.Background(), nil), ErrWaitTimeout)
+	})
+	t.Run("Queue full", func(t *testing.T) {
+		rLimit := NewRateLimiter(QueryUsers, WithMaxQueueDepth(1))
+		release := holdSlot(t, rLimit)
+		defer release()
+		go func() {
+			_ = rLimit.CallApiWithContext(context.Background(), okResponse)
+		}()
+		require.Eventually(t, func() bool { return queued(rLimit) == 1 }, time.Second, time.Millisecond)
+		assert.ErrorIs(t, rLimit.CallApiWithContext(context.Background(), nil), ErrQueueFull)
+	})
+	t.Run("Closed", func(t *testing.T) {
+		rLimit := NewRateLimiter(QueryUsers)
+		require.NoError(t, rLimit.Close(context.Background()))
+		assert.ErrorIs(t, rLimit.CallApiWithContext(context.Background(), nil), ErrClosed)
+	})
+}
+
+func TestTooManyRequestsInfo(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	tests := []struct {
+		name      string
+		err       error
+		wantOk    bool
+		wantReset int64
+	}{
+		{
+			name:      "With rate limit info",
+			err:       stream.Error{StatusCode: http.StatusTooManyRequests, RateLimit: &stream.RateLimitInfo{Limit: 60, Remaining: 3, Reset: 1020}},
+			wantOk:    true,
+			wantReset: 1020,
+		},
+		{
+			name:      "Without rate limit info",
+			err:       stream.Error{StatusCode: http.StatusTooManyRequests},
+			wantOk:    true,
+			wantReset: 1060,
+		},
+		{
+			name:      "Reset already passed",
+			err:       &stream.Error{StatusCode: http.StatusTooManyRequests, RateLimit: &stream.RateLimitInfo{Reset: 900}},
+			wantOk:    true,
+			wantReset: 1060,
+		},
+		{
+			name: "Other status code",
+			err:  stream.Error{StatusCode: http.StatusInternalServerError},
+		},
+		{
+			name: "Other error",
+			err:  assert.AnError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, ok := tooManyRequestsInfo(tt.err, now)
+			assert.Equal(t, tt.wantOk, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, int64(0), info.Remaining)
+			assert.Equal(t, tt.wantReset, info.Reset)
+		})
+	}
+}
@@ -0,0 +1,238 @@
+// Package etcdstore implements rate_limiter.Store on top of etcd, so that the
+// replicas of a service deployed on Kubernetes share the Stream quota of every
+// api without running Redis. The state of each api is a key updated by
+// compare-and-swap transactions, attached to a lease expiring once its window,
+// and the block until its reset, are over.
+package etcdstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// DefaultKeyPrefix prefixes the key holding the state of each api.
+const DefaultKeyPrefix = "/getstream-rate-limiter/"
+
+// keyTTL is how long the state outlives its window, so that idle apis expire.
+const keyTTL = time.Minute
+
+// maxAttempts bounds the compare-and-swap attempts of an update.
+const maxAttempts = 16
+
+// ErrConflict is returned when the state of an api keeps being updated by
+// other replicas during maxAttempts attempts.
+var ErrConflict = errors.New("etcdstore: too many conflicting updates")
+
+// KV is the subset of etcd used by Store. Gateway implements it through the
+// HTTP API of etcd; a clientv3.Client is adapted with a Get and a Txn
+// comparing the mod revision of the key.
+type KV interface {
+	// Get returns the value of key and its mod revision, 0 when the key does
+	// not exist.
+	Get(ctx context.Context, key string) (value []byte, revision int64, err error)
+	// Swap puts value at key, attached to a lease expiring after ttl, if the
+	// mod revision of key is still revision, reporting whether it did.
+	Swap(ctx context.Context, key string, revision int64, value []byte, ttl time.Duration) (bool, error)
+}
+
+// state is the value of the key of an api, times in Unix milliseconds.
+type state struct {
+	Limit int64 `json:"limit"`
+	// Remaining is nil until a response reported the remaining calls, or once
+	// the window is over.
+	Remaining    *int64 `json:"remaining,omitempty"`
+	Reset        int64  `json:"reset"`
+	BlockedUntil int64  `json:"blocked_until,omitempty"`
+}
+
+// ttl is how long the state is kept after now.
+func (st *state) ttl(now int64) time.Duration {
+	return time.Duration(max(max(st.Reset, st.BlockedUntil)-now, 0))*time.Millisecond + keyTTL
+}
+
+// usage is the value of the key of a budget.
+type usage struct {
+	Used int64 `json:"used"`
+}
+
+// lead is the value of the key of an election.
+type lead struct {
+	Candidate string `json:"candidate"`
+	Until     int64  `json:"until"` // Unix milliseconds
+}
+
+// Store is a rate_limiter.Store backed by etcd.
+type Store struct {
+	kv     KV
+	prefix string
+}
+
+var (
+	_ rate_limiter.CostStore   = (*Store)(nil)
+	_ rate_limiter.BudgetStore = (*Store)(nil)
+	_ rate_limiter.Elector     = (*Store)(nil)
+)
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithKeyPrefix replaces DefaultKeyPrefix, e.g. to isolate several Stream apps.
+func WithKeyPrefix(prefix string) Option {
+	return func(s *Store) {
+		s.prefix = prefix
+	}
+}
+
+// New returns a Store keeping its state through kv.
+func New(kv KV, opts ...Option) *Store {
+	s := &Store{
+		kv:     kv,
+		prefix: DefaultKeyPrefix,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Store) key(api string) string {
+	return s.prefix + api
+}
+
+// update applies f to the value of key, the zero T when it does not exist,
+// writing it back when f reports so, with the lease TTL returned by f, unless
+// another replica updated it meanwhile, in which case f is applied again.
+func update[T any](ctx context.Context, kv KV, key string, f func(value *T) (write bool, ttl time.Duration)) error {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		data, revision, err := kv.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		var value T
+		if revision != 0 {
+			if err := json.Unmarshal(data, &value); err != nil {
+				return errors.New("etcdstore: invalid value of " + key)
+			}
+		}
+		write, ttl := f(&value)
+		if !write {
+			return nil
+		}
+		if data, err = json.Marshal(value); err != nil {
+			return err
+		}
+		swapped, err := kv.Swap(ctx, key, revision, data, ttl)
+		if err != nil || swapped {
+			return err
+		}
+	}
+	return ErrConflict
+}
+
+func (s *Store) Take(ctx context.Context, api string, now time.Time) (time.Time, error) {
+	return s.TakeN(ctx, api, now, 1)
+}
+
+func (s *Store) TakeN(ctx context.Context, api string, now time.Time, n int) (time.Time, error) {
+	nowMs := now.UnixMilli()
+	var blockedUntil int64
+	err := update(ctx, s.kv, s.key(api), func(st *state) (bool, time.Duration) {
+		blockedUntil = 0
+		switch {
+		case st.BlockedUntil > nowMs:
+			blockedUntil = st.BlockedUntil
+			return false, 0
+		case st.Remaining == nil:
+			return false, 0
+		case st.Reset <= nowMs:
+			// the window is over, remaining calls are unknown until the next
+			// response
+			st.Remaining = nil
+		case *st.Remaining <= 0:
+			st.BlockedUntil = st.Reset
+			blockedUntil = st.Reset
+		default:
+			remaining := max(*st.Remaining-int64(n), 0)
+			st.Remaining = &remaining
+		}
+		return true, st.ttl(nowMs)
+	})
+	if err != nil || blockedUntil == 0 {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(blockedUntil), nil
+}
+
+func (s *Store) Observe(ctx context.Context, api string, info rate_limiter.LimitInfo, now time.Time) error {
+	reset := info.Reset * 1000
+	return update(ctx, s.kv, s.key(api), func(st *state) (bool, time.Duration) {
+		if reset < st.Reset {
+			// late response of a previous window
+			return false, 0
+		}
+		remaining := info.Remaining
+		if reset == st.Reset && st.Remaining != nil && *st.Remaining < remaining {
+			remaining = *st.Remaining
+		}
+		st.Limit = info.Limit
+		st.Remaining = &remaining
+		st.Reset = reset
+		if remaining <= 0 && reset > st.BlockedUntil {
+			st.BlockedUntil = reset
+		}
+		// relative to the caller's clock, so that clock skew with etcd cannot
+		// expire the state early
+		return true, st.ttl(now.UnixMilli())
+	})
+}
+
+func (s *Store) Load(ctx context.Context, api string) (rate_limiter.State, error) {
+	data, revision, err := s.kv.Get(ctx, s.key(api))
+	if err != nil || revision == 0 {
+		return rate_limiter.State{}, err
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return rate_limiter.State{}, errors.New("etcdstore: invalid value of " + s.key(api))
+	}
+	loaded := rate_limiter.State{Limit: st.Limit, Reset: time.UnixMilli(st.Reset)}
+	if st.Remaining != nil {
+		loaded.Remaining = *st.Remaining
+	}
+	if st.BlockedUntil != 0 {
+		loaded.BlockedUntil = time.UnixMilli(st.BlockedUntil)
+	}
+	return loaded, nil
+}
+
+// AddUsage counts the usage of the budgets of rate_limiter.WithBudget, so that
+// they are shared by the replicas and survive their restarts.
+func (s *Store) AddUsage(ctx context.Context, key string, n int64, expiresAt time.Time) (int64, error) {
+	var used int64
+	err := update(ctx, s.kv, s.prefix+"budget/"+key, func(u *usage) (bool, time.Duration) {
+		u.Used = max(u.Used+n, 0)
+		used = u.Used
+		return true, time.Until(expiresAt)
+	})
+	return used, err
+}
+
+// Campaign elects the leader of election among the replicas, the lead expiring
+// with the lease of the key holding it.
+func (s *Store) Campaign(ctx context.Context, election, candidate string, now time.Time, ttl time.Duration) (string, error) {
+	var leader string
+	err := update(ctx, s.kv, s.prefix+"leader/"+election, func(l *lead) (bool, time.Duration) {
+		if l.Candidate != "" && l.Candidate != candidate && l.Until > now.UnixMilli() {
+			leader = l.Candidate
+			return false, 0
+		}
+		l.Candidate, l.Until = candidate, now.Add(ttl).UnixMilli()
+		leader = candidate
+		return true, ttl
+	})
+	return leader, err
+}
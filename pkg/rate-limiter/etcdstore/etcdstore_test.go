@@ -0,0 +1,240 @@
+package etcdstore
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+// fakeEtcd serves the subset of the HTTP API of etcd used by Gateway.
+type fakeEtcd struct {
+	mu       sync.Mutex
+	revision int64
+	kvs      map[string]*fakeKV
+	leases   map[int64]int64 // TTL by ID
+	failNext int             // txns failing as if the key changed
+}
+
+type fakeKV struct {
+	value    []byte
+	revision int64
+	lease    int64
+}
+
+func newFakeEtcd(t *testing.T) (*fakeEtcd, *Gateway) {
+	etcd := &fakeEtcd{kvs: map[string]*fakeKV{}, leases: map[int64]int64{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/kv/range", handle(etcd, func(req rangeRequest) any {
+		var resp rangeResponse
+		if kv, ok := etcd.kvs[string(req.Key)]; ok {
+			resp.Kvs = append(resp.Kvs, struct {
+				Value       []byte `json:"value"`
+				ModRevision int64  `json:"mod_revision,string"`
+			}{kv.value, kv.revision})
+		}
+		return resp
+	}))
+	mux.HandleFunc("/v3/lease/grant", handle(etcd, func(req leaseGrantRequest) any {
+		id := int64(len(etcd.leases) + 1)
+		etcd.leases[id] = req.TTL
+		return leaseGrantResponse{ID: id}
+	}))
+	mux.HandleFunc("/v3/kv/txn", handle(etcd, func(req txnRequest) any {
+		compare := req.Compare[0]
+		current := int64(0)
+		if kv, ok := etcd.kvs[string(compare.Key)]; ok {
+			current = kv.revision
+		}
+		if etcd.failNext > 0 || compare.Target != "MOD" || current != compare.ModRevision {
+			etcd.failNext = max(etcd.failNext-1, 0)
+			return txnResponse{}
+		}
+		put := req.Success[0].RequestPut
+		etcd.revision++
+		etcd.kvs[string(put.Key)] = &fakeKV{value: put.Value, revision: etcd.revision, lease: put.Lease}
+		return txnResponse{Succeeded: true}
+	}))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return etcd, NewGateway(server.URL+"/", server.Client())
+}
+
+// handle serves the requests decoded as Req with f, holding e.mu.
+func handle[Req any](e *fakeEtcd, f func(Req) any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req Req
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(errorResponse{Message: err.Error()})
+			return
+		}
+		e.mu.Lock()
+		resp := f(req)
+		e.mu.Unlock()
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// ttl returns the TTL of the lease of key, 0 when the key does not exist.
+func (e *fakeEtcd) ttl(key string) time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	kv, ok := e.kvs[key]
+	if !ok {
+		return 0
+	}
+	return time.Duration(e.leases[kv.lease]) * time.Second
+}
+
+func TestStoreTakeAndObserve(t *testing.T) {
+	ctx := context.Background()
+	etcd, gateway := newFakeEtcd(t)
+	store := New(gateway)
+	now := time.Unix(1000, 0)
+	api := string(rate_limiter.QueryUsers)
+
+	blockedUntil, err := store.Take(ctx, api, now)
+	require.NoError(t, err)
+	assert.True(t, blockedUntil.IsZero(), "unknown apis are admitted")
+
+	require.NoError(t, store.Observe(ctx, api, rate_limiter.LimitInfo{Limit: 10, Remaining: 2, Reset: 1060}, now))
+	assert.Equal(t, 2*time.Minute, etcd.ttl(DefaultKeyPrefix+api), "expires after the window")
+	for i := 0; i < 2; i++ {
+		blockedUntil, err = store.Take(ctx, api, now)
+		require.NoError(t, err)
+		assert.True(t, blockedUntil.IsZero())
+	}
+	blockedUntil, err = store.Take(ctx, api, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1060, 0), blockedUntil.Local(), "blocked until the reset")
+
+	state, err := store.Load(ctx, api)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), state.Limit)
+	assert.Equal(t, int64(0), state.Remaining)
+	assert.True(t, state.BlockedUntil.Equal(time.Unix(1060, 0)))
+
+	blockedUntil, err = store.Take(ctx, api, time.Unix(1061, 0))
+	require.NoError(t, err)
+	assert.True(t, blockedUntil.IsZero(), "window over")
+}
+
+func TestStoreObserve(t *testing.T) {
+	ctx := context.Background()
+	_, gateway := newFakeEtcd(t)
+	store := New(gateway, WithKeyPrefix("/app/"))
+	now := time.Unix(1000, 0)
+
+	require.NoError(t, store.Observe(ctx, "QueryUsers", rate_limiter.LimitInfo{Limit: 10, Remaining: 5, Reset: 1060}, now))
+	require.NoError(t, store.Observe(ctx, "QueryUsers", rate_limiter.LimitInfo{Limit: 10, Remaining: 8, Reset: 1060}, now))
+	state, err := store.Load(ctx, "QueryUsers")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), state.Remaining, "lowest remaining of the window")
+
+	require.NoError(t, store.Observe(ctx, "QueryUsers", rate_limiter.LimitInfo{Limit: 10, Remaining: 1, Reset: 1000}, now))
+	state, err = store.Load(ctx, "QueryUsers")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), state.Remaining, "late response of a previous window")
+
+	require.NoError(t, store.Observe(ctx, "QueryUsers", rate_limiter.LimitInfo{Limit: 10, Remaining: 0, Reset: 1120}, now))
+	state, err = store.Load(ctx, "QueryUsers")
+	require.NoError(t, err)
+	assert.True(t, state.BlockedUntil.Equal(time.Unix(1120, 0)))
+
+	state, err = store.Load(ctx, "QueryChannels")
+	require.NoError(t, err)
+	assert.Equal(t, rate_limiter.State{}, state, "unknown api")
+}
+
+func TestStoreConflicts(t *testing.T) {
+	ctx := context.Background()
+	etcd, gateway := newFakeEtcd(t)
+	store := New(gateway)
+	now := time.Unix(1000, 0)
+
+	etcd.failNext = 3
+	require.NoError(t, store.Observe(ctx, "QueryUsers", rate_limiter.LimitInfo{Limit: 10, Remaining: 5, Reset: 1060}, now), "retried")
+	state, err := store.Load(ctx, "QueryUsers")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), state.Remaining)
+
+	etcd.failNext = maxAttempts
+	assert.ErrorIs(t, store.Observe(ctx, "QueryUsers", rate_limiter.LimitInfo{Limit: 10, Remaining: 1, Reset: 1060}, now), ErrConflict)
+}
+
+func TestStoreAddUsage(t *testing.T) {
+	ctx := context.Background()
+	etcd, gateway := newFakeEtcd(t)
+	store := New(gateway)
+	expiresAt := time.Now().Add(time.Hour)
+
+	used, err := store.AddUsage(ctx, "QueryUsers/day/2024-01-01", 3, expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), used)
+	used, err = store.AddUsage(ctx, "QueryUsers/day/2024-01-01", -5, expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), used, "never below 0")
+	assert.InDelta(t, time.Hour, etcd.ttl(DefaultKeyPrefix+"budget/QueryUsers/day/2024-01-01"), float64(time.Second))
+}
+
+func TestGatewayErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error": "etcdserver: no leader", "message": "etcdserver: no leader", "code": 14}`))
+	}))
+	defer server.Close()
+	store := New(NewGateway(server.URL, nil))
+	_, err := store.Take(context.Background(), "QueryUsers", time.Now())
+	assert.EqualError(t, err, "etcdstore: /v3/kv/range: etcdserver: no leader")
+}
+
+func TestStoreSharedBetweenLimiters(t *testing.T) {
+	_, gateway := newFakeEtcd(t)
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	replica := func() *rate_limiter.RateLimiter {
+		return rate_limiter.NewRateLimiter(rate_limiter.QueryUsers, rate_limiter.WithClock(clock), rate_limiter.WithStore(New(gateway)))
+	}
+	first, second := replica(), replica()
+
+	require.NoError(t, first.TryCallApi(func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1060}}, nil
+	}))
+	var limited rate_limiter.ErrRateLimited
+	require.ErrorAs(t, second.TryCallApi(func() (*stream.Response, error) {
+		t.Error("called while blocked by the other replica")
+		return nil, nil
+	}), &limited)
+	assert.WithinDuration(t, time.Unix(1060, 0), limited.ResetAt, 0)
+}
+
+func TestStoreCampaign(t *testing.T) {
+	ctx := context.Background()
+	etcd, gateway := newFakeEtcd(t)
+	store := New(gateway)
+	now := time.Unix(1000, 0)
+
+	leader, err := store.Campaign(ctx, "quota", "a:7000", now, 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a:7000", leader)
+	assert.Equal(t, 10*time.Second, etcd.ttl(DefaultKeyPrefix+"leader/quota"))
+	leader, err = store.Campaign(ctx, "quota", "b:7000", now.Add(5*time.Second), 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a:7000", leader, "lead held")
+	leader, err = store.Campaign(ctx, "quota", "a:7000", now.Add(8*time.Second), 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a:7000", leader, "lead renewed")
+	leader, err = store.Campaign(ctx, "quota", "b:7000", now.Add(19*time.Second), 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "b:7000", leader, "failover")
+}
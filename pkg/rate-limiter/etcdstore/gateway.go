@@ -0,0 +1,135 @@
+package etcdstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Gateway is a KV talking to etcd through its HTTP API, the gRPC gateway
+// served on the client port of etcd, so that no etcd client is needed.
+type Gateway struct {
+	endpoint string
+	client   *http.Client
+}
+
+var _ KV = (*Gateway)(nil)
+
+// NewGateway returns a Gateway to the etcd at endpoint, e.g.
+// http://etcd:2379, sending its requests through client, http.DefaultClient
+// when nil, e.g. one with the TLS config of the cluster.
+func NewGateway(endpoint string, client *http.Client) *Gateway {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Gateway{endpoint: strings.TrimSuffix(endpoint, "/"), client: client}
+}
+
+// The messages of the gateway, in the JSON mapping of the etcd protos: bytes
+// are base64 encoded, 64 bits integers are strings.
+type (
+	rangeRequest struct {
+		Key []byte `json:"key"`
+	}
+	rangeResponse struct {
+		Kvs []struct {
+			Value       []byte `json:"value"`
+			ModRevision int64  `json:"mod_revision,string"`
+		} `json:"kvs"`
+	}
+	leaseGrantRequest struct {
+		TTL int64 `json:"TTL,string"`
+	}
+	leaseGrantResponse struct {
+		ID    int64  `json:"ID,string"`
+		Error string `json:"error"`
+	}
+	txnRequest struct {
+		Compare []compare   `json:"compare"`
+		Success []requestOp `json:"success"`
+	}
+	compare struct {
+		Key         []byte `json:"key"`
+		Target      string `json:"target"`
+		Result      string `json:"result"`
+		ModRevision int64  `json:"mod_revision,string"`
+	}
+	requestOp struct {
+		RequestPut putRequest `json:"request_put"`
+	}
+	putRequest struct {
+		Key   []byte `json:"key"`
+		Value []byte `json:"value"`
+		Lease int64  `json:"lease,string"`
+	}
+	txnResponse struct {
+		Succeeded bool `json:"succeeded"`
+	}
+	errorResponse struct {
+		Message string `json:"message"`
+	}
+)
+
+func (g *Gateway) Get(ctx context.Context, key string) ([]byte, int64, error) {
+	var resp rangeResponse
+	if err := g.post(ctx, "/v3/kv/range", rangeRequest{Key: []byte(key)}, &resp); err != nil {
+		return nil, 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, 0, nil
+	}
+	return resp.Kvs[0].Value, resp.Kvs[0].ModRevision, nil
+}
+
+func (g *Gateway) Swap(ctx context.Context, key string, revision int64, value []byte, ttl time.Duration) (bool, error) {
+	// the lease of a failed swap is left to expire
+	var lease leaseGrantResponse
+	seconds := max(int64((ttl+time.Second-1)/time.Second), 1)
+	if err := g.post(ctx, "/v3/lease/grant", leaseGrantRequest{TTL: seconds}, &lease); err != nil {
+		return false, err
+	}
+	if lease.Error != "" {
+		return false, fmt.Errorf("etcdstore: lease grant: %s", lease.Error)
+	}
+	var resp txnResponse
+	err := g.post(ctx, "/v3/kv/txn", txnRequest{
+		Compare: []compare{{Key: []byte(key), Target: "MOD", Result: "EQUAL", ModRevision: revision}},
+		Success: []requestOp{{RequestPut: putRequest{Key: []byte(key), Value: value, Lease: lease.ID}}},
+	}, &resp)
+	return resp.Succeeded, err
+}
+
+// post sends request to the gateway at path, decoding its response into resp.
+func (g *Gateway) post(ctx context.Context, path string, request, resp any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		var failed errorResponse
+		if json.Unmarshal(data, &failed) != nil || failed.Message == "" {
+			failed.Message = res.Status
+		}
+		return fmt.Errorf("etcdstore: %s: %s", path, failed.Message)
+	}
+	return json.Unmarshal(data, resp)
+}
@@ -0,0 +1,162 @@
+package rate_limiter
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// eventBuffer is the capacity of the channels returned by Events.
+const eventBuffer = 64
+
+// EventType is the kind of an Event.
+type EventType int
+
+const (
+	// CallAdmitted is emitted when a call is admitted, right before the api
+	// is called.
+	CallAdmitted EventType = iota
+	// Blocked is emitted when the calls get blocked until ResumeAt.
+	Blocked
+	// Resumed is emitted when the blocked calls are resumed.
+	Resumed
+	// Rejected is emitted when a call is turned down without calling the
+	// api, e.g. with an ErrRateLimited by TryCallApi or an ErrWaitTimeout.
+	Rejected
+	// RetryScheduled is emitted when a failed call is retried at ResumeAt,
+	// see WithRetry.
+	RetryScheduled
+	// Draining is emitted by Drain whenever the calls Pending change.
+	Draining
+	// Drained is emitted by Drain once no call is pending.
+	Drained
+)
+
+func (t EventType) String() string {
+	switch t {
+	case CallAdmitted:
+		return "CallAdmitted"
+	case Blocked:
+		return "Blocked"
+	case Resumed:
+		return "Resumed"
+	case Rejected:
+		return "Rejected"
+	case RetryScheduled:
+		return "RetryScheduled"
+	case Draining:
+		return "Draining"
+	case Drained:
+		return "Drained"
+	default:
+		return fmt.Sprintf("EventType(%d)", int(t))
+	}
+}
+
+// Event is a state transition of a limiter, see Events.
+type Event struct {
+	Type EventType
+	Api  string
+	At   time.Time
+	// ResumeAt is when the calls resume for Blocked, zero for a Pause, and
+	// when the call is retried for RetryScheduled.
+	ResumeAt time.Time
+	// Err is the error of the call for Rejected and RetryScheduled.
+	Err error
+	// Tags are the tags of the call for CallAdmitted and Rejected, see
+	// WithTags.
+	Tags map[string]string
+	// Pending is the calls left to return for Draining.
+	Pending int
+}
+
+// eventStream fans the events of a limiter out to the channels of Events.
+type eventStream struct {
+	mu     sync.Mutex
+	subs   []chan Event
+	closed bool
+}
+
+// Events returns a channel receiving the events of r, e.g. to feed a dashboard
+// or a message bus; each call returns a channel of its own. The events are
+// sent without blocking the limiter: they are dropped while the channel is
+// full, as it is not drained. The channel is closed by Close.
+func (r *RateLimiter) Events() <-chan Event {
+	r.events.mu.Lock()
+	defer r.events.mu.Unlock()
+	ch := make(chan Event, eventBuffer)
+	if r.events.closed {
+		close(ch)
+		return ch
+	}
+	r.events.subs = append(r.events.subs, ch)
+	return ch
+}
+
+// emit stamps event and sends it to the channels of Events.
+func (r *RateLimiter) emit(event Event) {
+	r.events.mu.Lock()
+	defer r.events.mu.Unlock()
+	if r.events.closed || len(r.events.subs) == 0 {
+		return
+	}
+	event.Api = r.apiName
+	event.At = r.getClock().Now()
+	for _, ch := range r.events.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// emitRejected emits a Rejected event of a call with tags, and counts it for
+// Stats, when err turned the call down without calling the api.
+func (r *RateLimiter) emitRejected(err error, tags map[string]string) {
+	if err == nil {
+		return
+	}
+	var rateLimited ErrRateLimited
+	switch {
+	case errors.As(err, &rateLimited):
+		if rateLimited.Err != nil && !errors.Is(rateLimited.Err, ErrPaused) {
+			// rejected by Stream
+			return
+		}
+	case errors.Is(err, ErrWaitTimeout), errors.Is(err, ErrQueueFull), errors.Is(err, ErrCircuitOpen),
+		errors.As(err, new(ErrBudgetExceeded)):
+	default:
+		return
+	}
+	r.recordRejection()
+	r.emit(Event{Type: Rejected, Err: err, Tags: tags})
+}
+
+// closeEvents closes the channels of Events.
+func (r *RateLimiter) closeEvents() {
+	r.events.mu.Lock()
+	defer r.events.mu.Unlock()
+	if r.events.closed {
+		return
+	}
+	r.events.closed = true
+	for _, ch := range r.events.subs {
+		close(ch)
+	}
+	r.events.subs = nil
+}
+
+// unsubscribe closes ch, a channel of Events, which no longer receives the
+// events of r.
+func (r *RateLimiter) unsubscribe(ch <-chan Event) {
+	r.events.mu.Lock()
+	defer r.events.mu.Unlock()
+	for i, sub := range r.events.subs {
+		if sub == ch {
+			close(sub)
+			r.events.subs = append(r.events.subs[:i], r.events.subs[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+// nextEvent returns the next event of events, failing the test after a second.
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an event")
+		return Event{}
+	}
+}
+
+func TestEvents(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+	events := rLimit.Events()
+
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1060}}, nil
+	}))
+	assert.Equal(t, Event{Type: CallAdmitted, Api: string(QueryUsers), At: time.Unix(1000, 0)}, nextEvent(t, events))
+	assert.Equal(t, Event{Type: Blocked, Api: string(QueryUsers), At: time.Unix(1000, 0), ResumeAt: time.Unix(1060, 0)}, nextEvent(t, events))
+
+	err := rLimit.TryCallApi(nil)
+	require.Error(t, err)
+	assert.Equal(t, Event{Type: Rejected, Api: string(QueryUsers), At: time.Unix(1000, 0), Err: err}, nextEvent(t, events))
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, Event{Type: Resumed, Api: string(QueryUsers), At: time.Unix(1060, 0)}, nextEvent(t, events))
+
+	require.NoError(t, rLimit.Close(context.Background()))
+	_, ok := <-events
+	assert.False(t, ok, "closed by Close")
+	_, ok = <-rLimit.Events()
+	assert.False(t, ok)
+}
+
+func TestEventsRetryScheduled(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithRetry(RetryPolicy{MaxAttempts: 2, Backoff: ConstantBackoff(time.Millisecond)}))
+	events := rLimit.Events()
+	other := rLimit.Events()
+
+	var errs []error
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		if len(errs) == 0 {
+			errs = append(errs, stream.Error{StatusCode: 503})
+			return nil, errs[0]
+		}
+		return okResponse()
+	}))
+	types := func(events <-chan Event) []EventType {
+		var types []EventType
+		for len(events) > 0 {
+			event := <-events
+			if event.Type == RetryScheduled {
+				assert.Equal(t, errs[0], event.Err)
+				assert.False(t, event.ResumeAt.Before(event.At))
+			}
+			types = append(types, event.Type)
+		}
+		return types
+	}
+	assert.Equal(t, []EventType{CallAdmitted, RetryScheduled, CallAdmitted}, types(events))
+	assert.Equal(t, []EventType{CallAdmitted, RetryScheduled, CallAdmitted}, types(other), "each channel gets every event")
+}
+
+func TestEventsDroppedWhenFull(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	events := rLimit.Events()
+	for i := 0; i < eventBuffer+10; i++ {
+		require.NoError(t, rLimit.CallApiWithContext(context.Background(), okResponse))
+	}
+	assert.Len(t, events, eventBuffer)
+}
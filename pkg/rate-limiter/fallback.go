@@ -0,0 +1,82 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Fallback serves a call which would wait too long for the rate limit reset,
+// e.g. from cached data. Only its error is returned to the caller, which gets
+// the fallback data the same way it gets the response of its api call.
+type Fallback func(ctx context.Context) (*stream.Response, error)
+
+// ErrServedByFallback is reported, through a *ServedByFallbackError, by Call
+// when the fallback served the call, which has no typed result then.
+var ErrServedByFallback = errors.New("served by fallback")
+
+// ServedByFallbackError is returned by Call for a call served by the fallback
+// of WithFallback without error, carrying the response of the fallback. It
+// unwraps to ErrServedByFallback.
+type ServedByFallbackError struct {
+	ApiName  string
+	Response *stream.Response
+}
+
+func (e *ServedByFallbackError) Error() string {
+	return fmt.Sprintf("%v on %s", ErrServedByFallback, e.ApiName)
+}
+
+func (e *ServedByFallbackError) Unwrap() error {
+	return ErrServedByFallback
+}
+
+type fallbackKey struct{}
+
+// fallbackServed records whether the fallback served a call of Call, and its
+// response.
+type fallbackServed struct {
+	served bool
+	resp   *stream.Response
+}
+
+type fallbackPolicy struct {
+	after time.Duration
+	serve Fallback
+}
+
+// WithFallback invokes fallback instead of waiting when a call would wait
+// longer than after for the rate limit reset, see EstimateWait. Calls are made
+// as usual while the api is available.
+func WithFallback(after time.Duration, fallback Fallback) Option {
+	return func(r *RateLimiter) {
+		r.fallback = &fallbackPolicy{after: after, serve: fallback}
+	}
+}
+
+// tryFallback invokes the fallback, reporting true, when the call would wait
+// longer than allowed.
+func (r *RateLimiter) tryFallback(ctx context.Context, logger Logger) (bool, error) {
+	if r.fallback == nil {
+		return false, nil
+	}
+	wait := r.EstimateWait()
+	if wait <= r.fallback.after {
+		return false, nil
+	}
+	logAttrs(ctx, logger, slog.LevelDebug, "fallback invoked", []slog.Attr{
+		slog.String("api", r.apiName),
+		slog.Duration("wait", wait),
+	}, "Fallback invoked for %s instead of waiting %v\n", r.apiName, wait)
+	trace.SpanFromContext(ctx).AddEvent("fallback")
+	resp, err := r.fallback.serve(ctx)
+	if served, ok := ctx.Value(fallbackKey{}).(*fallbackServed); ok {
+		served.served, served.resp = true, resp
+	}
+	return true, err
+}
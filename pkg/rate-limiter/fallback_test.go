@@ -0,0 +1,88 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestWithFallback(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	served := 0
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithFallback(10*time.Second, func(ctx context.Context) (*stream.Response, error) {
+		served++
+		return nil, assert.AnError
+	}))
+	called := 0
+	exhausted := func() (*stream.Response, error) {
+		called++
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Reset: clock.Now().Unix() + 30}}, nil
+	}
+
+	// available: the api is called
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), exhausted))
+	assert.Equal(t, 1, called)
+	assert.True(t, rLimit.IsBlocked())
+
+	// blocked for 30s: the fallback serves the call
+	assert.ErrorIs(t, rLimit.CallApiWithContext(context.Background(), exhausted), assert.AnError)
+	assert.Equal(t, 1, called)
+	assert.Equal(t, 1, served)
+
+	// blocked for less than 10s: the call waits for the reset
+	clock.Advance(25 * time.Second)
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), exhausted)
+	}()
+	select {
+	case <-done:
+		t.Fatal("call served before the reset")
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(5 * time.Second)
+	require.NoError(t, receive(t, done))
+	assert.Equal(t, 2, called)
+	assert.Equal(t, 1, served)
+}
+
+func TestCallServedByFallback(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	fallback := &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 0}}
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithFallback(10*time.Second, func(ctx context.Context) (*stream.Response, error) {
+		return fallback, nil
+	}))
+	queryUsers := func() (*stream.QueryUsersResponse, *stream.Response, error) {
+		resp := &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Reset: clock.Now().Unix() + 30}}
+		return &stream.QueryUsersResponse{Users: []*stream.User{{ID: "u1"}}}, resp, nil
+	}
+
+	users, err := Call(context.Background(), rLimit, queryUsers)
+	require.NoError(t, err)
+	assert.Len(t, users.Users, 1)
+
+	// blocked for 30s: the fallback serves the call, without a typed result
+	users, err = Call(context.Background(), rLimit, queryUsers)
+	assert.Nil(t, users)
+	assert.ErrorIs(t, err, ErrServedByFallback)
+	var served *ServedByFallbackError
+	require.ErrorAs(t, err, &served)
+	assert.Equal(t, string(QueryUsers), served.ApiName)
+	assert.Same(t, fallback, served.Response)
+
+	// a failing fallback returns its error
+	rLimit = NewRateLimiter(QueryUsers, WithClock(clock), WithFallback(10*time.Second, func(ctx context.Context) (*stream.Response, error) {
+		return nil, assert.AnError
+	}))
+	_, err = Call(context.Background(), rLimit, queryUsers)
+	require.NoError(t, err)
+	_, err = Call(context.Background(), rLimit, queryUsers)
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.NotErrorIs(t, err, ErrServedByFallback)
+}
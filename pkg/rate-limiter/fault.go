@@ -0,0 +1,121 @@
+package rate_limiter
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// FaultInjector configures the faults injected by WithFaultInjector, each
+// with the probability of a call getting it, from 0 to 1.
+type FaultInjector struct {
+	// TooManyRequests fails the call with a Stream 429 error, without calling
+	// the api, reporting the reset of the latest response if still ahead.
+	TooManyRequests float64
+	// ServerError fails the call with a transient Stream 503 error, without
+	// calling the api.
+	ServerError float64
+	// Latency delays the call by LatencySpike, failing it with the ctx error
+	// when ctx is done meanwhile.
+	Latency      float64
+	LatencySpike time.Duration
+	// MalformedInfo replaces the rate limit info of a successful response by
+	// a malformed one: missing, with a zero limit, with more calls remaining
+	// than the limit, or with a reset already passed.
+	MalformedInfo float64
+	// Rand returns the random numbers in [0, 1) drawn for each fault,
+	// rand.Float64 when nil, e.g. to make a test deterministic. It must be
+	// safe for concurrent use.
+	Rand func() float64
+}
+
+// WithFaultInjector injects the faults of f into the calls of the limiter, to
+// test the resilience of an application built on it, e.g. its handling of
+// 429s or of slow calls, without a misbehaving Stream. The faults go through
+// the limiter as real responses would, blocking the api after a 429 and
+// feeding the retries, circuit breaker and metrics. Not meant for production.
+func WithFaultInjector(f FaultInjector) Option {
+	return func(r *RateLimiter) {
+		if f.Rand == nil {
+			f.Rand = rand.Float64
+		}
+		r.faults = &f
+	}
+}
+
+func (f *FaultInjector) draw(probability float64) bool {
+	return probability > 0 && f.Rand() < probability
+}
+
+// faulting is the built-in interceptor of WithFaultInjector, injecting its
+// faults into each attempt, save for the calls skipping the limiter.
+func (r *RateLimiter) faulting(next Caller) Caller {
+	return func(ctx context.Context, inv Invocation) error {
+		if !inv.Options.SkipLimit {
+			inv.call = r.injectFaults(ctx, inv.logger, inv.call)
+		}
+		return next(ctx, inv)
+	}
+}
+
+// injectFaults returns apiCall with the faults of WithFaultInjector, if any.
+func (r *RateLimiter) injectFaults(ctx context.Context, logger Logger, apiCall limitedCall) limitedCall {
+	f := r.faults
+	if f == nil {
+		return apiCall
+	}
+	injected := func(fault string) {
+		trace.SpanFromContext(ctx).AddEvent("fault injected", trace.WithAttributes(attribute.String("fault", fault)))
+		logAttrs(ctx, logger, slog.LevelDebug, "fault injected", []slog.Attr{
+			slog.String("api", r.apiName),
+			slog.String("fault", fault),
+		}, "Injected %s into a call of %s\n", fault, r.apiName)
+	}
+	return limitedFunc(func() (LimitInfo, bool, error) {
+		if f.LatencySpike > 0 && f.draw(f.Latency) {
+			injected("latency")
+			select {
+			case <-r.getClock().After(f.LatencySpike):
+			case <-ctx.Done():
+				return LimitInfo{}, false, ctx.Err()
+			}
+		}
+		if f.draw(f.TooManyRequests) {
+			injected("too many requests")
+			var reported *LimitInfo
+			if info, ok := r.lastInfo.Load(); ok && info.Reset > r.serverNow().Unix() {
+				reported = &LimitInfo{Limit: info.Limit, Remaining: 0, Reset: info.Reset}
+			}
+			return LimitInfo{}, false, streamError(http.StatusTooManyRequests, "injected: too many requests", reported)
+		}
+		if f.draw(f.ServerError) {
+			injected("server error")
+			return LimitInfo{}, false, streamError(http.StatusServiceUnavailable, "injected: service unavailable", nil)
+		}
+		info, ok, err := apiCall.call()
+		if err != nil || !f.draw(f.MalformedInfo) {
+			return info, ok, err
+		}
+		injected("malformed rate limit info")
+		if !ok {
+			return LimitInfo{}, false, nil
+		}
+		malformed := info
+		switch int(f.Rand() * 4) {
+		case 0:
+			return LimitInfo{}, false, nil
+		case 1:
+			malformed.Limit = 0
+		case 2:
+			malformed.Remaining = malformed.Limit + 1
+		default:
+			malformed.Reset = r.serverNow().Add(-time.Minute).Unix()
+		}
+		return malformed, true, nil
+	})
+}
@@ -0,0 +1,112 @@
+package rate_limiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+// draws returns a Rand func returning values in turn, then 0.99.
+func draws(values ...float64) func() float64 {
+	var mu sync.Mutex
+	return func() float64 {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(values) == 0 {
+			return 0.99
+		}
+		value := values[0]
+		values = values[1:]
+		return value
+	}
+}
+
+func TestFaultInjectorTooManyRequests(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithFaultInjector(FaultInjector{
+		TooManyRequests: 0.5,
+		// the first call passes, the second one gets a 429
+		Rand: draws(0.7, 0.1),
+	}))
+	called := 0
+	apiCall := func() (*stream.Response, error) {
+		called++
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 9, Reset: 1030}}, nil
+	}
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), apiCall))
+
+	err := rLimit.CallApiWithContext(context.Background(), apiCall)
+	var rateLimited ErrRateLimited
+	require.ErrorAs(t, err, &rateLimited)
+	assert.Equal(t, 429, rateLimited.Err.(stream.Error).StatusCode)
+	assert.Equal(t, 1, called, "the api is not called")
+	assert.True(t, rLimit.IsBlocked())
+	assert.Equal(t, time.Unix(1030, 0), rLimit.ResetAt(), "until the reset of the latest response")
+}
+
+func TestFaultInjectorServerError(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithFaultInjector(FaultInjector{ServerError: 1}))
+	err := rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		t.Fatal("the api is not called")
+		return nil, nil
+	})
+	assert.Equal(t, stream.Error{StatusCode: 503, Message: "injected: service unavailable"}, err)
+	assert.False(t, rLimit.IsBlocked())
+}
+
+func TestFaultInjectorLatency(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithFaultInjector(FaultInjector{Latency: 1, LatencySpike: 5 * time.Second}))
+
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), okResponse)
+	}()
+	blockUntil(t, clock, 1)
+	clock.Advance(5 * time.Second)
+	require.NoError(t, receive(t, done))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		done <- rLimit.CallApiWithContext(ctx, func() (*stream.Response, error) {
+			t.Error("the api is not called")
+			return nil, nil
+		})
+	}()
+	blockUntil(t, clock, 1)
+	cancel()
+	assert.ErrorIs(t, receive(t, done), context.Canceled)
+}
+
+func TestFaultInjectorMalformedInfo(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	apiCall := func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 5, Reset: 1060}}, nil
+	}
+	for name, tc := range map[string]struct {
+		variant float64
+		want    *LimitInfo
+	}{
+		"missing":         {variant: 0.1, want: nil},
+		"zero limit":      {variant: 0.3, want: &LimitInfo{Limit: 0, Remaining: 5, Reset: 1060}},
+		"remaining above": {variant: 0.6, want: &LimitInfo{Limit: 10, Remaining: 11, Reset: 1060}},
+		"reset passed":    {variant: 0.9, want: &LimitInfo{Limit: 10, Remaining: 5, Reset: 940}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithFaultInjector(FaultInjector{
+				MalformedInfo: 0.5,
+				Rand:          draws(0.2, tc.variant),
+			}))
+			require.NoError(t, rLimit.CallApiWithContext(context.Background(), apiCall))
+			assert.Equal(t, tc.want, orNil(rLimit.lastInfo.Load()))
+			assert.False(t, rLimit.IsBlocked())
+		})
+	}
+}
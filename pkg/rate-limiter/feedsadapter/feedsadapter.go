@@ -0,0 +1,68 @@
+// Package feedsadapter rate limits the calls of the Stream Feeds client,
+// stream-go2, with the limiters of the rate limiter, so that an app using both
+// Chat and Feeds shares one limiter package. The quota is read from the rate
+// limit headers of the Feeds responses, and a 429 blocks the endpoint like a
+// Stream Chat one.
+package feedsadapter
+
+import (
+	"context"
+	"net/http"
+
+	feeds "github.com/GetStream/stream-go2/v8"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// DefaultEndpoint names the limiter of the calls whose context names no
+// endpoint, see WithEndpoint.
+const DefaultEndpoint rate_limiter.GetStreamApiName = "Feeds"
+
+type endpointKey struct{}
+
+// WithEndpoint names the endpoint of the Feeds calls made with ctx, hence the
+// limiter they go through, e.g. "AddActivity".
+func WithEndpoint(ctx context.Context, apiName rate_limiter.GetStreamApiName) context.Context {
+	return context.WithValue(ctx, endpointKey{}, apiName)
+}
+
+func endpoint(req *http.Request) rate_limiter.GetStreamApiName {
+	if apiName, ok := req.Context().Value(endpointKey{}).(rate_limiter.GetStreamApiName); ok {
+		return apiName
+	}
+	return DefaultEndpoint
+}
+
+// NewRequester returns a feeds.Requester performing the requests through
+// client, http.DefaultClient when nil, rate limited by the limiters of manager:
+//
+//	feedsClient, err := feeds.New(key, secret, feeds.WithHTTPRequester(feedsadapter.NewRequester(manager, nil)))
+//
+// The requests are sent by a copy of client whose Transport is wrapped by a
+// rate_limiter.Transport.
+func NewRequester(manager *rate_limiter.LimiterManager, client *http.Client) feeds.Requester {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	requester := *client
+	requester.Transport = &rate_limiter.Transport{
+		Base:     client.Transport,
+		Limiters: manager,
+		Endpoint: endpoint,
+	}
+	return &requester
+}
+
+// RateLimitInfo converts the Rate of a Feeds response, e.g. to rate limit a
+// Feeds call with RateLimiter.CallRateLimited, nil when the response reported
+// no rate limit.
+func RateLimitInfo(rate *feeds.Rate) *rate_limiter.LimitInfo {
+	if rate == nil || *rate == (feeds.Rate{}) {
+		return nil
+	}
+	info := &rate_limiter.LimitInfo{Limit: int64(rate.Limit), Remaining: int64(rate.Remaining)}
+	if !rate.Reset.IsZero() {
+		info.Reset = rate.Reset.Unix()
+	}
+	return info
+}
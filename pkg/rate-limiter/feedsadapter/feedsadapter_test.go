@@ -0,0 +1,80 @@
+package feedsadapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	feeds "github.com/GetStream/stream-go2/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// newTestFeedsClient returns a Feeds client talking to handler through the
+// limiters of manager.
+func newTestFeedsClient(t *testing.T, manager *rate_limiter.LimiterManager, handler http.HandlerFunc) *feeds.Client {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	t.Setenv("STREAM_URL", srv.URL)
+	client, err := feeds.New("key", "secret", feeds.WithHTTPRequester(NewRequester(manager, nil)))
+	require.NoError(t, err)
+	return client
+}
+
+func TestRequester(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Unix()
+	manager := rate_limiter.NewLimiterManager()
+	client := newTestFeedsClient(t, manager, func(w http.ResponseWriter, r *http.Request) {
+		remaining := "9"
+		if r.Method == http.MethodPost {
+			remaining = "0"
+		}
+		w.Header().Set(feeds.HeaderRateLimit, "10")
+		w.Header().Set(feeds.HeaderRateRemaining, remaining)
+		w.Header().Set(feeds.HeaderRateReset, strconv.FormatInt(reset, 10))
+		_, _ = w.Write([]byte(`{}`))
+	})
+	feed, err := client.FlatFeed("user", "jane")
+	require.NoError(t, err)
+
+	_, err = feed.GetActivities(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), manager.For(DefaultEndpoint).Remaining())
+
+	ctx := WithEndpoint(context.Background(), "AddActivity")
+	_, err = feed.AddActivity(ctx, feeds.Activity{Actor: "jane", Verb: "post", Object: "post:1"})
+	require.NoError(t, err)
+	assert.True(t, manager.For("AddActivity").IsBlocked())
+	assert.Equal(t, time.Unix(reset, 0), manager.For("AddActivity").ResetAt())
+	assert.False(t, manager.For(DefaultEndpoint).IsBlocked())
+}
+
+func TestRequesterTooManyRequests(t *testing.T) {
+	manager := rate_limiter.NewLimiterManager()
+	client := newTestFeedsClient(t, manager, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(feeds.HeaderRateRemaining, "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"detail":"rate limited"}`))
+	})
+	feed, err := client.FlatFeed("user", "jane")
+	require.NoError(t, err)
+
+	_, err = feed.GetActivities(WithEndpoint(context.Background(), "GetActivities"))
+	apiErr, ok := feeds.ToAPIError(err)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	assert.True(t, manager.For("GetActivities").IsBlocked())
+}
+
+func TestRateLimitInfo(t *testing.T) {
+	reset := time.Unix(1000, 0)
+	assert.Nil(t, RateLimitInfo(nil))
+	assert.Nil(t, RateLimitInfo(&feeds.Rate{}))
+	assert.Equal(t, &rate_limiter.LimitInfo{Limit: 10, Remaining: 4, Reset: 1000},
+		RateLimitInfo(&feeds.Rate{Limit: 10, Remaining: 4, Reset: feeds.Time{Time: reset}}))
+}
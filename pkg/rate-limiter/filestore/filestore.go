@@ -0,0 +1,260 @@
+// Package filestore implements rate_limiter.Store on top of a file, so that
+// the rate limit state of the apis, until when they are blocked, and the usage
+// of the budgets survive the restarts of a process, e.g. of a cron-style worker.
+// The state is kept in memory, as by rate_limiter.MemoryStore, and written as a
+// JSON snapshot, restored by Open.
+package filestore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// snapshotVersion is the version of the format of the file.
+const snapshotVersion = 1
+
+// DefaultFlushInterval is how often a Store writes the state changed, see
+// WithFlushInterval.
+const DefaultFlushInterval = time.Second
+
+// snapshot is the content of the file.
+type snapshot struct {
+	Version int       `json:"version"`
+	SavedAt time.Time `json:"saved_at"`
+	rate_limiter.StoreSnapshot
+}
+
+// Store is a rate_limiter.Store persisted to a file. It is safe for
+// concurrent use, but the file must not be shared by several processes.
+type Store struct {
+	memory   *rate_limiter.MemoryStore
+	path     string
+	interval time.Duration
+	noSync   bool
+	onError  func(error)
+
+	dirty atomic.Bool
+	mu    sync.Mutex // serializes the writes
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once // closes stop
+}
+
+var (
+	_ rate_limiter.CostStore   = (*Store)(nil)
+	_ rate_limiter.BudgetStore = (*Store)(nil)
+)
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithFlushInterval replaces DefaultFlushInterval: the state changed is
+// written at most once per interval, from a background goroutine, so that the
+// calls do not wait for the disk. The changes of the last interval are lost on
+// a crash, Close writing them otherwise. An interval of 0 writes the state
+// after each change instead, each call then waiting for the write.
+func WithFlushInterval(interval time.Duration) Option {
+	return func(s *Store) {
+		s.interval = interval
+	}
+}
+
+// WithoutSync leaves the writes to the page cache instead of syncing the file,
+// and its directory, to disk: the state still survives the restarts of the
+// process, but a crash of the machine may lose the latest writes. The writes
+// are then cheaper, e.g. with a short WithFlushInterval.
+func WithoutSync() Option {
+	return func(s *Store) {
+		s.noSync = true
+	}
+}
+
+// WithErrorHandler reports the failures to write the file, which the methods
+// of rate_limiter.Store do not return, the state in memory being up to date
+// regardless: the limiter would otherwise ignore it. They are dropped by
+// default.
+func WithErrorHandler(onError func(error)) Option {
+	return func(s *Store) {
+		s.onError = onError
+	}
+}
+
+// Open returns a Store persisted to the file at path, restoring the state it
+// holds, if any. The directory of path must exist.
+func Open(path string, opts ...Option) (*Store, error) {
+	s := &Store{
+		memory:   rate_limiter.NewMemoryStore(),
+		path:     path,
+		interval: DefaultFlushInterval,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		var saved snapshot
+		if err := json.Unmarshal(data, &saved); err != nil {
+			return nil, fmt.Errorf("filestore: invalid %s: %w", path, err)
+		}
+		if saved.Version != snapshotVersion {
+			return nil, fmt.Errorf("filestore: unsupported version %d of %s", saved.Version, path)
+		}
+		s.memory.Restore(saved.StoreSnapshot)
+	}
+	if s.interval > 0 {
+		s.stop, s.done = make(chan struct{}), make(chan struct{})
+		go s.flushEvery(s.interval)
+	}
+	return s, nil
+}
+
+func (s *Store) flushEvery(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.report(s.Flush())
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// changed writes the state after a change, unless written periodically.
+func (s *Store) changed() {
+	s.dirty.Store(true)
+	if s.interval <= 0 {
+		s.report(s.Flush())
+	}
+}
+
+func (s *Store) report(err error) {
+	if err != nil && s.onError != nil {
+		s.onError(err)
+	}
+}
+
+// Flush writes the state to the file, if changed since the last write: to a
+// temporary file renamed over the file, so that a crash of the process leaves
+// either the previous state or the new one, synced to disk for a crash of the
+// machine unless WithoutSync.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty.Swap(false) {
+		return nil
+	}
+	if err := s.write(); err != nil {
+		s.dirty.Store(true)
+		return err
+	}
+	return nil
+}
+
+// write writes the state to the file. s.mu must be held.
+func (s *Store) write() error {
+	data, err := json.Marshal(snapshot{
+		Version:       snapshotVersion,
+		SavedAt:       time.Now(),
+		StoreSnapshot: s.memory.Snapshot(),
+	})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if !s.noSync {
+		if err := tmp.Sync(); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	if s.noSync {
+		return nil
+	}
+	return syncDir(filepath.Dir(s.path))
+}
+
+// syncDir syncs the directory entry of a renamed file.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// Close stops the periodic writes, if any, and writes the state changed since
+// the last one.
+func (s *Store) Close() error {
+	if s.stop != nil {
+		s.once.Do(func() { close(s.stop) })
+		<-s.done
+	}
+	return s.Flush()
+}
+
+func (s *Store) Take(ctx context.Context, api string, now time.Time) (time.Time, error) {
+	return s.TakeN(ctx, api, now, 1)
+}
+
+func (s *Store) TakeN(ctx context.Context, api string, now time.Time, n int) (time.Time, error) {
+	blockedUntil, err := s.memory.TakeN(ctx, api, now, n)
+	if err != nil {
+		return blockedUntil, err
+	}
+	s.changed()
+	return blockedUntil, nil
+}
+
+func (s *Store) Observe(ctx context.Context, api string, info rate_limiter.LimitInfo, now time.Time) error {
+	if err := s.memory.Observe(ctx, api, info, now); err != nil {
+		return err
+	}
+	s.changed()
+	return nil
+}
+
+func (s *Store) Load(ctx context.Context, api string) (rate_limiter.State, error) {
+	return s.memory.Load(ctx, api)
+}
+
+// AddUsage counts the usage of the budgets of rate_limiter.WithBudget, so that
+// they survive the restarts.
+func (s *Store) AddUsage(ctx context.Context, key string, n int64, expiresAt time.Time) (int64, error) {
+	used, err := s.memory.AddUsage(ctx, key, n, expiresAt)
+	if err != nil {
+		return used, err
+	}
+	s.changed()
+	return used, nil
+}
@@ -0,0 +1,155 @@
+package filestore
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestStoreRestoresState(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.json")
+	now := time.Unix(1000, 0)
+	api := string(rate_limiter.QueryUsers)
+
+	store, err := Open(path, WithFlushInterval(0))
+	require.NoError(t, err)
+	require.NoError(t, store.Observe(ctx, api, rate_limiter.LimitInfo{Limit: 10, Remaining: 0, Reset: 1060}, now))
+	used, err := store.AddUsage(ctx, "QueryUsers/day/2024-01-01", 3, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), used)
+
+	var saved map[string]any
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &saved), "written on each change")
+	assert.Equal(t, float64(1), saved["version"])
+	assert.Contains(t, saved["states"], api)
+
+	restarted, err := Open(path)
+	require.NoError(t, err)
+	defer restarted.Close()
+	blockedUntil, err := restarted.Take(ctx, api, now.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1060, 0), blockedUntil.Local(), "still blocked")
+	state, err := restarted.Load(ctx, api)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), state.Limit)
+	used, err = restarted.AddUsage(ctx, "QueryUsers/day/2024-01-01", 1, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), used, "usage restored")
+	used, err = restarted.AddUsage(ctx, "QueryUsers/day/2024-01-02", 1, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), used, "new period")
+}
+
+func TestStoreBlocksRestartedLimiter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+
+	store, err := Open(path)
+	require.NoError(t, err)
+	rLimit := rate_limiter.NewRateLimiter(rate_limiter.QueryUsers, rate_limiter.WithClock(clock), rate_limiter.WithStore(store))
+	require.NoError(t, rLimit.TryCallApi(func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1060}}, nil
+	}))
+	require.NoError(t, rLimit.Close(context.Background()))
+	require.NoError(t, store.Close())
+
+	store, err = Open(path)
+	require.NoError(t, err)
+	defer store.Close()
+	rLimit = rate_limiter.NewRateLimiter(rate_limiter.QueryUsers, rate_limiter.WithClock(clock), rate_limiter.WithStore(store))
+	var limited rate_limiter.ErrRateLimited
+	require.ErrorAs(t, rLimit.TryCallApi(func() (*stream.Response, error) {
+		t.Error("called while blocked")
+		return nil, nil
+	}), &limited)
+	assert.WithinDuration(t, time.Unix(1060, 0), limited.ResetAt, 0)
+}
+
+func TestStoreWithoutSync(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.json")
+	api := string(rate_limiter.QueryUsers)
+
+	store, err := Open(path, WithoutSync(), WithFlushInterval(0))
+	require.NoError(t, err)
+	assert.True(t, store.noSync)
+	require.NoError(t, store.Observe(ctx, api, rate_limiter.LimitInfo{Limit: 10, Remaining: 4, Reset: 1060}, time.Unix(1000, 0)))
+
+	restarted, err := Open(path)
+	require.NoError(t, err)
+	defer restarted.Close()
+	assert.False(t, restarted.noSync, "synced by default")
+	assert.Equal(t, DefaultFlushInterval, restarted.interval, "batched by default")
+	state, err := restarted.Load(ctx, api)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), state.Remaining)
+}
+
+func TestStoreFlushInterval(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.json")
+	store, err := Open(path, WithFlushInterval(time.Hour))
+	require.NoError(t, err)
+
+	require.NoError(t, store.Observe(ctx, "QueryUsers", rate_limiter.LimitInfo{Limit: 10, Remaining: 5, Reset: 1060}, time.Unix(1000, 0)))
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist, "not written before the interval")
+
+	require.NoError(t, store.Close())
+	require.NoError(t, store.Close(), "closing twice")
+	restarted, err := Open(path)
+	require.NoError(t, err)
+	defer restarted.Close()
+	state, err := restarted.Load(ctx, "QueryUsers")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), state.Remaining, "written by Close")
+
+	store, err = Open(path, WithFlushInterval(time.Millisecond))
+	require.NoError(t, err)
+	defer store.Close()
+	require.NoError(t, store.Observe(ctx, "QueryUsers", rate_limiter.LimitInfo{Limit: 10, Remaining: 2, Reset: 1060}, time.Unix(1000, 0)))
+	assert.Eventually(t, func() bool {
+		restarted, err := Open(path)
+		require.NoError(t, err)
+		defer restarted.Close()
+		state, _ := restarted.Load(ctx, "QueryUsers")
+		return state.Remaining == 2
+	}, time.Second, time.Millisecond)
+}
+
+func TestStoreErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	_, err := Open(path)
+	assert.ErrorContains(t, err, "filestore: invalid")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 2}`), 0o600))
+	_, err = Open(path)
+	assert.ErrorContains(t, err, "filestore: unsupported version 2")
+
+	var reported []error
+	store, err := Open(filepath.Join(dir, "missing", "state.json"), WithFlushInterval(0), WithErrorHandler(func(err error) {
+		reported = append(reported, err)
+	}))
+	require.NoError(t, err)
+	blockedUntil, err := store.Take(context.Background(), "QueryUsers", time.Unix(1000, 0))
+	assert.NoError(t, err, "write failures not returned")
+	assert.True(t, blockedUntil.IsZero())
+	require.Len(t, reported, 1)
+	assert.ErrorIs(t, reported[0], os.ErrNotExist)
+	assert.ErrorIs(t, store.Close(), os.ErrNotExist, "returned by Close")
+}
@@ -0,0 +1,92 @@
+// Package grpclimiter rate limits gRPC client calls, e.g. to an internal
+// service fronting Stream, with the limiters of a rate_limiter.LimiterManager.
+// The quota is read from the x-ratelimit-* headers or trailers of the
+// responses, and a ResourceExhausted error blocks the endpoint like a Stream
+// 429 error.
+package grpclimiter
+
+import (
+	"context"
+	"net/http"
+	"path"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// Option configures UnaryClientInterceptor.
+type Option func(*interceptor)
+
+// WithEndpoint names the endpoint, hence the limiter, of the calls to the full
+// method name, as "/package.Service/Method". By default a call is limited as
+// its method name, e.g. "/chat.Users/QueryUsers" as rate_limiter.QueryUsers.
+func WithEndpoint(endpoint func(fullMethod string) rate_limiter.GetStreamApiName) Option {
+	return func(i *interceptor) {
+		i.endpoint = endpoint
+	}
+}
+
+// WithMethods names the endpoint of the given full method names, the other
+// methods being named as by default.
+func WithMethods(methods map[string]rate_limiter.GetStreamApiName) Option {
+	return func(i *interceptor) {
+		i.methods = methods
+	}
+}
+
+type interceptor struct {
+	manager  *rate_limiter.LimiterManager
+	endpoint func(fullMethod string) rate_limiter.GetStreamApiName
+	methods  map[string]rate_limiter.GetStreamApiName
+}
+
+// UnaryClientInterceptor returns a grpc.UnaryClientInterceptor calling each
+// method through the limiter of its endpoint in manager, with the buckets of
+// manager applying as for the Stream client calls.
+func UnaryClientInterceptor(manager *rate_limiter.LimiterManager, opts ...Option) grpc.UnaryClientInterceptor {
+	i := &interceptor{manager: manager, endpoint: MethodName}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i.intercept
+}
+
+// MethodName names the endpoint of a call after its method name, the default
+// of UnaryClientInterceptor.
+func MethodName(fullMethod string) rate_limiter.GetStreamApiName {
+	return rate_limiter.GetStreamApiName(path.Base(fullMethod))
+}
+
+func (i *interceptor) apiName(fullMethod string) rate_limiter.GetStreamApiName {
+	if apiName, ok := i.methods[fullMethod]; ok {
+		return apiName
+	}
+	return i.endpoint(fullMethod)
+}
+
+func (i *interceptor) intercept(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	limiter := i.manager.For(i.apiName(method))
+	return limiter.CallWithHeaders(ctx, func() (http.Header, bool, error) {
+		var header, trailer metadata.MD
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header), grpc.Trailer(&trailer))...)
+		return headers(header, trailer), status.Code(err) == codes.ResourceExhausted, err
+	})
+}
+
+// headers merges the response metadata into http.Header, canonicalizing the
+// lowercase gRPC keys.
+func headers(mds ...metadata.MD) http.Header {
+	header := make(http.Header)
+	for _, md := range mds {
+		for key, values := range md {
+			for _, value := range values {
+				header.Add(key, value)
+			}
+		}
+	}
+	return header
+}
@@ -0,0 +1,81 @@
+package grpclimiter
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// invoker returns a grpc.UnaryInvoker answering with md as header and err,
+// recording the methods called.
+func invoker(md metadata.MD, err error, called *[]string) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		*called = append(*called, method)
+		for _, opt := range opts {
+			if header, ok := opt.(grpc.HeaderCallOption); ok {
+				*header.HeaderAddr = md
+			}
+		}
+		return err
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Unix()
+	manager := rate_limiter.NewLimiterManager(rate_limiter.WithEndpointBuckets())
+	intercept := UnaryClientInterceptor(manager)
+	var called []string
+	md := metadata.Pairs("x-ratelimit-limit", "20", "x-ratelimit-remaining", "7", "x-ratelimit-reset", strconv.FormatInt(reset, 10))
+
+	err := intercept(context.Background(), "/chat.Users/QueryUsers", nil, nil, nil, invoker(md, nil, &called))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/chat.Users/QueryUsers"}, called)
+	assert.Equal(t, int64(7), manager.For(rate_limiter.QueryUsers).Remaining())
+	assert.Equal(t, time.Unix(reset, 0), manager.For(rate_limiter.QueryUsers).ResetAt())
+
+	// the buckets of the manager apply
+	err = intercept(context.Background(), "/chat.Channels/AddMembers", nil, nil, nil, invoker(md, nil, &called))
+	require.NoError(t, err)
+	assert.Same(t, manager.For(rate_limiter.UpdateChannel), manager.For(rate_limiter.AddMembers))
+	assert.Equal(t, int64(7), manager.For(rate_limiter.UpdateChannel).Remaining())
+}
+
+func TestUnaryClientInterceptorResourceExhausted(t *testing.T) {
+	manager := rate_limiter.NewLimiterManager()
+	intercept := UnaryClientInterceptor(manager, WithMethods(map[string]rate_limiter.GetStreamApiName{
+		"/chat.Messages/Send": rate_limiter.SendMessage,
+	}))
+	var called []string
+	exhausted := status.Error(codes.ResourceExhausted, "slow down")
+
+	err := intercept(context.Background(), "/chat.Messages/Send", nil, nil, nil, invoker(metadata.Pairs("x-ratelimit-reset", "30"), exhausted, &called))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.True(t, manager.For(rate_limiter.SendMessage).IsBlocked())
+
+	var rateLimited rate_limiter.ErrRateLimited
+	err = manager.For(rate_limiter.SendMessage).TryCallApi(nil)
+	assert.ErrorAs(t, err, &rateLimited)
+}
+
+func TestWithEndpoint(t *testing.T) {
+	manager := rate_limiter.NewLimiterManager()
+	intercept := UnaryClientInterceptor(manager, WithEndpoint(func(string) rate_limiter.GetStreamApiName {
+		return rate_limiter.QueryChannels
+	}))
+	var called []string
+	md := metadata.Pairs("x-ratelimit-remaining", "3", "x-ratelimit-reset", "60")
+
+	require.NoError(t, intercept(context.Background(), "/chat.Channels/List", nil, nil, nil, invoker(md, nil, &called)))
+	assert.Equal(t, int64(3), manager.For(rate_limiter.QueryChannels).Remaining())
+	assert.Equal(t, rate_limiter.GetStreamApiName("List"), MethodName("/chat.Channels/List"))
+}
@@ -0,0 +1,155 @@
+package rate_limiter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// stateVersion is the version of the format of ExportState.
+const stateVersion = 1
+
+// exportedState is the format of ExportState, JSON encoded.
+type exportedState struct {
+	Version    int                            `json:"version"`
+	ExportedAt time.Time                      `json:"exported_at"`
+	Apis       map[string]apiState            `json:"apis"`
+	Tenants    map[string]map[string]apiState `json:"tenants,omitempty"`
+}
+
+// apiState is the state of the limiter of an api in ExportState.
+type apiState struct {
+	Limit     int64 `json:"limit"`
+	Remaining int64 `json:"remaining"`
+	// Reset is the unix time of the reset, as reported by Stream.
+	Reset        int64      `json:"reset"`
+	BlockedUntil *time.Time `json:"blocked_until,omitempty"`
+}
+
+// exportState returns the state of r, ok false when it has none worth
+// handing over.
+func (r *RateLimiter) exportState() (state apiState, ok bool) {
+	info, observed := r.lastInfo.Load()
+	if observed {
+		state = apiState{Limit: info.Limit, Remaining: info.Remaining, Reset: info.Reset}
+	}
+	r.mu.Lock()
+	blockedUntil := r.resetAt
+	if r.paused {
+		blockedUntil = r.pausedReset
+	}
+	r.mu.Unlock()
+	if !blockedUntil.IsZero() {
+		state.BlockedUntil = &blockedUntil
+	}
+	return state, observed || state.BlockedUntil != nil
+}
+
+// importState applies state to r: the rate limit info, unless r already
+// observed one, and the block, until the later of its own and the imported
+// one. A state whose reset has passed is ignored.
+func (r *RateLimiter) importState(state apiState) {
+	ctx, logger := context.Background(), r.getLogger(nil)
+	now := r.getClock().Now()
+	if state.Reset > 0 && r.localReset(state.Reset).After(now) {
+		if _, observed := r.lastInfo.Load(); !observed {
+			r.observeInfo(&LimitInfo{Limit: state.Limit, Remaining: state.Remaining, Reset: state.Reset})
+		}
+	}
+	if state.BlockedUntil != nil && state.BlockedUntil.After(now) {
+		r.blockFor(ctx, logger, *state.BlockedUntil, state.BlockedUntil.Sub(now))
+	}
+}
+
+// ExportState serializes the rate limit state of r, the remaining calls and
+// reset of the current window and until when the calls are blocked, so that
+// the process taking over, e.g. after a restart or a blue/green switchover,
+// does not overshoot the quota, see ImportState.
+func (r *RateLimiter) ExportState() ([]byte, error) {
+	exported := exportedState{
+		Version:    stateVersion,
+		ExportedAt: r.getClock().Now(),
+		Apis:       map[string]apiState{},
+	}
+	if state, ok := r.exportState(); ok {
+		exported.Apis[r.apiName] = state
+	}
+	return json.Marshal(exported)
+}
+
+// ImportState applies the state of the api of r serialized by ExportState,
+// e.g. by the process r takes over from: the calls are blocked until the end
+// of the block exported, if not yet passed, and the remaining calls are the
+// exported ones until r observes a response. The state of the other apis is
+// ignored.
+func (r *RateLimiter) ImportState(data []byte) error {
+	exported, err := parseState(data)
+	if err != nil {
+		return err
+	}
+	if state, ok := exported.Apis[r.apiName]; ok {
+		r.importState(state)
+	}
+	return nil
+}
+
+// ExportState serializes the rate limit state of every limiter of the
+// manager, the ones of the tenants included, as RateLimiter.ExportState.
+func (m *LimiterManager) ExportState() ([]byte, error) {
+	exported := exportedState{
+		Version:    stateVersion,
+		ExportedAt: m.now(),
+		Apis:       map[string]apiState{},
+	}
+	m.limiters.each(func(apiName GetStreamApiName, r *RateLimiter) {
+		if state, ok := r.exportState(); ok {
+			exported.Apis[string(apiName)] = state
+		}
+	})
+	m.tenantLimiters.each(func(key tenantKey, t *tenantLimiter) {
+		state, ok := t.r.exportState()
+		if !ok {
+			return
+		}
+		if exported.Tenants == nil {
+			exported.Tenants = map[string]map[string]apiState{}
+		}
+		if exported.Tenants[key.tenantID] == nil {
+			exported.Tenants[key.tenantID] = map[string]apiState{}
+		}
+		exported.Tenants[key.tenantID][string(key.apiName)] = state
+	})
+	return json.Marshal(exported)
+}
+
+// ImportState applies the state serialized by ExportState to the limiters of
+// the manager, as RateLimiter.ImportState, creating the ones of the apis and
+// tenants it covers.
+func (m *LimiterManager) ImportState(data []byte) error {
+	exported, err := parseState(data)
+	if err != nil {
+		return err
+	}
+	for apiName, state := range exported.Apis {
+		m.For(GetStreamApiName(apiName)).importState(state)
+	}
+	for tenantID, apis := range exported.Tenants {
+		for apiName, state := range apis {
+			m.ForTenant(tenantID, GetStreamApiName(apiName)).importState(state)
+		}
+	}
+	return nil
+}
+
+// parseState decodes the state serialized by ExportState.
+func parseState(data []byte) (*exportedState, error) {
+	var exported exportedState
+	if err := json.Unmarshal(data, &exported); err != nil {
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+	if exported.Version != stateVersion {
+		return nil, fmt.Errorf("unsupported state version %d", exported.Version)
+	}
+	return &exported, nil
+}
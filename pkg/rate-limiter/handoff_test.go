@@ -0,0 +1,114 @@
+package rate_limiter
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func exhaust(t *testing.T, r *RateLimiter, reset int64) {
+	t.Helper()
+	require.NoError(t, r.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: reset}}, nil
+	}))
+}
+
+func TestExportImportState(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	old := NewRateLimiter(QueryUsers, WithClock(clock))
+	exhaust(t, old, 1060)
+
+	data, err := old.ExportState()
+	require.NoError(t, err)
+	var exported map[string]any
+	require.NoError(t, json.Unmarshal(data, &exported))
+	assert.Equal(t, float64(1), exported["version"])
+	assert.Equal(t, map[string]any{
+		"QueryUsers": map[string]any{"limit": float64(10), "remaining": float64(0), "reset": float64(1060), "blocked_until": "1970-01-01T00:17:40Z"},
+	}, exported["apis"])
+
+	clock.Advance(10 * time.Second)
+	taking := NewRateLimiter(QueryUsers, WithClock(clock))
+	require.NoError(t, taking.ImportState(data))
+	assert.Equal(t, int64(0), taking.Remaining())
+	assert.Equal(t, time.Unix(1060, 0), taking.ResetAt())
+	assert.True(t, taking.IsBlocked())
+	assert.WithinDuration(t, time.Unix(1060, 0), taking.getResetAt(), 0)
+
+	clock.Advance(50 * time.Second)
+	assert.False(t, taking.IsBlocked(), "resumed at the reset")
+
+	other := NewRateLimiter(QueryChannels, WithClock(clock))
+	require.NoError(t, other.ImportState(data))
+	assert.Equal(t, int64(-1), other.Remaining(), "other apis ignored")
+}
+
+func TestImportStateStale(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	old := NewRateLimiter(QueryUsers, WithClock(clock))
+	exhaust(t, old, 1060)
+	data, err := old.ExportState()
+	require.NoError(t, err)
+
+	clock.Advance(2 * time.Minute)
+	taking := NewRateLimiter(QueryUsers, WithClock(clock))
+	require.NoError(t, taking.ImportState(data))
+	assert.Equal(t, int64(-1), taking.Remaining(), "window passed")
+	assert.False(t, taking.IsBlocked())
+}
+
+func TestImportStateKeepsObservedInfo(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	old := NewRateLimiter(QueryUsers, WithClock(clock))
+	exhaust(t, old, 1060)
+	data, err := old.ExportState()
+	require.NoError(t, err)
+
+	taking := NewRateLimiter(QueryUsers, WithClock(clock))
+	require.NoError(t, taking.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 7, Reset: 1060}}, nil
+	}))
+	require.NoError(t, taking.ImportState(data))
+	assert.Equal(t, int64(7), taking.Remaining(), "observed info kept")
+	assert.True(t, taking.IsBlocked(), "block applied")
+}
+
+func TestImportStateInvalid(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	assert.ErrorContains(t, rLimit.ImportState([]byte("{")), "invalid state")
+	assert.EqualError(t, rLimit.ImportState([]byte(`{"version": 2}`)), "unsupported state version 2")
+}
+
+func TestLimiterManagerExportImportState(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	old := NewLimiterManager(WithClock(clock))
+	exhaust(t, old.For(QueryUsers), 1060)
+	exhaust(t, old.ForTenant("acme", QueryChannels), 1030)
+	old.For(UpdateUsers) // no state
+
+	data, err := old.ExportState()
+	require.NoError(t, err)
+	var exported exportedState
+	require.NoError(t, json.Unmarshal(data, &exported))
+	assert.Contains(t, exported.Apis, "QueryUsers")
+	assert.Contains(t, exported.Apis, "QueryChannels", "parent level of the tenant")
+	assert.NotContains(t, exported.Apis, "UpdateUsers")
+	assert.Contains(t, exported.Tenants["acme"], "QueryChannels")
+
+	taking := NewLimiterManager(WithClock(clock))
+	require.NoError(t, taking.ImportState(data))
+	assert.True(t, taking.For(QueryUsers).IsBlocked())
+	assert.True(t, taking.ForTenant("acme", QueryChannels).IsBlocked())
+	assert.False(t, taking.ForTenant("globex", QueryChannels).IsBlocked())
+
+	clock.Advance(30 * time.Second)
+	assert.False(t, taking.ForTenant("acme", QueryChannels).IsBlocked())
+	assert.True(t, taking.For(QueryUsers).IsBlocked())
+}
@@ -0,0 +1,131 @@
+package rate_limiter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrUnhealthy is returned by Healthy when a limiter has been stuck for longer
+// than the duration set by WithUnhealthyAfter.
+var ErrUnhealthy = errors.New("unhealthy")
+
+// WithUnhealthyAfter marks the limiter unhealthy, see Healthy, once it has
+// been continuously blocked, or its calls have been continuously failing, for
+// longer than d, e.g. so that a Kubernetes liveness probe restarts a stuck
+// worker. Without it a limiter is always healthy.
+func WithUnhealthyAfter(d time.Duration) Option {
+	return func(r *RateLimiter) {
+		if d > 0 {
+			r.unhealthy = d
+		}
+	}
+}
+
+// trackFailures records since when the calls have been continuously failing.
+func (r *RateLimiter) trackFailures(err error) {
+	if err == nil {
+		r.failing.Store(0)
+		return
+	}
+	r.failing.CompareAndSwap(0, r.getClock().Now().UnixNano())
+}
+
+// Healthy returns an error wrapping ErrUnhealthy when r has been continuously
+// blocked, or its calls have been continuously failing, for longer than the
+// duration set by WithUnhealthyAfter.
+func (r *RateLimiter) Healthy() error {
+	if r.unhealthy == 0 {
+		return nil
+	}
+	now := r.getClock().Now()
+	r.mu.Lock()
+	blocked, blockedAt := r.blocked != nil, r.blockedAt
+	r.mu.Unlock()
+	if blocked && now.Sub(blockedAt) > r.unhealthy {
+		return fmt.Errorf("%w: %s blocked for %v", ErrUnhealthy, r.apiName, now.Sub(blockedAt))
+	}
+	if failing := r.failing.Load(); failing != 0 && now.Sub(time.Unix(0, failing)) > r.unhealthy {
+		return fmt.Errorf("%w: %s failing for %v", ErrUnhealthy, r.apiName, now.Sub(time.Unix(0, failing)))
+	}
+	return nil
+}
+
+// Ready returns nil when r would admit a call right now, otherwise the error
+// telling why not: ErrClosed once closed, ErrDraining once draining, an
+// ErrRateLimited while blocked until a reset, ErrCircuitOpen while the circuit
+// breaker is open. Unlike Healthy it fails as soon as the api gets blocked,
+// e.g. for a Kubernetes readiness probe.
+func (r *RateLimiter) Ready() error {
+	r.mu.Lock()
+	closed, draining := r.closed, r.draining
+	r.mu.Unlock()
+	if closed {
+		return r.errClosed()
+	}
+	if draining {
+		return r.errDraining()
+	}
+	if blocked, resetAt := r.barrier(); blocked != nil {
+		return r.errBlocked(resetAt)
+	}
+	if r.breaker != nil && r.breaker.isOpen(r.getClock().Now()) {
+		return r.errCircuitOpen()
+	}
+	return nil
+}
+
+// Healthy returns the errors of Healthy of the limiters created so far,
+// joined, or nil when all of them are healthy.
+func (m *LimiterManager) Healthy() error {
+	return m.check((*RateLimiter).Healthy)
+}
+
+// Ready returns the errors of Ready of the limiters created so far, joined, or
+// nil when all of them are ready.
+func (m *LimiterManager) Ready() error {
+	m.mu.Lock()
+	closed, draining := m.closed, m.draining
+	m.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+	if draining {
+		return ErrDraining
+	}
+	return m.check((*RateLimiter).Ready)
+}
+
+func (m *LimiterManager) check(f func(r *RateLimiter) error) error {
+	var errs []error
+	for _, r := range m.limiters.values() {
+		if err := f(r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HealthHandler returns an http.Handler for a liveness probe, answering 200
+// while m is Healthy and 503 otherwise.
+func HealthHandler(m *LimiterManager) http.Handler {
+	return probeHandler(m.Healthy)
+}
+
+// ReadyHandler returns an http.Handler for a readiness probe, answering 200
+// while m is Ready and 503 otherwise.
+func ReadyHandler(m *LimiterManager) http.Handler {
+	return probeHandler(m.Ready)
+}
+
+func probeHandler(check func() error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := check(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte("ok\n"))
+	})
+}
@@ -0,0 +1,104 @@
+package rate_limiter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestHealthyBlocked(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithUnhealthyAfter(time.Minute))
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1120}}, nil
+	}))
+	assert.NoError(t, rLimit.Healthy())
+	assert.ErrorIs(t, rLimit.Ready(), ErrRateLimited{})
+
+	clock.Advance(61 * time.Second)
+	assert.ErrorIs(t, rLimit.Healthy(), ErrUnhealthy)
+	assert.EqualError(t, rLimit.Healthy(), "unhealthy: QueryUsers blocked for 1m1s")
+
+	clock.Advance(time.Minute)
+	assert.NoError(t, rLimit.Healthy())
+	assert.NoError(t, rLimit.Ready())
+}
+
+func TestHealthyFailing(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithUnhealthyAfter(time.Minute))
+	fail := func() (*stream.Response, error) { return nil, stream.Error{StatusCode: 500} }
+
+	require.Error(t, rLimit.CallApiWithContext(context.Background(), fail))
+	clock.Advance(time.Minute)
+	require.Error(t, rLimit.CallApiWithContext(context.Background(), fail))
+	assert.NoError(t, rLimit.Healthy(), "failing for a minute")
+	clock.Advance(time.Second)
+	assert.ErrorIs(t, rLimit.Healthy(), ErrUnhealthy)
+	assert.NoError(t, rLimit.Ready(), "failing calls are still admitted")
+
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 9, Reset: 1120}}, nil
+	}))
+	assert.NoError(t, rLimit.Healthy())
+}
+
+func TestHealthyWithoutOption(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+	require.Error(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return nil, stream.Error{StatusCode: 500}
+	}))
+	clock.Advance(time.Hour)
+	assert.NoError(t, rLimit.Healthy())
+}
+
+func TestReadyCircuitOpen(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Minute}))
+	require.Error(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return nil, stream.Error{StatusCode: 500}
+	}))
+	assert.ErrorIs(t, rLimit.Ready(), ErrCircuitOpen)
+
+	require.NoError(t, rLimit.Close(context.Background()))
+	assert.ErrorIs(t, rLimit.Ready(), ErrClosed)
+}
+
+func TestProbeHandlers(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	manager := NewLimiterManager(WithClock(clock), WithUnhealthyAfter(time.Minute))
+	probe := func(handler http.Handler) (int, string) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		return recorder.Code, recorder.Body.String()
+	}
+	manager.For(CreateChannel)
+	code, body := probe(HealthHandler(manager))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok\n", body)
+
+	require.NoError(t, manager.For(QueryUsers).CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1300}}, nil
+	}))
+	code, _ = probe(HealthHandler(manager))
+	assert.Equal(t, http.StatusOK, code)
+	code, body = probe(ReadyHandler(manager))
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, body, "rate limited on QueryUsers")
+
+	clock.Advance(2 * time.Minute)
+	code, body = probe(HealthHandler(manager))
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unhealthy: QueryUsers blocked for 2m0s\n", body)
+
+	require.NoError(t, manager.Close(context.Background()))
+	assert.ErrorIs(t, manager.Ready(), ErrClosed)
+}
@@ -0,0 +1,102 @@
+package rate_limiter
+
+import "context"
+
+// GlobalApiName is the name of the app-level limiter of WithGlobalLimiter.
+const GlobalApiName GetStreamApiName = "Global"
+
+// WithGlobalLimiter makes the limiters of a LimiterManager call through an
+// app-level limiter built with opts, e.g. WithMaxConcurrency or WithStrategy
+// with a TokenBucket, so that every call must pass the budget of the whole app
+// on top of the one of its endpoint, see LimiterManager.Global. The global
+// limiter shapes the calls on the client side only: the rate limits reported
+// by Stream being the ones of the endpoints, they never block it. It shares the
+// clock, logger and metrics of the manager. It has no effect on
+// NewRateLimiter.
+func WithGlobalLimiter(opts ...Option) Option {
+	return func(r *RateLimiter) {
+		r.globalOpts = append([]Option{}, opts...)
+	}
+}
+
+// newGlobalLimiter returns the limiter of WithGlobalLimiter configured on
+// probe, nil when not configured.
+func newGlobalLimiter(probe *RateLimiter) *RateLimiter {
+	if probe.globalOpts == nil {
+		return nil
+	}
+	var opts []Option
+	if probe.clock != nil {
+		opts = append(opts, WithClock(probe.clock))
+	}
+	if probe.logger != nil {
+		opts = append(opts, WithLogger(probe.logger))
+	}
+	if probe.metrics != nil {
+		opts = append(opts, WithMetrics(probe.metrics))
+	}
+	opts = append(opts, probe.globalOpts...)
+	return NewRateLimiter(GlobalApiName, append(opts, WithClientSide())...)
+}
+
+// Global returns the app-level limiter set by WithGlobalLimiter, nil when not
+// set.
+func (m *LimiterManager) Global() *RateLimiter {
+	return m.global
+}
+
+// levelRejection carries the error of a level which did not call the api, so
+// that the other levels do not account it as a failed call. It unwraps to the
+// error of the level, e.g. an ErrRateLimited.
+type levelRejection struct {
+	err error
+}
+
+func (e *levelRejection) Error() string {
+	return e.err.Error()
+}
+
+func (e *levelRejection) Unwrap() error {
+	return e.err
+}
+
+// throughParent returns apiCall run through the parent level of r, if any,
+// waiting for it as callApi does; with try, the parent level is tried only,
+// as TryCallApi does. The levels are acquired from the most specific one up,
+// so that a call waiting for its own level does not hold the slots of the
+// shared ones, and released in the opposite order as the calls return.
+func (r *RateLimiter) throughParent(ctx context.Context, apiCall limitedCall, try bool) limitedCall {
+	if r.parent == nil {
+		return apiCall
+	}
+	// the parent levels account the call at the cost of r, and do not audit it
+	ctx = withLevel(withCost(ctx, r.costFrom(ctx)))
+	return limitedFunc(func() (LimitInfo, bool, error) {
+		var (
+			info   LimitInfo
+			ok     bool
+			called bool
+		)
+		parentCall := limitedFunc(func() (LimitInfo, bool, error) {
+			called = true
+			var err error
+			info, ok, err = apiCall.call()
+			return info, ok, err
+		})
+		var err error
+		if try {
+			err = r.parent.tryLevel(ctx, parentCall)
+		} else {
+			err = r.parent.callApi(ctx, r.parent.getLogger(nil), parentCall)
+		}
+		if err != nil && !called {
+			return LimitInfo{}, false, &levelRejection{err: err}
+		}
+		return info, ok, err
+	})
+}
+
+// tryLevel is TryCallApi for a limited call run by a child level.
+func (r *RateLimiter) tryLevel(ctx context.Context, apiCall limitedCall) error {
+	return r.run(ctx, Invocation{Try: true, call: apiCall})
+}
@@ -0,0 +1,162 @@
+package rate_limiter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+// inFlightOf returns the calls in flight through r.
+func inFlightOf(r *RateLimiter) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.inFlight
+}
+
+func TestGlobalLimiter(t *testing.T) {
+	manager := NewLimiterManager(WithMaxConcurrency(2), WithGlobalLimiter(WithMaxConcurrency(1)))
+	require.NotNil(t, manager.Global())
+	assert.Nil(t, NewLimiterManager().Global())
+
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- manager.For(QueryUsers).CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+			<-release
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 9}}, nil
+		})
+	}()
+	require.Eventually(t, func() bool { return inFlightOf(manager.Global()) == 1 }, time.Second, time.Millisecond)
+
+	var errored []error
+	manager.For(QueryChannels).OnError(func(api string, err error) {
+		errored = append(errored, err)
+	})
+	err := manager.For(QueryChannels).TryCallApi(func() (*stream.Response, error) {
+		t.Error("called beyond the global concurrency")
+		return nil, nil
+	})
+	var limited ErrRateLimited
+	require.ErrorAs(t, err, &limited)
+	assert.Equal(t, string(GlobalApiName), limited.ApiName)
+	assert.Empty(t, errored, "turned down by the global limiter, not a failed call")
+
+	close(release)
+	require.NoError(t, receive(t, done))
+	require.NoError(t, manager.For(QueryChannels).TryCallApi(func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 9}}, nil
+	}))
+}
+
+func TestGlobalLimiterIgnoresReportedLimits(t *testing.T) {
+	manager := NewLimiterManager(WithGlobalLimiter())
+	require.NoError(t, manager.For(QueryUsers).CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Reset: time.Now().Unix() + 60}}, nil
+	}))
+	assert.True(t, manager.For(QueryUsers).IsBlocked())
+	assert.False(t, manager.Global().IsBlocked())
+	assert.Equal(t, int64(-1), manager.Global().Remaining())
+	require.NoError(t, manager.For(QueryChannels).TryCallApi(func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 9}}, nil
+	}))
+}
+
+func TestGlobalLimiterStrategy(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	manager := NewLimiterManager(WithClock(clock), WithGlobalLimiter(WithStrategy(NewTokenBucket(1, 1))))
+	apiCall := func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 9}}, nil
+	}
+	require.NoError(t, manager.For(QueryUsers).CallApiWithContext(context.Background(), apiCall))
+
+	// the bucket is shared by every endpoint
+	done := make(chan error)
+	go func() {
+		done <- manager.For(QueryChannels).CallApiWithContext(context.Background(), apiCall)
+	}()
+	blockUntil(t, clock, 1)
+	clock.Advance(time.Second)
+	require.NoError(t, receive(t, done))
+}
+
+func TestTenantPassesEndpoint(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	manager := NewLimiterManager(WithClock(clock))
+	require.NoError(t, manager.ForTenant("jane", QueryUsers).CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Reset: clock.Now().Unix() + 60}}, nil
+	}))
+	assert.True(t, manager.For(QueryUsers).IsBlocked(), "the endpoint observed the response")
+
+	// another tenant waits for the endpoint reset
+	done := make(chan error)
+	go func() {
+		done <- manager.ForTenant("john", QueryUsers).CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 9, Reset: clock.Now().Unix() + 60}}, nil
+		})
+	}()
+	blockUntil(t, clock, 2)
+	clock.Advance(time.Minute)
+	require.NoError(t, receive(t, done))
+}
+
+func TestHierarchyReleasesOnError(t *testing.T) {
+	manager := NewLimiterManager(WithGlobalLimiter())
+	tenant := manager.ForTenant("jane", QueryUsers)
+	assert.ErrorIs(t, tenant.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return nil, assert.AnError
+	}), assert.AnError)
+	for _, r := range []*RateLimiter{tenant, manager.For(QueryUsers), manager.Global()} {
+		assert.Zero(t, inFlightOf(r), r.apiName)
+	}
+	require.NoError(t, tenant.TryCallApi(func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 9}}, nil
+	}))
+}
+
+func TestHierarchyRejectionCancelsProbe(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	manager := NewLimiterManager(WithClock(clock), WithMaxInFlight(1),
+		WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenDuration: time.Minute}),
+		WithGlobalLimiter(WithStrategy(NewTokenBucket(1, 1))))
+	endpoint := manager.For(QueryUsers)
+	calls := 0
+	succeeding := func() (*stream.Response, error) {
+		calls++
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 9}}, nil
+	}
+	require.Error(t, endpoint.TryCallApi(func() (*stream.Response, error) {
+		return nil, stream.Error{StatusCode: http.StatusInternalServerError}
+	}))
+	require.ErrorIs(t, endpoint.TryCallApi(succeeding), ErrCircuitOpen)
+
+	// half-open, the probe is turned down by the global bucket emptied by
+	// another endpoint
+	clock.Advance(time.Minute)
+	require.NoError(t, manager.For(QueryChannels).TryCallApi(succeeding))
+	var limited ErrRateLimited
+	require.ErrorAs(t, endpoint.TryCallApi(succeeding), &limited)
+	assert.Equal(t, string(GlobalApiName), limited.ApiName)
+	assert.Zero(t, inFlightOf(endpoint))
+	assert.Zero(t, endpoint.OutstandingCalls())
+
+	// the probe is still to be made
+	clock.Advance(time.Second)
+	require.NoError(t, endpoint.TryCallApi(succeeding))
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, "closed", endpoint.breaker.state.String())
+}
+
+func TestCloseClosesGlobal(t *testing.T) {
+	manager := NewLimiterManager(WithGlobalLimiter())
+	require.NoError(t, manager.Close(context.Background()))
+	assert.ErrorIs(t, manager.Global().TryCallApi(func() (*stream.Response, error) {
+		return nil, nil
+	}), ErrClosed)
+}
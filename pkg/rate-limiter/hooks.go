@@ -0,0 +1,66 @@
+package rate_limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// hooks holds the callbacks registered on a RateLimiter. Registering only
+// appends, so the fire methods run a snapshot of the callbacks outside of the
+// lock, letting a callback register further hooks.
+type hooks struct {
+	mu        sync.RWMutex
+	onBlocked []func(api string, resumeAt time.Time)
+	onResumed []func(api string)
+	onError   []func(api string, err error)
+}
+
+// OnBlocked registers fn to be called when calls get blocked until resumeAt,
+// the zero time for a Pause. Callbacks run synchronously on the limiter path
+// and should return quickly.
+func (r *RateLimiter) OnBlocked(fn func(api string, resumeAt time.Time)) {
+	r.hooks.mu.Lock()
+	defer r.hooks.mu.Unlock()
+	r.hooks.onBlocked = append(r.hooks.onBlocked, fn)
+}
+
+// OnResumed registers fn to be called when blocked calls are resumed.
+func (r *RateLimiter) OnResumed(fn func(api string)) {
+	r.hooks.mu.Lock()
+	defer r.hooks.mu.Unlock()
+	r.hooks.onResumed = append(r.hooks.onResumed, fn)
+}
+
+// OnError registers fn to be called when the wrapped api call fails.
+func (r *RateLimiter) OnError(fn func(api string, err error)) {
+	r.hooks.mu.Lock()
+	defer r.hooks.mu.Unlock()
+	r.hooks.onError = append(r.hooks.onError, fn)
+}
+
+func (r *RateLimiter) fireBlocked(resumeAt time.Time) {
+	r.hooks.mu.RLock()
+	fns := r.hooks.onBlocked
+	r.hooks.mu.RUnlock()
+	for _, fn := range fns {
+		fn(r.apiName, resumeAt)
+	}
+}
+
+func (r *RateLimiter) fireResumed() {
+	r.hooks.mu.RLock()
+	fns := r.hooks.onResumed
+	r.hooks.mu.RUnlock()
+	for _, fn := range fns {
+		fn(r.apiName)
+	}
+}
+
+func (r *RateLimiter) fireError(err error) {
+	r.hooks.mu.RLock()
+	fns := r.hooks.onError
+	r.hooks.mu.RUnlock()
+	for _, fn := range fns {
+		fn(r.apiName, err)
+	}
+}
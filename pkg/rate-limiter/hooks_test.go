@@ -0,0 +1,71 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestHooks(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock := clocktest.NewFake(now)
+	rLimit := NewRateLimiter(QueryChannel, WithClock(clock))
+
+	blocked := make(chan time.Time, 1)
+	resumed := make(chan string, 1)
+	var errs []error
+	rLimit.OnBlocked(func(api string, resumeAt time.Time) {
+		assert.Equal(t, string(QueryChannel), api)
+		blocked <- resumeAt
+	})
+	rLimit.OnResumed(func(api string) {
+		resumed <- api
+	})
+	rLimit.OnError(func(api string, err error) {
+		errs = append(errs, err)
+	})
+
+	assert.Error(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		return nil, assert.AnError
+	}))
+	assert.Equal(t, []error{assert.AnError}, errs)
+
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Reset: 1030}}, nil
+	}))
+	assert.Equal(t, time.Unix(1030, 0), <-blocked)
+
+	blockUntil(t, clock, 1)
+	clock.Set(time.Unix(1030, 0))
+	select {
+	case api := <-resumed:
+		assert.Equal(t, string(QueryChannel), api)
+	case <-time.After(time.Second):
+		t.Fatal("resumed hook not called")
+	}
+}
+
+func TestHookRegisteringHook(t *testing.T) {
+	rLimit := NewRateLimiter(QueryChannel)
+	registered := 0
+	rLimit.OnError(func(api string, err error) {
+		// would deadlock if callbacks ran under the hooks lock
+		rLimit.OnError(func(api string, err error) {})
+		registered++
+	})
+
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+			return nil, assert.AnError
+		})
+	}()
+	assert.ErrorIs(t, receive(t, done), assert.AnError)
+	assert.Equal(t, 1, registered)
+	assert.Len(t, rLimit.hooks.onError, 2)
+}
@@ -0,0 +1,101 @@
+package rate_limiter
+
+import (
+	"context"
+	"time"
+)
+
+// admit counts a call of the given cost in flight, unless the remaining calls
+// reported by the latest response, minus the calls already in flight and the
+// calls reserved by others than res, see Reserve, do not leave room for it
+// beyond the headroom. In that case admit returns a channel closed once a call
+// in flight completes, as its response updates the remaining calls, or the
+// barrier when a response blocked the api meanwhile; calls held back by the
+// reservations are also woken up at reset, the end of their window.
+func (r *RateLimiter) admit(now time.Time, cost int, res *Reservation) (wake chan struct{}, reset time.Time, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.blocked != nil {
+		return r.blocked, time.Time{}, false
+	}
+	var reserved int64
+	if info, ok := r.lastInfo.Load(); ok && time.Unix(info.Reset, 0).After(now) {
+		reserved = r.reservedLocked(info.Reset, res)
+		reset = time.Unix(info.Reset, 0)
+	}
+	if (r.inFlight > 0 || reserved > 0) && r.effectiveRemainingLocked(now)-reserved-int64(cost-1) <= r.headroom.Load() {
+		if r.settled == nil {
+			r.settled = make(chan struct{})
+		}
+		if reserved == 0 {
+			reset = time.Time{}
+		}
+		return r.settled, reset, false
+	}
+	r.inFlight += cost
+	return nil, time.Time{}, true
+}
+
+// effectiveRemainingLocked returns the remaining calls reported by the latest
+// response not counting the calls in flight, or the calls in flight plus the
+// headroom when unknown or once the window is over. r.mu must be held.
+func (r *RateLimiter) effectiveRemainingLocked(now time.Time) int64 {
+	info, ok := r.lastInfo.Load()
+	if !ok || !time.Unix(info.Reset, 0).After(now) {
+		return r.headroom.Load() + 1
+	}
+	return info.Remaining - int64(r.inFlight)
+}
+
+// settle counts out a call of the given cost admitted by admit, waking up the
+// calls waiting for room.
+func (r *RateLimiter) settle(cost int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight -= cost
+	r.wakeLocked()
+}
+
+// wakeLocked wakes up the calls waiting for room. r.mu must be held.
+func (r *RateLimiter) wakeLocked() {
+	if r.settled != nil {
+		close(r.settled)
+		r.settled = nil
+	}
+}
+
+// waitAdmission waits, while holding a token, until the call is admitted by
+// admit, reporting whether it had to wait.
+func (r *RateLimiter) waitAdmission(ctx context.Context, timeout *maxWaitTimer) (bool, error) {
+	waited := false
+	for {
+		now := r.getClock().Now()
+		wake, reset, ok := r.admit(now, r.costFrom(ctx), reservationFrom(ctx))
+		if ok {
+			return waited, nil
+		}
+		waited = true
+		var windowOver <-chan time.Time
+		if !reset.IsZero() {
+			windowOver = r.getClock().After(reset.Sub(now))
+		}
+		select {
+		case <-wake:
+		case <-windowOver:
+		case <-ctx.Done():
+			return waited, ctx.Err()
+		case <-timeout.C():
+			return waited, r.errWaitTimeout()
+		case <-r.closing():
+			return waited, r.errClosed()
+		}
+	}
+}
+
+// othersInFlight is the number of calls in flight besides a call of the given
+// cost, each of which is going to consume one of the remaining calls.
+func (r *RateLimiter) othersInFlight(cost int) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(max(r.inFlight-cost, 0))
+}
@@ -0,0 +1,115 @@
+package rate_limiter
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+// localStore keeps no count of the remaining calls, leaving the limiter
+// to its own in-flight accounting.
+type localStore struct{}
+
+func (localStore) Take(ctx context.Context, api string, now time.Time) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func (localStore) Observe(ctx context.Context, api string, info LimitInfo, now time.Time) error {
+	return nil
+}
+
+func (localStore) Load(ctx context.Context, api string) (State, error) {
+	return State{}, nil
+}
+
+// quotaServer answers like Stream with a quota of limit calls per minute.
+type quotaServer struct {
+	clock *clocktest.Fake
+	limit int64
+
+	mu      sync.Mutex
+	reset   int64
+	used    int64
+	started int
+	over    int // 429 answered
+}
+
+func (s *quotaServer) call() (*stream.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now := s.clock.Now().Unix(); now >= s.reset {
+		s.reset = now + 60
+		s.used = 0
+	}
+	s.used++
+	if s.used > s.limit {
+		s.over++
+		return nil, stream.Error{StatusCode: http.StatusTooManyRequests, RateLimit: &stream.RateLimitInfo{Limit: s.limit, Reset: s.reset}}
+	}
+	return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: s.limit, Remaining: s.limit - s.used, Reset: s.reset}}, nil
+}
+
+func (s *quotaServer) getStarted() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.started
+}
+
+func TestInFlightCallsDoNotOvershootQuota(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	server := &quotaServer{clock: clock, limit: 3}
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithMaxConcurrency(4), WithStore(localStore{}))
+	assert.NoError(t, rLimit.TryCallApi(server.call))
+
+	// two calls left for four slots
+	const n = 4
+	gate := make(chan struct{})
+	done := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			done <- rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+				server.mu.Lock()
+				server.started++
+				server.mu.Unlock()
+				<-gate
+				return server.call()
+			})
+		}()
+	}
+	assert.Eventually(t, func() bool { return server.getStarted() == 2 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 2, server.getStarted(), "calls admitted beyond the remaining calls")
+
+	var rateLimited ErrRateLimited
+	assert.ErrorAs(t, rLimit.TryCallApi(server.call), &rateLimited)
+
+	close(gate)
+	assert.NoError(t, receive(t, done))
+	assert.NoError(t, receive(t, done))
+	// the quota is exhausted, the other calls resume after the reset
+	blockUntil(t, clock, 1)
+	clock.Advance(time.Minute)
+	assert.NoError(t, receive(t, done))
+	assert.NoError(t, receive(t, done))
+	assert.Equal(t, 0, server.over, "calls answered with a 429")
+}
+
+func TestInFlightAccountingWithUnknownRemaining(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithMaxConcurrency(2))
+	settled, _, ok := rLimit.admit(time.Now(), 1, nil)
+	assert.True(t, ok)
+	assert.Nil(t, settled)
+	_, _, ok = rLimit.admit(time.Now(), 1, nil)
+	assert.True(t, ok, "admitted while no response reported the remaining calls")
+	assert.Equal(t, int64(1), rLimit.othersInFlight(1))
+	rLimit.settle(1)
+	rLimit.settle(1)
+	assert.Equal(t, int64(0), rLimit.othersInFlight(1))
+}
@@ -0,0 +1,119 @@
+package rate_limiter
+
+import "context"
+
+// Invocation is a call through a limiter, as handed to its interceptors.
+type Invocation struct {
+	// Api is the name of the api of the limiter.
+	Api string
+	// Try reports a call of TryCallApi, turned down instead of waiting.
+	Try bool
+	// Options are the options of the call, which an interceptor may change
+	// for the next ones, see CallOption.
+	Options CallOptions
+
+	logger Logger
+	call   limitedCall
+}
+
+// Caller runs an Invocation through a limiter, see Interceptor.
+type Caller func(ctx context.Context, inv Invocation) error
+
+// Interceptor wraps the Caller of the next interceptor, running code around
+// every call of a limiter, e.g. to audit the calls or to change their context
+// or options. next must be called for the call to go on; an interceptor not
+// calling it turns the call down with its error.
+type Interceptor func(next Caller) Caller
+
+// WithInterceptors runs every call of the limiter through interceptors, the
+// first one being the outermost, around the built-in interceptors of the
+// limiter: the audit of WithAuditSink, tracing, the Rejected events and the
+// retries of WithRetry, in this order, then, for each attempt, the faults of
+// WithFaultInjector, the metrics and the logging of the outcome. The
+// interceptors of several WithInterceptors are appended.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(r *RateLimiter) {
+		r.interceptors = append(r.interceptors, interceptors...)
+	}
+}
+
+// caller returns the chain of the interceptors of r, built on first use.
+func (r *RateLimiter) caller() Caller {
+	r.callerOnce.Do(func() {
+		next := Caller(r.attempt)
+		next = r.logging(next)
+		if _, nop := r.metrics.(nopMetrics); !nop && r.metrics != nil {
+			next = r.metering(next)
+		}
+		if r.faults != nil {
+			next = r.faulting(next)
+		}
+		if r.retry != nil {
+			next = r.retrying(next)
+		}
+		next = r.rejections(next)
+		next = r.tracing(next)
+		if r.audit != nil {
+			next = r.auditing(next)
+		}
+		for i := len(r.interceptors) - 1; i >= 0; i-- {
+			next = r.interceptors[i](next)
+		}
+		r.pipeline = next
+	})
+	return r.pipeline
+}
+
+// run runs inv through the interceptors of r, counting it as a call in flight
+// for Close.
+func (r *RateLimiter) run(ctx context.Context, inv Invocation) error {
+	r.initToken()
+	if err := r.enter(); err != nil {
+		return err
+	}
+	defer r.leave()
+	inv.Api = r.apiName
+	if inv.logger == nil {
+		inv.logger = r.getLogger(nil)
+	}
+	return r.caller()(ctx, inv)
+}
+
+// attempt is the innermost Caller, running inv once through the limiter.
+func (r *RateLimiter) attempt(ctx context.Context, inv Invocation) error {
+	ctx = withOptions(r.withDefaultPriority(ctx), inv.Options)
+	switch {
+	case inv.Options.SkipLimit:
+		return r.callUnlimited(ctx, inv.logger, inv.call)
+	case inv.Try:
+		return r.tryCallApi(ctx, inv.call)
+	default:
+		return r.callApiOnce(ctx, inv.logger, inv.call)
+	}
+}
+
+// tracing is the built-in interceptor wrapping a call, with all its attempts,
+// in a span.
+func (r *RateLimiter) tracing(next Caller) Caller {
+	return func(ctx context.Context, inv Invocation) error {
+		ctx, span := r.startSpan(ctx)
+		defer span.End()
+		traceTags(span, inv.Options.Tags)
+		if inv.Options.SkipLimit {
+			span.AddEvent("limit skipped")
+		}
+		err := next(ctx, inv)
+		traceStatus(span, err)
+		return err
+	}
+}
+
+// rejections is the built-in interceptor emitting a Rejected event for the
+// calls turned down.
+func (r *RateLimiter) rejections(next Caller) Caller {
+	return func(ctx context.Context, inv Invocation) error {
+		err := next(ctx, inv)
+		r.emitRejected(err, inv.Options.Tags)
+		return err
+	}
+}
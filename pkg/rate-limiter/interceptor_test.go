@@ -0,0 +1,112 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func okCall() (*stream.Response, error) {
+	return &stream.Response{}, nil
+}
+
+// recordInterceptor appends name to trace before and after the next ones.
+func recordInterceptor(name string, trace *[]string) Interceptor {
+	return func(next Caller) Caller {
+		return func(ctx context.Context, inv Invocation) error {
+			*trace = append(*trace, name+">")
+			err := next(ctx, inv)
+			*trace = append(*trace, "<"+name)
+			return err
+		}
+	}
+}
+
+func TestInterceptorsOrder(t *testing.T) {
+	var trace []string
+	rLimit := NewRateLimiter(QueryUsers,
+		WithInterceptors(recordInterceptor("a", &trace), recordInterceptor("b", &trace)),
+		WithInterceptors(recordInterceptor("c", &trace)),
+	)
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		trace = append(trace, "call")
+		return &stream.Response{}, nil
+	}))
+	assert.Equal(t, []string{"a>", "b>", "c>", "call", "<c", "<b", "<a"}, trace)
+}
+
+func TestInterceptorSeesInvocation(t *testing.T) {
+	var got []Invocation
+	rLimit := NewRateLimiter(QueryUsers, WithInterceptors(func(next Caller) Caller {
+		return func(ctx context.Context, inv Invocation) error {
+			got = append(got, inv)
+			return next(ctx, inv)
+		}
+	}))
+	require.NoError(t, rLimit.TryCallApi(okCall, WithTags(map[string]string{"job": "sync"})))
+	require.NoError(t, rLimit.CallWithCost(context.Background(), 2, okCall))
+
+	require.Len(t, got, 2)
+	assert.True(t, got[0].Try)
+	assert.Equal(t, string(QueryUsers), got[0].Api)
+	assert.Equal(t, map[string]string{"job": "sync"}, got[0].Options.Tags)
+	assert.False(t, got[1].Try)
+}
+
+func TestInterceptorChangesOptions(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithInterceptors(func(next Caller) Caller {
+		return func(ctx context.Context, inv Invocation) error {
+			inv.Options.Tags = map[string]string{"source": "interceptor"}
+			return next(ctx, inv)
+		}
+	}))
+	events := rLimit.Events()
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), okCall))
+	assert.Equal(t, map[string]string{"source": "interceptor"}, (<-events).Tags)
+}
+
+func TestInterceptorTurnsCallDown(t *testing.T) {
+	errDenied := errors.New("denied")
+	rLimit := NewRateLimiter(QueryUsers, WithInterceptors(func(next Caller) Caller {
+		return func(ctx context.Context, inv Invocation) error {
+			return errDenied
+		}
+	}))
+	err := rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		t.Error("called")
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, errDenied)
+	// the call did not hold the slot
+	assert.NoError(t, rLimit.tryCallApi(context.Background(), streamCall(okCall)))
+}
+
+func TestInterceptorAroundRetries(t *testing.T) {
+	calls := 0
+	intercepted := 0
+	rLimit := NewRateLimiter(QueryUsers,
+		WithClock(&instantClock{now: time.Unix(1000, 0)}),
+		WithRetry(RetryPolicy{MaxAttempts: 3}),
+		WithInterceptors(func(next Caller) Caller {
+			return func(ctx context.Context, inv Invocation) error {
+				intercepted++
+				return next(ctx, inv)
+			}
+		}),
+	)
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		calls++
+		if calls < 3 {
+			return nil, stream.Error{StatusCode: http.StatusServiceUnavailable}
+		}
+		return &stream.Response{}, nil
+	}))
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, 1, intercepted, "once around every attempt")
+}
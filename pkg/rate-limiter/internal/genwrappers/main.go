@@ -0,0 +1,255 @@
+// Command genwrappers generates the rate limited methods of
+// rate_limiter.Client and rate_limiter.Channel, reflecting over the methods
+// of stream.Client and stream.Channel: every method taking a context and
+// returning a response carrying the rate limit info is routed to the limiter
+// of its endpoint.
+//
+//	go run ./internal/genwrappers -o client_gen.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+)
+
+const streamPkg = "github.com/GetStream/stream-chat-go/v6"
+
+// endpoints maps the methods whose endpoint is not named after them, e.g.
+// the variants of another method. Every endpoint must be declared as a
+// GetStreamApiName constant of the same name.
+var endpoints = map[string]map[string]string{
+	"Client": {
+		"CreateChannelWithMembers": "CreateChannel",
+		"HardDeleteMessage":        "DeleteMessage",
+		"PartialUpdateUser":        "UpdateUsersPartial",
+		"PartialUpdateUsers":       "UpdateUsersPartial",
+		"SearchWithFullResponse":   "Search",
+		"UpsertUser":               "UpdateUsers",
+		"UpsertUsers":              "UpdateUsers",
+	},
+	"Channel": {
+		"AddModeratorsWithMessage":    "AddModerators",
+		"Delete":                      "DeleteChannel",
+		"DemoteModeratorsWithMessage": "DemoteModerators",
+		"Hide":                        "HideChannel",
+		"HideWithHistoryClear":        "HideChannel",
+		"InviteMembersWithMessage":    "InviteMembers",
+		"Mute":                        "MuteChannel",
+		"PartialUpdate":               "UpdateChannelPartial",
+		"Query":                       "QueryChannel",
+		"Show":                        "ShowChannel",
+		"Truncate":                    "TruncateChannel",
+		"Unmute":                      "UnmuteChannel",
+		"Update":                      "UpdateChannel",
+	},
+}
+
+// reserved are the hand-written methods of the wrappers.
+var reserved = map[string]map[string]bool{
+	"Client": {
+		"Manager":     true,
+		"SendMessage": true, // takes the channel
+		"Stream":      true,
+		"WrapChannel": true,
+	},
+	"Channel": {
+		"Stream": true,
+	},
+}
+
+var (
+	contextType  = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	responseType = reflect.TypeOf(stream.Response{})
+)
+
+func main() {
+	out := flag.String("o", "client_gen.go", "output file")
+	flag.Parse()
+
+	src, err := generate()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type generator struct {
+	imports map[string]bool
+	body    bytes.Buffer
+}
+
+// generate returns the formatted source of the wrappers.
+func generate() ([]byte, error) {
+	g := &generator{imports: map[string]bool{"context": true, streamPkg: true}}
+	g.wrap("Client", "c", "c.client", reflect.TypeOf(&stream.Client{}))
+	g.wrap("Channel", "ch", "ch.channel", reflect.TypeOf(&stream.Channel{}))
+
+	var src bytes.Buffer
+	src.WriteString("// Code generated by genwrappers; DO NOT EDIT.\n\npackage rate_limiter\n\nimport (\n")
+	imports := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	for _, imp := range imports {
+		if imp != streamPkg {
+			fmt.Fprintf(&src, "\t%q\n", imp)
+		}
+	}
+	fmt.Fprintf(&src, "\n\tstream %q\n", streamPkg)
+	src.WriteString(")\n")
+	src.Write(g.body.Bytes())
+	return format.Source(src.Bytes())
+}
+
+// wrap emits the methods of the wrapper named typ, with receiver recv, for the
+// methods of wrapped, reached through field.
+func (g *generator) wrap(typ, recv, field string, wrapped reflect.Type) {
+	for i := 0; i < wrapped.NumMethod(); i++ {
+		m := wrapped.Method(i)
+		if reserved[typ][m.Name] {
+			continue
+		}
+		if err := g.method(typ, recv, field, m); err != nil {
+			// not an api call, or not expressible outside of the stream package
+			continue
+		}
+	}
+}
+
+func (g *generator) method(typ, recv, field string, m reflect.Method) error {
+	ft := m.Type
+	// the first input is the receiver
+	if ft.NumIn() < 2 || ft.In(1) != contextType {
+		return fmt.Errorf("%s does not take a context", m.Name)
+	}
+	if ft.NumOut() != 2 || ft.Out(1) != errorType || ft.Out(0).Kind() != reflect.Pointer {
+		return fmt.Errorf("%s does not return a response", m.Name)
+	}
+	respType := ft.Out(0)
+	response, err := responseOf(respType.Elem())
+	if err != nil {
+		return err
+	}
+
+	var params, args []string
+	for i := 2; i < ft.NumIn(); i++ {
+		in := ft.In(i)
+		name := fmt.Sprintf("p%d", i-1)
+		if ft.IsVariadic() && i == ft.NumIn()-1 {
+			elem, err := g.typeString(in.Elem())
+			if err != nil {
+				return err
+			}
+			params = append(params, name+" ..."+elem)
+			args = append(args, name+"...")
+			continue
+		}
+		t, err := g.typeString(in)
+		if err != nil {
+			return err
+		}
+		params = append(params, name+" "+t)
+		args = append(args, name)
+	}
+	result, err := g.typeString(respType)
+	if err != nil {
+		return err
+	}
+
+	endpoint := m.Name
+	if name, ok := endpoints[typ][m.Name]; ok {
+		endpoint = name
+	}
+	call := fmt.Sprintf("%s.%s(%s)", field, m.Name, strings.Join(append([]string{"ctx"}, args...), ", "))
+
+	b := &g.body
+	fmt.Fprintf(b, "\n// %s calls stream.%s.%s through the limiter of the %s endpoint.\n", m.Name, typ, m.Name, endpoint)
+	fmt.Fprintf(b, "func (%s *%s) %s(%s) (%s, error) {\n", recv, typ, m.Name, strings.Join(append([]string{"ctx context.Context"}, params...), ", "), result)
+	fmt.Fprintf(b, "\treturn Call(ctx, %s.manager.For(%s), func() (%s, *stream.Response, error) {\n", recv, endpoint, result)
+	if response == "resp" {
+		fmt.Fprintf(b, "\t\tresp, err := %s\n\t\treturn resp, resp, err\n", call)
+	} else {
+		fmt.Fprintf(b, "\t\tresp, err := %s\n\t\tif err != nil {\n\t\t\treturn nil, nil, err\n\t\t}\n\t\treturn resp, %s, nil\n", call, response)
+	}
+	b.WriteString("\t})\n}\n")
+	return nil
+}
+
+// responseOf returns the expression of the *stream.Response carried by resp,
+// a pointer to t.
+func responseOf(t reflect.Type) (string, error) {
+	if t == responseType {
+		return "resp", nil
+	}
+	if t.Kind() != reflect.Struct {
+		return "", fmt.Errorf("%s is not a response", t)
+	}
+	f, ok := t.FieldByName("Response")
+	if !ok || !f.Anonymous || len(f.Index) != 1 {
+		return "", fmt.Errorf("%s does not embed stream.Response", t)
+	}
+	switch f.Type {
+	case responseType:
+		return "&resp.Response", nil
+	case reflect.PointerTo(responseType):
+		return "resp.Response", nil
+	}
+	return "", fmt.Errorf("%s does not embed stream.Response", t)
+}
+
+// typeString returns t as written in the generated file, recording the
+// imports it needs.
+func (g *generator) typeString(t reflect.Type) (string, error) {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name(), nil
+		}
+		if !unicode.IsUpper(rune(t.Name()[0])) || strings.Contains(t.Name(), "[") {
+			return "", fmt.Errorf("%s cannot be referenced", t)
+		}
+		g.imports[t.PkgPath()] = true
+		if t.PkgPath() == streamPkg {
+			return "stream." + t.Name(), nil
+		}
+		return path.Base(t.PkgPath()) + "." + t.Name(), nil
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem, err := g.typeString(t.Elem())
+		return "*" + elem, err
+	case reflect.Slice:
+		elem, err := g.typeString(t.Elem())
+		return "[]" + elem, err
+	case reflect.Array:
+		elem, err := g.typeString(t.Elem())
+		return fmt.Sprintf("[%d]%s", t.Len(), elem), err
+	case reflect.Map:
+		key, err := g.typeString(t.Key())
+		if err != nil {
+			return "", err
+		}
+		elem, err := g.typeString(t.Elem())
+		return "map[" + key + "]" + elem, err
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "interface{}", nil
+		}
+	}
+	return "", fmt.Errorf("%s is not supported", t)
+}
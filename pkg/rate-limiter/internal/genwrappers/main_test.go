@@ -0,0 +1,17 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratedWrappersUpToDate(t *testing.T) {
+	want, err := generate()
+	require.NoError(t, err)
+	got, err := os.ReadFile("../../client_gen.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got), "run go generate in pkg/rate-limiter")
+}
@@ -0,0 +1,215 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"sync"
+	"time"
+)
+
+// LatencyAlgorithm is how WithLatencyLimit derives the cap of the calls
+// outstanding from their latency.
+type LatencyAlgorithm int
+
+const (
+	// LatencyGradient shrinks the cap by the ratio of the long term average
+	// latency, the baseline, to the short term one once beyond the tolerance,
+	// and grows it by its square root otherwise.
+	LatencyGradient LatencyAlgorithm = iota
+	// LatencyVegas estimates the calls queued by Stream from the latency above
+	// the lowest one observed, the baseline, growing the cap while few calls
+	// are queued and shrinking it once too many are.
+	LatencyVegas
+)
+
+// LatencyLimitConfig configures WithLatencyLimit.
+type LatencyLimitConfig struct {
+	// Algorithm is LatencyGradient, the default, or LatencyVegas.
+	Algorithm LatencyAlgorithm
+	// MinLimit is the lowest cap (default 1).
+	MinLimit int
+	// Tolerance is how many times the baseline the short term latency may
+	// reach before the cap shrinks, for LatencyGradient (default 1.5).
+	Tolerance float64
+	// Smoothing weighs each new cap against the current one, between 0 and 1,
+	// for LatencyGradient (default 0.2).
+	Smoothing float64
+	// BaselineTTL is how long the lowest latency stays the baseline before
+	// being measured again, for LatencyVegas, so that the baseline follows a
+	// lasting change of Stream (default 1m).
+	BaselineTTL time.Duration
+}
+
+// The weights of the latest latency in the short and long term averages of
+// LatencyGradient.
+const (
+	shortLatencyWeight = 0.3
+	longLatencyWeight  = 0.02
+)
+
+// latencyLimit adapts the cap of WithMaxInFlight to the latency of the calls.
+// As aimd does with the slots, the cap is lowered by parking tokens in the
+// channel of the calls outstanding.
+type latencyLimit struct {
+	config LatencyLimitConfig
+
+	mu         sync.Mutex
+	max        int
+	limit      float64
+	parked     int
+	short      float64 // seconds, see LatencyGradient
+	long       float64
+	baseline   time.Duration // see LatencyVegas
+	baselineAt time.Time
+}
+
+// WithLatencyLimit adapts the cap of WithMaxInFlight to the latency of the
+// calls, shrinking it when Stream slows down and growing it back up to the cap
+// once the latency recovers, on top of the limits of the quota. Without
+// WithMaxInFlight, the WithMaxConcurrency slots are the highest cap. The cap
+// is halved on the calls timing out; the other failed calls are ignored.
+func WithLatencyLimit(config LatencyLimitConfig) Option {
+	return func(r *RateLimiter) {
+		if config.MinLimit < 1 {
+			config.MinLimit = 1
+		}
+		if config.Tolerance <= 0 {
+			config.Tolerance = 1.5
+		}
+		if config.Smoothing <= 0 || config.Smoothing > 1 {
+			config.Smoothing = 0.2
+		}
+		if config.BaselineTTL <= 0 {
+			config.BaselineTTL = time.Minute
+		}
+		// sized by NewRateLimiter once the cap is known
+		r.latency = &latencyLimit{config: config}
+	}
+}
+
+func (l *latencyLimit) init(n int) {
+	l.max = n
+	l.limit = float64(n)
+	l.config.MinLimit = min(l.config.MinLimit, n)
+}
+
+// observe updates the cap with the latency of a call ended at now, failed with
+// err.
+func (l *latencyLimit) observe(now time.Time, latency time.Duration, err error, onWire chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch {
+	case isTimeout(err):
+		l.limit /= 2
+	case err != nil || latency <= 0:
+		return
+	case l.config.Algorithm == LatencyVegas:
+		l.vegas(now, latency)
+	default:
+		l.gradient(latency)
+	}
+	l.limit = math.Max(math.Min(l.limit, float64(l.max)), float64(l.config.MinLimit))
+	l.rebalance(onWire)
+}
+
+func (l *latencyLimit) gradient(latency time.Duration) {
+	sample := latency.Seconds()
+	if l.long == 0 {
+		l.short, l.long = sample, sample
+	}
+	l.short += (sample - l.short) * shortLatencyWeight
+	l.long += (sample - l.long) * longLatencyWeight
+	// a baseline way above the latency is one of a past slowdown
+	if l.long > 2*l.short {
+		l.long *= 0.95
+	}
+	gradient := math.Max(0.5, math.Min(1, l.config.Tolerance*l.long/l.short))
+	next := l.limit*gradient + math.Sqrt(l.limit)
+	l.limit = l.limit*(1-l.config.Smoothing) + next*l.config.Smoothing
+}
+
+func (l *latencyLimit) vegas(now time.Time, latency time.Duration) {
+	if l.baseline == 0 || latency < l.baseline || now.Sub(l.baselineAt) > l.config.BaselineTTL {
+		l.baseline, l.baselineAt = latency, now
+	}
+	queued := l.limit * (1 - float64(l.baseline)/float64(latency))
+	step := math.Max(1, math.Log10(l.limit))
+	switch {
+	case queued <= 3*step:
+		l.limit += step
+	case queued >= 6*step:
+		l.limit -= step
+	}
+}
+
+// rebalance parks or releases tokens so that the cap is left to the calls.
+// Tokens held by calls outstanding are parked once released, see absorb.
+func (l *latencyLimit) rebalance(onWire chan struct{}) {
+	target := l.max - int(l.limit)
+	for l.parked > target {
+		<-onWire
+		l.parked--
+	}
+	for l.parked < target {
+		select {
+		case onWire <- struct{}{}:
+			l.parked++
+		default:
+			return
+		}
+	}
+}
+
+// absorb parks the token of a call returning when fewer tokens than needed
+// could be parked so far, reporting whether the token is now parked.
+func (l *latencyLimit) absorb() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.parked < l.max-int(l.limit) {
+		l.parked++
+		return true
+	}
+	return false
+}
+
+func (l *latencyLimit) getParked() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.parked
+}
+
+func (l *latencyLimit) current() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// isTimeout reports whether err is a call timing out.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// InFlightLimit returns how many calls may currently be outstanding at the
+// same time, lower than the cap of WithMaxInFlight while WithLatencyLimit
+// shrinks it, 0 when not capped.
+func (r *RateLimiter) InFlightLimit() int {
+	if r.latency != nil {
+		return r.latency.current()
+	}
+	return cap(r.onWire)
+}
+
+// observeLatency reports the latency of a call to WithLatencyLimit, if set.
+func (r *RateLimiter) observeLatency(calledAt time.Time, err error) {
+	if r.latency == nil {
+		return
+	}
+	now := r.getClock().Now()
+	r.latency.observe(now, now.Sub(calledAt), err, r.onWire)
+}
@@ -0,0 +1,81 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+// callsTaking runs n calls through rLimit, each taking latency on clock.
+func callsTaking(t *testing.T, rLimit *RateLimiter, clock *clocktest.Fake, n int, latency time.Duration) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+			clock.Advance(latency)
+			return &stream.Response{}, nil
+		}))
+	}
+}
+
+func TestWithLatencyLimit(t *testing.T) {
+	for name, algorithm := range map[string]LatencyAlgorithm{"gradient": LatencyGradient, "vegas": LatencyVegas} {
+		t.Run(name, func(t *testing.T) {
+			clock := clocktest.NewFake(time.Unix(1000, 0))
+			rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithMaxInFlight(20),
+				WithLatencyLimit(LatencyLimitConfig{Algorithm: algorithm, MinLimit: 2}))
+			assert.Equal(t, 20, rLimit.InFlightLimit())
+
+			callsTaking(t, rLimit, clock, 50, 100*time.Millisecond)
+			assert.Equal(t, 20, rLimit.InFlightLimit(), "steady latency")
+
+			callsTaking(t, rLimit, clock, 20, time.Second)
+			limit := rLimit.InFlightLimit()
+			assert.Less(t, limit, 10, "Stream slowed down")
+			assert.GreaterOrEqual(t, limit, 2)
+			assert.Equal(t, 20-limit, rLimit.latency.getParked())
+			assert.Equal(t, 0, rLimit.OutstandingCalls())
+
+			callsTaking(t, rLimit, clock, 100, 100*time.Millisecond)
+			assert.Equal(t, 20, rLimit.InFlightLimit(), "recovered")
+			assert.Equal(t, 0, rLimit.latency.getParked())
+		})
+	}
+}
+
+func TestWithLatencyLimitTimeouts(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithMaxConcurrency(8), WithLatencyLimit(LatencyLimitConfig{}))
+	assert.Equal(t, 8, rLimit.InFlightLimit(), "capped by the slots")
+
+	timeout := func() (*stream.Response, error) {
+		return nil, context.DeadlineExceeded
+	}
+	assert.ErrorIs(t, rLimit.CallApiWithContext(context.Background(), timeout), context.DeadlineExceeded)
+	assert.Equal(t, 4, rLimit.InFlightLimit())
+	for i := 0; i < 5; i++ {
+		_ = rLimit.CallApiWithContext(context.Background(), timeout)
+	}
+	assert.Equal(t, 1, rLimit.InFlightLimit(), "down to MinLimit")
+
+	// the parked calls leave a single one on the wire
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+			close(entered)
+			<-unblock
+			return &stream.Response{}, nil
+		})
+	}()
+	<-entered
+	var rateLimited ErrRateLimited
+	assert.ErrorAs(t, rLimit.TryCallApi(func() (*stream.Response, error) { return &stream.Response{}, nil }), &rateLimited)
+	close(unblock)
+	require.NoError(t, receive(t, done))
+}
@@ -0,0 +1,84 @@
+package rate_limiter
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// LimitInfo is the rate limit reported by a response, whatever the SDK, and
+// the version of it, reporting it: the core of the limiter, its strategies and
+// stores only deal with LimitInfo, the responses of each SDK are converted by
+// extractor functions, see streamchat.go.
+type LimitInfo struct {
+	Limit     int64
+	Remaining int64
+	Reset     int64 // Unix timestamp in seconds
+}
+
+// limitedCall is an api call as run by the limiter, returning the rate limit
+// reported by its response, ok false when it reports none. It is an interface,
+// implemented by GetStreamApiCaller as is, and the info is returned by value,
+// so that running a call does not allocate.
+type limitedCall interface {
+	call() (info LimitInfo, ok bool, err error)
+}
+
+// limitedFunc adapts a func to a limitedCall.
+type limitedFunc func() (LimitInfo, bool, error)
+
+func (f limitedFunc) call() (LimitInfo, bool, error) {
+	return f()
+}
+
+// clone returns a copy of i, nil if i is nil, handed to a Strategy instead of
+// i so that i, held by the call path, does not escape to the heap.
+func (i *LimitInfo) clone() *LimitInfo {
+	if i == nil {
+		return nil
+	}
+	info := *i
+	return &info
+}
+
+// orNil returns a pointer to info when ok, nil otherwise.
+func orNil(info LimitInfo, ok bool) *LimitInfo {
+	if !ok {
+		return nil
+	}
+	return &info
+}
+
+// infoCell holds the latest LimitInfo, stored without allocating: a seqlock,
+// whose readers retry while a store is in progress.
+type infoCell struct {
+	mu   sync.Mutex // serializes the stores
+	seq  atomic.Uint64
+	info [3]atomic.Int64 // Limit, Remaining, Reset
+}
+
+// Load returns the latest info stored, false when none was.
+func (c *infoCell) Load() (LimitInfo, bool) {
+	for {
+		seq := c.seq.Load()
+		if seq&1 != 0 {
+			// a store is in progress
+			runtime.Gosched()
+			continue
+		}
+		info := LimitInfo{Limit: c.info[0].Load(), Remaining: c.info[1].Load(), Reset: c.info[2].Load()}
+		if c.seq.Load() == seq {
+			return info, seq != 0
+		}
+	}
+}
+
+func (c *infoCell) Store(info LimitInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq.Add(1)
+	c.info[0].Store(info.Limit)
+	c.info[1].Store(info.Remaining)
+	c.info[2].Store(info.Reset)
+	c.seq.Add(1)
+}
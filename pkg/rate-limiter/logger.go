@@ -0,0 +1,120 @@
+package rate_limiter
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LevelTrace is the slog level of trace messages, below slog.LevelDebug.
+const LevelTrace = slog.LevelDebug - 4
+
+// Logger is the logging interface used by the limiter.
+// A *logrus.Logger satisfies it as is.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Tracef(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+}
+
+// StructuredLogger can be implemented by a Logger to receive messages with
+// structured attributes (api, remaining, limit, reset, wait) instead of
+// formatted strings. A *slog.Logger has this very method.
+type StructuredLogger interface {
+	LogAttrs(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debugf(format string, args ...interface{}) {}
+
+func (nopLogger) Tracef(format string, args ...interface{}) {}
+
+func (nopLogger) Warnf(format string, args ...interface{}) {}
+
+// logEnabled reports whether logger may log at level, so that the hot path
+// spares building messages that would be dropped.
+func logEnabled(ctx context.Context, logger Logger, level slog.Level) bool {
+	switch logger := logger.(type) {
+	case nopLogger:
+		return false
+	case interface {
+		Enabled(ctx context.Context, level slog.Level) bool
+	}:
+		return logger.Enabled(ctx, level)
+	default:
+		return true
+	}
+}
+
+// logAttrs logs msg with attrs when logger is a StructuredLogger,
+// otherwise the formatted message at the matching level, unless logger does
+// not log at level.
+func logAttrs(ctx context.Context, logger Logger, level slog.Level, msg string, attrs []slog.Attr, format string, args ...interface{}) {
+	if !logEnabled(ctx, logger, level) {
+		return
+	}
+	if structured, ok := logger.(StructuredLogger); ok {
+		structured.LogAttrs(ctx, level, msg, attrs...)
+		return
+	}
+	switch {
+	case level <= LevelTrace:
+		logger.Tracef(format, args...)
+	case level < slog.LevelWarn:
+		logger.Debugf(format, args...)
+	default:
+		logger.Warnf(format, args...)
+	}
+}
+
+// logging is the built-in interceptor logging the outcome of each attempt
+// reaching the api, as soon as it returns: a 429, or the calls left, when
+// exhausted too.
+func (r *RateLimiter) logging(next Caller) Caller {
+	return func(ctx context.Context, inv Invocation) error {
+		logger := inv.logger
+		if r.clientSide || !logEnabled(ctx, logger, slog.LevelWarn) {
+			return next(ctx, inv)
+		}
+		cost := r.costFrom(ctx)
+		if inv.Options.Cost > 0 {
+			cost = inv.Options.Cost
+		}
+		call := inv.call
+		inv.call = limitedFunc(func() (LimitInfo, bool, error) {
+			info, ok, err := call.call()
+			r.logOutcome(ctx, logger, info, ok, err, cost)
+			return info, ok, err
+		})
+		return next(ctx, inv)
+	}
+}
+
+// logOutcome logs the outcome of a call of the given cost.
+func (r *RateLimiter) logOutcome(ctx context.Context, logger Logger, info LimitInfo, ok bool, err error, cost int) {
+	if err != nil {
+		if reported, tooManyRequests := tooManyRequestsInfo(err, r.serverNow()); tooManyRequests {
+			logAttrs(ctx, logger, slog.LevelWarn, "too many requests", []slog.Attr{
+				slog.String("api", r.apiName),
+				slog.Int64("reset", reported.Reset),
+			}, "Too many requests for %s, blocking until %d\n", r.apiName, reported.Reset)
+		}
+		return
+	}
+	if !ok {
+		return
+	}
+	if logEnabled(ctx, logger, LevelTrace) {
+		logAttrs(ctx, logger, LevelTrace, "api called", []slog.Attr{
+			slog.String("api", r.apiName),
+			slog.Int64("remaining", info.Remaining),
+			slog.Int64("limit", info.Limit),
+		}, "After api call for %s, remaining api calls %d/%d\n", r.apiName, info.Remaining, info.Limit)
+	}
+	if r.exhausted(info.Remaining, cost) {
+		logAttrs(ctx, logger, slog.LevelDebug, "no more calls left", []slog.Attr{
+			slog.String("api", r.apiName),
+			slog.Int64("reset", info.Reset),
+		}, "No more call left for %s.\n", r.apiName)
+	}
+}
@@ -0,0 +1,91 @@
+package rate_limiter
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogrusLoggerIsALogger(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(log.TraceLevel)
+	rLimit := NewRateLimiter(QueryUsers, WithLogger(logger))
+
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 4, Limit: 5}}, nil
+	}))
+	assert.Equal(t, "After api call for QueryUsers, remaining api calls 4/5\n", hook.LastEntry().Message)
+}
+
+func TestDefaultLoggerIsNop(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+
+	assert.Equal(t, nopLogger{}, rLimit.logger)
+	assert.NoError(t, rLimit.CallApiAndBlockOnRateLimit(nil, func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 4}}, nil
+	}))
+}
+
+type structuredRecorder struct {
+	nopLogger
+	mu    sync.Mutex
+	msgs  []string
+	attrs [][]slog.Attr
+}
+
+func (s *structuredRecorder) LogAttrs(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.msgs = append(s.msgs, msg)
+	s.attrs = append(s.attrs, attrs)
+}
+
+// logged returns a snapshot of the messages logged so far, and of their attrs.
+func (s *structuredRecorder) logged() ([]string, [][]slog.Attr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.msgs...), append([][]slog.Attr{}, s.attrs...)
+}
+
+func TestStructuredLogger(t *testing.T) {
+	logger := &structuredRecorder{}
+	rLimit := NewRateLimiter(QueryUsers, WithLogger(logger))
+
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 4, Limit: 5}}, nil
+	}))
+	msgs, attrs := logger.logged()
+	assert.Equal(t, []string{"api called"}, msgs)
+	assert.Equal(t, []slog.Attr{
+		slog.String("api", "QueryUsers"),
+		slog.Int64("remaining", 4),
+		slog.Int64("limit", 5),
+	}, attrs[0])
+}
+
+func TestStructuredLoggerTooManyRequests(t *testing.T) {
+	logger := &structuredRecorder{}
+	clock := &instantClock{now: time.Unix(1000, 0)}
+	rLimit := NewRateLimiter(QueryUsers, WithLogger(logger), WithClock(clock))
+
+	err := rLimit.TryCallApi(func() (resp *stream.Response, err error) {
+		return nil, streamError(http.StatusTooManyRequests, "too many requests", &LimitInfo{Limit: 5, Reset: 1060})
+	})
+	assert.ErrorIs(t, err, ErrRateLimited{})
+	msgs, attrs := logger.logged()
+	require.NotEmpty(t, msgs)
+	assert.Equal(t, "too many requests", msgs[0], "logged before blocking")
+	assert.Equal(t, []slog.Attr{
+		slog.String("api", "QueryUsers"),
+		slog.Int64("reset", 1060),
+	}, attrs[0])
+}
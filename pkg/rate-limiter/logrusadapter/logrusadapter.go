@@ -0,0 +1,42 @@
+// Package logrusadapter plugs any logrus.FieldLogger, such as a *logrus.Entry
+// carrying fields, into the rate limiter.
+package logrusadapter
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+type tracer interface {
+	Tracef(format string, args ...interface{})
+}
+
+// Adapter implements rate_limiter.Logger on top of a logrus.FieldLogger.
+// Trace messages fall back to the debug level when the logger has no Tracef.
+type Adapter struct {
+	logger log.FieldLogger
+}
+
+var _ rate_limiter.Logger = (*Adapter)(nil)
+
+// New returns an Adapter for logger.
+func New(logger log.FieldLogger) *Adapter {
+	return &Adapter{logger: logger}
+}
+
+func (a *Adapter) Debugf(format string, args ...interface{}) {
+	a.logger.Debugf(format, args...)
+}
+
+func (a *Adapter) Tracef(format string, args ...interface{}) {
+	if t, ok := a.logger.(tracer); ok {
+		t.Tracef(format, args...)
+		return
+	}
+	a.logger.Debugf(format, args...)
+}
+
+func (a *Adapter) Warnf(format string, args ...interface{}) {
+	a.logger.Warnf(format, args...)
+}
@@ -0,0 +1,51 @@
+package logrusadapter
+
+import (
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type debugOnlyLogger struct {
+	log.FieldLogger
+}
+
+func TestAdapter(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(log.TraceLevel)
+
+	tests := []struct {
+		name      string
+		logger    log.FieldLogger
+		wantLevel log.Level
+	}{
+		{
+			name:      "Entry traces",
+			logger:    logger.WithField("component", "rate-limiter"),
+			wantLevel: log.TraceLevel,
+		},
+		{
+			name:      "Without Tracef falls back to debug",
+			logger:    debugOnlyLogger{logger},
+			wantLevel: log.DebugLevel,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook.Reset()
+			adapter := New(tt.logger)
+
+			adapter.Tracef("trace %s", "QueryUsers")
+			assert.Equal(t, tt.wantLevel, hook.LastEntry().Level)
+			assert.Equal(t, "trace QueryUsers", hook.LastEntry().Message)
+
+			adapter.Debugf("debug")
+			assert.Equal(t, log.DebugLevel, hook.LastEntry().Level)
+
+			adapter.Warnf("warn")
+			assert.Equal(t, log.WarnLevel, hook.LastEntry().Level)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package rate_limiter
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LimiterManager lazily creates and caches one RateLimiter per api name,
+// so that every caller of the same api shares a single limiter. The zero value
+// is usable, building limiters with the default options.
+type LimiterManager struct {
+	opts    []Option
+	buckets map[GetStreamApiName]GetStreamApiName
+	tenants *tenantConfig
+	clock   Clock
+	metrics Metrics
+	global  *RateLimiter
+	config  *ManagerConfig // see ApplyConfig
+
+	// the registries are written holding mu, read without it
+	mu             sync.Mutex
+	limiters       registry[GetStreamApiName, *RateLimiter]
+	tenantLimiters registry[tenantKey, *tenantLimiter]
+	tenantSweep    atomic.Int64 // unix nanos of the next scan of the idle tenant limiters
+	closed         bool
+	draining       bool // see Drain
+}
+
+// NewLimiterManager returns a LimiterManager building its limiters with opts.
+func NewLimiterManager(opts ...Option) *LimiterManager {
+	// the buckets, tenant shares and global limiter are set on the limiters
+	// by their options, collect them once
+	var probe RateLimiter
+	for _, opt := range opts {
+		opt(&probe)
+	}
+	return &LimiterManager{
+		opts:    opts,
+		buckets: probe.buckets,
+		tenants: probe.tenants,
+		clock:   probe.clock,
+		metrics: probe.metrics,
+		global:  newGlobalLimiter(&probe),
+	}
+}
+
+// For returns the RateLimiter of apiName, or of its bucket when set by
+// WithBucket, creating it on first use. Its calls pass the global limiter too,
+// if any, see WithGlobalLimiter.
+func (m *LimiterManager) For(apiName GetStreamApiName) *RateLimiter {
+	apiName = m.bucket(apiName)
+	if r, ok := m.limiters.load(apiName); ok {
+		return r
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.forLocked(apiName)
+}
+
+// Known reports whether apiName is a known endpoint or has a limiter already,
+// e.g. to turn down the apis sent by clients before calling For, which would
+// otherwise create a limiter for any of them.
+func (m *LimiterManager) Known(apiName GetStreamApiName) bool {
+	if _, ok := LookupEndpoint(apiName); ok {
+		return true
+	}
+	_, ok := m.limiters.load(m.bucket(apiName))
+	return ok
+}
+
+// forLocked returns the limiter of the bucket apiName. m.mu must be held.
+func (m *LimiterManager) forLocked(apiName GetStreamApiName) *RateLimiter {
+	if r, ok := m.limiters.load(apiName); ok {
+		return r
+	}
+	r := NewRateLimiter(apiName, m.opts...)
+	r.parent = m.global
+	m.applyConfigLocked(apiName, r)
+	if m.closed {
+		r.shutdown()
+	}
+	if m.draining {
+		r.startDrain()
+	}
+	m.limiters.store(apiName, r)
+	return r
+}
+
+// bucket resolves the bucket of apiName, following the buckets mapped to
+// other buckets.
+func (m *LimiterManager) bucket(apiName GetStreamApiName) GetStreamApiName {
+	// bounded, in case of a cycle
+	for i := 0; i < len(m.buckets); i++ {
+		bucket, ok := m.buckets[apiName]
+		if !ok || bucket == apiName {
+			break
+		}
+		apiName = bucket
+	}
+	return apiName
+}
+
+func (m *LimiterManager) now() time.Time {
+	if m.clock == nil {
+		return time.Now()
+	}
+	return m.clock.Now()
+}
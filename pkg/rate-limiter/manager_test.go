@@ -0,0 +1,108 @@
+package rate_limiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiterManagerFor(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	manager := NewLimiterManager(WithLogger(logger), WithMaxConcurrency(2))
+
+	queryUsers := manager.For(QueryUsers)
+	assert.Same(t, queryUsers, manager.For(QueryUsers))
+	assert.NotSame(t, queryUsers, manager.For(CreateChannel))
+	assert.Equal(t, string(QueryUsers), queryUsers.apiName)
+	assert.Same(t, logger, queryUsers.logger)
+	assert.Equal(t, 2, cap(queryUsers.tokens()))
+
+	assert.NoError(t, manager.For(QueryUsers).Call(context.Background(), func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 1}}, nil
+	}))
+	assert.ErrorIs(t, manager.For(QueryUsers).Call(context.Background(), func() (resp *stream.Response, err error) {
+		return nil, assert.AnError
+	}), assert.AnError)
+}
+
+func TestZeroValueLimiterManager(t *testing.T) {
+	var manager LimiterManager
+
+	queryUsers := manager.For(QueryUsers)
+	assert.Same(t, queryUsers, manager.For(QueryUsers))
+	assert.NoError(t, queryUsers.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 1}}, nil
+	}))
+}
+
+func TestLimiterManagerConcurrentFor(t *testing.T) {
+	manager := NewLimiterManager()
+
+	var wg sync.WaitGroup
+	limiters := make([]*RateLimiter, 50)
+	for i := range limiters {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			limiters[i] = manager.For(QueryChannel)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, r := range limiters {
+		assert.Same(t, limiters[0], r)
+	}
+}
+
+func TestLimiterManagerBuckets(t *testing.T) {
+	manager := NewLimiterManager(WithBucket("AddMember", AddMembers), WithBucket(AddMembers, UpdateChannel))
+
+	updateChannel := manager.For(UpdateChannel)
+	assert.Same(t, updateChannel, manager.For(AddMembers))
+	assert.Same(t, updateChannel, manager.For("AddMember"), "buckets are followed")
+	assert.Equal(t, string(UpdateChannel), updateChannel.apiName)
+	assert.NotSame(t, updateChannel, manager.For(RemoveMembers))
+
+	cyclic := NewLimiterManager(WithBucket(QueryUsers, UpdateUsers), WithBucket(UpdateUsers, QueryUsers))
+	assert.NotNil(t, cyclic.For(QueryUsers))
+}
+
+func TestLimiterManagerKnown(t *testing.T) {
+	manager := NewLimiterManager(WithBucket("AddMember", AddMembers))
+
+	assert.True(t, manager.Known(QueryUsers))
+	assert.False(t, manager.Known("Custom"))
+	assert.False(t, manager.Known("AddMember"), "no limiter yet")
+	manager.For("Custom")
+	manager.For(AddMembers)
+	assert.True(t, manager.Known("Custom"))
+	assert.True(t, manager.Known("AddMember"))
+	assert.Equal(t, 2, manager.limiters.len(), "Known creates no limiter")
+}
+
+func TestLimiterManagerEndpointBuckets(t *testing.T) {
+	manager := NewLimiterManager(WithEndpointBuckets())
+
+	assert.Same(t, manager.For(UpdateChannel), manager.For(RemoveMembers))
+	assert.Same(t, manager.For(GetOrCreateChannel), manager.For(CreateChannel))
+	assert.NotSame(t, manager.For(QueryUsers), manager.For(UpdateUsers))
+}
+
+func TestLimiterManagerApiOptions(t *testing.T) {
+	manager := NewLimiterManager(
+		WithMaxConcurrency(2),
+		WithApiOptions(QueryUsers, WithMaxConcurrency(5), WithHeadroom(10)),
+		WithBucket(AddMembers, UpdateChannel),
+		WithApiOptions(UpdateChannel, WithMaxConcurrency(3)),
+	)
+
+	assert.Equal(t, 5, cap(manager.For(QueryUsers).tokens()))
+	assert.Equal(t, int64(10), manager.For(QueryUsers).headroom.Load())
+	assert.Equal(t, 2, cap(manager.For(QueryChannels).tokens()))
+	assert.Equal(t, int64(0), manager.For(QueryChannels).headroom.Load())
+	assert.Equal(t, 3, cap(manager.For(AddMembers).tokens()), "options of the bucket")
+}
@@ -0,0 +1,72 @@
+package rate_limiter
+
+import "context"
+
+// WithMaxInFlight caps the calls outstanding at the same time at n, whatever
+// the quota left, protecting both Stream and the connection pool of the client
+// during bursts. Unlike the slots of WithMaxConcurrency, also held by the calls
+// waiting for a reset or for the strategy, and tuned by
+// WithAdaptiveConcurrency, the cap only counts the calls on the wire and never
+// changes. The calls of SkipLimit are not capped. Values lower than 1 are
+// ignored.
+func WithMaxInFlight(n int) Option {
+	return func(r *RateLimiter) {
+		if n > 0 {
+			r.onWire = make(chan struct{}, n)
+		}
+	}
+}
+
+// enterInFlight takes one of the calls of WithMaxInFlight, if set, waiting for
+// one to return. It reports whether it had to wait.
+func (r *RateLimiter) enterInFlight(ctx context.Context, timeout *maxWaitTimer) (bool, error) {
+	if r.onWire == nil {
+		return false, nil
+	}
+	select {
+	case r.onWire <- struct{}{}:
+		return false, nil
+	default:
+	}
+	select {
+	case r.onWire <- struct{}{}:
+		return true, nil
+	case <-ctx.Done():
+		return true, ctx.Err()
+	case <-timeout.C():
+		return true, r.errWaitTimeout()
+	case <-r.closing():
+		return true, r.errClosed()
+	}
+}
+
+// tryEnterInFlight is like enterInFlight, without waiting.
+func (r *RateLimiter) tryEnterInFlight() bool {
+	if r.onWire == nil {
+		return true
+	}
+	select {
+	case r.onWire <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// leaveInFlight gives back the call taken by enterInFlight, unless
+// WithLatencyLimit keeps it parked.
+func (r *RateLimiter) leaveInFlight() {
+	if r.onWire == nil || (r.latency != nil && r.latency.absorb()) {
+		return
+	}
+	<-r.onWire
+}
+
+// OutstandingCalls returns the calls on the wire counted by WithMaxInFlight,
+// 0 when not set.
+func (r *RateLimiter) OutstandingCalls() int {
+	if r.latency != nil {
+		return len(r.onWire) - r.latency.getParked()
+	}
+	return len(r.onWire)
+}
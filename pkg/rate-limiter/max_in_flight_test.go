@@ -0,0 +1,59 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMaxInFlight(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithMaxConcurrency(5), WithMaxInFlight(2))
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+	call := func() (*stream.Response, error) {
+		entered <- struct{}{}
+		<-unblock
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 100, Remaining: 90, Reset: time.Now().Add(time.Minute).Unix()}}, nil
+	}
+
+	done := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			done <- rLimit.CallApiWithContext(context.Background(), call)
+		}()
+	}
+	<-entered
+	<-entered
+	select {
+	case <-entered:
+		t.Fatal("third call on the wire")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Equal(t, 2, rLimit.OutstandingCalls())
+
+	var rateLimited ErrRateLimited
+	assert.ErrorAs(t, rLimit.TryCallApi(call), &rateLimited)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, rLimit.CallApiWithContext(ctx, call), context.DeadlineExceeded)
+
+	unblock <- struct{}{}
+	require.NoError(t, receive(t, done))
+	<-entered
+	close(unblock)
+	require.NoError(t, receive(t, done))
+	require.NoError(t, receive(t, done))
+	assert.Equal(t, 0, rLimit.OutstandingCalls())
+	rLimit.mu.Lock()
+	defer rLimit.mu.Unlock()
+	assert.Equal(t, 0, rLimit.inFlight, "the calls turned down settled")
+}
+
+func TestWithMaxInFlightIgnoresInvalid(t *testing.T) {
+	assert.Nil(t, NewRateLimiter(QueryUsers, WithMaxInFlight(0)).onWire)
+	assert.Equal(t, 0, NewRateLimiter(QueryUsers).OutstandingCalls())
+}
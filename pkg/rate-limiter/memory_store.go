@@ -0,0 +1,186 @@
+package rate_limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is the default Store, keeping the state in process. A single
+// MemoryStore can be shared by several limiters of the same api, e.g. created
+// by different LimiterManager instances.
+type MemoryStore struct {
+	mu      sync.Mutex
+	states  map[string]*memoryState
+	leaders map[string]memoryLeader // by election
+	usage   usageCounter
+}
+
+type memoryLeader struct {
+	candidate string
+	until     time.Time
+}
+
+type memoryState struct {
+	State
+	// known is false until a response reported the remaining calls, or once
+	// the window is over
+	known bool
+}
+
+var (
+	_ CostStore   = (*MemoryStore)(nil)
+	_ BudgetStore = (*MemoryStore)(nil)
+	_ Elector     = (*MemoryStore)(nil)
+)
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]*memoryState)}
+}
+
+func (s *MemoryStore) state(api string) *memoryState {
+	if s.states == nil {
+		s.states = make(map[string]*memoryState)
+	}
+	state, ok := s.states[api]
+	if !ok {
+		state = &memoryState{}
+		s.states[api] = state
+	}
+	return state
+}
+
+func (s *MemoryStore) Take(ctx context.Context, api string, now time.Time) (time.Time, error) {
+	return s.TakeN(ctx, api, now, 1)
+}
+
+func (s *MemoryStore) TakeN(ctx context.Context, api string, now time.Time, n int) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.state(api)
+	if state.BlockedUntil.After(now) {
+		return state.BlockedUntil, nil
+	}
+	if !state.known {
+		return time.Time{}, nil
+	}
+	if !state.Reset.After(now) {
+		// the window is over, remaining calls are unknown until the next response
+		state.known = false
+		state.Remaining = 0
+		return time.Time{}, nil
+	}
+	if state.Remaining <= 0 {
+		state.BlockedUntil = state.Reset
+		return state.Reset, nil
+	}
+	state.Remaining = max(state.Remaining-int64(n), 0)
+	return time.Time{}, nil
+}
+
+func (s *MemoryStore) Observe(ctx context.Context, api string, info LimitInfo, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.state(api)
+	reset := time.Unix(info.Reset, 0)
+	if reset.Before(state.Reset) {
+		// late response of a previous window
+		return nil
+	}
+	remaining := info.Remaining
+	if reset.Equal(state.Reset) && state.known && state.Remaining < remaining {
+		remaining = state.Remaining
+	}
+	state.Limit = info.Limit
+	state.Remaining = remaining
+	state.Reset = reset
+	state.known = true
+	if remaining <= 0 && reset.After(state.BlockedUntil) {
+		state.BlockedUntil = reset
+	}
+	return nil
+}
+
+func (s *MemoryStore) Load(ctx context.Context, api string) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[api]
+	if !ok {
+		return State{}, nil
+	}
+	return state.State, nil
+}
+
+// AddUsage counts the usage of the budgets of WithBudget, keeping the counter
+// of the current period of each budget only.
+func (s *MemoryStore) AddUsage(ctx context.Context, key string, n int64, expiresAt time.Time) (int64, error) {
+	return s.usage.AddUsage(ctx, key, n, expiresAt)
+}
+
+// Campaign elects a leader among the limiters sharing s, e.g. in tests of the
+// remote package.
+func (s *MemoryStore) Campaign(ctx context.Context, election, candidate string, now time.Time, ttl time.Duration) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	leader := s.leaders[election]
+	if leader.candidate != candidate && leader.until.After(now) {
+		return leader.candidate, nil
+	}
+	if s.leaders == nil {
+		s.leaders = make(map[string]memoryLeader)
+	}
+	s.leaders[election] = memoryLeader{candidate: candidate, until: now.Add(ttl)}
+	return candidate, nil
+}
+
+// StoreSnapshot is the content of a MemoryStore, e.g. to persist it across
+// restarts, see the filestore package.
+type StoreSnapshot struct {
+	States map[string]SnapshotState `json:"states,omitempty"`
+	// Usage are the usage counters of the current period of the budgets, by
+	// key, see BudgetStore.
+	Usage map[string]int64 `json:"usage,omitempty"`
+}
+
+// SnapshotState is the state of an api in a StoreSnapshot.
+type SnapshotState struct {
+	State
+	// Known is false when the remaining calls of the window are unknown.
+	Known bool `json:"known"`
+}
+
+// Snapshot returns the content of s.
+func (s *MemoryStore) Snapshot() StoreSnapshot {
+	s.mu.Lock()
+	snapshot := StoreSnapshot{States: make(map[string]SnapshotState, len(s.states))}
+	for api, state := range s.states {
+		snapshot.States[api] = SnapshotState{State: state.State, Known: state.known}
+	}
+	s.mu.Unlock()
+	s.usage.mu.Lock()
+	defer s.usage.mu.Unlock()
+	if len(s.usage.counts) > 0 {
+		snapshot.Usage = make(map[string]int64, len(s.usage.counts))
+		for _, count := range s.usage.counts {
+			snapshot.Usage[count.key] = count.used
+		}
+	}
+	return snapshot
+}
+
+// Restore replaces the content of s with snapshot.
+func (s *MemoryStore) Restore(snapshot StoreSnapshot) {
+	s.mu.Lock()
+	s.states = make(map[string]*memoryState, len(snapshot.States))
+	for api, state := range snapshot.States {
+		s.states[api] = &memoryState{State: state.State, known: state.Known}
+	}
+	s.mu.Unlock()
+	s.usage.mu.Lock()
+	defer s.usage.mu.Unlock()
+	s.usage.counts = make(map[string]usageCount, len(snapshot.Usage))
+	for key, used := range snapshot.Usage {
+		s.usage.counts[budgetOf(key)] = usageCount{key: key, used: used}
+	}
+}
@@ -0,0 +1,200 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestMemoryStoreTakeAndObserve(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Unix(1000, 0)
+	api := string(QueryUsers)
+
+	blockedUntil, err := store.Take(ctx, api, now)
+	require.NoError(t, err)
+	assert.True(t, blockedUntil.IsZero(), "unknown apis are admitted")
+
+	require.NoError(t, store.Observe(ctx, api, LimitInfo{Limit: 10, Remaining: 2, Reset: 1060}, now))
+	for i := 0; i < 2; i++ {
+		blockedUntil, err = store.Take(ctx, api, now)
+		require.NoError(t, err)
+		assert.True(t, blockedUntil.IsZero())
+	}
+	blockedUntil, err = store.Take(ctx, api, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1060, 0), blockedUntil)
+
+	state, err := store.Load(ctx, api)
+	require.NoError(t, err)
+	assert.Equal(t, State{
+		Limit:        10,
+		Remaining:    0,
+		Reset:        time.Unix(1060, 0),
+		BlockedUntil: time.Unix(1060, 0),
+	}, state)
+
+	blockedUntil, err = store.Take(ctx, api, time.Unix(1060, 0))
+	require.NoError(t, err)
+	assert.True(t, blockedUntil.IsZero(), "admitted once the window is over")
+}
+
+func TestMemoryStoreObserve(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	api := string(QueryChannel)
+	now := time.Unix(1000, 0)
+
+	require.NoError(t, store.Observe(ctx, api, LimitInfo{Limit: 10, Remaining: 5, Reset: 1060}, now))
+
+	// a late response of the same window does not raise the remaining calls
+	require.NoError(t, store.Observe(ctx, api, LimitInfo{Limit: 10, Remaining: 7, Reset: 1060}, now))
+	state, err := store.Load(ctx, api)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), state.Remaining)
+
+	// nor does a response of a previous window
+	require.NoError(t, store.Observe(ctx, api, LimitInfo{Limit: 10, Remaining: 9, Reset: 1000}, now))
+	state, err = store.Load(ctx, api)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1060, 0), state.Reset)
+
+	require.NoError(t, store.Observe(ctx, api, LimitInfo{Limit: 10, Remaining: 0, Reset: 1060}, now))
+	state, err = store.Load(ctx, api)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1060, 0), state.BlockedUntil)
+
+	state, err = store.Load(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Equal(t, State{}, state)
+}
+
+func TestDefaultStoreIsMemoryStore(t *testing.T) {
+	assert.IsType(t, &MemoryStore{}, NewRateLimiter(QueryUsers).store)
+	assert.NotSame(t, NewRateLimiter(QueryUsers).store, NewRateLimiter(QueryUsers).store)
+
+	var store MemoryStore
+	_, err := store.Take(context.Background(), string(QueryUsers), time.Now())
+	assert.NoError(t, err, "the zero value is usable")
+}
+
+func TestMemoryStoreSharedBetweenLimiters(t *testing.T) {
+	store := NewMemoryStore()
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	first := NewRateLimiter(QueryUsers, WithStore(store), WithClock(clock))
+	second := NewRateLimiter(QueryUsers, WithStore(store), WithClock(clock))
+
+	assert.NoError(t, first.TryCallApi(func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1060}}, nil
+	}))
+
+	var rateLimited ErrRateLimited
+	assert.False(t, second.IsBlocked())
+	assert.ErrorAs(t, second.TryCallApi(nil), &rateLimited)
+	assert.Equal(t, time.Unix(1060, 0), rateLimited.ResetAt)
+}
+
+func TestMemoryStoreTakeN(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Unix(1000, 0)
+	api := string(UpdateUsers)
+
+	require.NoError(t, store.Observe(ctx, api, LimitInfo{Limit: 10, Remaining: 5, Reset: 1060}, now))
+	blockedUntil, err := store.TakeN(ctx, api, now, 3)
+	require.NoError(t, err)
+	assert.True(t, blockedUntil.IsZero())
+	state, err := store.Load(ctx, api)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), state.Remaining)
+
+	blockedUntil, err = store.TakeN(ctx, api, now, 3)
+	require.NoError(t, err)
+	assert.True(t, blockedUntil.IsZero(), "Stream decides on the calls left")
+	blockedUntil, err = store.Take(ctx, api, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1060, 0), blockedUntil)
+}
+
+func TestMemoryStoreAddUsage(t *testing.T) {
+	ctx := context.Background()
+	var store MemoryStore
+	end := time.Now()
+	used, err := store.AddUsage(ctx, "QueryUsers/daily/2026-10-14", 2, end)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), used)
+	used, err = store.AddUsage(ctx, "QueryUsers/monthly/2026-10", 1, end)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), used, "another budget")
+	used, err = store.AddUsage(ctx, "QueryUsers/daily/2026-10-15", 1, end)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), used, "a new period")
+	assert.Len(t, store.usage.counts, 2, "the past periods are dropped")
+}
+
+func TestMemoryStoreSnapshot(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Unix(1000, 0)
+	require.NoError(t, store.Observe(ctx, "QueryUsers", LimitInfo{Limit: 10, Remaining: 2, Reset: 1060}, now))
+	_, err := store.Take(ctx, "QueryChannels", now)
+	require.NoError(t, err)
+	_, err = store.AddUsage(ctx, "QueryUsers/day/2024-01-01", 3, now.Add(time.Hour))
+	require.NoError(t, err)
+
+	snapshot := store.Snapshot()
+	assert.Equal(t, StoreSnapshot{
+		States: map[string]SnapshotState{
+			"QueryUsers":    {State: State{Limit: 10, Remaining: 2, Reset: time.Unix(1060, 0)}, Known: true},
+			"QueryChannels": {},
+		},
+		Usage: map[string]int64{"QueryUsers/day/2024-01-01": 3},
+	}, snapshot)
+
+	restored := NewMemoryStore()
+	restored.Restore(snapshot)
+	assert.Equal(t, snapshot, restored.Snapshot())
+	for i := 0; i < 2; i++ {
+		blockedUntil, err := restored.Take(ctx, "QueryUsers", now)
+		require.NoError(t, err)
+		assert.True(t, blockedUntil.IsZero())
+	}
+	blockedUntil, err := restored.Take(ctx, "QueryUsers", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1060, 0), blockedUntil, "remaining calls restored")
+	used, err := restored.AddUsage(ctx, "QueryUsers/day/2024-01-01", 1, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), used)
+}
+
+func TestMemoryStoreCampaign(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Unix(1000, 0)
+
+	leader, err := store.Campaign(ctx, "quota", "a", now, 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a", leader)
+	leader, err = store.Campaign(ctx, "quota", "b", now.Add(5*time.Second), 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a", leader, "lead held")
+	leader, err = store.Campaign(ctx, "quota", "a", now.Add(8*time.Second), 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a", leader, "lead renewed")
+	leader, err = store.Campaign(ctx, "quota", "b", now.Add(15*time.Second), 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a", leader, "until the renewed lead expires")
+	leader, err = store.Campaign(ctx, "quota", "b", now.Add(18*time.Second), 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "b", leader, "failover")
+	leader, err = store.Campaign(ctx, "other", "a", now, 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a", leader, "elections are independent")
+}
@@ -0,0 +1,74 @@
+package rate_limiter
+
+import (
+	"context"
+	"time"
+)
+
+// Metrics receives the measurements of a RateLimiter, see the metrics package
+// for a Prometheus implementation.
+type Metrics interface {
+	// ObserveCall counts a call of the wrapped api.
+	ObserveCall(api string)
+	// ObserveError counts a failed call of the wrapped api.
+	ObserveError(api string)
+	// ObserveBlock counts the api being blocked until a rate limit reset.
+	ObserveBlock(api string)
+	// ObserveWait records how long a call waited before being admitted.
+	ObserveWait(api string, wait time.Duration)
+	// SetRemaining records the remaining calls reported by the api.
+	SetRemaining(api string, remaining int64)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveCall(api string) {}
+
+func (nopMetrics) ObserveError(api string) {}
+
+func (nopMetrics) ObserveBlock(api string) {}
+
+func (nopMetrics) ObserveWait(api string, wait time.Duration) {}
+
+func (nopMetrics) SetRemaining(api string, remaining int64) {}
+
+// metering is the built-in interceptor of WithMetrics, observing each attempt
+// reaching the api: how long it waited to be admitted, the call and its
+// error, if any.
+func (r *RateLimiter) metering(next Caller) Caller {
+	return func(ctx context.Context, inv Invocation) error {
+		var (
+			start          = r.getClock().Now()
+			calledAt       time.Time
+			called, failed bool
+		)
+		call := inv.call
+		inv.call = limitedFunc(func() (LimitInfo, bool, error) {
+			// failed stays set when the call panics
+			calledAt, called, failed = r.getClock().Now(), true, true
+			info, ok, err := call.call()
+			failed = err != nil
+			return info, ok, err
+		})
+		err := next(ctx, inv)
+		if !called {
+			return err
+		}
+		metrics := r.getMetrics()
+		if !inv.Options.SkipLimit {
+			metrics.ObserveWait(r.apiName, calledAt.Sub(start))
+		}
+		metrics.ObserveCall(r.apiName)
+		if failed {
+			metrics.ObserveError(r.apiName)
+		}
+		return err
+	}
+}
+
+func (r *RateLimiter) getMetrics() Metrics {
+	if r.metrics == nil {
+		return nopMetrics{}
+	}
+	return r.metrics
+}
@@ -0,0 +1,176 @@
+// Package metrics exposes the rate limiter measurements as Prometheus metrics.
+// Wire a Collector into the limiters with rate_limiter.WithMetrics and
+// register it into a prometheus.Registerer.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+const (
+	apiLabel = "api"
+	appLabel = "app"
+)
+
+// Collector implements rate_limiter.Metrics and prometheus.Collector.
+type Collector struct {
+	calls     *prometheus.CounterVec
+	errors    *prometheus.CounterVec
+	blocks    *prometheus.CounterVec
+	wait      *prometheus.HistogramVec
+	remaining *prometheus.GaugeVec
+	cacheHits *prometheus.CounterVec
+	cacheMiss *prometheus.CounterVec
+	tenants   *prometheus.GaugeVec
+
+	app   string
+	byApp bool
+}
+
+var (
+	_ rate_limiter.AppMetrics    = (*Collector)(nil)
+	_ rate_limiter.CacheMetrics  = (*Collector)(nil)
+	_ rate_limiter.TenantMetrics = (*Collector)(nil)
+	_ prometheus.Collector       = (*Collector)(nil)
+)
+
+// NewCollector returns a Collector whose metrics are prefixed by namespace.
+func NewCollector(namespace string) *Collector {
+	return newCollector(namespace, []string{apiLabel})
+}
+
+// NewAppCollector is NewCollector labelling the metrics with the Stream app
+// too, once wired into a rate_limiter.MultiAppManager.
+func NewAppCollector(namespace string) *Collector {
+	c := newCollector(namespace, []string{appLabel, apiLabel})
+	c.byApp = true
+	return c
+}
+
+func newCollector(namespace string, labels []string) *Collector {
+	return &Collector{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limiter_calls_total",
+			Help:      "Number of api calls performed through the rate limiter.",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limiter_errors_total",
+			Help:      "Number of failed api calls.",
+		}, labels),
+		blocks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limiter_blocks_total",
+			Help:      "Number of times an api was blocked until the rate limit reset.",
+		}, labels),
+		wait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "rate_limiter_wait_seconds",
+			Help:      "Time spent by calls waiting to be admitted.",
+			Buckets:   []float64{.001, .01, .1, .5, 1, 5, 15, 30, 60, 120},
+		}, labels),
+		remaining: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "rate_limiter_remaining",
+			Help:      "Remaining calls in the current rate limit window, as reported by the api.",
+		}, labels),
+		cacheHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limiter_cache_hits_total",
+			Help:      "Number of api calls served from cache.",
+		}, labels),
+		cacheMiss: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limiter_cache_misses_total",
+			Help:      "Number of cached api calls not found in cache.",
+		}, labels),
+		// one gauge per manager, the api being the last label
+		tenants: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "rate_limiter_tenant_limiters",
+			Help:      "Number of live limiters of the tenants.",
+		}, labels[:len(labels)-1]),
+	}
+}
+
+// ForApp returns the Collector labelling the metrics with appKey, sharing the
+// metrics of c, which is the one to register. A Collector not built by
+// NewAppCollector has no app label and returns itself.
+func (c *Collector) ForApp(appKey string) rate_limiter.Metrics {
+	if !c.byApp {
+		return c
+	}
+	app := *c
+	app.app = appKey
+	return &app
+}
+
+func (c *Collector) labels(api string) []string {
+	if c.byApp {
+		return []string{c.app, api}
+	}
+	return []string{api}
+}
+
+func (c *Collector) ObserveCall(api string) {
+	c.calls.WithLabelValues(c.labels(api)...).Inc()
+}
+
+func (c *Collector) ObserveError(api string) {
+	c.errors.WithLabelValues(c.labels(api)...).Inc()
+}
+
+func (c *Collector) ObserveBlock(api string) {
+	c.blocks.WithLabelValues(c.labels(api)...).Inc()
+}
+
+func (c *Collector) ObserveWait(api string, wait time.Duration) {
+	c.wait.WithLabelValues(c.labels(api)...).Observe(wait.Seconds())
+}
+
+func (c *Collector) SetRemaining(api string, remaining int64) {
+	c.remaining.WithLabelValues(c.labels(api)...).Set(float64(remaining))
+}
+
+func (c *Collector) ObserveCacheHit(api string) {
+	c.cacheHits.WithLabelValues(c.labels(api)...).Inc()
+}
+
+func (c *Collector) ObserveCacheMiss(api string) {
+	c.cacheMiss.WithLabelValues(c.labels(api)...).Inc()
+}
+
+func (c *Collector) SetTenantLimiters(count int) {
+	var labels []string
+	if c.byApp {
+		labels = []string{c.app}
+	}
+	c.tenants.WithLabelValues(labels...).Set(float64(count))
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.calls.Describe(ch)
+	c.errors.Describe(ch)
+	c.blocks.Describe(ch)
+	c.wait.Describe(ch)
+	c.remaining.Describe(ch)
+	c.cacheHits.Describe(ch)
+	c.cacheMiss.Describe(ch)
+	c.tenants.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.calls.Collect(ch)
+	c.errors.Collect(ch)
+	c.blocks.Collect(ch)
+	c.wait.Collect(ch)
+	c.remaining.Collect(ch)
+	c.cacheHits.Collect(ch)
+	c.cacheMiss.Collect(ch)
+	c.tenants.Collect(ch)
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+func TestCollector(t *testing.T) {
+	collector := NewCollector("test")
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	rLimit := rate_limiter.NewRateLimiter(rate_limiter.QueryUsers, rate_limiter.WithMetrics(collector))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.NoError(t, rLimit.CallApiWithContext(ctx, func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 7}}, nil
+	}))
+	assert.Error(t, rLimit.CallApiWithContext(ctx, func() (resp *stream.Response, err error) {
+		return nil, assert.AnError
+	}))
+	assert.NoError(t, rLimit.CallApiWithContext(ctx, func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Reset: time.Now().Unix() + 60}}, nil
+	}))
+
+	expected := `
+# HELP test_rate_limiter_blocks_total Number of times an api was blocked until the rate limit reset.
+# TYPE test_rate_limiter_blocks_total counter
+test_rate_limiter_blocks_total{api="QueryUsers"} 1
+# HELP test_rate_limiter_calls_total Number of api calls performed through the rate limiter.
+# TYPE test_rate_limiter_calls_total counter
+test_rate_limiter_calls_total{api="QueryUsers"} 3
+# HELP test_rate_limiter_errors_total Number of failed api calls.
+# TYPE test_rate_limiter_errors_total counter
+test_rate_limiter_errors_total{api="QueryUsers"} 1
+# HELP test_rate_limiter_remaining Remaining calls in the current rate limit window, as reported by the api.
+# TYPE test_rate_limiter_remaining gauge
+test_rate_limiter_remaining{api="QueryUsers"} 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"test_rate_limiter_blocks_total",
+		"test_rate_limiter_calls_total",
+		"test_rate_limiter_errors_total",
+		"test_rate_limiter_remaining",
+	))
+	assert.Equal(t, 1, testutil.CollectAndCount(collector, "test_rate_limiter_wait_seconds"))
+}
+
+func TestAppCollector(t *testing.T) {
+	collector := NewAppCollector("test")
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	apps := rate_limiter.NewMultiAppManager(rate_limiter.WithMetrics(collector))
+	for _, app := range []string{"app-a", "app-b"} {
+		assert.NoError(t, apps.For(app).For(rate_limiter.QueryUsers).CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 7}}, nil
+		}))
+	}
+
+	expected := `
+# HELP test_rate_limiter_calls_total Number of api calls performed through the rate limiter.
+# TYPE test_rate_limiter_calls_total counter
+test_rate_limiter_calls_total{api="QueryUsers",app="app-a"} 1
+test_rate_limiter_calls_total{api="QueryUsers",app="app-b"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "test_rate_limiter_calls_total"))
+
+	plain := NewCollector("plain")
+	assert.Same(t, plain, plain.ForApp("app-a"), "no app label")
+}
+
+func TestCollectorCache(t *testing.T) {
+	collector := NewCollector("test")
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	rLimit := rate_limiter.NewRateLimiter(rate_limiter.QueryUsers, rate_limiter.WithMetrics(collector))
+	cache := rate_limiter.NewLRUCache(10, nil)
+	for i := 0; i < 3; i++ {
+		_, err := rate_limiter.CallCached(context.Background(), rLimit, cache, "all", time.Minute, func() (int, *stream.Response, error) {
+			return 1, &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 7}}, nil
+		})
+		require.NoError(t, err)
+	}
+
+	expected := `
+# HELP test_rate_limiter_cache_hits_total Number of api calls served from cache.
+# TYPE test_rate_limiter_cache_hits_total counter
+test_rate_limiter_cache_hits_total{api="QueryUsers"} 2
+# HELP test_rate_limiter_cache_misses_total Number of cached api calls not found in cache.
+# TYPE test_rate_limiter_cache_misses_total counter
+test_rate_limiter_cache_misses_total{api="QueryUsers"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"test_rate_limiter_cache_hits_total",
+		"test_rate_limiter_cache_misses_total",
+	))
+}
+
+func TestCollectorTenants(t *testing.T) {
+	collector := NewCollector("test")
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	manager := rate_limiter.NewLimiterManager(rate_limiter.WithMetrics(collector), rate_limiter.WithMaxTenantLimiters(2))
+	for _, tenant := range []string{"jane", "john", "jack"} {
+		manager.ForTenant(tenant, rate_limiter.QueryUsers)
+	}
+
+	expected := `
+# HELP test_rate_limiter_tenant_limiters Number of live limiters of the tenants.
+# TYPE test_rate_limiter_tenant_limiters gauge
+test_rate_limiter_tenant_limiters 2
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "test_rate_limiter_tenant_limiters"))
+}
@@ -0,0 +1,110 @@
+package rate_limiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+)
+
+type metricsRecorder struct {
+	mu        sync.Mutex
+	calls     int
+	errors    int
+	blocks    int
+	waits     []time.Duration
+	remaining int64
+}
+
+func (m *metricsRecorder) ObserveCall(api string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+}
+
+func (m *metricsRecorder) ObserveError(api string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors++
+}
+
+func (m *metricsRecorder) ObserveBlock(api string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blocks++
+}
+
+func (m *metricsRecorder) ObserveWait(api string, wait time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.waits = append(m.waits, wait)
+}
+
+func (m *metricsRecorder) SetRemaining(api string, remaining int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remaining = remaining
+}
+
+func TestWithMetrics(t *testing.T) {
+	metrics := &metricsRecorder{}
+	rLimit := NewRateLimiter(QueryUsers, WithMetrics(metrics))
+
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Reset: time.Now().Unix() + 60}}, nil
+	}))
+	assert.Error(t, rLimit.TryCallApi(func() (resp *stream.Response, err error) {
+		return nil, assert.AnError
+	}))
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	assert.Equal(t, 1, metrics.calls)
+	assert.Equal(t, 0, metrics.errors)
+	assert.Equal(t, 1, metrics.blocks)
+	assert.Len(t, metrics.waits, 1)
+	assert.Equal(t, int64(0), metrics.remaining)
+}
+
+func TestMetricsObserveAttempts(t *testing.T) {
+	metrics := &metricsRecorder{}
+	rLimit := NewRateLimiter(QueryUsers, WithMetrics(metrics),
+		WithClock(&instantClock{now: time.Unix(1000, 0)}),
+		WithRetry(RetryPolicy{MaxAttempts: 2}),
+		WithFaultInjector(FaultInjector{ServerError: 1}))
+
+	assert.Error(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		t.Error("the api is not called")
+		return nil, nil
+	}))
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	assert.Equal(t, 2, metrics.calls, "each attempt, the injected faults included")
+	assert.Equal(t, 2, metrics.errors)
+	assert.Equal(t, []time.Duration{0, 0}, metrics.waits)
+}
+
+func TestMetricsIgnoreCallsTurnedDown(t *testing.T) {
+	metrics := &metricsRecorder{}
+	manager := NewLimiterManager(WithMetrics(metrics), WithGlobalLimiter(WithMaxConcurrency(1)))
+	release := holdSlot(t, manager.Global())
+	defer release()
+
+	assert.Error(t, manager.For(QueryUsers).TryCallApi(func() (*stream.Response, error) {
+		t.Error("called beyond the global concurrency")
+		return nil, nil
+	}))
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	assert.Zero(t, metrics.calls)
+	assert.Zero(t, metrics.errors)
+	assert.Empty(t, metrics.waits)
+}
+
+func TestDefaultMetricsIsNop(t *testing.T) {
+	assert.Equal(t, nopMetrics{}, NewRateLimiter(QueryUsers).metrics)
+	assert.Equal(t, nopMetrics{}, (&RateLimiter{}).getMetrics())
+}
@@ -0,0 +1,77 @@
+package rate_limiter
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MissingInfoPolicy is how a limiter accounts the successful calls whose
+// response does not report any RateLimitInfo, e.g. of some SDK calls or of
+// mocked responses, see WithMissingInfoPolicy. The zero value is
+// AssumeAvailable.
+type MissingInfoPolicy struct {
+	exhaustedFor time.Duration
+	strategy     Strategy
+}
+
+// AssumeAvailable takes the quota as available: the calls are not blocked
+// until a response reports it exhausted. It is the default.
+func AssumeAvailable() MissingInfoPolicy {
+	return MissingInfoPolicy{}
+}
+
+// AssumeExhaustedFor takes the quota as exhausted for d after a response not
+// reporting the RateLimitInfo, blocking the api meanwhile, so that the calls
+// without info are spaced by d at least.
+func AssumeExhaustedFor(d time.Duration) MissingInfoPolicy {
+	return MissingInfoPolicy{exhaustedFor: d}
+}
+
+// FallbackStrategy shapes the calls through strategy, e.g. a TokenBucket, as
+// long as the latest response did not report the RateLimitInfo, on top of the
+// Strategy of the limiter, if any.
+func FallbackStrategy(strategy Strategy) MissingInfoPolicy {
+	return MissingInfoPolicy{strategy: strategy}
+}
+
+// WithMissingInfoPolicy sets how the responses not reporting the RateLimitInfo
+// are accounted, AssumeAvailable by default.
+func WithMissingInfoPolicy(policy MissingInfoPolicy) Option {
+	return func(r *RateLimiter) {
+		r.missing = policy
+	}
+}
+
+// missingStrategy returns the Strategy of FallbackStrategy while the latest
+// response did not report the RateLimitInfo, nil otherwise.
+func (r *RateLimiter) missingStrategy() Strategy {
+	if r.missing.strategy == nil || !r.noInfo.Load() {
+		return nil
+	}
+	return r.missing.strategy
+}
+
+// observeMissingInfo accounts a successful call, started at calledAt, whose
+// response did not report the RateLimitInfo, as set by WithMissingInfoPolicy.
+func (r *RateLimiter) observeMissingInfo(ctx context.Context, logger Logger, calledAt time.Time) {
+	r.noInfo.Store(true)
+	r.observe(calledAt, nil)
+	now := r.getClock().Now()
+	if r.strategy != nil {
+		r.strategy.Observe(now, nil, nil)
+	}
+	if r.missing.strategy != nil {
+		r.missing.strategy.Observe(now, nil, nil)
+	}
+	trace.SpanFromContext(ctx).AddEvent("no rate limit info")
+	logAttrs(ctx, logger, LevelTrace, "no rate limit info", []slog.Attr{
+		slog.String("api", r.apiName),
+	}, "No rate limit info reported for %s\n", r.apiName)
+	r.adapt(false)
+	if d := r.missing.exhaustedFor; d > 0 {
+		r.blockFor(ctx, logger, now.Add(d), d)
+	}
+}
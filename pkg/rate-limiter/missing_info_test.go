@@ -0,0 +1,66 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestMissingInfoAssumeAvailable(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{}, nil
+	}))
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return nil, nil
+	}), "a nil response")
+	require.NoError(t, rLimit.TryCallApi(func() (*stream.Response, error) {
+		return &stream.Response{}, nil
+	}))
+	assert.False(t, rLimit.IsBlocked())
+	assert.Equal(t, int64(-1), rLimit.Remaining())
+}
+
+func TestMissingInfoAssumeExhaustedFor(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithMissingInfoPolicy(AssumeExhaustedFor(5*time.Second)))
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{}, nil
+	}))
+	require.True(t, rLimit.IsBlocked())
+	var rateLimited ErrRateLimited
+	require.ErrorAs(t, rLimit.TryCallApi(nil), &rateLimited)
+	assert.Equal(t, time.Unix(1005, 0), rateLimited.ResetAt)
+
+	clock.Advance(5 * time.Second)
+	require.Eventually(t, func() bool { return !rLimit.IsBlocked() }, time.Second, time.Millisecond)
+}
+
+func TestMissingInfoFallbackStrategy(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithMaxConcurrency(5),
+		WithMissingInfoPolicy(FallbackStrategy(NewTokenBucket(1, 1))))
+	noInfo := func() (*stream.Response, error) {
+		return &stream.Response{}, nil
+	}
+	withInfo := func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 1000, Remaining: 1000, Reset: clock.Now().Unix() + 60}}, nil
+	}
+
+	require.NoError(t, rLimit.TryCallApi(withInfo))
+	require.NoError(t, rLimit.TryCallApi(noInfo), "not shaped while the info is reported")
+	require.NoError(t, rLimit.TryCallApi(noInfo), "the first token of the bucket")
+	var rateLimited ErrRateLimited
+	require.ErrorAs(t, rLimit.TryCallApi(noInfo), &rateLimited)
+	assert.Equal(t, time.Unix(1001, 0), rateLimited.ResetAt)
+
+	clock.Advance(time.Second)
+	require.NoError(t, rLimit.TryCallApi(withInfo))
+	require.NoError(t, rLimit.TryCallApi(withInfo), "no longer shaped")
+}
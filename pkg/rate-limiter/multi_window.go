@@ -0,0 +1,98 @@
+package rate_limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// Window is a limit of calls over a sliding period, see NewMultiWindow.
+type Window struct {
+	Limit  int
+	Period time.Duration
+}
+
+// MultiWindow is a Strategy enforcing several sliding windows at once, e.g.
+// the per-minute and per-hour limits Stream applies to some endpoints, while
+// the responses report a single one: a call is admitted once every window
+// permits it, that is at the earliest time the most constraining one does.
+type MultiWindow struct {
+	mu      sync.Mutex
+	windows []*SlidingWindow
+}
+
+var _ StrategyCanceler = (*MultiWindow)(nil)
+
+// NewMultiWindow returns a MultiWindow enforcing windows. As for
+// NewSlidingWindow, a limit lower than 1 is raised to 1.
+func NewMultiWindow(windows ...Window) *MultiWindow {
+	m := &MultiWindow{}
+	for _, w := range windows {
+		m.windows = append(m.windows, NewSlidingWindow(w.Limit, w.Period))
+	}
+	return m
+}
+
+// next returns when every window permits the next call: a window permitting
+// a call at some time still does later on, its calls only leaving it.
+func (m *MultiWindow) next(now time.Time) time.Time {
+	at := now
+	for _, w := range m.windows {
+		if next := w.next(now); next.After(at) {
+			at = next
+		}
+	}
+	return at
+}
+
+func (m *MultiWindow) add(at time.Time) {
+	for _, w := range m.windows {
+		w.add(at)
+	}
+}
+
+func (m *MultiWindow) Reserve(now time.Time) time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	at := m.next(now)
+	m.add(at)
+	return at
+}
+
+func (m *MultiWindow) TryReserve(now time.Time) (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	at := m.next(now)
+	if at.After(now) {
+		return at, false
+	}
+	m.add(at)
+	return at, true
+}
+
+func (m *MultiWindow) Cancel(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, w := range m.windows {
+		w.removeLatest()
+	}
+}
+
+func (m *MultiWindow) Observe(now time.Time, info *LimitInfo, err error) {}
+
+// Remaining returns the calls each window still admits at now, in the order
+// of the windows of NewMultiWindow, not counting the calls reserved later on.
+func (m *MultiWindow) Remaining(now time.Time) []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	remaining := make([]int, len(m.windows))
+	for i, w := range m.windows {
+		used := 0
+		for _, start := range w.starts {
+			if start.After(now.Add(-w.window)) && !start.After(now) {
+				used++
+			}
+		}
+		remaining[i] = max(w.limit-used, 0)
+	}
+	return remaining
+}
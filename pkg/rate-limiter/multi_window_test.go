@@ -0,0 +1,60 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestMultiWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	windows := NewMultiWindow(Window{Limit: 2, Period: time.Minute}, Window{Limit: 3, Period: time.Hour})
+
+	for i := 0; i < 2; i++ {
+		_, ok := windows.TryReserve(now)
+		require.True(t, ok, "call %d", i)
+	}
+	assert.Equal(t, []int{0, 1}, windows.Remaining(now.Add(30*time.Second)))
+	assert.Equal(t, []int{2, 1}, windows.Remaining(now.Add(time.Minute)))
+
+	// the minute window permits the third call first
+	at, ok := windows.TryReserve(now.Add(30 * time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, time.Unix(1060, 0), at)
+	assert.Equal(t, time.Unix(1060, 0), windows.Reserve(now))
+
+	// then the hour window is the most constraining one
+	assert.Equal(t, time.Unix(4600, 0), windows.Reserve(time.Unix(1100, 0)))
+	at, ok = windows.TryReserve(time.Unix(1100, 0))
+	assert.False(t, ok)
+	assert.Equal(t, time.Unix(4600, 0), at)
+	assert.Equal(t, []int{2, 0}, windows.Remaining(time.Unix(1200, 0)), "the reservation at 4600 not counted yet")
+}
+
+func TestMultiWindowLimiter(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock),
+		WithStrategy(NewMultiWindow(Window{Limit: 10, Period: time.Minute}, Window{Limit: 1, Period: time.Hour})))
+	plenty := func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 100, Remaining: 99, Reset: 1060}}, nil
+	}
+
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), plenty))
+	var rateLimited ErrRateLimited
+	assert.ErrorAs(t, rLimit.TryCallApi(plenty), &rateLimited)
+	assert.Equal(t, time.Unix(4600, 0), rateLimited.ResetAt)
+
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), plenty)
+	}()
+	blockUntil(t, clock, 1)
+	clock.Advance(time.Hour)
+	assert.NoError(t, receive(t, done))
+}
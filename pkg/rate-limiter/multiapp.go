@@ -0,0 +1,120 @@
+package rate_limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// AppMetrics is implemented by the Metrics able to label the measurements with
+// the Stream app they belong to, see MultiAppManager.
+type AppMetrics interface {
+	Metrics
+	// ForApp returns the Metrics of the app identified by appKey.
+	ForApp(appKey string) Metrics
+}
+
+// MultiAppManager holds one LimiterManager per Stream app, keyed by app key,
+// for services talking to several Stream apps: each app has its own quota,
+// hence its own limiters. The zero value is usable, building limiters with the
+// default options.
+type MultiAppManager struct {
+	opts    []Option
+	metrics Metrics
+	store   Store
+
+	mu       sync.Mutex
+	managers map[string]*LimiterManager
+	closed   bool
+}
+
+// NewMultiAppManager returns a MultiAppManager whose managers build their
+// limiters with opts. A Store set by WithStore is shared by the apps under a
+// key per app, and Metrics implementing AppMetrics are labelled by app.
+func NewMultiAppManager(opts ...Option) *MultiAppManager {
+	var probe RateLimiter
+	for _, opt := range opts {
+		opt(&probe)
+	}
+	return &MultiAppManager{
+		opts:     opts,
+		metrics:  probe.metrics,
+		store:    probe.store,
+		managers: make(map[string]*LimiterManager),
+	}
+}
+
+// For returns the LimiterManager of the app identified by appKey, creating it
+// on first use.
+func (m *MultiAppManager) For(appKey string) *LimiterManager {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if manager, ok := m.managers[appKey]; ok {
+		return manager
+	}
+	if m.managers == nil {
+		m.managers = make(map[string]*LimiterManager)
+	}
+	opts := append([]Option(nil), m.opts...)
+	if metrics, ok := m.metrics.(AppMetrics); ok {
+		opts = append(opts, WithMetrics(metrics.ForApp(appKey)))
+	}
+	if m.store != nil {
+		opts = append(opts, WithStore(appStore{store: m.store, prefix: appKey + ":", usage: &usageCounter{}}))
+	}
+	manager := NewLimiterManager(opts...)
+	if m.closed {
+		manager.shutdown()
+	}
+	m.managers[appKey] = manager
+	return manager
+}
+
+// Close closes the managers of every app, as LimiterManager.Close. Managers
+// requested afterwards are closed as well.
+func (m *MultiAppManager) Close(ctx context.Context) error {
+	m.mu.Lock()
+	m.closed = true
+	var idle []<-chan struct{}
+	for _, manager := range m.managers {
+		idle = append(idle, manager.shutdown()...)
+	}
+	m.mu.Unlock()
+	return waitIdle(ctx, idle)
+}
+
+// appStore isolates the state of an app in a Store shared by several apps.
+type appStore struct {
+	store  Store
+	prefix string
+	usage  *usageCounter // when the shared store is not a BudgetStore
+}
+
+func (s appStore) Take(ctx context.Context, api string, now time.Time) (time.Time, error) {
+	return s.store.Take(ctx, s.prefix+api, now)
+}
+
+// TakeN falls back to Take when the shared store is not a CostStore.
+func (s appStore) TakeN(ctx context.Context, api string, now time.Time, n int) (time.Time, error) {
+	if store, ok := s.store.(CostStore); ok {
+		return store.TakeN(ctx, s.prefix+api, now, n)
+	}
+	return s.store.Take(ctx, s.prefix+api, now)
+}
+
+// AddUsage counts the usage in process when the shared store is not a
+// BudgetStore.
+func (s appStore) AddUsage(ctx context.Context, key string, n int64, expiresAt time.Time) (int64, error) {
+	if store, ok := s.store.(BudgetStore); ok {
+		return store.AddUsage(ctx, s.prefix+key, n, expiresAt)
+	}
+	return s.usage.AddUsage(ctx, key, n, expiresAt)
+}
+
+func (s appStore) Observe(ctx context.Context, api string, info LimitInfo, now time.Time) error {
+	return s.store.Observe(ctx, s.prefix+api, info, now)
+}
+
+func (s appStore) Load(ctx context.Context, api string) (State, error) {
+	return s.store.Load(ctx, s.prefix+api)
+}
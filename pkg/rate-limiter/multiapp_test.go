@@ -0,0 +1,49 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiAppManager(t *testing.T) {
+	store := NewMemoryStore()
+	apps := NewMultiAppManager(WithStore(store))
+
+	appA := apps.For("app-a")
+	assert.Same(t, appA, apps.For("app-a"))
+	assert.NotSame(t, appA.For(QueryUsers), apps.For("app-b").For(QueryUsers))
+
+	reset := time.Now().Add(time.Minute).Unix()
+	assert.NoError(t, appA.For(QueryUsers).TryCallApi(func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: reset}}, nil
+	}))
+	assert.True(t, appA.For(QueryUsers).IsBlocked())
+
+	// the exhausted quota of app-a does not block app-b, sharing the store
+	assert.NoError(t, apps.For("app-b").For(QueryUsers).TryCallApi(func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 9, Reset: reset}}, nil
+	}))
+	stateA, err := store.Load(context.Background(), "app-a:QueryUsers")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), stateA.Remaining)
+	stateB, err := store.Load(context.Background(), "app-b:QueryUsers")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9), stateB.Remaining)
+}
+
+func TestMultiAppManagerClose(t *testing.T) {
+	var apps MultiAppManager
+	rLimit := apps.For("app-a").For(QueryUsers)
+	assert.NoError(t, apps.Close(context.Background()))
+
+	apiCall := func() (resp *stream.Response, err error) {
+		t.Error("call invoked after Close")
+		return nil, nil
+	}
+	assert.ErrorIs(t, rLimit.TryCallApi(apiCall), ErrClosed)
+	assert.ErrorIs(t, apps.For("app-b").For(QueryUsers).TryCallApi(apiCall), ErrClosed, "apps requested after Close")
+}
@@ -0,0 +1,178 @@
+package rate_limiter
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Option configures a RateLimiter built by NewRateLimiter.
+type Option func(*RateLimiter)
+
+// WithLogger sets the logger used when no logger is passed to the call.
+func WithLogger(logger Logger) Option {
+	return func(r *RateLimiter) {
+		if logger != nil {
+			r.logger = logger
+		}
+	}
+}
+
+// WithMaxConcurrency sets how many calls may be in flight at the same time,
+// i.e. the capacity of the token channel. Values lower than 1 are ignored.
+// Once the rate limit is exhausted all of them are blocked until reset.
+func WithMaxConcurrency(n int) Option {
+	return func(r *RateLimiter) {
+		if n > 0 {
+			r.slots = n
+		}
+	}
+}
+
+// WithHeadroom blocks calls until reset once the remaining calls drop to n,
+// instead of 0, reserving the last n calls of the window to other services
+// sharing the same Stream app. Negative values are ignored.
+func WithHeadroom(n int) Option {
+	return func(r *RateLimiter) {
+		if n >= 0 {
+			r.headroom.Store(int64(n))
+		}
+	}
+}
+
+// WithPacing spreads the remaining calls evenly until the reset, delaying each
+// call by the time left in the window divided by the remaining calls, instead
+// of bursting until the quota is exhausted and then stalling until the reset.
+// It is the Strategy of the limiter, see WithStrategy.
+func WithPacing() Option {
+	return WithStrategy(&pacer{})
+}
+
+// WithSlots is an alias of WithMaxConcurrency.
+//
+// Deprecated: use WithMaxConcurrency.
+func WithSlots(n int) Option {
+	return WithMaxConcurrency(n)
+}
+
+// WithClock replaces the time source, mostly useful in tests with the fake
+// clock of the clocktest package.
+func WithClock(clock Clock) Option {
+	return func(r *RateLimiter) {
+		if clock != nil {
+			r.clock = clock
+		}
+	}
+}
+
+// WithMetrics reports calls, errors, blocks, waits and remaining quota to metrics.
+func WithMetrics(metrics Metrics) Option {
+	return func(r *RateLimiter) {
+		if metrics != nil {
+			r.metrics = metrics
+		}
+	}
+}
+
+// WithTracerProvider creates a span per call with the tracers of provider,
+// with "queued", "blocked" and "resumed" events and the rate limit info.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(r *RateLimiter) {
+		if provider != nil {
+			r.tracer = provider.Tracer(tracerName)
+		}
+	}
+}
+
+// WithMaxWait bounds how long a call waits for a slot or for the rate limit
+// reset, returning ErrWaitTimeout afterwards. Zero, the default, waits forever.
+func WithMaxWait(d time.Duration) Option {
+	return func(r *RateLimiter) {
+		if d > 0 {
+			r.maxWait.Store(int64(d))
+		}
+	}
+}
+
+// WithRetry retries failed calls according to policy. Retries go through the
+// limiter like any other call, TryCallApi never retries.
+func WithRetry(policy RetryPolicy) Option {
+	return func(r *RateLimiter) {
+		if policy.MaxAttempts > 1 {
+			r.retry = &policy
+			r.retries = nil
+			if policy.Budget.Ratio > 0 {
+				r.retries = newRetryBudget(policy.Budget)
+			}
+		}
+	}
+}
+
+// WithMaxQueueDepth bounds how many callers wait for a slot: once n callers
+// are queued, further calls fail right away with ErrQueueFull instead of piling
+// up during an extended outage. Zero, the default, queues every caller.
+func WithMaxQueueDepth(n int) Option {
+	return func(r *RateLimiter) {
+		if n > 0 {
+			r.queue.maxDepth = n
+		}
+	}
+}
+
+// WithCircuitBreaker fails calls fast with ErrCircuitOpen once the api keeps
+// failing, instead of burning quota on a dead upstream.
+func WithCircuitBreaker(config CircuitBreakerConfig) Option {
+	return func(r *RateLimiter) {
+		r.breaker = newCircuitBreaker(config)
+	}
+}
+
+// WithStore shares the rate limit state through store, so that limiters of the
+// same api in different processes do not collectively exceed the limit. It
+// replaces the MemoryStore each limiter gets by default.
+func WithStore(store Store) Option {
+	return func(r *RateLimiter) {
+		if store != nil {
+			r.store = store
+		}
+	}
+}
+
+// WithBucket makes a LimiterManager share the limiter of bucket with apiName,
+// for the endpoints counting against the same server side rate limit, e.g.
+// WithBucket(AddMembers, AddMembers.Bucket()), see Endpoints. It has no effect
+// on NewRateLimiter.
+func WithBucket(apiName, bucket GetStreamApiName) Option {
+	return func(r *RateLimiter) {
+		if r.buckets == nil {
+			r.buckets = make(map[GetStreamApiName]GetStreamApiName)
+		}
+		r.buckets[apiName] = bucket
+	}
+}
+
+// WithEndpointBuckets applies WithBucket to every known endpoint sharing the
+// rate limit of another one, as described by Endpoints.
+func WithEndpointBuckets() Option {
+	return func(r *RateLimiter) {
+		for _, endpoint := range endpoints {
+			if endpoint.Bucket != endpoint.Name {
+				WithBucket(endpoint.Name, endpoint.Bucket)(r)
+			}
+		}
+	}
+}
+
+// WithApiOptions applies opts to the limiter of apiName only, e.g. to give an
+// endpoint more concurrency than the others of a LimiterManager. The options
+// of the limiter of a bucket are those of the bucket, see WithBucket.
+func WithApiOptions(apiName GetStreamApiName, opts ...Option) Option {
+	return func(r *RateLimiter) {
+		if r.apiName != string(apiName) {
+			return
+		}
+		for _, opt := range opts {
+			opt(r)
+		}
+	}
+}
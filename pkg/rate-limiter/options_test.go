@@ -0,0 +1,99 @@
+package rate_limiter
+
+import (
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+
+	assert.Equal(t, string(QueryUsers), rLimit.apiName)
+	assert.Equal(t, 1, cap(rLimit.tokens()))
+	assert.NotNil(t, rLimit.logger)
+	assert.Equal(t, realClock{}, rLimit.clock)
+}
+
+func TestNewRateLimiterOptions(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		wantCap  int
+		validate func(t *testing.T, r *RateLimiter)
+	}{
+		{
+			name:    "With max concurrency",
+			opts:    []Option{WithMaxConcurrency(3)},
+			wantCap: 3,
+		},
+		{
+			name:    "With slots",
+			opts:    []Option{WithSlots(2)},
+			wantCap: 2,
+		},
+		{
+			name:    "Invalid slots are ignored",
+			opts:    []Option{WithMaxConcurrency(0)},
+			wantCap: 1,
+		},
+		{
+			name:    "With logger",
+			opts:    []Option{WithLogger(logger)},
+			wantCap: 1,
+			validate: func(t *testing.T, r *RateLimiter) {
+				assert.Same(t, logger, r.logger)
+			},
+		},
+		{
+			name:    "With clock",
+			opts:    []Option{WithClock(clock)},
+			wantCap: 1,
+			validate: func(t *testing.T, r *RateLimiter) {
+				assert.Same(t, clock, r.clock)
+			},
+		},
+		{
+			name:    "With headroom",
+			opts:    []Option{WithHeadroom(5), WithHeadroom(-1)},
+			wantCap: 1,
+			validate: func(t *testing.T, r *RateLimiter) {
+				assert.Equal(t, int64(5), r.headroom.Load())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rLimit := NewRateLimiter(CreateChannel, tt.opts...)
+			assert.Equal(t, tt.wantCap, cap(rLimit.tokens()))
+			if tt.validate != nil {
+				tt.validate(t, rLimit)
+			}
+		})
+	}
+}
+
+func TestRateLimiterUsesInjectedClock(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryChannel, WithClock(clock), WithLogger(logger))
+
+	err := rLimit.CallApiAndBlockOnRateLimit(nil, func() (resp *stream.Response, err error) {
+		return &stream.Response{
+			RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Reset: 1005},
+		}, nil
+	})
+
+	assert.NoError(t, err)
+	blockUntil(t, clock, 1)
+	deadline, _ := clock.NextDeadline()
+	assert.Equal(t, time.Unix(1005, 0), deadline)
+}
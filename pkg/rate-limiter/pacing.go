@@ -0,0 +1,66 @@
+package rate_limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// pacer is the Strategy of WithPacing, spreading the remaining calls of a
+// window evenly until its reset, scheduling each call one interval after the
+// previous one.
+type pacer struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time // start of the latest call
+	next     time.Time
+}
+
+var _ StrategyCanceler = (*pacer)(nil)
+
+// Observe derives the interval between calls from the latest response,
+// observed at now, rescheduling the next call accordingly.
+func (p *pacer) Observe(now time.Time, info *LimitInfo, err error) {
+	if info == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	window := time.Unix(info.Reset, 0).Sub(now)
+	if window <= 0 || info.Remaining <= 0 {
+		// the window is over or exhausted, the barrier takes over
+		p.interval = 0
+	} else {
+		p.interval = window / time.Duration(info.Remaining)
+	}
+	p.next = p.last.Add(p.interval)
+}
+
+func (p *pacer) Reserve(now time.Time) time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	at := now
+	if p.next.After(at) {
+		at = p.next
+	}
+	p.last = at
+	p.next = at.Add(p.interval)
+	return at
+}
+
+func (p *pacer) TryReserve(now time.Time) (time.Time, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.next.After(now) {
+		return p.next, false
+	}
+	p.last = now
+	p.next = now.Add(p.interval)
+	return now, true
+}
+
+// Cancel gives the start of the latest call to the next one.
+func (p *pacer) Cancel(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.next = p.last
+}
@@ -0,0 +1,65 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestPacer(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var p pacer
+
+	assert.Equal(t, now, p.Reserve(now), "no interval before the first response")
+	assert.Equal(t, now, p.Reserve(now))
+	// the response of the latest call sets the interval after it
+	p.Observe(now, &LimitInfo{Remaining: 4, Reset: 1060}, nil)
+	assert.Equal(t, 15*time.Second, p.interval)
+
+	assert.Equal(t, now.Add(15*time.Second), p.Reserve(now))
+	next, ok := p.TryReserve(now)
+	assert.False(t, ok)
+	assert.Equal(t, now.Add(30*time.Second), next)
+	_, ok = p.TryReserve(next)
+	assert.True(t, ok)
+
+	p.Observe(now, &LimitInfo{Remaining: 0, Reset: 1060}, nil)
+	assert.Zero(t, p.interval, "the barrier handles exhausted windows")
+	p.Observe(now, nil, nil)
+	assert.Zero(t, p.interval)
+}
+
+func TestWithPacing(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithPacing())
+	remaining := func(remaining int64) GetStreamApiCaller {
+		return func() (resp *stream.Response, err error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: remaining, Reset: 1060}}, nil
+		}
+	}
+
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), remaining(4)))
+
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), remaining(3))
+	}()
+	// 60 seconds left for 4 calls
+	blockUntil(t, clock, 1)
+	deadline, _ := clock.NextDeadline()
+	assert.Equal(t, time.Unix(1015, 0), deadline)
+	clock.Advance(15 * time.Second)
+	assert.NoError(t, receive(t, done))
+
+	// 45 seconds left for 3 calls
+	var rateLimited ErrRateLimited
+	assert.ErrorAs(t, rLimit.TryCallApi(remaining(2)), &rateLimited)
+	assert.Equal(t, time.Unix(1030, 0), rateLimited.ResetAt)
+	clock.Advance(15 * time.Second)
+	assert.NoError(t, rLimit.TryCallApi(remaining(2)))
+}
@@ -0,0 +1,123 @@
+package rate_limiter
+
+import (
+	"context"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+)
+
+// Default page sizes of the paginated queries of Client, the largest allowed
+// by Stream.
+const (
+	DefaultUsersPageSize    = 100
+	DefaultChannelsPageSize = 30
+)
+
+// PageFetcher fetches the page of up to limit items starting at offset,
+// alongside the response carrying the rate limit info.
+type PageFetcher[T any] func(offset, limit int) ([]T, *stream.Response, error)
+
+// Pager fetches the pages of a paginated query one after the other through a
+// limiter, waiting for the rate limit resets as any other call. The pages are
+// over once a page holds fewer items than the page size.
+type Pager[T any] struct {
+	ctx    context.Context
+	r      *RateLimiter
+	fetch  PageFetcher[T]
+	limit  int
+	offset int
+	page   []T
+	done   bool
+	err    error
+}
+
+// Paginate returns a Pager fetching pages of limit items through r.
+func Paginate[T any](ctx context.Context, r *RateLimiter, limit int, fetch PageFetcher[T]) *Pager[T] {
+	return &Pager[T]{ctx: ctx, r: r, fetch: fetch, limit: max(limit, 1)}
+}
+
+// Next fetches the next page, reporting false once the pages are over or a
+// fetch failed, see Err.
+func (p *Pager[T]) Next() bool {
+	if p.done {
+		return false
+	}
+	page, err := Call(p.ctx, p.r, func() ([]T, *stream.Response, error) {
+		return p.fetch(p.offset, p.limit)
+	})
+	if err != nil {
+		p.done, p.err, p.page = true, err, nil
+		return false
+	}
+	p.offset += len(page)
+	p.done = len(page) < p.limit
+	p.page = page
+	return len(page) > 0
+}
+
+// Page returns the page fetched by the latest Next.
+func (p *Pager[T]) Page() []T {
+	return p.page
+}
+
+// Err returns the error which stopped the pages, if any.
+func (p *Pager[T]) Err() error {
+	return p.err
+}
+
+// Items streams the items of the pages left on a channel, closed once the
+// pages are over, a fetch failed or the context of the Pager is done, see
+// Err. It must not be mixed with Next.
+func (p *Pager[T]) Items() <-chan T {
+	items := make(chan T)
+	go func() {
+		defer close(items)
+		for p.Next() {
+			for _, item := range p.page {
+				select {
+				case items <- item:
+				case <-p.ctx.Done():
+					p.err = p.ctx.Err()
+					return
+				}
+			}
+		}
+	}()
+	return items
+}
+
+// QueryUsersPages pages through the users matching q, from q.Offset, by pages
+// of q.Limit users, DefaultUsersPageSize when not set.
+func (c *Client) QueryUsersPages(ctx context.Context, q *stream.QueryOption, sorters ...*stream.SortOption) *Pager[*stream.User] {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultUsersPageSize
+	}
+	return Paginate(ctx, c.manager.For(QueryUsers), limit, func(offset, limit int) ([]*stream.User, *stream.Response, error) {
+		page := *q
+		page.Offset, page.Limit = q.Offset+offset, limit
+		resp, err := c.client.QueryUsers(ctx, &page, sorters...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp.Users, &resp.Response, nil
+	})
+}
+
+// QueryChannelsPages pages through the channels matching q, from q.Offset, by
+// pages of q.Limit channels, DefaultChannelsPageSize when not set.
+func (c *Client) QueryChannelsPages(ctx context.Context, q *stream.QueryOption, sorters ...*stream.SortOption) *Pager[*stream.Channel] {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultChannelsPageSize
+	}
+	return Paginate(ctx, c.manager.For(QueryChannels), limit, func(offset, limit int) ([]*stream.Channel, *stream.Response, error) {
+		page := *q
+		page.Offset, page.Limit = q.Offset+offset, limit
+		resp, err := c.client.QueryChannels(ctx, &page, sorters...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp.Channels, &resp.Response, nil
+	})
+}
@@ -0,0 +1,102 @@
+package rate_limiter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+// pages returns a PageFetcher over n ints, reporting remaining calls.
+func pages(n int, info func() *stream.RateLimitInfo) PageFetcher[int] {
+	return func(offset, limit int) ([]int, *stream.Response, error) {
+		var page []int
+		for i := offset; i < n && i < offset+limit; i++ {
+			page = append(page, i)
+		}
+		return page, &stream.Response{RateLimitInfo: info()}, nil
+	}
+}
+
+func TestPager(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Unix()
+	pager := Paginate(context.Background(), NewRateLimiter(QueryUsers), 10, pages(25, func() *stream.RateLimitInfo {
+		return &stream.RateLimitInfo{Remaining: 100, Reset: reset}
+	}))
+
+	var sizes []int
+	for pager.Next() {
+		sizes = append(sizes, len(pager.Page()))
+	}
+	require.NoError(t, pager.Err())
+	assert.Equal(t, []int{10, 10, 5}, sizes)
+	assert.False(t, pager.Next())
+}
+
+func TestPagerWaitsForReset(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+	pager := Paginate(context.Background(), rLimit, 2, pages(4, func() *stream.RateLimitInfo {
+		return &stream.RateLimitInfo{Remaining: 0, Reset: clock.Now().Unix() + 60}
+	}))
+
+	var items []int
+	done := make(chan error)
+	go func() {
+		for item := range pager.Items() {
+			items = append(items, item)
+		}
+		done <- pager.Err()
+	}()
+
+	// the first page exhausts the window
+	blockUntil(t, clock, 1)
+	clock.Advance(time.Minute)
+	blockUntil(t, clock, 1)
+	clock.Advance(time.Minute)
+	require.NoError(t, receive(t, done))
+	assert.Equal(t, []int{0, 1, 2, 3}, items)
+}
+
+func TestPagerError(t *testing.T) {
+	pager := Paginate(context.Background(), NewRateLimiter(QueryUsers), 10, func(offset, limit int) ([]int, *stream.Response, error) {
+		return nil, nil, assert.AnError
+	})
+	assert.False(t, pager.Next())
+	assert.ErrorIs(t, pager.Err(), assert.AnError)
+	assert.Nil(t, pager.Page())
+}
+
+func TestQueryUsersPages(t *testing.T) {
+	client := newTestStreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var query struct {
+			Limit  int `json:"limit"`
+			Offset int `json:"offset"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(r.URL.Query().Get("payload")), &query))
+		w.Header().Set(stream.HeaderRateRemaining, "50")
+		w.Header().Set(stream.HeaderRateReset, strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+		var users []map[string]string
+		for i := query.Offset; i < 5 && i < query.Offset+query.Limit; i++ {
+			users = append(users, map[string]string{"id": fmt.Sprint("user-", i)})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"users": users})
+	})
+	wrapped := WrapClient(client)
+
+	var ids []string
+	for user := range wrapped.QueryUsersPages(context.Background(), &stream.QueryOption{Offset: 1, Limit: 2}).Items() {
+		ids = append(ids, user.ID)
+	}
+	assert.Equal(t, []string{"user-1", "user-2", "user-3", "user-4"}, ids)
+	assert.Equal(t, int64(50), wrapped.Manager().For(QueryUsers).Remaining())
+}
@@ -0,0 +1,51 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+)
+
+// ErrCallPanicked is reported, through a *PanicError, by the calls whose api
+// call panicked.
+var ErrCallPanicked = errors.New("call panicked")
+
+// PanicError is returned by a call whose api call panicked, carrying the value
+// passed to panic and the stack of the goroutine at that time. The limiter
+// recovers the panic, so that the slot of the call is released and the other
+// calls go on. It unwraps to ErrCallPanicked, and to the panic value when it is
+// an error.
+type PanicError struct {
+	ApiName string
+	Value   any
+	Stack   []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v on %s: %v", ErrCallPanicked, e.ApiName, e.Value)
+}
+
+func (e *PanicError) Unwrap() []error {
+	if err, ok := e.Value.(error); ok {
+		return []error{ErrCallPanicked, err}
+	}
+	return []error{ErrCallPanicked}
+}
+
+// safeCall runs apiCall, turning a panic into a *PanicError.
+func (r *RateLimiter) safeCall(ctx context.Context, logger Logger, apiCall limitedCall) (info LimitInfo, ok bool, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			panicErr := &PanicError{ApiName: r.apiName, Value: v, Stack: debug.Stack()}
+			logAttrs(ctx, logger, slog.LevelError, "api call panicked", []slog.Attr{
+				slog.String("api", r.apiName),
+				slog.Any("panic", v),
+				slog.String("stack", string(panicErr.Stack)),
+			}, "Api call of %s panicked: %v\n%s", r.apiName, v, panicErr.Stack)
+			info, ok, err = LimitInfo{}, false, panicErr
+		}
+	}()
+	return apiCall.call()
+}
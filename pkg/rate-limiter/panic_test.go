@@ -0,0 +1,52 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPanickingCallReleasesSlot(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	err := rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		panic("boom")
+	})
+
+	var panicErr *PanicError
+	require.ErrorAs(t, err, &panicErr)
+	assert.ErrorIs(t, err, ErrCallPanicked)
+	assert.Equal(t, "boom", panicErr.Value)
+	assert.Contains(t, string(panicErr.Stack), "panic_test.go")
+	assert.EqualError(t, err, "call panicked on QueryUsers: boom")
+
+	// the slot is free again
+	assert.NoError(t, rLimit.TryCallApi(func() (*stream.Response, error) {
+		return &stream.Response{}, nil
+	}))
+}
+
+func TestPanicErrorUnwrapsErrorValue(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	err := rLimit.TryCallApi(func() (*stream.Response, error) {
+		panic(assert.AnError)
+	})
+	assert.ErrorIs(t, err, ErrCallPanicked)
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestPanickingCallThroughLevels(t *testing.T) {
+	manager := NewLimiterManager(WithGlobalLimiter())
+	err := manager.For(QueryUsers).CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		panic("boom")
+	})
+	assert.ErrorIs(t, err, ErrCallPanicked)
+
+	for _, r := range []*RateLimiter{manager.For(QueryUsers), manager.Global()} {
+		assert.NoError(t, r.TryCallApi(func() (*stream.Response, error) {
+			return &stream.Response{}, nil
+		}), r.apiName)
+	}
+}
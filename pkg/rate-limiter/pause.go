@@ -0,0 +1,113 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// ErrPaused is wrapped by the ErrRateLimited of the calls of TryCallApi turned
+// down while the limiter is paused, see Pause.
+var ErrPaused = errors.New("paused")
+
+// Pause blocks every call of the api until Resume, e.g. to freeze the writes
+// to Stream from a runbook during an incident: the calls wait as for a rate
+// limit reset, honouring WithMaxWait and their context, while TryCallApi
+// returns an ErrRateLimited wrapping ErrPaused. The calls in flight are not
+// interrupted. The Blocked event of a pause has no ResumeAt.
+func (r *RateLimiter) Pause() {
+	clock := r.getClock()
+	r.mu.Lock()
+	if r.closed || r.paused {
+		r.mu.Unlock()
+		return
+	}
+	r.paused = true
+	// the reset of the current block, if any, is resumed by Resume
+	r.pausedReset = r.resetAt
+	r.resetAt = time.Time{}
+	r.stopResetLocked()
+	wasBlocked := r.blocked != nil
+	if !wasBlocked {
+		r.blocked = make(chan struct{})
+		r.blockedAt = clock.Now()
+	}
+	r.mu.Unlock()
+
+	if !wasBlocked {
+		r.getMetrics().ObserveBlock(r.apiName)
+		r.recordBlock()
+		r.fireBlocked(time.Time{})
+		r.emit(Event{Type: Blocked})
+	}
+	logAttrs(context.Background(), r.getLogger(nil), slog.LevelWarn, "api paused", []slog.Attr{
+		slog.String("api", r.apiName),
+	}, "Pausing api %s\n", r.apiName)
+}
+
+// Resume lifts Pause, resuming the waiting calls, unless a rate limit reset
+// still blocks them, in which case they resume at the reset.
+func (r *RateLimiter) Resume() {
+	ctx, logger := context.Background(), r.getLogger(nil)
+	clock := r.getClock()
+	r.mu.Lock()
+	if !r.paused {
+		r.mu.Unlock()
+		return
+	}
+	r.paused = false
+	resumeAt := r.pausedReset
+	r.pausedReset = time.Time{}
+	gen := r.resetGen
+	if wait := resumeAt.Sub(clock.Now()); wait > 0 && !r.closed {
+		r.resetAt = resumeAt
+		r.scheduleResetLocked(ctx, logger, clock, wait)
+		r.mu.Unlock()
+		logAttrs(ctx, logger, slog.LevelWarn, "api resumed at reset", []slog.Attr{
+			slog.String("api", r.apiName),
+			slog.Int64("reset", resumeAt.Unix()),
+		}, "Resuming api %s at reset %v\n", r.apiName, resumeAt.UTC())
+		return
+	}
+	r.mu.Unlock()
+	logAttrs(ctx, logger, slog.LevelWarn, "api resumed", []slog.Attr{
+		slog.String("api", r.apiName),
+	}, "Resuming api %s\n", r.apiName)
+	r.unblock(ctx, logger, gen)
+}
+
+// Paused reports whether the limiter is paused, see Pause.
+func (r *RateLimiter) Paused() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.paused
+}
+
+// SetRemaining overrides the remaining calls of the current window and its
+// reset, as if reported by a response, e.g. to throttle an api Stream is known
+// to be struggling with. The api is blocked until reset when remaining does
+// not cover the headroom, and unblocked otherwise; a paused api stays paused.
+// The Store of the limiter is updated too.
+func (r *RateLimiter) SetRemaining(ctx context.Context, remaining int64, reset time.Time) {
+	logger := r.getLogger(nil)
+	info, _ := r.lastInfo.Load()
+	info.Remaining = remaining
+	// as reported by the server, see ObserveServerTime
+	info.Reset = reset.Add(r.skew.get()).Unix()
+	r.observeInfo(&info)
+	r.observeStore(ctx, logger, &info)
+	logAttrs(ctx, logger, slog.LevelWarn, "remaining calls overridden", []slog.Attr{
+		slog.String("api", r.apiName),
+		slog.Int64("remaining", remaining),
+		slog.Int64("reset", reset.Unix()),
+	}, "Overriding remaining calls of %s to %d until %v\n", r.apiName, remaining, reset.UTC())
+	if r.exhausted(remaining, 1) {
+		r.block(ctx, logger, info.Reset)
+		return
+	}
+	r.mu.Lock()
+	gen := r.resetGen
+	r.mu.Unlock()
+	r.unblock(ctx, logger, gen)
+}
@@ -0,0 +1,134 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestPauseResume(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	events := rLimit.Events()
+
+	rLimit.Pause()
+	rLimit.Pause()
+	assert.True(t, rLimit.Paused())
+	assert.True(t, rLimit.IsBlocked())
+	event := <-events
+	assert.Equal(t, Blocked, event.Type)
+	assert.True(t, event.ResumeAt.IsZero())
+
+	err := rLimit.TryCallApi(okCall)
+	assert.ErrorIs(t, err, ErrPaused)
+	assert.ErrorIs(t, err, ErrRateLimited{})
+	assert.ErrorIs(t, rLimit.Ready(), ErrPaused)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), okCall)
+	}()
+	require.Eventually(t, func() bool { return len(rLimit.tokens()) == 1 }, time.Second, time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("called while paused: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	rLimit.Resume()
+	assert.False(t, rLimit.Paused())
+	require.NoError(t, receive(t, done))
+	assert.False(t, rLimit.IsBlocked())
+	assert.NoError(t, rLimit.TryCallApi(okCall))
+}
+
+func TestPauseRejected(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	events := rLimit.Events()
+	rLimit.Pause()
+	assert.Equal(t, Blocked, (<-events).Type)
+
+	assert.ErrorIs(t, rLimit.TryCallApi(okCall), ErrPaused)
+	event := <-events
+	assert.Equal(t, Rejected, event.Type)
+	assert.ErrorIs(t, event.Err, ErrPaused)
+	assert.Equal(t, int64(1), rLimit.Stats().LastMinute.Rejections)
+}
+
+func TestPauseMaxWait(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithMaxWait(time.Millisecond))
+	rLimit.Pause()
+	assert.ErrorIs(t, rLimit.CallApiWithContext(context.Background(), okCall), ErrWaitTimeout)
+}
+
+func TestResumeKeepsResetBlock(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1060}}, nil
+	}))
+	require.True(t, rLimit.IsBlocked())
+
+	rLimit.Pause()
+	clock.Advance(30 * time.Second)
+	assert.True(t, rLimit.IsBlocked(), "the reset does not lift a pause")
+	assert.Equal(t, 0, clock.Waiters(), "no reset timer while paused")
+
+	rLimit.Resume()
+	assert.True(t, rLimit.IsBlocked(), "blocked until the reset")
+	var limited ErrRateLimited
+	require.ErrorAs(t, rLimit.TryCallApi(okCall), &limited)
+	assert.Equal(t, time.Unix(1060, 0), limited.ResetAt)
+	assert.False(t, errors.Is(limited, ErrPaused))
+
+	clock.Advance(30 * time.Second)
+	assert.False(t, rLimit.IsBlocked())
+}
+
+func TestPauseDefersNewBlocks(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+	rLimit.Pause()
+
+	rLimit.SetRemaining(context.Background(), 0, time.Unix(1060, 0))
+	assert.True(t, rLimit.Paused())
+	assert.Equal(t, 0, clock.Waiters())
+	rLimit.Resume()
+	assert.Equal(t, time.Unix(1060, 0), rLimit.getResetAt(), "blocked by the reset set while paused")
+
+	rLimit.Pause()
+	rLimit.SetRemaining(context.Background(), 50, time.Unix(1060, 0))
+	assert.True(t, rLimit.Paused(), "stays paused")
+	rLimit.Resume()
+	assert.False(t, rLimit.IsBlocked(), "no longer exhausted")
+}
+
+func TestSetRemaining(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	store := NewMemoryStore()
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithHeadroom(5), WithStore(store))
+
+	rLimit.SetRemaining(context.Background(), 20, time.Unix(1060, 0))
+	assert.Equal(t, int64(20), rLimit.Remaining())
+	assert.Equal(t, time.Unix(1060, 0), rLimit.ResetAt())
+	assert.False(t, rLimit.IsBlocked())
+	state, err := store.Load(context.Background(), string(QueryUsers))
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), state.Remaining, "store updated")
+
+	rLimit.SetRemaining(context.Background(), 5, time.Unix(1060, 0))
+	assert.True(t, rLimit.IsBlocked(), "within the headroom")
+	assert.Equal(t, time.Unix(1060, 0), rLimit.getResetAt())
+
+	rLimit.SetRemaining(context.Background(), 100, time.Unix(1120, 0))
+	assert.False(t, rLimit.IsBlocked(), "unblocked")
+	assert.NoError(t, rLimit.TryCallApi(okCall))
+	clock.Advance(time.Minute)
+	assert.False(t, rLimit.IsBlocked())
+}
@@ -0,0 +1,153 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrPoolClosed is returned by Pool.Submit once the pool is closed.
+var ErrPoolClosed = errors.New("pool closed")
+
+// Pool runs the jobs submitted to its queue through a limiter with up to
+// WithWorkers workers. The workers scale with the quota: no more workers than
+// the remaining calls reported by the latest response are running, down to a
+// single one while the api is blocked, and all of them again once the window
+// is over.
+type Pool struct {
+	r       *RateLimiter
+	workers int
+
+	mu      sync.Mutex
+	cond    sync.Cond
+	queue   []poolJob
+	running int
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+type poolJob struct {
+	ctx     context.Context
+	apiCall GetStreamApiCaller
+	done    chan error
+}
+
+// PoolOption configures NewPool.
+type PoolOption func(*Pool)
+
+// WithWorkers sets the largest number of workers of the pool, the concurrency
+// of the limiter by default. Values lower than 1 are ignored.
+func WithWorkers(n int) PoolOption {
+	return func(p *Pool) {
+		if n > 0 {
+			p.workers = n
+		}
+	}
+}
+
+// NewPool returns a Pool running its jobs through r, with its workers started.
+func NewPool(r *RateLimiter, opts ...PoolOption) *Pool {
+	p := &Pool{r: r, workers: r.Concurrency()}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.cond.L = &p.mu
+	p.wg.Add(p.workers)
+	for i := 0; i < p.workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues apiCall, returning a channel receiving its error once run
+// through the limiter with ctx. It fails with ErrPoolClosed once the pool is
+// closed.
+func (p *Pool) Submit(ctx context.Context, apiCall GetStreamApiCaller) (<-chan error, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	done := make(chan error, 1)
+	p.queue = append(p.queue, poolJob{ctx: ctx, apiCall: apiCall, done: done})
+	p.cond.Signal()
+	return done, nil
+}
+
+// Workers returns how many workers may currently run a job.
+func (p *Pool) Workers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.targetLocked()
+}
+
+// Close stops accepting jobs and waits until the jobs already queued are done,
+// or until ctx is done.
+func (p *Pool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.cond.Broadcast()
+	p.mu.Unlock()
+
+	idle := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(idle)
+	}()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// targetLocked returns how many workers may run a job given the remaining
+// calls of the limiter. p.mu must be held.
+func (p *Pool) targetLocked() int {
+	if p.r.IsBlocked() {
+		return 1
+	}
+	info, ok := p.r.lastInfo.Load()
+	if !ok || info.Reset <= p.r.getClock().Now().Unix() {
+		// unknown quota or window over
+		return p.workers
+	}
+	return min(max(int(info.Remaining), 1), p.workers)
+}
+
+// next waits for a job a worker may run, reporting false once the pool is
+// closed and its queue empty.
+func (p *Pool) next() (poolJob, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		if len(p.queue) > 0 && p.running < p.targetLocked() {
+			job := p.queue[0]
+			p.queue[0] = poolJob{}
+			p.queue = p.queue[1:]
+			p.running++
+			return job, true
+		}
+		if p.closed && len(p.queue) == 0 {
+			return poolJob{}, false
+		}
+		p.cond.Wait()
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for {
+		job, ok := p.next()
+		if !ok {
+			return
+		}
+		job.done <- p.r.CallApiWithContext(job.ctx, job.apiCall)
+		p.mu.Lock()
+		p.running--
+		// the response may have changed the number of workers
+		p.cond.Broadcast()
+		p.mu.Unlock()
+	}
+}
@@ -0,0 +1,92 @@
+package rate_limiter
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestPool(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithMaxConcurrency(3))
+	pool := NewPool(rLimit)
+	var running, peak atomic.Int32
+	reset := time.Now().Add(time.Minute).Unix()
+
+	var results []<-chan error
+	for i := 0; i < 10; i++ {
+		done, err := pool.Submit(context.Background(), func() (*stream.Response, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 100, Reset: reset}}, nil
+		})
+		require.NoError(t, err)
+		results = append(results, done)
+	}
+	require.NoError(t, pool.Close(context.Background()))
+	for _, done := range results {
+		assert.NoError(t, receive(t, done))
+	}
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+
+	_, err := pool.Submit(context.Background(), nil)
+	assert.ErrorIs(t, err, ErrPoolClosed)
+}
+
+func TestPoolScalesWithQuota(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithMaxConcurrency(4), WithClock(clock))
+	pool := NewPool(rLimit)
+	defer pool.Close(context.Background())
+	assert.Equal(t, 4, pool.Workers())
+
+	call := func(remaining int64) GetStreamApiCaller {
+		return func() (*stream.Response, error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: remaining, Reset: 1060}}, nil
+		}
+	}
+	done, err := pool.Submit(context.Background(), call(2))
+	require.NoError(t, err)
+	require.NoError(t, receive(t, done))
+	assert.Equal(t, 2, pool.Workers())
+
+	done, err = pool.Submit(context.Background(), call(0))
+	require.NoError(t, err)
+	require.NoError(t, receive(t, done))
+	assert.True(t, rLimit.IsBlocked())
+	assert.Equal(t, 1, pool.Workers())
+
+	// back to every worker after the reset
+	clock.Advance(time.Minute)
+	assert.Eventually(t, func() bool { return pool.Workers() == 4 }, time.Second, time.Millisecond)
+}
+
+func TestPoolCloseContext(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	pool := NewPool(rLimit, WithWorkers(1))
+	release := make(chan struct{})
+	_, err := pool.Submit(context.Background(), func() (*stream.Response, error) {
+		<-release
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 10}}, nil
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, pool.Close(ctx), context.DeadlineExceeded)
+	close(release)
+	assert.NoError(t, pool.Close(context.Background()))
+}
@@ -0,0 +1,101 @@
+package rate_limiter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+)
+
+// RateLimitsGetter fetches the current rate limits of the Stream app, it is
+// implemented by *stream.Client.
+type RateLimitsGetter interface {
+	GetRateLimits(ctx context.Context, options ...stream.GetRateLimitsOption) (stream.GetRateLimitsResponse, error)
+}
+
+// Preflight fetches the current server side rate limit of the api through
+// client, so that the limiter knows its budget before the first call. An
+// exhausted api is blocked until the reset.
+func (r *RateLimiter) Preflight(ctx context.Context, client RateLimitsGetter) error {
+	limits, err := client.GetRateLimits(ctx, stream.WithServerSide(), stream.WithEndpoints(r.apiName))
+	if err != nil {
+		return err
+	}
+	return r.applyRateLimits(ctx, limits.ServerSide)
+}
+
+// applyRateLimits records the rate limit of the api found in limits, as if it
+// was reported by a response.
+func (r *RateLimiter) applyRateLimits(ctx context.Context, limits stream.RateLimitsMap) error {
+	reported, ok := limits[r.apiName]
+	if !ok {
+		return fmt.Errorf("no rate limit reported for %s", r.apiName)
+	}
+	info := fromRateLimitInfo(&reported)
+	logger := r.getLogger(nil)
+	r.observeInfo(info)
+	r.observeStore(ctx, logger, info)
+	logAttrs(ctx, logger, LevelTrace, "rate limit fetched", []slog.Attr{
+		slog.String("api", r.apiName),
+		slog.Int64("remaining", info.Remaining),
+		slog.Int64("limit", info.Limit),
+	}, "Fetched rate limit of %s, remaining api calls %d/%d\n", r.apiName, info.Remaining, info.Limit)
+	if r.exhausted(info.Remaining, 1) {
+		r.block(ctx, logger, info.Reset)
+	}
+	return nil
+}
+
+// Preflight fetches at once the current rate limits of apiNames through
+// client, creating their limiters if needed. The limiters of the apis reported
+// are updated even when others are missing, which is reported as an error.
+func (m *LimiterManager) Preflight(ctx context.Context, client RateLimitsGetter, apiNames ...GetStreamApiName) error {
+	endpoints := make([]string, len(apiNames))
+	for i, apiName := range apiNames {
+		endpoints[i] = string(apiName)
+	}
+	limits, err := client.GetRateLimits(ctx, stream.WithServerSide(), stream.WithEndpoints(endpoints...))
+	if err != nil {
+		return err
+	}
+	var missing error
+	for _, apiName := range apiNames {
+		if err := m.For(apiName).applyRateLimits(ctx, limits.ServerSide); err != nil && missing == nil {
+			missing = err
+		}
+	}
+	return missing
+}
+
+// RefreshEvery runs Preflight for apiNames right away and then every interval,
+// in the background, until ctx is done. Failed refreshes are logged by the
+// limiter of the first api and retried at the next interval.
+func (m *LimiterManager) RefreshEvery(ctx context.Context, client RateLimitsGetter, interval time.Duration, apiNames ...GetStreamApiName) {
+	if len(apiNames) == 0 {
+		return
+	}
+	// every limiter is built with the same options, hence the same clock
+	first := m.For(apiNames[0])
+	clock := first.getClock()
+	refresh := func() {
+		if err := m.Preflight(ctx, client, apiNames...); err != nil && ctx.Err() == nil {
+			logAttrs(ctx, first.getLogger(nil), slog.LevelWarn, "rate limits refresh failed", []slog.Attr{
+				slog.String("error", err.Error()),
+			}, "Rate limits refresh failed: %v\n", err)
+		}
+	}
+
+	refresh()
+	go func() {
+		for {
+			select {
+			case <-clock.After(interval):
+				refresh()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,89 @@
+package rate_limiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+type rateLimitsGetterFunc func(ctx context.Context) (stream.GetRateLimitsResponse, error)
+
+func (f rateLimitsGetterFunc) GetRateLimits(ctx context.Context, options ...stream.GetRateLimitsOption) (stream.GetRateLimitsResponse, error) {
+	return f(ctx)
+}
+
+func serverSide(limits stream.RateLimitsMap) rateLimitsGetterFunc {
+	return func(ctx context.Context) (stream.GetRateLimitsResponse, error) {
+		return stream.GetRateLimitsResponse{ServerSide: limits}, nil
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+
+	require.NoError(t, rLimit.Preflight(context.Background(), serverSide(stream.RateLimitsMap{
+		string(QueryUsers): {Limit: 10, Remaining: 7, Reset: 1060},
+	})))
+	assert.Equal(t, int64(7), rLimit.Remaining())
+	assert.Equal(t, time.Unix(1060, 0), rLimit.ResetAt())
+	assert.False(t, rLimit.IsBlocked())
+	assert.True(t, rLimit.LastCallAt().IsZero(), "a preflight is not a call")
+
+	require.NoError(t, rLimit.Preflight(context.Background(), serverSide(stream.RateLimitsMap{
+		string(QueryUsers): {Limit: 10, Remaining: 0, Reset: 1060},
+	})))
+	assert.True(t, rLimit.IsBlocked())
+
+	assert.EqualError(t, rLimit.Preflight(context.Background(), serverSide(nil)), "no rate limit reported for QueryUsers")
+	assert.ErrorIs(t, rLimit.Preflight(context.Background(), rateLimitsGetterFunc(func(ctx context.Context) (stream.GetRateLimitsResponse, error) {
+		return stream.GetRateLimitsResponse{}, assert.AnError
+	})), assert.AnError)
+}
+
+func TestLimiterManagerPreflight(t *testing.T) {
+	manager := NewLimiterManager()
+
+	err := manager.Preflight(context.Background(), serverSide(stream.RateLimitsMap{
+		string(QueryUsers):    {Limit: 10, Remaining: 7, Reset: time.Now().Unix() + 60},
+		string(CreateChannel): {Limit: 20, Remaining: 12, Reset: time.Now().Unix() + 60},
+	}), QueryUsers, CreateChannel, QueryChannel)
+	assert.EqualError(t, err, "no rate limit reported for QueryChannel")
+	assert.Equal(t, int64(7), manager.For(QueryUsers).Remaining())
+	assert.Equal(t, int64(12), manager.For(CreateChannel).Remaining())
+	assert.Equal(t, int64(-1), manager.For(QueryChannel).Remaining())
+}
+
+func TestLimiterManagerRefreshEvery(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	manager := NewLimiterManager(WithClock(clock))
+
+	var mu sync.Mutex
+	remaining := int64(7)
+	client := rateLimitsGetterFunc(func(ctx context.Context) (stream.GetRateLimitsResponse, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return stream.GetRateLimitsResponse{ServerSide: stream.RateLimitsMap{
+			string(QueryUsers): {Limit: 10, Remaining: remaining, Reset: 1060},
+		}}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	manager.RefreshEvery(ctx, client, 10*time.Second, QueryUsers)
+	assert.Equal(t, int64(7), manager.For(QueryUsers).Remaining(), "refreshed at startup")
+
+	blockUntil(t, clock, 1)
+	mu.Lock()
+	remaining = 3
+	mu.Unlock()
+	clock.Advance(10 * time.Second)
+	assert.Eventually(t, func() bool { return manager.For(QueryUsers).Remaining() == 3 }, time.Second, time.Millisecond)
+}
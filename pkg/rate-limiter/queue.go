@@ -0,0 +1,175 @@
+package rate_limiter
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrQueueFull is returned without waiting when WithMaxQueueDepth callers are
+// already queued for a slot.
+var ErrQueueFull = errors.New("queue full")
+
+// Priority orders the callers waiting for a slot: higher priorities are
+// served first, callers of the same priority in arrival order.
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+type priorityKey struct{}
+
+func withPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+func priorityFrom(ctx context.Context) Priority {
+	priority, _ := ctx.Value(priorityKey{}).(Priority)
+	return priority
+}
+
+// CallWithPriority is like CallApiWithContext, serving the call, while slots
+// are busy, before the calls of lower priority, e.g. user facing requests
+// before background jobs.
+func (r *RateLimiter) CallWithPriority(ctx context.Context, priority Priority, apiCall GetStreamApiCaller) error {
+	return r.callApi(withPriority(ctx, priority), r.getLogger(nil), streamCall(apiCall))
+}
+
+// queuedCall is a caller waiting in the waitQueue.
+type queuedCall struct {
+	priority Priority
+	seq      uint64
+	index    int
+	granted  bool
+	turn     chan struct{} // closed once granted a token
+}
+
+type callHeap []*queuedCall
+
+func (h callHeap) Len() int { return len(h) }
+
+func (h callHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h callHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *callHeap) Push(x any) {
+	call := x.(*queuedCall)
+	call.index = len(*h)
+	*h = append(*h, call)
+}
+
+func (h *callHeap) Pop() any {
+	old := *h
+	call := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	call.index = -1
+	return call
+}
+
+// waitQueue orders the callers waiting for a token. A released token is handed
+// over to the first queued caller instead of going back to the channel, so
+// that a token is only free while nobody is queued.
+type waitQueue struct {
+	mu       sync.Mutex
+	seq      uint64
+	calls    callHeap
+	maxDepth int // zero for no bound
+	excess   int // tokens held beyond the capacity of the channel, see resize
+}
+
+// enqueue takes a free token, reporting true, or queues a caller of the given
+// priority, failing with ErrQueueFull when maxDepth callers are queued.
+func (q *waitQueue) enqueue(token *atomic.Pointer[chan struct{}], priority Priority) (*queuedCall, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	select {
+	case *token.Load() <- struct{}{}:
+		return nil, true, nil
+	default:
+	}
+	if q.maxDepth > 0 && len(q.calls) >= q.maxDepth {
+		return nil, false, ErrQueueFull
+	}
+	q.seq++
+	call := &queuedCall{priority: priority, seq: q.seq, turn: make(chan struct{})}
+	heap.Push(&q.calls, call)
+	return call, false, nil
+}
+
+// release hands a token over to the first queued caller, or gives it back.
+func (q *waitQueue) release(token *atomic.Pointer[chan struct{}]) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.excess > 0 {
+		// held beyond the capacity of a channel shrunk by resize
+		q.excess--
+		return
+	}
+	if len(q.calls) == 0 {
+		<-*token.Load()
+		return
+	}
+	call := heap.Pop(&q.calls).(*queuedCall)
+	call.granted = true
+	close(call.turn)
+}
+
+// leave removes a caller giving up, reporting whether it was granted a token
+// meanwhile.
+func (q *waitQueue) leave(call *queuedCall) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if call.granted {
+		return true
+	}
+	heap.Remove(&q.calls, call.index)
+	return false
+}
+
+// QueueDepth returns the number of callers queued for a slot.
+func (r *RateLimiter) QueueDepth() int {
+	r.queue.mu.Lock()
+	defer r.queue.mu.Unlock()
+	return len(r.queue.calls)
+}
+
+// queueForToken waits in the queue until granted a token.
+func (r *RateLimiter) queueForToken(ctx context.Context, timeout *maxWaitTimer) error {
+	call, ok, err := r.queue.enqueue(&r.token, priorityFrom(ctx))
+	if err != nil {
+		return fmt.Errorf("%w on %s", err, r.apiName)
+	}
+	if ok {
+		return nil
+	}
+	select {
+	case <-call.turn:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout.C():
+		err = r.errWaitTimeout()
+	case <-r.closing():
+		err = r.errClosed()
+	}
+	if r.queue.leave(call) {
+		r.release()
+	}
+	return err
+}
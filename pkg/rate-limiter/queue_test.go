@@ -0,0 +1,185 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func queued(r *RateLimiter) int {
+	r.queue.mu.Lock()
+	defer r.queue.mu.Unlock()
+	return len(r.queue.calls)
+}
+
+// holdSlot takes the only slot of r until the returned func is called.
+func holdSlot(t *testing.T, r *RateLimiter) func() {
+	inFlight := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- r.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+			close(inFlight)
+			<-release
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 100, Remaining: 50, Reset: time.Now().Add(time.Minute).Unix()}}, nil
+		})
+	}()
+	<-inFlight
+	return func() {
+		close(release)
+		assert.NoError(t, receive(t, done))
+	}
+}
+
+func TestCallWithPriority(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	releaseSlot := holdSlot(t, rLimit)
+
+	order := make(chan Priority, 3)
+	done := make(chan error, 3)
+	for i, priority := range []Priority{PriorityLow, PriorityNormal, PriorityHigh} {
+		priority := priority
+		go func() {
+			done <- rLimit.CallWithPriority(context.Background(), priority, func() (resp *stream.Response, err error) {
+				order <- priority
+				return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 100, Remaining: 50, Reset: time.Now().Add(time.Minute).Unix()}}, nil
+			})
+		}()
+		n := i + 1
+		assert.Eventually(t, func() bool { return queued(rLimit) == n }, time.Second, time.Millisecond)
+	}
+
+	releaseSlot()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, receive(t, done))
+	}
+	close(order)
+	var got []Priority
+	for priority := range order {
+		got = append(got, priority)
+	}
+	assert.Equal(t, []Priority{PriorityHigh, PriorityNormal, PriorityLow}, got)
+}
+
+func TestCallWithPriorityArrivalOrder(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	releaseSlot := holdSlot(t, rLimit)
+
+	order := make(chan int, 3)
+	done := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		i := i
+		go func() {
+			done <- rLimit.CallWithPriority(context.Background(), PriorityHigh, func() (resp *stream.Response, err error) {
+				order <- i
+				return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 100, Remaining: 50, Reset: time.Now().Add(time.Minute).Unix()}}, nil
+			})
+		}()
+		assert.Eventually(t, func() bool { return queued(rLimit) == i+1 }, time.Second, time.Millisecond)
+	}
+
+	releaseSlot()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, receive(t, done))
+	}
+	close(order)
+	var got []int
+	for i := range order {
+		got = append(got, i)
+	}
+	assert.Equal(t, []int{0, 1, 2}, got)
+}
+
+func TestCallWithPriorityCanceled(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	releaseSlot := holdSlot(t, rLimit)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- rLimit.CallWithPriority(ctx, PriorityHigh, func() (resp *stream.Response, err error) {
+			t.Error("canceled call invoked")
+			return nil, nil
+		})
+	}()
+	assert.Eventually(t, func() bool { return queued(rLimit) == 1 }, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, receive(t, done), context.Canceled)
+	assert.Equal(t, 0, queued(rLimit))
+
+	releaseSlot()
+	assert.Equal(t, 0, len(rLimit.tokens()), "the slot is free again")
+}
+
+func TestBlockedCallersResumeInArrivalOrder(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+
+	// every call exhausts the window, so that each reset admits a single caller
+	exhausting := func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 1, Remaining: 0, Reset: clock.Now().Unix() + 1}}, nil
+	}
+	assert.NoError(t, rLimit.TryCallApi(exhausting))
+	blockUntil(t, clock, 1)
+
+	order := make(chan int, 4)
+	done := make(chan error, 4)
+	call := func(i int) {
+		go func() {
+			done <- rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+				order <- i
+				return exhausting()
+			})
+		}()
+	}
+	// the first caller waits for the reset holding the token, the others queue
+	call(0)
+	assert.Eventually(t, func() bool { return len(rLimit.tokens()) == 1 }, time.Second, time.Millisecond)
+	for i := 1; i < 3; i++ {
+		call(i)
+		n := i
+		assert.Eventually(t, func() bool { return queued(rLimit) == n }, time.Second, time.Millisecond)
+	}
+
+	var got []int
+	for i := 0; i < 4; i++ {
+		clock.Advance(time.Second)
+		got = append(got, <-order)
+		assert.NoError(t, receive(t, done))
+		blockUntil(t, clock, 1)
+		if i == 0 {
+			// a late caller waits behind the ones blocked for several resets
+			call(3)
+			assert.Eventually(t, func() bool { return queued(rLimit) == 2 }, time.Second, time.Millisecond)
+		}
+	}
+	assert.Equal(t, []int{0, 1, 2, 3}, got)
+}
+
+func TestMaxQueueDepth(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithMaxQueueDepth(1))
+	releaseSlot := holdSlot(t, rLimit)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 100, Remaining: 50, Reset: time.Now().Add(time.Minute).Unix()}}, nil
+		})
+	}()
+	assert.Eventually(t, func() bool { return queued(rLimit) == 1 }, time.Second, time.Millisecond)
+
+	err := rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		t.Error("shed call invoked")
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, ErrQueueFull)
+	assert.EqualError(t, err, "queue full on QueryUsers")
+
+	releaseSlot()
+	assert.NoError(t, receive(t, done))
+}
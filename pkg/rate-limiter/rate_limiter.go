@@ -1,51 +1,699 @@
 package rate_limiter
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	stream "github.com/GetStream/stream-chat-go/v6"
-	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
-type GetStreamApiCaller func() (resp *stream.Response, err error)
-
+// GetStreamApiName is the name of a Stream Chat endpoint, see Endpoints.
 type GetStreamApiName string
 
-const (
-	CreateChannel GetStreamApiName = "CreateChannel"
-	QueryChannel  GetStreamApiName = "QueryChannel"
-	QueryUsers    GetStreamApiName = "QueryUsers"
-)
-
+// RateLimiter serializes the calls of one api. NewRateLimiter configures it,
+// although the zero value is usable too, allowing a single call at a time.
 type RateLimiter struct {
-	apiName string
-	token   chan struct{}
+	apiName    string
+	token      atomic.Pointer[chan struct{}] // see tokens
+	tokenOnce  sync.Once
+	slots      int
+	logger     Logger
+	clock      Clock
+	metrics    Metrics
+	tracer     trace.Tracer
+	maxWait    atomic.Int64 // time.Duration, see WithMaxWait
+	headroom   atomic.Int64
+	priority   atomic.Int64 // Priority, see WithDefaultPriority
+	strategy   Strategy
+	schedule   []ThrottleRule
+	maxRate    float64       // calls per second, see WithMaxRate
+	jitter     time.Duration // of the resumes, see WithResumeJitter
+	maxBlock   time.Duration // see WithMaxBlock
+	unhealthy  time.Duration // see WithUnhealthyAfter
+	aimd       *aimd
+	retry      *RetryPolicy
+	retries    *retryBudget
+	store      Store
+	breaker    *circuitBreaker
+	buckets    map[GetStreamApiName]GetStreamApiName // read by LimiterManager
+	tenants    *tenantConfig                         // read by LimiterManager
+	globalOpts []Option                              // read by LimiterManager
+	parent     *RateLimiter                          // level every call also passes
+	clientSide bool                                  // ignores the reported rate limits
+	cost       int                                   // of a call, see WithCost
+	onWire     chan struct{}                         // the calls outstanding, see WithMaxInFlight
+	latency    *latencyLimit
+	slowStart  *slowStart
+	cooldown   *cooldown
+	budgets    []budget
+	usage      usageCounter // of the budgets without a BudgetStore
+	fallback   *fallbackPolicy
+	faults     *FaultInjector
+	alerts     *utilizationAlerts
+	missing    MissingInfoPolicy
+	audit      AuditSink
+	tenant     string // of the limiters of LimiterManager.ForTenant
+
+	mu          sync.Mutex
+	blocked     chan struct{} // non-nil while blocked, closed on reset
+	resetAt     time.Time
+	blockedAt   time.Time
+	stopReset   func() bool // stops the timer ending the block at resetAt
+	paused      bool        // see Pause
+	pausedReset time.Time   // the reset blocking the api once resumed
+	resetGen    uint64      // bumped whenever the timer is stopped
+	closed      bool
+	done        chan struct{} // closed by Close
+	calls       int           // calls entered and not yet returned
+	idle        chan struct{} // closed once no call is left after Close
+	draining    bool          // see Drain
+	left        chan struct{} // closed once a call leaves while draining
+	inFlight    int           // cost of the calls admitted and not yet settled
+	settled     chan struct{} // closed once a call in flight settles
+	reserved    map[int64]int // calls reserved by Reserve, by window reset
+
+	lastInfo   infoCell
+	lastCallAt atomic.Int64  // unix nano
+	skew       clockSkew     // see ObserveServerTime
+	noInfo     atomic.Bool   // in the latest response, see WithMissingInfoPolicy
+	failing    atomic.Int64  // unix nano, since when the calls fail, see Healthy
+	rank       atomic.Uint64 // creation order, see Chain
+
+	interceptors []Interceptor
+	callerOnce   sync.Once
+	pipeline     Caller // the interceptors, see caller
+
+	hooks  hooks
+	events eventStream
+	stats  rollingStats
+	queue  waitQueue
+}
+
+// NewRateLimiter returns a RateLimiter for the given api, with its token
+// channel allocated. By default a single call at a time is allowed.
+func NewRateLimiter(apiName GetStreamApiName, opts ...Option) *RateLimiter {
+	r := &RateLimiter{
+		apiName: string(apiName),
+		slots:   1,
+		logger:  nopLogger{},
+		clock:   realClock{},
+		metrics: nopMetrics{},
+		tracer:  noop.NewTracerProvider().Tracer(tracerName),
+		store:   NewMemoryStore(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if len(r.schedule) > 0 {
+		r.strategy = newThrottleSchedule(r.schedule, r.strategy)
+	}
+	if r.maxRate > 0 {
+		r.strategy = newSpikeArrest(r.maxRate, r.strategy)
+	}
+	r.order()
+	token := make(chan struct{}, r.slots)
+	r.token.Store(&token)
+	if r.aimd != nil {
+		r.aimd = newAIMD(r.slots)
+	}
+	if r.slowStart != nil {
+		r.slowStart.full = r.slots
+	}
+	if r.latency != nil {
+		if r.onWire == nil {
+			r.onWire = make(chan struct{}, r.slots)
+		}
+		r.latency.init(cap(r.onWire))
+	}
+	return r
+}
+
+// initToken allocates the token channel unless NewRateLimiter already did,
+// so that the zero value RateLimiter does not block forever on a nil channel.
+func (r *RateLimiter) initToken() {
+	r.tokenOnce.Do(func() {
+		if r.token.Load() == nil {
+			token := make(chan struct{}, max(r.slots, 1))
+			r.token.Store(&token)
+		}
+	})
+}
+
+// tokens returns the token channel, holding a token per call in flight, nil
+// before initToken.
+func (r *RateLimiter) tokens() chan struct{} {
+	if token := r.token.Load(); token != nil {
+		return *token
+	}
+	return nil
+}
+
+func (r *RateLimiter) getMaxWait() time.Duration {
+	return time.Duration(r.maxWait.Load())
+}
+
+func (r *RateLimiter) getClock() Clock {
+	if r.clock == nil {
+		return realClock{}
+	}
+	return r.clock
+}
+
+func (r *RateLimiter) getLogger(logger Logger) Logger {
+	if logger != nil {
+		return logger
+	}
+	if r.logger != nil {
+		return r.logger
+	}
+	return nopLogger{}
+}
+
+// --> Slots Channel + Timed Barrier [more performant]
+func (r *RateLimiter) CallApiAndBlockOnRateLimit(logger Logger, apiCall GetStreamApiCaller) error {
+	return r.callApi(context.Background(), r.getLogger(logger), streamCall(apiCall))
+}
+
+// CallApiWithContext is like CallApiAndBlockOnRateLimit, using the configured logger.
+// It stops waiting for the token or for the rate limit reset as soon as ctx is
+// done; the api stays blocked for the other callers until the reset. opts
+// adjust the call, see CallOption.
+func (r *RateLimiter) CallApiWithContext(ctx context.Context, apiCall GetStreamApiCaller, opts ...CallOption) error {
+	return r.run(ctx, Invocation{Options: ApplyCallOptions(opts...), call: streamCall(apiCall)})
+}
+
+// Call is CallApiWithContext, e.g. manager.For(QueryUsers).Call(ctx, apiCall).
+// The package level Call hands back the typed result of the call instead.
+func (r *RateLimiter) Call(ctx context.Context, apiCall GetStreamApiCaller, opts ...CallOption) error {
+	return r.CallApiWithContext(ctx, apiCall, opts...)
+}
+
+// TryCallApi calls the api only if a slot is available right away, otherwise
+// it returns an ErrRateLimited without blocking. opts adjust the call, see
+// CallOption.
+func (r *RateLimiter) TryCallApi(apiCall GetStreamApiCaller, opts ...CallOption) error {
+	return r.run(context.Background(), Invocation{Try: true, Options: ApplyCallOptions(opts...), call: streamCall(apiCall)})
+}
+
+func (r *RateLimiter) tryCallApi(ctx context.Context, apiCall limitedCall) error {
+	select {
+	case r.tokens() <- struct{}{}:
+	default:
+		return ErrRateLimited{ApiName: r.apiName, ResetAt: r.getResetAt()}
+	}
+	if blocked, resetAt := r.barrier(); blocked != nil {
+		r.release()
+		return r.errBlocked(resetAt)
+	}
+	now := r.getClock().Now()
+	if r.strategy != nil {
+		if next, ok := r.strategy.TryReserve(now); !ok {
+			r.release()
+			return ErrRateLimited{ApiName: r.apiName, ResetAt: next}
+		}
+	}
+	if strategy := r.missingStrategy(); strategy != nil {
+		if next, ok := strategy.TryReserve(now); !ok {
+			r.release()
+			return ErrRateLimited{ApiName: r.apiName, ResetAt: next}
+		}
+	}
+	res := reservationFrom(ctx)
+	if _, reset, ok := r.admit(now, r.costFrom(ctx), res); !ok {
+		r.release()
+		if reset.IsZero() {
+			reset = r.getResetAt()
+		}
+		return ErrRateLimited{ApiName: r.apiName, ResetAt: reset}
+	}
+	logger := r.getLogger(nil)
+	if blockedUntil := r.takeFromStore(ctx, logger, now); !blockedUntil.IsZero() {
+		r.settle(r.costFrom(ctx))
+		r.release()
+		return ErrRateLimited{ApiName: r.apiName, ResetAt: blockedUntil}
+	}
+	if !r.tryEnterSlowStart() {
+		r.settle(r.costFrom(ctx))
+		r.release()
+		return ErrRateLimited{ApiName: r.apiName, ResetAt: r.getResetAt()}
+	}
+	if !r.tryEnterInFlight() {
+		r.leaveSlowStart()
+		r.settle(r.costFrom(ctx))
+		r.release()
+		return ErrRateLimited{ApiName: r.apiName, ResetAt: r.getResetAt()}
+	}
+	r.consumeReservation(res, r.costFrom(ctx))
+	r.recordWait(now, 0)
+	return r.invoke(ctx, logger, r.throughParent(ctx, apiCall, true))
+}
+
+// callApi runs apiCall through the interceptors of r, retried according to the
+// retry policy if any, within a single span shared by all the attempts.
+func (r *RateLimiter) callApi(ctx context.Context, logger Logger, apiCall limitedCall) error {
+	return r.run(ctx, Invocation{logger: logger, call: apiCall})
+}
+
+func (r *RateLimiter) callApiOnce(ctx context.Context, logger Logger, apiCall limitedCall) error {
+	span := trace.SpanFromContext(ctx)
+	if r.breaker != nil && r.breaker.isOpen(r.getClock().Now()) {
+		err := r.errCircuitOpen()
+		traceError(span, err)
+		return err
+	}
+
+	if ok, err := r.tryFallback(ctx, logger); ok {
+		return err
+	}
+
+	start := r.getClock().Now()
+	err := r.acquire(ctx, logger)
+	admittedAt := r.getClock().Now()
+	wait := admittedAt.Sub(start)
+	recordQueued(ctx, wait)
+	if err != nil {
+		traceError(span, err)
+		return err
+	}
+	r.recordWait(admittedAt, wait)
+	return r.invoke(ctx, logger, r.throughParent(ctx, apiCall, false))
+}
+
+// acquire takes a token and waits for the barrier, recording on the span of
+// ctx whether the call has been queued or blocked before being resumed.
+func (r *RateLimiter) acquire(ctx context.Context, logger Logger) error {
+	span := trace.SpanFromContext(ctx)
+	timeout := &maxWaitTimer{clock: r.getClock(), maxWait: r.getMaxWait()}
+	waited := false
+
+	select {
+	case r.tokens() <- struct{}{}:
+	default:
+		waited = true
+		span.AddEvent("queued")
+		if err := r.queueForToken(ctx, timeout); err != nil {
+			return err
+		}
+	}
+	// the token is kept while blocked, so that callers resume in the order they
+	// were granted a token, however many resets they wait for
+	blocked, resetAt := r.barrier()
+	if blocked != nil {
+		waited = true
+		span.AddEvent("blocked", trace.WithAttributes(attrReset.Int64(resetAt.Unix())))
+		recordBlocked(ctx)
+	}
+	if err := r.waitBarrier(ctx, timeout); err != nil {
+		r.release()
+		return err
+	}
+	if blocked != nil {
+		if err := r.jitterResume(ctx, timeout); err != nil {
+			r.release()
+			return err
+		}
+	}
+	for _, strategy := range []Strategy{r.strategy, r.missingStrategy()} {
+		if strategy == nil {
+			continue
+		}
+		if at := strategy.Reserve(r.getClock().Now()); at.After(r.getClock().Now()) {
+			waited = true
+			span.AddEvent("paced")
+			if err := r.waitUntil(ctx, at, timeout); err != nil {
+				r.release()
+				return err
+			}
+		}
+	}
+	admitWaited := false
+	for {
+		// the calls in flight, or reserved, may consume the calls left
+		admissionWaited, err := r.waitAdmission(ctx, timeout)
+		admitWaited = admitWaited || admissionWaited
+		if err != nil {
+			r.release()
+			return err
+		}
+		// the shared state may block a call admitted by the local state
+		now := r.getClock().Now()
+		blockedUntil := r.takeFromStore(ctx, logger, now)
+		if blockedUntil.IsZero() {
+			break
+		}
+		r.settle(r.costFrom(ctx))
+		waited = true
+		span.AddEvent("blocked", trace.WithAttributes(attrReset.Int64(blockedUntil.Unix())))
+		recordBlocked(ctx)
+		r.blockFor(ctx, logger, blockedUntil, blockedUntil.Sub(now))
+		if err := r.waitBarrier(ctx, timeout); err != nil {
+			r.release()
+			return err
+		}
+	}
+	slowStartWaited, err := r.enterSlowStart(ctx, timeout)
+	if err != nil {
+		r.settle(r.costFrom(ctx))
+		r.release()
+		return err
+	}
+	inFlightWaited, err := r.enterInFlight(ctx, timeout)
+	if err != nil {
+		r.leaveSlowStart()
+		r.settle(r.costFrom(ctx))
+		r.release()
+		return err
+	}
+	r.consumeReservation(reservationFrom(ctx), r.costFrom(ctx))
+	waited = waited || admitWaited || slowStartWaited || inFlightWaited
+	if waited {
+		span.AddEvent("resumed")
+		recordDelayed(ctx)
+	}
+	return nil
+}
+
+// waitUntil waits, while holding a token, until at.
+func (r *RateLimiter) waitUntil(ctx context.Context, at time.Time, timeout *maxWaitTimer) error {
+	clock := r.getClock()
+	select {
+	case <-clock.After(at.Sub(clock.Now())):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeout.C():
+		return r.errWaitTimeout()
+	case <-r.closing():
+		return r.errClosed()
+	}
+}
+
+// waitBarrier waits, while holding a token, until the api is no longer blocked.
+func (r *RateLimiter) waitBarrier(ctx context.Context, timeout *maxWaitTimer) error {
+	for {
+		blocked, _ := r.barrier()
+		if blocked == nil {
+			return ctx.Err()
+		}
+		select {
+		case <-blocked:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout.C():
+			return r.errWaitTimeout()
+		case <-r.closing():
+			return r.errClosed()
+		}
+	}
+}
+
+func (r *RateLimiter) errCircuitOpen() error {
+	return fmt.Errorf("%w on %s", ErrCircuitOpen, r.apiName)
+}
+
+// recordOutcome feeds the circuit breaker, if any, with the outcome of a call.
+func (r *RateLimiter) recordOutcome(ctx context.Context, logger Logger, err error) {
+	if r.breaker == nil {
+		return
+	}
+	if state, changed := r.breaker.record(r.getClock().Now(), err); changed {
+		logAttrs(ctx, logger, slog.LevelWarn, "circuit breaker state changed", []slog.Attr{
+			slog.String("api", r.apiName),
+			slog.String("state", state.String()),
+		}, "Circuit breaker of %s is now %s\n", r.apiName, state)
+	}
 }
 
-// --> Single Slot Channel + Sleep [more performant]
-func (r *RateLimiter) CallApiAndBlockOnRateLimit(logger *log.Logger, apiCall GetStreamApiCaller) error {
-	r.token <- struct{}{}
+func (r *RateLimiter) errWaitTimeout() error {
+	return fmt.Errorf("%w on %s after %v", ErrWaitTimeout, r.apiName, r.getMaxWait())
+}
+
+// maxWaitTimer starts the max wait timer only once a call actually has to wait.
+type maxWaitTimer struct {
+	clock   Clock
+	maxWait time.Duration
+	c       <-chan time.Time
+}
+
+// C returns the timer channel, nil (i.e. never firing) without a max wait.
+func (t *maxWaitTimer) C() <-chan time.Time {
+	if t.maxWait <= 0 {
+		return nil
+	}
+	if t.c == nil {
+		t.c = t.clock.After(t.maxWait)
+	}
+	return t.c
+}
+
+// invoke runs apiCall while holding a token. When the calls left do not cover
+// the calls already in flight, every slot is blocked behind a barrier until the
+// rate limit is reset.
+func (r *RateLimiter) invoke(ctx context.Context, logger Logger, apiCall limitedCall) error {
+	defer r.release()
+	defer r.settle(r.costFrom(ctx))
+	defer r.leaveInFlight()
+	defer r.leaveSlowStart()
+
 	// Alt. Direct API call in GetStream <-- requires network traffic
 	// resp, err := r.client.GetRateLimits(context.TODO(), WithEndpoints(r.apiName))
 
+	span := trace.SpanFromContext(ctx)
+	spent, err := r.spendBudgets(ctx, logger)
+	if err != nil {
+		traceError(span, err)
+		return err
+	}
+	if r.breaker != nil && !r.breaker.allow(r.getClock().Now()) {
+		r.refundBudgets(ctx, logger, spent)
+		err := r.errCircuitOpen()
+		traceError(span, err)
+		return err
+	}
+
 	// Injected api call
-	resp, err := apiCall()
+	r.emit(Event{Type: CallAdmitted, Tags: tagsFrom(ctx)})
+	calledAt := r.getClock().Now()
+	result, ok, err := r.safeCall(ctx, logger, apiCall)
+	info := orNil(result, ok)
+	if err != nil && errors.As(err, new(*levelRejection)) {
+		// turned down by another level, the api was not called: neither a
+		// probe of the breaker, a call of the strategies nor a latency
+		// sample, and the in-flight accounting is released by the deferred
+		// calls; the error stays marked for the levels above
+		r.refundBudgets(ctx, logger, spent)
+		if r.breaker != nil {
+			r.breaker.cancel()
+		}
+		now := r.getClock().Now()
+		cancelReservation(r.strategy, now)
+		cancelReservation(r.missingStrategy(), now)
+		traceError(span, err)
+		return err
+	}
+	r.observeLatency(calledAt, err)
+	r.recordCall(calledAt, err)
+	r.trackFailures(err)
+	r.recordOutcome(ctx, logger, err)
+	r.coolDown(ctx, logger, err)
+	if r.clientSide {
+		r.observe(calledAt, nil)
+		if r.strategy != nil {
+			r.strategy.Observe(r.getClock().Now(), nil, err)
+		}
+		if err != nil {
+			r.fireError(err)
+			traceError(span, err)
+		}
+		return err
+	}
 	if err != nil {
-		<-r.token
-		return err
-	}
-	logger.Tracef("After api call for %s, remaining api calls %d/%d\n", r.apiName, resp.RateLimitInfo.Remaining, resp.RateLimitInfo.Limit)
-	if resp.RateLimitInfo.Remaining == 0 {
-		logger.Debugf("No more call left for %s.\n", r.apiName)
-		go func(duration int64) {
-			start := time.Now()
-			logger.Debugf("Blocking future calls of %s for %d seconds\n", r.apiName, time.Duration(duration-start.Unix()))
-			time.Sleep((time.Second * time.Duration(duration-start.Unix())).Abs())
-			<-r.token
-			logger.Tracef("Restarting api %s after %f seconds at %v\n", r.apiName, time.Since(start).Seconds(), time.Now().UTC())
-		}(resp.RateLimitInfo.Reset) // <-- when the current limit will reset (Unix timestamp in seconds)
-	} else {
-		<-r.token
+		info, tooManyRequests := tooManyRequestsInfo(err, r.serverNow())
+		r.observe(calledAt, info)
+		r.checkUtilization(ctx, logger, info)
+		if tooManyRequests {
+			r.observeStore(ctx, logger, info)
+		}
+		if r.strategy != nil {
+			r.strategy.Observe(r.getClock().Now(), info.clone(), err)
+		}
+		r.fireError(err)
+		traceError(span, err)
+		if tooManyRequests {
+			r.adapt(true)
+			r.block(ctx, logger, info.Reset)
+			return r.rateLimitedError(err, r.getResetAt())
+		}
+		return err
+	}
+	if info == nil {
+		r.observeMissingInfo(ctx, logger, calledAt)
+		return nil
+	}
+	r.noInfo.Store(false)
+	r.observe(calledAt, info)
+	r.checkUtilization(ctx, logger, info)
+	r.observeStore(ctx, logger, info)
+	if r.strategy != nil {
+		r.strategy.Observe(r.getClock().Now(), info.clone(), nil)
+	}
+	if r.missing.strategy != nil {
+		r.missing.strategy.Observe(r.getClock().Now(), info.clone(), nil)
+	}
+	traceRateLimitInfo(span, info)
+	exhausted := r.exhausted(info.Remaining, r.costFrom(ctx))
+	r.adapt(exhausted)
+	if exhausted {
+		r.block(ctx, logger, info.Reset) // <-- when the current limit will reset (Unix timestamp in seconds)
 	}
 	return nil
 }
+
+// exhausted reports whether the remaining calls no longer cover the headroom
+// and the calls already in flight besides a call of the given cost, so that
+// calls must be blocked until reset.
+func (r *RateLimiter) exhausted(remaining int64, cost int) bool {
+	return remaining <= r.headroom.Load()+r.othersInFlight(cost)
+}
+
+// block raises the barrier until reset (Unix timestamp in seconds of the clock
+// of the server, see ObserveServerTime), unless the api is already blocked, for
+// WithMaxBlock at most. A reset already passed does not block.
+func (r *RateLimiter) block(ctx context.Context, logger Logger, reset int64) {
+	now := r.getClock().Now()
+	resumeAt := r.localReset(reset)
+	if !resumeAt.After(now) {
+		r.logResetPassed(ctx, logger, reset)
+		return
+	}
+	if r.maxBlock > 0 && resumeAt.Sub(now) > r.maxBlock {
+		resumeAt = now.Add(r.maxBlock)
+	}
+	r.blockFor(ctx, logger, resumeAt, resumeAt.Sub(now))
+}
+
+// blockFor raises the barrier for wait, until resumeAt, or pushes back the end
+// of the current block when resumeAt is later.
+func (r *RateLimiter) blockFor(ctx context.Context, logger Logger, resumeAt time.Time, wait time.Duration) {
+	clock := r.getClock()
+	// the barrier is shared by all callers: cancelling the caller which raised
+	// it must not resume the others before the reset
+	ctx = context.WithoutCancel(ctx)
+
+	r.mu.Lock()
+	if r.paused {
+		// the block starts once resumed
+		if resumeAt.After(r.pausedReset) {
+			r.pausedReset = resumeAt
+		}
+		r.mu.Unlock()
+		return
+	}
+	if r.closed || (r.blocked != nil && !resumeAt.After(r.resetAt)) {
+		r.mu.Unlock()
+		return
+	}
+	if r.blocked != nil {
+		// a newer response reports a later reset
+		r.resetAt = resumeAt
+		r.scheduleResetLocked(ctx, logger, clock, wait)
+		r.mu.Unlock()
+		logAttrs(ctx, logger, slog.LevelDebug, "extending block until reset", []slog.Attr{
+			slog.String("api", r.apiName),
+			slog.Int64("reset", resumeAt.Unix()),
+			slog.Duration("wait", wait),
+		}, "Extending block of %s for %d seconds\n", r.apiName, int64(wait.Seconds()))
+		return
+	}
+	r.blocked = make(chan struct{})
+	r.resetAt = resumeAt
+	r.blockedAt = clock.Now()
+	r.scheduleResetLocked(ctx, logger, clock, wait)
+	r.mu.Unlock()
+
+	r.getMetrics().ObserveBlock(r.apiName)
+	r.recordBlock()
+	r.fireBlocked(resumeAt)
+	r.emit(Event{Type: Blocked, ResumeAt: resumeAt})
+	logAttrs(ctx, logger, slog.LevelDebug, "blocking calls until reset", []slog.Attr{
+		slog.String("api", r.apiName),
+		slog.Int64("reset", resumeAt.Unix()),
+		slog.Duration("wait", wait),
+	}, "Blocking future calls of %s for %d seconds\n", r.apiName, int64(wait.Seconds()))
+}
+
+// scheduleResetLocked (re)schedules the end of the block in wait, stopping the
+// timer previously scheduled if any. r.mu must be held.
+func (r *RateLimiter) scheduleResetLocked(ctx context.Context, logger Logger, clock Clock, wait time.Duration) {
+	r.stopResetLocked()
+	gen := r.resetGen
+	r.stopReset = afterFunc(clock, wait, func() { r.unblock(ctx, logger, gen) })
+}
+
+// stopResetLocked stops the pending reset timer. A timer which could not be
+// stopped in time finds a newer generation and does nothing. r.mu must be held.
+func (r *RateLimiter) stopResetLocked() {
+	if r.stopReset != nil {
+		r.stopReset()
+		r.stopReset = nil
+	}
+	r.resetGen++
+}
+
+// unblock lifts the barrier raised by blockFor, unless its timer of generation
+// gen has been stopped meanwhile.
+func (r *RateLimiter) unblock(ctx context.Context, logger Logger, gen uint64) {
+	clock := r.getClock()
+	r.mu.Lock()
+	if gen != r.resetGen || r.blocked == nil {
+		r.mu.Unlock()
+		return
+	}
+	if r.paused {
+		// no longer blocked once resumed
+		r.pausedReset = time.Time{}
+		r.mu.Unlock()
+		return
+	}
+	blocked := r.blocked
+	waited := clock.Now().Sub(r.blockedAt)
+	r.blocked = nil
+	r.resetAt = time.Time{}
+	r.stopReset = nil
+	r.mu.Unlock()
+
+	logAttrs(ctx, logger, LevelTrace, "restarting calls", []slog.Attr{
+		slog.String("api", r.apiName),
+		slog.Duration("wait", waited),
+	}, "Restarting api %s after %f seconds at %v\n", r.apiName, waited.Seconds(), clock.Now().UTC())
+	close(blocked)
+	r.fireResumed()
+	r.emit(Event{Type: Resumed})
+}
+
+func (r *RateLimiter) barrier() (chan struct{}, time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.blocked, r.resetAt
+}
+
+// errBlocked returns the error of a call turned down by the barrier raised
+// until resetAt.
+func (r *RateLimiter) errBlocked(resetAt time.Time) error {
+	err := ErrRateLimited{ApiName: r.apiName, ResetAt: resetAt}
+	if resetAt.IsZero() && r.Paused() {
+		err.Err = ErrPaused
+	}
+	return err
+}
+
+func (r *RateLimiter) getResetAt() time.Time {
+	_, resetAt := r.barrier()
+	return resetAt
+}
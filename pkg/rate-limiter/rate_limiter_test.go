@@ -1,18 +1,43 @@
 package rate_limiter
 
 import (
-	"fmt"
-	"math"
+	"context"
+	"net/http"
+	"sync"
 	"testing"
 	"time"
 
 	stream "github.com/GetStream/stream-chat-go/v6"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
 )
 
+// blockUntil waits for n waiters on clock, failing the test after a second.
+func blockUntil(t *testing.T, clock *clocktest.Fake, n int) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := clock.BlockUntilContext(ctx, n); err != nil {
+		t.Fatalf("waiting for %d waiters: %v", n, err)
+	}
+}
+
+// receive returns the result of a call run in a goroutine, failing the test
+// after a second.
+func receive(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the call")
+		return nil
+	}
+}
+
 func TestRateLimiter(t *testing.T) {
-	var start time.Time
 	logger, _ := test.NewNullLogger()
 
 	type rateLimtedCase struct {
@@ -40,30 +65,45 @@ func TestRateLimiter(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			start := time.Unix(1000, 0)
+			clock := clocktest.NewFake(start)
 			rLimit := RateLimiter{
 				apiName: tt.name,
-				token:   make(chan struct{}, 1),
+				slots:   1,
+				clock:   clock,
 			}
 
 			mockResponse := &stream.Response{
 				RateLimitInfo: &stream.RateLimitInfo{
 					Remaining: int64(tt.iterationCount - 1),
-					Reset:     time.Now().Unix() + tt.resetSeconds,
+					Reset:     start.Unix() + tt.resetSeconds,
 				},
 			}
 
-			for i := 0; i < tt.iterationCount; i++ {
-				start = time.Now()
-				rLimit.CallApiAndBlockOnRateLimit(logger, func() (resp *stream.Response, err error) {
-					mockResponse.RateLimitInfo.Remaining -= 1
-					return mockResponse, nil
-				})
-			}
+			done := make(chan error)
+			go func() {
+				var err error
+				for i := 0; i < tt.iterationCount && err == nil; i++ {
+					err = rLimit.CallApiAndBlockOnRateLimit(logger, func() (resp *stream.Response, err error) {
+						mockResponse.RateLimitInfo.Remaining -= 1
+						return mockResponse, nil
+					})
+				}
+				done <- err
+			}()
 
-			since := time.Since(start)
-			assert.Greater(t, since, time.Duration((tt.resetSeconds-1)*int64(math.Pow10(9))))
-			assert.Less(t, since, time.Duration((tt.resetSeconds+1)*int64(4*math.Pow10(9))))
-			fmt.Printf("Time passed %v\n", time.Since(start))
+			if tt.resetSeconds > 0 {
+				// the last iteration waits for the reset
+				blockUntil(t, clock, 1)
+				select {
+				case <-done:
+					t.Fatal("calls admitted before the reset")
+				default:
+				}
+				clock.Advance(time.Duration(tt.resetSeconds) * time.Second)
+			}
+			assert.NoError(t, receive(t, done))
+			assert.Equal(t, time.Duration(tt.resetSeconds)*time.Second, clock.Now().Sub(start))
 		})
 	}
 }
@@ -99,10 +139,350 @@ func TestRateLimitErrorInApiCall(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			rLimit := RateLimiter{
-				token: make(chan struct{}, 1),
+				slots: 1,
 			}
 
 			tt.wantError(t, rLimit.CallApiAndBlockOnRateLimit(logger, tt.mockFn))
 		})
 	}
 }
+
+func TestZeroValueRateLimiter(t *testing.T) {
+	var rLimit RateLimiter
+
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 1}}, nil
+	}))
+	assert.NoError(t, rLimit.TryCallApi(func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 1}}, nil
+	}))
+	assert.Equal(t, 1, cap(rLimit.tokens()))
+}
+
+func TestCallApiWithContext(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	exhausted := func() (resp *stream.Response, err error) {
+		return &stream.Response{
+			RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Reset: 1060},
+		}, nil
+	}
+
+	t.Run("Blocked call is cancelled", func(t *testing.T) {
+		clock := clocktest.NewFake(time.Unix(1000, 0))
+		rLimit := NewRateLimiter(QueryUsers, WithLogger(logger), WithClock(clock))
+		assert.NoError(t, rLimit.CallApiWithContext(context.Background(), exhausted))
+		blockUntil(t, clock, 1)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		called := false
+		done := make(chan error)
+		go func() {
+			done <- rLimit.CallApiWithContext(ctx, func() (resp *stream.Response, err error) {
+				called = true
+				return exhausted()
+			})
+		}()
+		cancel()
+		assert.ErrorIs(t, receive(t, done), context.Canceled)
+		assert.False(t, called)
+	})
+
+	t.Run("Already cancelled context", func(t *testing.T) {
+		clock := clocktest.NewFake(time.Unix(1000, 0))
+		rLimit := NewRateLimiter(QueryUsers, WithLogger(logger), WithClock(clock))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, rLimit.CallApiWithContext(ctx, exhausted), context.Canceled)
+	})
+
+	t.Run("Cancel keeps other callers blocked", func(t *testing.T) {
+		clock := clocktest.NewFake(time.Unix(1000, 0))
+		rLimit := NewRateLimiter(QueryUsers, WithLogger(logger), WithClock(clock))
+		ctx, cancel := context.WithCancel(context.Background())
+		assert.NoError(t, rLimit.CallApiWithContext(ctx, func() (resp *stream.Response, err error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Reset: 1060}}, nil
+		}))
+		blockUntil(t, clock, 1)
+		cancel()
+
+		done := make(chan error)
+		go func() {
+			done <- rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+				return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 1}}, nil
+			})
+		}()
+		select {
+		case err := <-done:
+			t.Fatalf("call resumed before reset: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		}
+		assert.True(t, rLimit.IsBlocked())
+
+		clock.Advance(time.Minute)
+		assert.NoError(t, receive(t, done))
+	})
+}
+
+func TestTryCallApi(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	reset := time.Now().Unix() + 60
+	rLimit := NewRateLimiter(QueryChannel, WithLogger(logger))
+
+	assert.NoError(t, rLimit.TryCallApi(func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Reset: reset}}, nil
+	}))
+
+	called := false
+	err := rLimit.TryCallApi(func() (resp *stream.Response, err error) {
+		called = true
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 1}}, nil
+	})
+	var rateLimited ErrRateLimited
+	assert.ErrorAs(t, err, &rateLimited)
+	assert.Equal(t, time.Unix(reset, 0), rateLimited.ResetAt)
+	assert.Equal(t, string(QueryChannel), rateLimited.ApiName)
+	assert.False(t, called)
+}
+
+func TestMaxConcurrency(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithLogger(logger), WithMaxConcurrency(3), WithClock(clock))
+
+	var wg sync.WaitGroup
+	inFlight := make(chan struct{}, 3)
+	release := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+				inFlight <- struct{}{}
+				<-release
+				return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 100}}, nil
+			}))
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-inFlight:
+		case <-time.After(time.Second):
+			t.Fatal("calls were not run concurrently")
+		}
+	}
+	assert.Error(t, rLimit.TryCallApi(func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 100}}, nil
+	}))
+	close(release)
+	wg.Wait()
+}
+
+func TestMaxConcurrencyBlocksAllSlotsWhenExhausted(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	reset := int64(1060)
+	rLimit := NewRateLimiter(QueryUsers, WithLogger(logger), WithMaxConcurrency(3), WithClock(clock), WithMaxWait(time.Second))
+
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Reset: reset}}, nil
+	}))
+
+	var rateLimited ErrRateLimited
+	for i := 0; i < 3; i++ {
+		err := rLimit.TryCallApi(func() (resp *stream.Response, err error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 1}}, nil
+		})
+		assert.ErrorAs(t, err, &rateLimited)
+		assert.Equal(t, time.Unix(reset, 0), rateLimited.ResetAt)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 1}}, nil
+		})
+	}()
+	// the reset and the max wait of the blocked call
+	blockUntil(t, clock, 2)
+	clock.Advance(time.Second)
+	assert.ErrorIs(t, receive(t, done), ErrWaitTimeout)
+}
+
+func TestMaxConcurrencyBlocksWhenRemainingBelowInFlight(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	const n = 3
+	rLimit := NewRateLimiter(QueryUsers, WithLogger(logger), WithMaxConcurrency(n), WithClock(clock))
+
+	var wg sync.WaitGroup
+	inFlight := make(chan struct{}, n)
+	release := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+				inFlight <- struct{}{}
+				<-release
+				// fewer calls left than the calls in flight
+				return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: n - 1, Reset: 1060}}, nil
+			}))
+		}()
+	}
+	for i := 0; i < n; i++ {
+		select {
+		case <-inFlight:
+		case <-time.After(time.Second):
+			t.Fatal("calls were not run concurrently")
+		}
+	}
+	close(release)
+	wg.Wait()
+
+	assert.True(t, rLimit.IsBlocked())
+	var rateLimited ErrRateLimited
+	assert.ErrorAs(t, rLimit.TryCallApi(nil), &rateLimited)
+	assert.Equal(t, time.Unix(1060, 0), rateLimited.ResetAt)
+}
+
+func TestHeadroom(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithHeadroom(3))
+	remaining := func(remaining int64) GetStreamApiCaller {
+		return func() (resp *stream.Response, err error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: remaining, Reset: 1060}}, nil
+		}
+	}
+
+	assert.NoError(t, rLimit.TryCallApi(remaining(4)))
+	assert.False(t, rLimit.IsBlocked())
+
+	// the last 3 calls of the window are left to others
+	assert.NoError(t, rLimit.TryCallApi(remaining(3)))
+	assert.True(t, rLimit.IsBlocked())
+	var rateLimited ErrRateLimited
+	assert.ErrorAs(t, rLimit.TryCallApi(remaining(2)), &rateLimited)
+	assert.Equal(t, time.Unix(1060, 0), rateLimited.ResetAt)
+}
+
+func TestMaxWait(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	start := time.Unix(1000, 0)
+	remaining := func(remaining int64, resetSeconds int64) GetStreamApiCaller {
+		return func() (resp *stream.Response, err error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: remaining, Reset: start.Unix() + resetSeconds}}, nil
+		}
+	}
+
+	t.Run("Blocked call times out", func(t *testing.T) {
+		clock := clocktest.NewFake(start)
+		rLimit := NewRateLimiter(QueryUsers, WithLogger(logger), WithMaxWait(50*time.Millisecond), WithClock(clock))
+		assert.NoError(t, rLimit.CallApiWithContext(context.Background(), remaining(0, 60)))
+
+		done := make(chan error)
+		go func() {
+			done <- rLimit.CallApiWithContext(context.Background(), remaining(1, 60))
+		}()
+		blockUntil(t, clock, 2)
+		clock.Advance(50 * time.Millisecond)
+		err := receive(t, done)
+		assert.ErrorIs(t, err, ErrWaitTimeout)
+		assert.EqualError(t, err, "wait timeout on QueryUsers after 50ms")
+	})
+
+	t.Run("Queued call times out", func(t *testing.T) {
+		clock := clocktest.NewFake(start)
+		rLimit := NewRateLimiter(QueryUsers, WithLogger(logger), WithMaxWait(50*time.Millisecond), WithClock(clock))
+		rLimit.tokens() <- struct{}{}
+
+		done := make(chan error)
+		go func() {
+			done <- rLimit.CallApiWithContext(context.Background(), remaining(1, 60))
+		}()
+		blockUntil(t, clock, 1)
+		clock.Advance(50 * time.Millisecond)
+		assert.ErrorIs(t, receive(t, done), ErrWaitTimeout)
+	})
+
+	t.Run("Reset before max wait", func(t *testing.T) {
+		clock := clocktest.NewFake(start)
+		rLimit := NewRateLimiter(QueryUsers, WithLogger(logger), WithMaxWait(5*time.Second), WithClock(clock))
+		assert.NoError(t, rLimit.CallApiWithContext(context.Background(), remaining(0, 1)))
+
+		done := make(chan error)
+		go func() {
+			done <- rLimit.CallApiWithContext(context.Background(), remaining(1, 60))
+		}()
+		blockUntil(t, clock, 2)
+		clock.Advance(time.Second)
+		assert.NoError(t, receive(t, done))
+	})
+}
+
+func TestTooManyRequestsBlocks(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	reset := time.Now().Unix() + 60
+	rLimit := NewRateLimiter(QueryUsers, WithLogger(logger))
+
+	err := rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		return nil, stream.Error{StatusCode: http.StatusTooManyRequests, RateLimit: &stream.RateLimitInfo{Limit: 60, Reset: reset}}
+	})
+	assert.Error(t, err)
+	assert.True(t, rLimit.IsBlocked())
+	assert.Equal(t, int64(0), rLimit.Remaining())
+
+	var rateLimited ErrRateLimited
+	assert.ErrorAs(t, rLimit.TryCallApi(func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 1}}, nil
+	}), &rateLimited)
+	assert.Equal(t, time.Unix(reset, 0), rateLimited.ResetAt)
+}
+
+func TestRateLimiterWithFakeClock(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+	exhausted := func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Reset: clock.Now().Unix() + 4}}, nil
+	}
+
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), exhausted))
+	blockUntil(t, clock, 1)
+
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), exhausted)
+	}()
+
+	clock.Advance(3 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("call admitted before the reset")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clock.Advance(time.Second)
+	assert.NoError(t, receive(t, done))
+	blockUntil(t, clock, 1)
+	deadline, _ := clock.NextDeadline()
+	assert.Equal(t, time.Unix(1008, 0), deadline)
+}
+
+func TestLaterResetExtendsBlock(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+	assert.NoError(t, rLimit.TryCallApi(func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 1, Remaining: 0, Reset: 1060}}, nil
+	}))
+
+	// e.g. a late response of a call in flight or a preflight
+	assert.NoError(t, rLimit.applyRateLimits(context.Background(), stream.RateLimitsMap{
+		string(QueryUsers): {Limit: 1, Remaining: 0, Reset: 1120},
+	}))
+	assert.Equal(t, 1, clock.Waiters(), "timer rescheduled")
+	assert.Equal(t, time.Unix(1120, 0), rLimit.getResetAt())
+
+	clock.Advance(time.Minute)
+	assert.True(t, rLimit.IsBlocked())
+	clock.Advance(time.Minute)
+	assert.False(t, rLimit.IsBlocked())
+}
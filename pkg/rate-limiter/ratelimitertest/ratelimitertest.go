@@ -0,0 +1,230 @@
+// Package ratelimitertest provides a fake rate_limiter.Limiter with scripted
+// decisions, so that services can unit-test their handling of the limiter,
+// e.g. of a rejected or blocked call, without sleeping or calling Stream.
+package ratelimitertest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+type decisionKind int
+
+const (
+	admit decisionKind = iota
+	block
+	reject
+)
+
+// Decision is how a Fake handles a call, see Admit, BlockFor and Reject.
+type Decision struct {
+	kind decisionKind
+	d    time.Duration
+	err  error
+}
+
+// Admit admits the call right away.
+func Admit() Decision {
+	return Decision{kind: admit}
+}
+
+// BlockFor admits the call once d elapsed on the clock of the Fake, failing
+// with the ctx error when ctx is done meanwhile. TryCallApi turns it down with
+// an ErrRateLimited instead. Without a clock the block is only recorded.
+func BlockFor(d time.Duration) Decision {
+	return Decision{kind: block, d: d}
+}
+
+// Reject turns the call down with err, an ErrRateLimited when nil, without
+// calling the api.
+func Reject(err error) Decision {
+	return Decision{kind: reject, err: err}
+}
+
+func (d Decision) String() string {
+	switch d.kind {
+	case block:
+		return fmt.Sprintf("BlockFor(%v)", d.d)
+	case reject:
+		return fmt.Sprintf("Reject(%v)", d.err)
+	default:
+		return "Admit()"
+	}
+}
+
+// Call is a call recorded by a Fake.
+type Call struct {
+	// Try reports a call of TryCallApi.
+	Try      bool
+	Decision Decision
+	// Called reports whether the api was called.
+	Called bool
+	// Blocked is how long the call was blocked.
+	Blocked time.Duration
+	Err     error
+	At      time.Time
+	// Options are the settings of the call, see rate_limiter.CallOption.
+	Options rate_limiter.CallOptions
+}
+
+// Fake is a rate_limiter.Limiter handling the calls as scripted by Then,
+// admitting them once the script is over, and recording them. The calls with
+// rate_limiter.SkipLimit are admitted without using the script. It is safe for
+// concurrent use.
+type Fake struct {
+	apiName string
+	clock   rate_limiter.Clock
+
+	mu     sync.Mutex
+	script []Decision
+	calls  []Call
+}
+
+var _ rate_limiter.Limiter = (*Fake)(nil)
+
+// NewFake returns a Fake of apiName, blocking the calls on clock, e.g. a
+// clocktest.Fake; a nil clock only records the blocks.
+func NewFake(apiName rate_limiter.GetStreamApiName, clock rate_limiter.Clock) *Fake {
+	return &Fake{apiName: string(apiName), clock: clock}
+}
+
+// Then appends decisions to the script, one per call in order.
+func (f *Fake) Then(decisions ...Decision) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.script = append(f.script, decisions...)
+	return f
+}
+
+func (f *Fake) now() time.Time {
+	if f.clock == nil {
+		return time.Now()
+	}
+	return f.clock.Now()
+}
+
+// next pops the next decision of the script for a call with options, admitting
+// the calls skipping the limiter without using a decision.
+func (f *Fake) next(options rate_limiter.CallOptions) Decision {
+	if options.SkipLimit {
+		return Admit()
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.script) == 0 {
+		return Admit()
+	}
+	decision := f.script[0]
+	f.script = f.script[1:]
+	return decision
+}
+
+func (f *Fake) record(call Call) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+}
+
+func (f *Fake) errRateLimited(resetAt time.Time) error {
+	return rate_limiter.ErrRateLimited{ApiName: f.apiName, ResetAt: resetAt}
+}
+
+func (f *Fake) CallApiWithContext(ctx context.Context, apiCall rate_limiter.GetStreamApiCaller, opts ...rate_limiter.CallOption) error {
+	options := rate_limiter.ApplyCallOptions(opts...)
+	call := Call{Decision: f.next(options), At: f.now(), Options: options}
+	defer func() { f.record(call) }()
+	switch call.Decision.kind {
+	case reject:
+		call.Err = call.Decision.err
+		if call.Err == nil {
+			call.Err = f.errRateLimited(time.Time{})
+		}
+		return call.Err
+	case block:
+		call.Blocked = call.Decision.d
+		if f.clock != nil {
+			select {
+			case <-f.clock.After(call.Decision.d):
+			case <-ctx.Done():
+				call.Err = ctx.Err()
+				return call.Err
+			}
+		}
+	}
+	call.Called = true
+	_, call.Err = apiCall()
+	return call.Err
+}
+
+func (f *Fake) TryCallApi(apiCall rate_limiter.GetStreamApiCaller, opts ...rate_limiter.CallOption) error {
+	options := rate_limiter.ApplyCallOptions(opts...)
+	call := Call{Try: true, Decision: f.next(options), At: f.now(), Options: options}
+	defer func() { f.record(call) }()
+	switch call.Decision.kind {
+	case reject:
+		call.Err = call.Decision.err
+		if call.Err == nil {
+			call.Err = f.errRateLimited(time.Time{})
+		}
+		return call.Err
+	case block:
+		call.Err = f.errRateLimited(call.At.Add(call.Decision.d))
+		return call.Err
+	}
+	call.Called = true
+	_, call.Err = apiCall()
+	return call.Err
+}
+
+// Calls returns the calls recorded so far.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// AssertCalls reports whether want calls were recorded, failing t otherwise.
+func (f *Fake) AssertCalls(t testing.TB, want int) bool {
+	t.Helper()
+	if got := len(f.Calls()); got != want {
+		t.Errorf("%s: got %d calls, want %d", f.apiName, got, want)
+		return false
+	}
+	return true
+}
+
+// AssertCalled reports whether the api was called want times, failing t
+// otherwise.
+func (f *Fake) AssertCalled(t testing.TB, want int) bool {
+	t.Helper()
+	got := 0
+	for _, call := range f.Calls() {
+		if call.Called {
+			got++
+		}
+	}
+	if got != want {
+		t.Errorf("%s: api called %d times, want %d", f.apiName, got, want)
+		return false
+	}
+	return true
+}
+
+// AssertScriptDone reports whether every scripted decision was used, failing
+// t otherwise.
+func (f *Fake) AssertScriptDone(t testing.TB) bool {
+	t.Helper()
+	f.mu.Lock()
+	left := append([]Decision(nil), f.script...)
+	f.mu.Unlock()
+	if len(left) > 0 {
+		t.Errorf("%s: %d scripted decisions left: %v", f.apiName, len(left), left)
+		return false
+	}
+	return true
+}
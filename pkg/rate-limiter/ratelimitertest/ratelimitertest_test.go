@@ -0,0 +1,114 @@
+package ratelimitertest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/ratelimitertest"
+)
+
+func okCall() (*stream.Response, error) {
+	return &stream.Response{}, nil
+}
+
+// recordingT records the errors of the assertions instead of failing.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestFake(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	errDown := errors.New("down")
+	fake := ratelimitertest.NewFake(rate_limiter.QueryUsers, clock).
+		Then(ratelimitertest.Reject(nil), ratelimitertest.Reject(errDown), ratelimitertest.BlockFor(time.Minute))
+
+	err := fake.CallApiWithContext(context.Background(), okCall)
+	assert.True(t, errors.Is(err, rate_limiter.ErrRateLimited{}))
+	assert.ErrorIs(t, fake.CallApiWithContext(context.Background(), okCall), errDown)
+
+	done := make(chan error)
+	go func() {
+		done <- fake.CallApiWithContext(context.Background(), okCall)
+	}()
+	require.NoError(t, clock.BlockUntilContext(context.Background(), 1))
+	clock.Advance(time.Minute)
+	require.NoError(t, <-done)
+	require.NoError(t, fake.TryCallApi(okCall), "admitted once the script is over")
+
+	assert.Equal(t, []ratelimitertest.Call{
+		{Decision: ratelimitertest.Reject(nil), Err: rate_limiter.ErrRateLimited{ApiName: "QueryUsers"}, At: time.Unix(1000, 0)},
+		{Decision: ratelimitertest.Reject(errDown), Err: errDown, At: time.Unix(1000, 0)},
+		{Decision: ratelimitertest.BlockFor(time.Minute), Called: true, Blocked: time.Minute, At: time.Unix(1000, 0)},
+		{Try: true, Decision: ratelimitertest.Admit(), Called: true, At: time.Unix(1060, 0)},
+	}, fake.Calls())
+	assert.True(t, fake.AssertCalls(t, 4))
+	assert.True(t, fake.AssertCalled(t, 2))
+	assert.True(t, fake.AssertScriptDone(t))
+}
+
+func TestFakeBlockWithoutClock(t *testing.T) {
+	fake := ratelimitertest.NewFake(rate_limiter.QueryUsers, nil).
+		Then(ratelimitertest.BlockFor(time.Hour), ratelimitertest.BlockFor(time.Hour))
+	require.NoError(t, fake.CallApiWithContext(context.Background(), okCall), "recorded without sleeping")
+
+	err := fake.TryCallApi(okCall)
+	var rateLimited rate_limiter.ErrRateLimited
+	require.ErrorAs(t, err, &rateLimited)
+	assert.Equal(t, fake.Calls()[1].At.Add(time.Hour), rateLimited.ResetAt)
+	fake.AssertCalled(t, 1)
+}
+
+func TestFakeBlockCancelled(t *testing.T) {
+	fake := ratelimitertest.NewFake(rate_limiter.QueryUsers, clocktest.NewFake(time.Unix(1000, 0))).
+		Then(ratelimitertest.BlockFor(time.Minute))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, fake.CallApiWithContext(ctx, okCall), context.Canceled)
+	fake.AssertCalled(t, 0)
+}
+
+func TestFakeAssertionsFail(t *testing.T) {
+	fake := ratelimitertest.NewFake(rate_limiter.QueryUsers, nil).Then(ratelimitertest.Reject(nil), ratelimitertest.Admit())
+	_ = fake.TryCallApi(okCall)
+
+	recorder := &recordingT{TB: t}
+	assert.False(t, fake.AssertCalls(recorder, 2))
+	assert.False(t, fake.AssertCalled(recorder, 1))
+	assert.False(t, fake.AssertScriptDone(recorder))
+	assert.Equal(t, []string{
+		"QueryUsers: got 1 calls, want 2",
+		"QueryUsers: api called 0 times, want 1",
+		"QueryUsers: 1 scripted decisions left: [Admit()]",
+	}, recorder.errors)
+}
+
+func TestFakeCallOptions(t *testing.T) {
+	fake := ratelimitertest.NewFake(rate_limiter.QueryUsers, nil).Then(ratelimitertest.Reject(nil))
+
+	assert.NoError(t, fake.TryCallApi(okCall, rate_limiter.SkipLimit(), rate_limiter.WithTags(map[string]string{"job": "sync"})))
+	assert.False(t, fake.AssertScriptDone(&recordingT{TB: t}), "no decision used")
+	calls := fake.Calls()
+	require.Len(t, calls, 1)
+	assert.True(t, calls[0].Called)
+	assert.Equal(t, map[string]string{"job": "sync"}, calls[0].Options.Tags)
+
+	assert.Error(t, fake.CallApiWithContext(context.Background(), okCall, rate_limiter.WithPriority(rate_limiter.PriorityHigh)))
+	assert.Equal(t, rate_limiter.PriorityHigh, fake.Calls()[1].Options.Priority)
+	fake.AssertScriptDone(t)
+}
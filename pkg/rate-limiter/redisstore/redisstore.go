@@ -0,0 +1,196 @@
+// Package redisstore implements rate_limiter.Store on top of Redis, so that
+// the replicas of a service share the Stream quota of every api. State
+// changes run as Lua scripts, hence atomically.
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// DefaultKeyPrefix prefixes the key of the hash holding the state of each api.
+const DefaultKeyPrefix = "getstream-rate-limiter:"
+
+// keyTTL is how long the state outlives its window, so that idle apis expire.
+const keyTTL = time.Minute
+
+// Times are stored as Unix milliseconds.
+var takeScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local n = tonumber(ARGV[2] or '1')
+local blocked = tonumber(redis.call('HGET', KEYS[1], 'blocked_until') or '0')
+if blocked > now then
+  return blocked
+end
+local remaining = redis.call('HGET', KEYS[1], 'remaining')
+if not remaining then
+  return 0
+end
+local reset = tonumber(redis.call('HGET', KEYS[1], 'reset') or '0')
+if reset <= now then
+  -- the window is over, remaining calls are unknown until the next response
+  redis.call('HDEL', KEYS[1], 'remaining')
+  return 0
+end
+remaining = tonumber(remaining)
+if remaining <= 0 then
+  redis.call('HSET', KEYS[1], 'blocked_until', reset)
+  return reset
+end
+redis.call('HSET', KEYS[1], 'remaining', math.max(remaining - n, 0))
+return 0
+`)
+
+var observeScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local remaining = tonumber(ARGV[2])
+local reset = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+local now = tonumber(ARGV[5])
+local current_reset = tonumber(redis.call('HGET', KEYS[1], 'reset') or '0')
+if reset < current_reset then
+  -- late response of a previous window
+  return 0
+end
+if reset == current_reset then
+  local current = redis.call('HGET', KEYS[1], 'remaining')
+  if current and tonumber(current) < remaining then
+    remaining = tonumber(current)
+  end
+end
+redis.call('HSET', KEYS[1], 'limit', limit, 'remaining', remaining, 'reset', reset)
+local blocked = tonumber(redis.call('HGET', KEYS[1], 'blocked_until') or '0')
+if remaining <= 0 and reset > blocked then
+  blocked = reset
+  redis.call('HSET', KEYS[1], 'blocked_until', blocked)
+end
+-- relative to the caller's clock, so that clock skew with Redis cannot expire
+-- the state early
+redis.call('PEXPIRE', KEYS[1], math.max(math.max(reset, blocked) - now, 0) + ttl)
+return 1
+`)
+
+// budgetScript counts the usage of a budget, never below 0, expiring it at the
+// end of its period.
+var budgetScript = redis.NewScript(`
+local used = redis.call('INCRBY', KEYS[1], ARGV[1])
+if used < 0 then
+  used = 0
+  redis.call('SET', KEYS[1], 0)
+end
+redis.call('PEXPIREAT', KEYS[1], ARGV[2])
+return used
+`)
+
+// campaignScript elects ARGV[1] as the leader for ARGV[2] milliseconds, unless
+// another candidate holds the key, returning the leader.
+var campaignScript = redis.NewScript(`
+local leader = redis.call('GET', KEYS[1])
+if leader and leader ~= ARGV[1] then
+  return leader
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return ARGV[1]
+`)
+
+// Store is a rate_limiter.Store backed by Redis.
+type Store struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+var (
+	_ rate_limiter.CostStore   = (*Store)(nil)
+	_ rate_limiter.BudgetStore = (*Store)(nil)
+	_ rate_limiter.Elector     = (*Store)(nil)
+)
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithKeyPrefix replaces DefaultKeyPrefix, e.g. to isolate several Stream apps.
+func WithKeyPrefix(prefix string) Option {
+	return func(s *Store) {
+		s.prefix = prefix
+	}
+}
+
+// New returns a Store keeping its state through client.
+func New(client redis.UniversalClient, opts ...Option) *Store {
+	s := &Store{
+		client: client,
+		prefix: DefaultKeyPrefix,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Store) key(api string) string {
+	return s.prefix + api
+}
+
+func (s *Store) Take(ctx context.Context, api string, now time.Time) (time.Time, error) {
+	return s.TakeN(ctx, api, now, 1)
+}
+
+func (s *Store) TakeN(ctx context.Context, api string, now time.Time, n int) (time.Time, error) {
+	blockedUntil, err := takeScript.Run(ctx, s.client, []string{s.key(api)}, now.UnixMilli(), n).Int64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if blockedUntil == 0 {
+		return time.Time{}, nil
+	}
+	return time.UnixMilli(blockedUntil), nil
+}
+
+func (s *Store) Observe(ctx context.Context, api string, info rate_limiter.LimitInfo, now time.Time) error {
+	return observeScript.Run(ctx, s.client, []string{s.key(api)},
+		info.Limit, info.Remaining, info.Reset*1000, keyTTL.Milliseconds(), now.UnixMilli(),
+	).Err()
+}
+
+func (s *Store) Load(ctx context.Context, api string) (rate_limiter.State, error) {
+	fields, err := s.client.HGetAll(ctx, s.key(api)).Result()
+	if err != nil {
+		return rate_limiter.State{}, err
+	}
+	var state rate_limiter.State
+	for name, value := range fields {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return rate_limiter.State{}, errors.New("redisstore: invalid " + name + " for " + api)
+		}
+		switch name {
+		case "limit":
+			state.Limit = n
+		case "remaining":
+			state.Remaining = n
+		case "reset":
+			state.Reset = time.UnixMilli(n)
+		case "blocked_until":
+			state.BlockedUntil = time.UnixMilli(n)
+		}
+	}
+	return state, nil
+}
+
+// AddUsage counts the usage of the budgets of rate_limiter.WithBudget, so that
+// they are shared by the replicas and survive their restarts.
+func (s *Store) AddUsage(ctx context.Context, key string, n int64, expiresAt time.Time) (int64, error) {
+	return budgetScript.Run(ctx, s.client, []string{s.prefix + "budget:" + key}, n, expiresAt.UnixMilli()).Int64()
+}
+
+// Campaign elects the leader of election among the replicas, the lead expiring
+// with the key holding it, on the clock of Redis.
+func (s *Store) Campaign(ctx context.Context, election, candidate string, now time.Time, ttl time.Duration) (string, error) {
+	return campaignScript.Run(ctx, s.client, []string{s.prefix + "leader:" + election}, candidate, max(ttl.Milliseconds(), 1)).Text()
+}
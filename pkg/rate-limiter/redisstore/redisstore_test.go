@@ -0,0 +1,236 @@
+package redisstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func newTestStore(t *testing.T, opts ...Option) (*Store, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return New(client, opts...), server
+}
+
+func TestStoreTakeAndObserve(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestStore(t)
+	now := time.Unix(1000, 0)
+	api := string(rate_limiter.QueryUsers)
+
+	blockedUntil, err := store.Take(ctx, api, now)
+	require.NoError(t, err)
+	assert.True(t, blockedUntil.IsZero(), "unknown apis are admitted")
+
+	require.NoError(t, store.Observe(ctx, api, rate_limiter.LimitInfo{Limit: 10, Remaining: 2, Reset: 1060}, now))
+	for i := 0; i < 2; i++ {
+		blockedUntil, err = store.Take(ctx, api, now)
+		require.NoError(t, err)
+		assert.True(t, blockedUntil.IsZero())
+	}
+	blockedUntil, err = store.Take(ctx, api, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1060, 0), blockedUntil)
+
+	state, err := store.Load(ctx, api)
+	require.NoError(t, err)
+	assert.Equal(t, rate_limiter.State{
+		Limit:        10,
+		Remaining:    0,
+		Reset:        time.Unix(1060, 0),
+		BlockedUntil: time.Unix(1060, 0),
+	}, state)
+
+	blockedUntil, err = store.Take(ctx, api, time.Unix(1060, 0))
+	require.NoError(t, err)
+	assert.True(t, blockedUntil.IsZero(), "admitted once the window is over")
+}
+
+func TestStoreObserve(t *testing.T) {
+	ctx := context.Background()
+	store, server := newTestStore(t, WithKeyPrefix("test:"))
+	api := string(rate_limiter.QueryChannel)
+	now := time.Unix(1000, 0)
+
+	require.NoError(t, store.Observe(ctx, api, rate_limiter.LimitInfo{Limit: 10, Remaining: 5, Reset: 1060}, now))
+	assert.True(t, server.Exists("test:QueryChannel"))
+	assert.Equal(t, time.Minute+keyTTL, server.TTL("test:QueryChannel"), "expires after the reset")
+
+	// a late response of the same window does not raise the remaining calls
+	require.NoError(t, store.Observe(ctx, api, rate_limiter.LimitInfo{Limit: 10, Remaining: 7, Reset: 1060}, now))
+	state, err := store.Load(ctx, api)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), state.Remaining)
+
+	// nor does a response of a previous window
+	require.NoError(t, store.Observe(ctx, api, rate_limiter.LimitInfo{Limit: 10, Remaining: 9, Reset: 1000}, now))
+	state, err = store.Load(ctx, api)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1060, 0), state.Reset)
+
+	require.NoError(t, store.Observe(ctx, api, rate_limiter.LimitInfo{Limit: 10, Remaining: 0, Reset: 1060}, now))
+	state, err = store.Load(ctx, api)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1060, 0), state.BlockedUntil)
+
+	state, err = store.Load(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Equal(t, rate_limiter.State{}, state)
+}
+
+func TestStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	store, server := newTestStore(t)
+	api := string(rate_limiter.QueryUsers)
+
+	// a reset already passed keeps the state for keyTTL only
+	require.NoError(t, store.Observe(ctx, api, rate_limiter.LimitInfo{Limit: 10, Remaining: 5, Reset: 1000}, time.Unix(1030, 0)))
+	assert.Equal(t, keyTTL, server.TTL(DefaultKeyPrefix+api))
+
+	server.FastForward(keyTTL)
+	assert.False(t, server.Exists(DefaultKeyPrefix+api))
+}
+
+// blockUntil waits for n waiters on clock, failing the test after a second.
+func blockUntil(t *testing.T, clock *clocktest.Fake, n int) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, clock.BlockUntilContext(ctx, n), "waiting for %d waiters", n)
+}
+
+func receive(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the call")
+		return nil
+	}
+}
+
+func TestStoreSharedBetweenLimiters(t *testing.T) {
+	store, _ := newTestStore(t)
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	first := rate_limiter.NewRateLimiter(rate_limiter.QueryUsers, rate_limiter.WithStore(store), rate_limiter.WithClock(clock))
+	second := rate_limiter.NewRateLimiter(rate_limiter.QueryUsers, rate_limiter.WithStore(store), rate_limiter.WithClock(clock))
+
+	assert.NoError(t, first.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1060}}, nil
+	}))
+
+	var rateLimited rate_limiter.ErrRateLimited
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- second.CallApiWithContext(ctx, func() (resp *stream.Response, err error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 9, Reset: 1120}}, nil
+		})
+	}()
+	// first waits for its own reset, second for the shared one
+	blockUntil(t, clock, 2)
+	assert.True(t, second.IsBlocked())
+	assert.ErrorAs(t, second.TryCallApi(nil), &rateLimited)
+	assert.Equal(t, time.Unix(1060, 0), rateLimited.ResetAt)
+
+	clock.Advance(time.Minute)
+	assert.NoError(t, receive(t, done))
+	cancel()
+}
+
+func TestStoreTryCallApi(t *testing.T) {
+	store, _ := newTestStore(t)
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	first := rate_limiter.NewRateLimiter(rate_limiter.QueryUsers, rate_limiter.WithStore(store), rate_limiter.WithClock(clock))
+	second := rate_limiter.NewRateLimiter(rate_limiter.QueryUsers, rate_limiter.WithStore(store), rate_limiter.WithClock(clock))
+
+	assert.NoError(t, first.TryCallApi(func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1060}}, nil
+	}))
+
+	// second is not blocked locally, the shared state rejects the call
+	assert.False(t, second.IsBlocked())
+	var rateLimited rate_limiter.ErrRateLimited
+	called := false
+	assert.ErrorAs(t, second.TryCallApi(func() (resp *stream.Response, err error) {
+		called = true
+		return &stream.Response{}, nil
+	}), &rateLimited)
+	assert.False(t, called)
+	assert.Equal(t, string(rate_limiter.QueryUsers), rateLimited.ApiName)
+	assert.Equal(t, time.Unix(1060, 0), rateLimited.ResetAt)
+}
+
+func TestStoreTakeN(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newTestStore(t)
+	now := time.Unix(1000, 0)
+	api := string(rate_limiter.UpdateUsers)
+
+	require.NoError(t, store.Observe(ctx, api, rate_limiter.LimitInfo{Limit: 10, Remaining: 5, Reset: 1060}, now))
+	blockedUntil, err := store.TakeN(ctx, api, now, 3)
+	require.NoError(t, err)
+	assert.True(t, blockedUntil.IsZero())
+	state, err := store.Load(ctx, api)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), state.Remaining)
+
+	// a call costing more than the calls left is admitted, Stream deciding
+	blockedUntil, err = store.TakeN(ctx, api, now, 3)
+	require.NoError(t, err)
+	assert.True(t, blockedUntil.IsZero())
+	blockedUntil, err = store.Take(ctx, api, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1060, 0), blockedUntil)
+}
+
+func TestStoreAddUsage(t *testing.T) {
+	ctx := context.Background()
+	store, server := newTestStore(t)
+	end := time.Now().Add(time.Hour)
+
+	used, err := store.AddUsage(ctx, "QueryUsers/daily/2026-10-14", 3, end)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), used)
+	used, err = store.AddUsage(ctx, "QueryUsers/daily/2026-10-14", -5, end)
+	require.NoError(t, err)
+	assert.Zero(t, used, "never below 0")
+	assert.InDelta(t, time.Hour, server.TTL(DefaultKeyPrefix+"budget:QueryUsers/daily/2026-10-14"), float64(time.Second))
+}
+
+func TestStoreCampaign(t *testing.T) {
+	ctx := context.Background()
+	store, server := newTestStore(t)
+
+	leader, err := store.Campaign(ctx, "quota", "a:7000", time.Now(), 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a:7000", leader)
+	leader, err = store.Campaign(ctx, "quota", "b:7000", time.Now(), 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a:7000", leader, "lead held")
+
+	server.FastForward(5 * time.Second)
+	leader, err = store.Campaign(ctx, "quota", "a:7000", time.Now(), 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a:7000", leader, "lead renewed")
+	server.FastForward(8 * time.Second)
+	leader, err = store.Campaign(ctx, "quota", "b:7000", time.Now(), 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a:7000", leader)
+
+	server.FastForward(3 * time.Second)
+	leader, err = store.Campaign(ctx, "quota", "b:7000", time.Now(), 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "b:7000", leader, "failover")
+}
@@ -0,0 +1,58 @@
+package rate_limiter
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// registry maps keys to the limiters of a LimiterManager. Its lookups do not
+// lock, so that the limiters of many tenants requested on every call scale
+// with the cores instead of contending on the mutex of the manager; its
+// writes must be serialized by the caller, holding the mutex of the manager.
+// The zero value is empty and ready to use.
+type registry[K comparable, V any] struct {
+	m sync.Map
+	n atomic.Int64
+}
+
+// load returns the value of key, if any.
+func (r *registry[K, V]) load(key K) (V, bool) {
+	v, ok := r.m.Load(key)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return v.(V), true
+}
+
+// store sets the value of key, not in r yet.
+func (r *registry[K, V]) store(key K, v V) {
+	r.m.Store(key, v)
+	r.n.Add(1)
+}
+
+// delete removes key, in r.
+func (r *registry[K, V]) delete(key K) {
+	r.m.Delete(key)
+	r.n.Add(-1)
+}
+
+// len returns the number of keys of r.
+func (r *registry[K, V]) len() int {
+	return int(r.n.Load())
+}
+
+// each applies f to the keys and values of r, in no particular order.
+func (r *registry[K, V]) each(f func(key K, v V)) {
+	r.m.Range(func(key, v any) bool {
+		f(key.(K), v.(V))
+		return true
+	})
+}
+
+// values returns the values of r, in no particular order.
+func (r *registry[K, V]) values() []V {
+	values := make([]V, 0, r.len())
+	r.each(func(_ K, v V) { values = append(values, v) })
+	return values
+}
@@ -0,0 +1,47 @@
+package rate_limiter
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	var r registry[string, int]
+	_, ok := r.load("a")
+	assert.False(t, ok)
+
+	r.store("a", 1)
+	r.store("b", 2)
+	v, ok := r.load("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, 2, r.len())
+	assert.ElementsMatch(t, []int{1, 2}, r.values())
+
+	r.delete("a")
+	_, ok = r.load("a")
+	assert.False(t, ok)
+	assert.Equal(t, []int{2}, r.values())
+}
+
+func TestLimiterManagerConcurrentForTenant(t *testing.T) {
+	manager := NewLimiterManager()
+	const callers = 50
+	limiters := make([]*RateLimiter, callers)
+	var wg sync.WaitGroup
+	for i := range limiters {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			limiters[i] = manager.ForTenant("jane", QueryUsers)
+		}(i)
+	}
+	wg.Wait()
+	for _, r := range limiters {
+		assert.Same(t, limiters[0], r, "a single limiter per tenant")
+	}
+	assert.Equal(t, 1, manager.TenantLimiters())
+	assert.Same(t, manager.For(QueryUsers), limiters[0].parent)
+}
@@ -0,0 +1,159 @@
+package rate_limiter
+
+import (
+	"container/heap"
+	"context"
+	"time"
+)
+
+// Settings are the settings of a limiter which can be changed while it is
+// being called, see RateLimiter.ApplySettings. The zero value of each field
+// is the default of NewRateLimiter.
+type Settings struct {
+	// MaxConcurrency is the calls in flight at the same time, see
+	// WithMaxConcurrency. Values lower than 1 allow a single call.
+	MaxConcurrency int
+	// Headroom is the calls of a window left to the other services, see
+	// WithHeadroom.
+	Headroom int
+	// MaxWait bounds the wait of the calls, see WithMaxWait.
+	MaxWait time.Duration
+	// MaxQueueDepth bounds the callers queued for a slot, see
+	// WithMaxQueueDepth.
+	MaxQueueDepth int
+	// Priority is the priority of the calls without one of their own, see
+	// WithDefaultPriority.
+	Priority Priority
+}
+
+// ManagerConfig are the Settings of the limiters of a LimiterManager, see
+// LimiterManager.ApplyConfig.
+type ManagerConfig struct {
+	// Defaults are the settings of the limiters of the apis without settings
+	// of their own.
+	Defaults Settings
+	// Apis are the settings per api name, or per bucket, see WithBucket. The
+	// global limiter, see WithGlobalLimiter, is only changed by the settings
+	// of GlobalApiName.
+	Apis map[GetStreamApiName]Settings
+}
+
+// settings returns the settings of the limiter of apiName, ok false for the
+// global limiter without settings of its own.
+func (c *ManagerConfig) settings(apiName GetStreamApiName) (Settings, bool) {
+	if s, ok := c.Apis[apiName]; ok {
+		return s, true
+	}
+	return c.Defaults, apiName != GlobalApiName
+}
+
+// WithDefaultPriority gives priority to the calls of the limiter without one
+// of their own, see CallWithPriority, e.g. so that the calls of an endpoint
+// are served first by the global limiter, see WithGlobalLimiter.
+func WithDefaultPriority(priority Priority) Option {
+	return func(r *RateLimiter) {
+		r.priority.Store(int64(priority))
+	}
+}
+
+// withDefaultPriority returns ctx with the default priority of r, unless ctx
+// already carries a priority.
+func (r *RateLimiter) withDefaultPriority(ctx context.Context) context.Context {
+	priority := Priority(r.priority.Load())
+	if priority == PriorityNormal {
+		return ctx
+	}
+	if _, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return ctx
+	}
+	return withPriority(ctx, priority)
+}
+
+// ApplySettings changes the settings of r while it is being called: the calls
+// in flight and the callers queued are kept, the latter being admitted right
+// away when the concurrency is raised, as is the state of the rate limit. A
+// lowered concurrency applies as the calls in flight return.
+func (r *RateLimiter) ApplySettings(s Settings) {
+	r.headroom.Store(int64(max(s.Headroom, 0)))
+	r.maxWait.Store(int64(max(s.MaxWait, 0)))
+	r.priority.Store(int64(s.Priority))
+	r.queue.mu.Lock()
+	r.queue.maxDepth = max(s.MaxQueueDepth, 0)
+	r.queue.mu.Unlock()
+	r.resize(max(s.MaxConcurrency, 1))
+}
+
+// resize replaces the token channel with one of capacity n, moving the tokens
+// held by the calls in flight into it, and the ones beyond n to the excess of
+// the queue, and handing the new slots over to the queued callers.
+func (r *RateLimiter) resize(n int) {
+	r.initToken()
+	q := &r.queue
+	q.mu.Lock()
+	old := r.tokens()
+	if cap(old) == n {
+		q.mu.Unlock()
+		return
+	}
+	// filled up, the old channel admits no call anymore
+	free := 0
+	for filled := false; !filled; {
+		select {
+		case old <- struct{}{}:
+			free++
+		default:
+			filled = true
+		}
+	}
+	held := cap(old) - free + q.excess
+	token := make(chan struct{}, n)
+	for i := 0; i < min(held, n); i++ {
+		token <- struct{}{}
+	}
+	q.excess = max(held-n, 0)
+	for len(token) < n && len(q.calls) > 0 {
+		token <- struct{}{}
+		call := heap.Pop(&q.calls).(*queuedCall)
+		call.granted = true
+		close(call.turn)
+	}
+	r.token.Store(&token)
+	q.mu.Unlock()
+
+	if r.aimd != nil {
+		r.aimd.resize(n, r.tokens(), func() { r.queue.release(&r.token) })
+	}
+}
+
+// ApplyConfig changes the settings of the limiters of m, the ones created so
+// far and the ones created afterwards, without dropping their queued callers
+// nor their rate limit state, see RateLimiter.ApplySettings. The limiters of
+// the tenants get the settings of their api. The settings given by the options
+// of m are overridden by cfg, the options not covered by Settings are kept.
+func (m *LimiterManager) ApplyConfig(cfg ManagerConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config = &cfg
+	if m.global != nil {
+		if s, ok := cfg.settings(GlobalApiName); ok {
+			m.global.ApplySettings(s)
+		}
+	}
+	m.limiters.each(func(apiName GetStreamApiName, r *RateLimiter) {
+		m.applyConfigLocked(apiName, r)
+	})
+	m.tenantLimiters.each(func(key tenantKey, t *tenantLimiter) {
+		m.applyConfigLocked(key.apiName, t.r)
+	})
+}
+
+// applyConfigLocked applies the config of m, if any, to r, the limiter of
+// apiName. m.mu must be held.
+func (m *LimiterManager) applyConfigLocked(apiName GetStreamApiName, r *RateLimiter) {
+	if m.config == nil {
+		return
+	}
+	if s, ok := m.config.settings(apiName); ok {
+		r.ApplySettings(s)
+	}
+}
@@ -0,0 +1,127 @@
+package rate_limiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplySettingsRaisesConcurrency(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	release := holdSlot(t, rLimit)
+	defer release()
+
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			done <- rLimit.CallApiWithContext(context.Background(), okCall)
+		}()
+	}
+	require.Eventually(t, func() bool { return queued(rLimit) == 2 }, time.Second, time.Millisecond)
+
+	rLimit.ApplySettings(Settings{MaxConcurrency: 3})
+	assert.Equal(t, 3, rLimit.Concurrency())
+	require.NoError(t, receive(t, done), "queued callers admitted")
+	require.NoError(t, receive(t, done))
+}
+
+func TestApplySettingsLowersConcurrency(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithMaxConcurrency(3))
+	releases := []func(){holdSlot(t, rLimit), holdSlot(t, rLimit), holdSlot(t, rLimit)}
+
+	rLimit.ApplySettings(Settings{MaxConcurrency: 1})
+	assert.Equal(t, 1, rLimit.Concurrency())
+	for _, release := range releases[:2] {
+		release()
+		var limited ErrRateLimited
+		assert.ErrorAs(t, rLimit.TryCallApi(okCall), &limited, "beyond the concurrency")
+	}
+	releases[2]()
+	assert.NoError(t, rLimit.TryCallApi(okCall))
+	assert.Empty(t, rLimit.tokens(), "every token released")
+}
+
+func TestApplySettingsKeepsState(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithMaxConcurrency(2), WithHeadroom(10), WithMaxQueueDepth(5))
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 100, Remaining: 20, Reset: time.Now().Add(time.Minute).Unix()}}, nil
+	}))
+
+	rLimit.ApplySettings(Settings{Headroom: 30, MaxWait: time.Millisecond, MaxQueueDepth: 1})
+	assert.Equal(t, int64(20), rLimit.Remaining(), "rate limit state kept")
+	assert.Equal(t, 1, rLimit.Concurrency(), "unset concurrency is the default")
+	assert.Equal(t, int64(30), rLimit.headroom.Load())
+	assert.Equal(t, time.Millisecond, rLimit.getMaxWait())
+	rLimit.queue.mu.Lock()
+	assert.Equal(t, 1, rLimit.queue.maxDepth)
+	rLimit.queue.mu.Unlock()
+}
+
+func TestApplySettingsAdaptiveConcurrency(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithMaxConcurrency(4), WithAdaptiveConcurrency())
+	rLimit.adapt(true)
+	assert.Equal(t, 2, rLimit.Concurrency())
+
+	rLimit.ApplySettings(Settings{MaxConcurrency: 8})
+	assert.Equal(t, 2, rLimit.Concurrency(), "adapted concurrency kept")
+	assert.Equal(t, 6, len(rLimit.tokens()), "slots parked")
+	rLimit.ApplySettings(Settings{MaxConcurrency: 1})
+	assert.Equal(t, 1, rLimit.Concurrency())
+	assert.Equal(t, 0, len(rLimit.tokens()), "parked slots released")
+}
+
+func TestDefaultPriority(t *testing.T) {
+	manager := NewLimiterManager(WithGlobalLimiter(), WithApiOptions(QueryUsers, WithDefaultPriority(PriorityHigh)))
+	release := holdSlot(t, manager.For(QueryMembers))
+
+	var (
+		mu     sync.Mutex
+		served []GetStreamApiName
+	)
+	done := make(chan error, 2)
+	for i, apiName := range []GetStreamApiName{QueryChannels, QueryUsers} {
+		apiName := apiName
+		go func() {
+			done <- manager.For(apiName).CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				served = append(served, apiName)
+				return &stream.Response{}, nil
+			})
+		}()
+		require.Eventually(t, func() bool { return queued(manager.Global()) == i+1 }, time.Second, time.Millisecond)
+	}
+	release()
+	require.NoError(t, receive(t, done))
+	require.NoError(t, receive(t, done))
+	assert.Equal(t, []GetStreamApiName{QueryUsers, QueryChannels}, served)
+}
+
+func TestApplyConfig(t *testing.T) {
+	manager := NewLimiterManager(WithMaxConcurrency(2), WithGlobalLimiter(WithMaxConcurrency(10)))
+	queryUsers := manager.For(QueryUsers)
+	tenant := manager.ForTenant("acme", QueryChannels)
+
+	manager.ApplyConfig(ManagerConfig{
+		Defaults: Settings{MaxConcurrency: 4},
+		Apis: map[GetStreamApiName]Settings{
+			QueryUsers: {MaxConcurrency: 8, Headroom: 5},
+		},
+	})
+	assert.Same(t, queryUsers, manager.For(QueryUsers), "limiters kept")
+	assert.Equal(t, 8, queryUsers.Concurrency())
+	assert.Equal(t, int64(5), queryUsers.headroom.Load())
+	assert.Equal(t, 4, tenant.Concurrency(), "settings of the api of the tenant")
+	assert.Equal(t, 4, manager.For(QueryChannels).Concurrency())
+	assert.Equal(t, 4, manager.For(UpdateUsers).Concurrency(), "limiters created afterwards")
+	assert.Equal(t, 10, manager.Global().Concurrency(), "global limiter without settings")
+
+	manager.ApplyConfig(ManagerConfig{Apis: map[GetStreamApiName]Settings{GlobalApiName: {MaxConcurrency: 3}}})
+	assert.Equal(t, 3, manager.Global().Concurrency())
+	assert.Equal(t, 1, queryUsers.Concurrency(), "settings of the new config only")
+}
@@ -0,0 +1,224 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// Client calls the Limiter service, e.g. of ratelimiterd, so that the calls
+// of a Go service share the Stream quota of the services of other languages
+// calling it too. See Limiter to run the calls through it.
+type Client struct {
+	client  LimiterClient
+	lease   time.Duration
+	onError func(error)
+}
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// WithLease sets how long the leases of the client last, the default of the
+// server otherwise. A call not over by then no longer holds its slot.
+func WithLease(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.lease = d
+	}
+}
+
+// WithErrorHandler reports the errors releasing the leases of the calls of
+// Limiter, which do not fail the calls: the server releases the leases once
+// expired.
+func WithErrorHandler(onError func(error)) ClientOption {
+	return func(c *Client) {
+		c.onError = onError
+	}
+}
+
+// NewClient returns a Client calling the Limiter service through conn, e.g.
+// a grpc.ClientConn to ratelimiterd.
+func NewClient(conn grpc.ClientConnInterface, opts ...ClientOption) *Client {
+	c := &Client{client: NewLimiterClient(conn)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Lease is a slot of an api acquired from the Limiter service.
+type Lease struct {
+	ID        string
+	ExpiresAt time.Time
+
+	client *Client
+}
+
+// Acquire waits until a call of api is admitted, or until ctx is done,
+// returning the Lease of its slot, to be released once the call is over.
+func (c *Client) Acquire(ctx context.Context, api rate_limiter.GetStreamApiName) (*Lease, error) {
+	return c.acquire(ctx, api, false)
+}
+
+// TryAcquire is like Acquire, but returns an ErrRateLimited when the call is
+// not admitted right away.
+func (c *Client) TryAcquire(ctx context.Context, api rate_limiter.GetStreamApiName) (*Lease, error) {
+	return c.acquire(ctx, api, true)
+}
+
+func (c *Client) acquire(ctx context.Context, api rate_limiter.GetStreamApiName, try bool) (*Lease, error) {
+	var trailer metadata.MD
+	req := &AcquireRequest{Api: string(api), LeaseMs: c.lease.Milliseconds(), Try: try}
+	resp, err := c.client.Acquire(ctx, req, grpc.Trailer(&trailer))
+	if err != nil {
+		if status.Code(err) != codes.ResourceExhausted {
+			return nil, err
+		}
+		rateLimited := rate_limiter.ErrRateLimited{ApiName: string(api)}
+		if reset := trailer.Get(resetTrailer); len(reset) > 0 {
+			if unix, err := strconv.ParseInt(reset[0], 10, 64); err == nil {
+				rateLimited.ResetAt = time.Unix(unix, 0)
+			}
+		}
+		return nil, rateLimited
+	}
+	return &Lease{ID: resp.LeaseId, ExpiresAt: unixMilli(resp.ExpiresMs), client: c}, nil
+}
+
+// Release gives the slot of l back, reporting the rate limit info of the
+// response of the call, nil when none was received. throttled reports a call
+// rejected for too many requests, blocking the api until the reset.
+func (l *Lease) Release(ctx context.Context, info *rate_limiter.LimitInfo, throttled bool) error {
+	req := &ReleaseRequest{LeaseId: l.ID, Throttled: throttled}
+	if info != nil {
+		req.Limit, req.Remaining, req.Reset_ = info.Limit, info.Remaining, info.Reset
+	}
+	_, err := l.client.client.Release(ctx, req)
+	return err
+}
+
+// Stats returns the status of the limiters of the server, sorted by api name.
+func (c *Client) Stats(ctx context.Context) ([]rate_limiter.LimiterStatus, error) {
+	resp, err := c.client.Stats(ctx, &StatsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]rate_limiter.LimiterStatus, 0, len(resp.Limiters))
+	for _, st := range resp.Limiters {
+		status := rate_limiter.LimiterStatus{
+			Api:        st.Api,
+			Remaining:  st.Remaining,
+			Blocked:    st.Blocked,
+			QueueDepth: int(st.QueueDepth),
+			InFlight:   int(st.InFlight),
+		}
+		if st.ResetMs != 0 {
+			resetAt := time.UnixMilli(st.ResetMs)
+			status.ResetAt = &resetAt
+		}
+		status.Stats.LastMinute = fromWindowStats(st.LastMinute)
+		status.Stats.Last5Minutes = fromWindowStats(st.Last_5Minutes)
+		status.Stats.LastHour = fromWindowStats(st.LastHour)
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// fromWindowStats converts w, zero when missing.
+func fromWindowStats(w *WindowStats) rate_limiter.WindowStatus {
+	return rate_limiter.WindowStatus{
+		Calls:      w.GetCalls(),
+		Errors:     w.GetErrors(),
+		Blocks:     w.GetBlocks(),
+		Rejections: w.GetRejections(),
+		AvgWaitMs:  float64(w.GetAvgWaitUs()) / 1000,
+		P99WaitMs:  float64(w.GetP99WaitUs()) / 1000,
+	}
+}
+
+// Limiter runs the calls of an api through the Limiter service, acquiring a
+// lease for each of them. Of the CallOption, only SkipLimit applies, the
+// other ones are ignored.
+type Limiter struct {
+	client *Client
+	api    rate_limiter.GetStreamApiName
+}
+
+var _ rate_limiter.Limiter = (*Limiter)(nil)
+
+// Limiter returns the Limiter of api.
+func (c *Client) Limiter(api rate_limiter.GetStreamApiName) *Limiter {
+	return &Limiter{client: c, api: api}
+}
+
+func (l *Limiter) CallApiWithContext(ctx context.Context, apiCall rate_limiter.GetStreamApiCaller, opts ...rate_limiter.CallOption) error {
+	return l.call(ctx, false, apiCall, opts)
+}
+
+func (l *Limiter) TryCallApi(apiCall rate_limiter.GetStreamApiCaller, opts ...rate_limiter.CallOption) error {
+	return l.call(context.Background(), true, apiCall, opts)
+}
+
+func (l *Limiter) call(ctx context.Context, try bool, apiCall rate_limiter.GetStreamApiCaller, opts []rate_limiter.CallOption) error {
+	if rate_limiter.ApplyCallOptions(opts...).SkipLimit {
+		_, err := apiCall()
+		return err
+	}
+	lease, err := l.client.acquire(ctx, l.api, try)
+	if err != nil {
+		return err
+	}
+	resp, err := apiCall()
+	var (
+		info      *rate_limiter.LimitInfo
+		throttled bool
+	)
+	if resp != nil {
+		info = limitInfo(resp.RateLimitInfo)
+	}
+	if apiErr, ok := tooManyRequests(err); ok {
+		info, throttled = limitInfo(apiErr.RateLimit), true
+	}
+	if releaseErr := lease.Release(context.WithoutCancel(ctx), info, throttled); releaseErr != nil && l.client.onError != nil {
+		l.client.onError(releaseErr)
+	}
+	if throttled {
+		rateLimited := rate_limiter.ErrRateLimited{ApiName: string(l.api), Err: err}
+		if info != nil && info.Reset != 0 {
+			rateLimited.ResetAt = time.Unix(info.Reset, 0)
+		}
+		return rateLimited
+	}
+	return err
+}
+
+// limitInfo converts the rate limit info reported by the SDK, nil if missing.
+func limitInfo(info *stream.RateLimitInfo) *rate_limiter.LimitInfo {
+	if info == nil {
+		return nil
+	}
+	return &rate_limiter.LimitInfo{Limit: info.Limit, Remaining: info.Remaining, Reset: info.Reset}
+}
+
+// tooManyRequests returns the Stream error of a call rejected for too many
+// requests, the SDK returning it by value or by pointer.
+func tooManyRequests(err error) (*stream.Error, bool) {
+	var apiErr stream.Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests {
+		return &apiErr, true
+	}
+	var apiErrPtr *stream.Error
+	if errors.As(err, &apiErrPtr) && apiErrPtr != nil && apiErrPtr.StatusCode == http.StatusTooManyRequests {
+		return apiErrPtr, true
+	}
+	return nil, false
+}
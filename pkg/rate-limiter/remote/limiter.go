@@ -0,0 +1,228 @@
+package remote
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// DefaultLease is how long a lease of the Limiter service lasts when its
+// client does not set it.
+const DefaultLease = 30 * time.Second
+
+// resetTrailer carries the reset of a call turned down by an api blocked until
+// then, in Unix seconds.
+const resetTrailer = "x-ratelimit-reset"
+
+// LimiterService serves the Limiter service, admitting the calls of its
+// clients with the limiters of a LimiterManager, e.g. for the services sharing
+// the Stream quota of ratelimiterd, see Client.
+type LimiterService struct {
+	UnimplementedLimiterServer
+
+	manager  *rate_limiter.LimiterManager
+	lease    time.Duration
+	maxLease time.Duration
+
+	mu     sync.Mutex
+	leases map[string]*lease
+}
+
+// lease is a slot acquired by a client.
+type lease struct {
+	release func(info *rate_limiter.LimitInfo)
+	timer   *time.Timer
+}
+
+// ServiceOption configures a LimiterService.
+type ServiceOption func(*LimiterService)
+
+// WithDefaultLease replaces DefaultLease.
+func WithDefaultLease(d time.Duration) ServiceOption {
+	return func(s *LimiterService) {
+		if d > 0 {
+			s.lease = d
+		}
+	}
+}
+
+// WithMaxLease caps the leases requested by the clients, unbounded by
+// default.
+func WithMaxLease(d time.Duration) ServiceOption {
+	return func(s *LimiterService) {
+		s.maxLease = d
+	}
+}
+
+// NewLimiterService returns a LimiterService admitting the calls with the
+// limiters of manager.
+func NewLimiterService(manager *rate_limiter.LimiterManager, opts ...ServiceOption) *LimiterService {
+	s := &LimiterService{
+		manager: manager,
+		lease:   DefaultLease,
+		leases:  map[string]*lease{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Register serves the Limiter service of s on server.
+func (s *LimiterService) Register(server *grpc.Server) {
+	RegisterLimiterServer(server, s)
+}
+
+// Leases returns the number of leases not released yet.
+func (s *LimiterService) Leases() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.leases)
+}
+
+// Acquire serves the Acquire method of the Limiter service.
+func (s *LimiterService) Acquire(ctx context.Context, req *AcquireRequest) (*AcquireResponse, error) {
+	if req.Api == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing api")
+	}
+	api := rate_limiter.GetStreamApiName(req.Api)
+	// For would create a limiter for any api sent by a client
+	if !s.manager.Known(api) {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown api %q", req.Api)
+	}
+	limiter := s.manager.For(api)
+	var (
+		release func(info *rate_limiter.LimitInfo)
+		err     error
+	)
+	if req.Try {
+		release, err = limiter.TryAcquire()
+	} else {
+		release, err = limiter.Acquire(ctx)
+	}
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	d := s.lease
+	if req.LeaseMs > 0 {
+		d = time.Duration(req.LeaseMs) * time.Millisecond
+	}
+	if s.maxLease > 0 {
+		d = min(d, s.maxLease)
+	}
+	id := newLeaseID()
+	l := &lease{release: release}
+	s.mu.Lock()
+	s.leases[id] = l
+	// the slot of a lease not released in time is given back without any rate
+	// limit, as the call may not have been made
+	l.timer = time.AfterFunc(d, func() {
+		if s.take(id) != nil {
+			release(nil)
+		}
+	})
+	s.mu.Unlock()
+	return &AcquireResponse{LeaseId: id, ExpiresMs: time.Now().Add(d).UnixMilli()}, nil
+}
+
+// take removes the lease id, returning it, nil once released or expired.
+func (s *LimiterService) take(id string) *lease {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.leases[id]
+	delete(s.leases, id)
+	return l
+}
+
+// Release serves the Release method of the Limiter service.
+func (s *LimiterService) Release(ctx context.Context, req *ReleaseRequest) (*ReleaseResponse, error) {
+	l := s.take(req.LeaseId)
+	if l == nil {
+		return nil, status.Error(codes.NotFound, "unknown or expired lease")
+	}
+	l.timer.Stop()
+	var info *rate_limiter.LimitInfo
+	if req.Reset_ != 0 || req.Throttled {
+		info = &rate_limiter.LimitInfo{Limit: req.Limit, Remaining: req.Remaining, Reset: req.Reset_}
+	}
+	if req.Throttled {
+		// blocked until the reset, for a rate limit window when not reported,
+		// as the limiter does on a 429 error
+		info.Remaining = 0
+		if now := time.Now(); info.Reset <= now.Unix() {
+			info.Reset = now.Add(time.Minute).Unix()
+		}
+	}
+	l.release(info)
+	return &ReleaseResponse{}, nil
+}
+
+// Stats serves the Stats method of the Limiter service.
+func (s *LimiterService) Stats(ctx context.Context, req *StatsRequest) (*StatsResponse, error) {
+	statuses := s.manager.Status()
+	resp := &StatsResponse{Limiters: make([]*LimiterStatus, 0, len(statuses))}
+	for _, st := range statuses {
+		status := &LimiterStatus{
+			Api:           st.Api,
+			Remaining:     st.Remaining,
+			Blocked:       st.Blocked,
+			QueueDepth:    int64(st.QueueDepth),
+			InFlight:      int64(st.InFlight),
+			LastMinute:    toWindowStats(st.Stats.LastMinute),
+			Last_5Minutes: toWindowStats(st.Stats.Last5Minutes),
+			LastHour:      toWindowStats(st.Stats.LastHour),
+		}
+		if st.ResetAt != nil {
+			status.ResetMs = st.ResetAt.UnixMilli()
+		}
+		resp.Limiters = append(resp.Limiters, status)
+	}
+	return resp, nil
+}
+
+func toWindowStats(w rate_limiter.WindowStatus) *WindowStats {
+	return &WindowStats{
+		Calls:      w.Calls,
+		Errors:     w.Errors,
+		Blocks:     w.Blocks,
+		Rejections: w.Rejections,
+		AvgWaitUs:  int64(w.AvgWaitMs * 1000),
+		P99WaitUs:  int64(w.P99WaitMs * 1000),
+	}
+}
+
+func newLeaseID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// statusError converts an error of a limiter into a gRPC status error.
+func statusError(ctx context.Context, err error) error {
+	var rateLimited rate_limiter.ErrRateLimited
+	switch {
+	case errors.As(err, &rateLimited):
+		if !rateLimited.ResetAt.IsZero() {
+			grpc.SetTrailer(ctx, metadata.Pairs(resetTrailer, strconv.FormatInt(rateLimited.ResetAt.Unix(), 10)))
+		}
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, rate_limiter.ErrWaitTimeout):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, rate_limiter.ErrClosed), errors.Is(err, rate_limiter.ErrDraining):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
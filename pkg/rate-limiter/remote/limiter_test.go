@@ -0,0 +1,196 @@
+package remote
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// newLimiterService serves a LimiterService of manager, returning it and a
+// Client to it.
+func newLimiterService(t *testing.T, manager *rate_limiter.LimiterManager, opts ...ServiceOption) (*LimiterService, *grpc.ClientConn) {
+	net := newNetwork()
+	service := NewLimiterService(manager, opts...)
+	net.serve(t, "127.0.0.1:7000", service.Register)
+	conn, err := grpc.NewClient("127.0.0.1:7000", net.dialOptions()...)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return service, conn
+}
+
+func TestLimiterAcquireRelease(t *testing.T) {
+	ctx := context.Background()
+	manager := rate_limiter.NewLimiterManager()
+	service, conn := newLimiterService(t, manager)
+	client := NewClient(conn)
+
+	lease, err := client.Acquire(ctx, rate_limiter.QueryUsers)
+	require.NoError(t, err)
+	assert.Equal(t, 1, service.Leases())
+	assert.WithinDuration(t, time.Now().Add(DefaultLease), lease.ExpiresAt, time.Second)
+
+	// the single slot is held by the lease
+	var rateLimited rate_limiter.ErrRateLimited
+	_, err = client.TryAcquire(ctx, rate_limiter.QueryUsers)
+	require.ErrorAs(t, err, &rateLimited)
+	assert.True(t, rateLimited.ResetAt.IsZero())
+
+	reset := time.Now().Add(time.Minute).Unix()
+	require.NoError(t, lease.Release(ctx, &rate_limiter.LimitInfo{Limit: 10, Remaining: 0, Reset: reset}, false))
+	assert.Zero(t, service.Leases())
+	assert.True(t, manager.For(rate_limiter.QueryUsers).IsBlocked(), "blocked until the reset")
+	assert.Equal(t, codes.NotFound, status.Code(lease.Release(ctx, nil, false)), "released once")
+
+	_, err = client.TryAcquire(ctx, rate_limiter.QueryUsers)
+	require.ErrorAs(t, err, &rateLimited)
+	assert.Equal(t, string(rate_limiter.QueryUsers), rateLimited.ApiName)
+	assert.Equal(t, time.Unix(reset, 0), rateLimited.ResetAt.Local())
+
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = client.Acquire(waitCtx, rate_limiter.QueryUsers)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestLimiterLeaseExpires(t *testing.T) {
+	ctx := context.Background()
+	manager := rate_limiter.NewLimiterManager()
+	service, conn := newLimiterService(t, manager, WithMaxLease(20*time.Millisecond))
+	client := NewClient(conn, WithLease(time.Hour))
+
+	lease, err := client.Acquire(ctx, rate_limiter.QueryUsers)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), lease.ExpiresAt, time.Second, "capped")
+
+	// the client crashed: the slot is released once the lease expires
+	next, err := client.Acquire(ctx, rate_limiter.QueryUsers)
+	require.NoError(t, err)
+	assert.Equal(t, 1, service.Leases())
+	assert.False(t, manager.For(rate_limiter.QueryUsers).IsBlocked())
+	assert.Equal(t, codes.NotFound, status.Code(lease.Release(ctx, nil, false)))
+	require.NoError(t, next.Release(ctx, nil, false))
+}
+
+func TestLimiterUnavailable(t *testing.T) {
+	ctx := context.Background()
+	manager := rate_limiter.NewLimiterManager()
+	_, conn := newLimiterService(t, manager)
+	client := NewClient(conn)
+
+	_, err := client.Acquire(ctx, "")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	require.NoError(t, manager.For(rate_limiter.QueryUsers).Close(ctx))
+	_, err = client.Acquire(ctx, rate_limiter.QueryUsers)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestLimiterCalls(t *testing.T) {
+	ctx := context.Background()
+	manager := rate_limiter.NewLimiterManager()
+	service, conn := newLimiterService(t, manager)
+	limiter := NewClient(conn).Limiter(rate_limiter.QueryUsers)
+
+	reset := time.Now().Add(time.Minute).Unix()
+	require.NoError(t, limiter.CallApiWithContext(ctx, func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 7, Reset: reset}}, nil
+	}))
+	assert.Zero(t, service.Leases())
+	assert.Equal(t, int64(7), manager.For(rate_limiter.QueryUsers).Remaining())
+
+	// a 429 error without rate limit blocks the api for a window
+	err := limiter.CallApiWithContext(ctx, func() (*stream.Response, error) {
+		return nil, &stream.Error{StatusCode: http.StatusTooManyRequests}
+	})
+	var rateLimited rate_limiter.ErrRateLimited
+	require.ErrorAs(t, err, &rateLimited)
+	assert.Equal(t, string(rate_limiter.QueryUsers), rateLimited.ApiName)
+	assert.True(t, manager.For(rate_limiter.QueryUsers).IsBlocked())
+	assert.WithinDuration(t, time.Now().Add(time.Minute), manager.For(rate_limiter.QueryUsers).ResetAt(), 2*time.Second)
+
+	require.ErrorAs(t, limiter.TryCallApi(func() (*stream.Response, error) {
+		t.Error("called while blocked")
+		return nil, nil
+	}), &rateLimited)
+
+	called := false
+	require.NoError(t, limiter.TryCallApi(func() (*stream.Response, error) {
+		called = true
+		return nil, nil
+	}, rate_limiter.SkipLimit()))
+	assert.True(t, called)
+}
+
+func TestLimiterReleaseError(t *testing.T) {
+	ctx := context.Background()
+	_, conn := newLimiterService(t, rate_limiter.NewLimiterManager(), WithMaxLease(time.Millisecond))
+	var errs []error
+	limiter := NewClient(conn, WithErrorHandler(func(err error) { errs = append(errs, err) })).Limiter(rate_limiter.QueryUsers)
+
+	require.NoError(t, limiter.CallApiWithContext(ctx, func() (*stream.Response, error) {
+		time.Sleep(20 * time.Millisecond)
+		return nil, nil
+	}), "the call succeeded")
+	require.Len(t, errs, 1)
+	assert.Equal(t, codes.NotFound, status.Code(errs[0]))
+}
+
+func TestLimiterStats(t *testing.T) {
+	ctx := context.Background()
+	manager := rate_limiter.NewLimiterManager()
+	_, conn := newLimiterService(t, manager)
+	client := NewClient(conn)
+
+	reset := time.Now().Add(time.Minute).Unix()
+	for _, api := range []rate_limiter.GetStreamApiName{rate_limiter.QueryUsers, rate_limiter.QueryChannels} {
+		require.NoError(t, client.Limiter(api).CallApiWithContext(ctx, func() (*stream.Response, error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 4, Reset: reset}}, nil
+		}))
+	}
+	lease, err := client.Acquire(ctx, rate_limiter.QueryUsers)
+	require.NoError(t, err)
+	defer lease.Release(ctx, nil, false)
+
+	statuses, err := client.Stats(ctx)
+	require.NoError(t, err)
+	want := manager.Status()
+	require.Len(t, statuses, len(want))
+	for i, status := range statuses {
+		assert.Equal(t, want[i].Api, status.Api)
+		assert.Equal(t, want[i].Remaining, status.Remaining)
+		assert.True(t, want[i].ResetAt.Equal(*status.ResetAt))
+		assert.Equal(t, want[i].InFlight, status.InFlight)
+		assert.Equal(t, want[i].Stats.LastMinute.Calls, status.Stats.LastMinute.Calls)
+		assert.Equal(t, want[i].Stats.LastHour.Calls, status.Stats.LastHour.Calls)
+	}
+	assert.Equal(t, string(rate_limiter.QueryChannels), statuses[0].Api)
+	assert.Equal(t, 1, statuses[1].InFlight)
+	assert.Equal(t, int64(1), statuses[0].Stats.LastMinute.Calls)
+}
+
+func TestLimiterUnknownApi(t *testing.T) {
+	ctx := context.Background()
+	manager := rate_limiter.NewLimiterManager()
+	service, conn := newLimiterService(t, manager)
+	client := NewClient(conn)
+
+	_, err := client.TryAcquire(ctx, "NoSuchApi")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Zero(t, service.Leases())
+	assert.Empty(t, manager.Status(), "no limiter created")
+
+	manager.For("Custom")
+	lease, err := client.TryAcquire(ctx, "Custom")
+	require.NoError(t, err)
+	require.NoError(t, lease.Release(ctx, nil, false))
+}
@@ -0,0 +1,235 @@
+// Package remote shares the Stream quota of a fleet through gRPC, for the
+// fleets where sharing the state through a Store on every call is too chatty,
+// see Node, or where the services are not written in Go, see LimiterService
+// and the ratelimiterd command. See ratelimiter.proto for the services.
+package remote
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative ratelimiter.proto
+
+// DefaultLeaseTTL is how long the lead of the leader lasts without being
+// renewed, Run renewing it every third of it.
+const DefaultLeaseTTL = 10 * time.Second
+
+// DefaultElection is the name of the election of the leader in the Elector.
+const DefaultElection = "quota"
+
+// Node is a process of a fleet sharing the Stream quota through a leader: the
+// nodes elect one of them through an Elector, e.g. the Store of the redisstore
+// package, which owns the quota, in memory. A Node is the Store of the
+// limiters of its process: the leader admits their calls itself, the
+// followers take them from the leader over gRPC, see the Quota service. When
+// the leader stops renewing its lead, another node takes over.
+type Node struct {
+	elector  rate_limiter.Elector
+	addr     string
+	election string
+	ttl      time.Duration
+	dialOpts []grpc.DialOption
+	clock    rate_limiter.Clock
+
+	// quota is the state of the quota, served while leading
+	quota *rate_limiter.MemoryStore
+
+	mu          sync.Mutex
+	leader      string
+	leaderUntil time.Time // when the lead last observed expires
+	conn        *grpc.ClientConn
+	connAddr    string
+}
+
+var _ rate_limiter.CostStore = (*Node)(nil)
+
+// NodeOption configures a Node.
+type NodeOption func(*Node)
+
+// WithLeaseTTL replaces DefaultLeaseTTL: a shorter lease fails over sooner,
+// at the cost of more campaigns.
+func WithLeaseTTL(ttl time.Duration) NodeOption {
+	return func(n *Node) {
+		if ttl > 0 {
+			n.ttl = ttl
+		}
+	}
+}
+
+// WithElection replaces DefaultElection, e.g. for several fleets sharing the
+// Elector.
+func WithElection(election string) NodeOption {
+	return func(n *Node) {
+		n.election = election
+	}
+}
+
+// WithDialOptions replaces the options dialing the leader, insecure by
+// default, e.g. with TLS credentials.
+func WithDialOptions(opts ...grpc.DialOption) NodeOption {
+	return func(n *Node) {
+		n.dialOpts = opts
+	}
+}
+
+// WithClock replaces the clock of the campaigns and of the lead.
+func WithClock(clock rate_limiter.Clock) NodeOption {
+	return func(n *Node) {
+		n.clock = clock
+	}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// NewNode returns a Node campaigning through elector, addr being the address
+// at which the other nodes reach its gRPC server, see Register.
+func NewNode(elector rate_limiter.Elector, addr string, opts ...NodeOption) *Node {
+	n := &Node{
+		elector:  elector,
+		addr:     addr,
+		election: DefaultElection,
+		ttl:      DefaultLeaseTTL,
+		dialOpts: []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+		clock:    systemClock{},
+		quota:    rate_limiter.NewMemoryStore(),
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
+}
+
+// Register serves the Quota service of n on server.
+func (n *Node) Register(server *grpc.Server) {
+	RegisterQuotaServer(server, &quotaServer{store: n.quota, clock: n.clock})
+}
+
+// Run campaigns to lead the fleet, renewing the lead or following the leader,
+// until ctx is done, returning ctx.Err(). The campaigns failing, e.g. while the
+// Elector is unavailable, the leader is deemed gone once its lead expires, n
+// then admitting the calls itself.
+func (n *Node) Run(ctx context.Context) error {
+	defer n.closeConn()
+	for {
+		n.Campaign(ctx)
+		select {
+		case <-n.clock.After(n.ttl / 3):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Campaign runs a single campaign, as Run does periodically, returning its
+// error, if any.
+func (n *Node) Campaign(ctx context.Context) error {
+	now := n.clock.Now()
+	leader, err := n.elector.Campaign(ctx, n.election, n.addr, now, n.ttl)
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.leader = leader
+	n.leaderUntil = now.Add(n.ttl)
+	return nil
+}
+
+// Leader returns the address of the leader, empty when unknown.
+func (n *Node) Leader() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.clock.Now().Before(n.leaderUntil) {
+		return ""
+	}
+	return n.leader
+}
+
+// IsLeader reports whether n leads the fleet.
+func (n *Node) IsLeader() bool {
+	return n.Leader() == n.addr
+}
+
+// remote returns the client of the leader, ok false when n admits the calls
+// itself: when leading, or when the leader is unknown.
+func (n *Node) remote() (client leaderClient, ok bool, err error) {
+	leader := n.Leader()
+	if leader == "" || leader == n.addr {
+		return leaderClient{}, false, nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.conn == nil || n.connAddr != leader {
+		if n.conn != nil {
+			n.conn.Close()
+		}
+		n.conn = nil
+		conn, err := grpc.NewClient(leader, n.dialOpts...)
+		if err != nil {
+			return leaderClient{}, false, err
+		}
+		n.conn, n.connAddr = conn, leader
+	}
+	return leaderClient{client: NewQuotaClient(n.conn)}, true, nil
+}
+
+func (n *Node) closeConn() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.conn != nil {
+		n.conn.Close()
+		n.conn = nil
+	}
+}
+
+func (n *Node) Take(ctx context.Context, api string, now time.Time) (time.Time, error) {
+	return n.TakeN(ctx, api, now, 1)
+}
+
+func (n *Node) TakeN(ctx context.Context, api string, now time.Time, cost int) (time.Time, error) {
+	client, ok, err := n.remote()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !ok {
+		return n.quota.TakeN(ctx, api, now, cost)
+	}
+	return client.takeN(ctx, api, cost)
+}
+
+func (n *Node) Observe(ctx context.Context, api string, info rate_limiter.LimitInfo, now time.Time) error {
+	client, ok, err := n.remote()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return n.quota.Observe(ctx, api, info, now)
+	}
+	return client.observe(ctx, api, info)
+}
+
+func (n *Node) Load(ctx context.Context, api string) (rate_limiter.State, error) {
+	client, ok, err := n.remote()
+	if err != nil {
+		return rate_limiter.State{}, err
+	}
+	if !ok {
+		return n.quota.Load(ctx, api)
+	}
+	return client.load(ctx, api)
+}
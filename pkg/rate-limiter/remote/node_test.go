@@ -0,0 +1,198 @@
+package remote
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+// network connects the gRPC servers and clients of a test in memory.
+type network struct {
+	mu        sync.Mutex
+	listeners map[string]*bufconn.Listener
+}
+
+func newNetwork() *network {
+	return &network{listeners: map[string]*bufconn.Listener{}}
+}
+
+// serve serves register at addr until the end of the test.
+func (n *network) serve(t *testing.T, addr string, register func(*grpc.Server)) *grpc.Server {
+	listener := bufconn.Listen(1 << 16)
+	server := grpc.NewServer()
+	register(server)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	n.mu.Lock()
+	n.listeners[addr] = listener
+	n.mu.Unlock()
+	return server
+}
+
+func (n *network) dialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			n.mu.Lock()
+			listener, ok := n.listeners[addr]
+			n.mu.Unlock()
+			if !ok {
+				return nil, &net.OpError{Op: "dial", Net: "bufconn", Err: net.UnknownNetworkError(addr)}
+			}
+			return listener.DialContext(ctx)
+		}),
+	}
+}
+
+// node returns a Node at addr, serving its Quota service on net.
+func (n *network) node(t *testing.T, elector rate_limiter.Elector, addr string, opts ...NodeOption) *Node {
+	node := NewNode(elector, addr, append([]NodeOption{WithDialOptions(n.dialOptions()...)}, opts...)...)
+	n.serve(t, addr, node.Register)
+	return node
+}
+
+func exhausted(reset int64) func() (*stream.Response, error) {
+	return func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: reset}}, nil
+	}
+}
+
+func TestNodeFollowerTakesFromLeader(t *testing.T) {
+	ctx := context.Background()
+	net, elector := newNetwork(), rate_limiter.NewMemoryStore()
+	leader := net.node(t, elector, "127.0.0.1:7001")
+	follower := net.node(t, elector, "127.0.0.1:7002")
+	require.NoError(t, leader.Campaign(ctx))
+	require.NoError(t, follower.Campaign(ctx))
+	assert.True(t, leader.IsLeader())
+	assert.False(t, follower.IsLeader())
+	assert.Equal(t, "127.0.0.1:7001", follower.Leader())
+
+	reset := time.Now().Add(time.Minute).Unix()
+	onFollower := rate_limiter.NewRateLimiter(rate_limiter.QueryUsers, rate_limiter.WithStore(follower))
+	require.NoError(t, onFollower.TryCallApi(exhausted(reset)))
+
+	state, err := leader.quota.Load(ctx, string(rate_limiter.QueryUsers))
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(reset, 0), state.BlockedUntil.Local(), "observed by the leader")
+	assert.Equal(t, rate_limiter.State{}, mustLoad(t, follower.quota), "nothing kept by the follower")
+	remote, err := follower.Load(ctx, string(rate_limiter.QueryUsers))
+	require.NoError(t, err)
+	assert.Equal(t, state.Limit, remote.Limit)
+	assert.True(t, state.BlockedUntil.Equal(remote.BlockedUntil))
+
+	onLeader := rate_limiter.NewRateLimiter(rate_limiter.QueryUsers, rate_limiter.WithStore(leader))
+	var limited rate_limiter.ErrRateLimited
+	require.ErrorAs(t, onLeader.TryCallApi(func() (*stream.Response, error) {
+		t.Error("called while blocked by the follower")
+		return nil, nil
+	}), &limited)
+
+	blockedUntil, err := follower.TakeN(ctx, string(rate_limiter.QueryUsers), time.Now(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(reset, 0), blockedUntil.Local(), "blocked by the leader")
+}
+
+func mustLoad(t *testing.T, store *rate_limiter.MemoryStore) rate_limiter.State {
+	state, err := store.Load(context.Background(), string(rate_limiter.QueryUsers))
+	require.NoError(t, err)
+	return state
+}
+
+func TestNodeFailover(t *testing.T) {
+	ctx := context.Background()
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	net, elector := newNetwork(), rate_limiter.NewMemoryStore()
+	first := net.node(t, elector, "127.0.0.1:7001", WithClock(clock), WithLeaseTTL(9*time.Second))
+	second := net.node(t, elector, "127.0.0.1:7002", WithClock(clock), WithLeaseTTL(9*time.Second))
+	require.NoError(t, first.Campaign(ctx))
+	require.NoError(t, second.Campaign(ctx))
+	assert.True(t, first.IsLeader())
+
+	// the first node stops campaigning
+	clock.Advance(3 * time.Second)
+	require.NoError(t, second.Campaign(ctx))
+	assert.Equal(t, "127.0.0.1:7001", second.Leader(), "lead not yet expired")
+	clock.Advance(7 * time.Second)
+	assert.Empty(t, first.Leader(), "lead expired")
+	require.NoError(t, second.Campaign(ctx))
+	assert.True(t, second.IsLeader(), "took over")
+
+	require.NoError(t, first.Campaign(ctx))
+	assert.Equal(t, "127.0.0.1:7002", first.Leader(), "follows the new leader")
+	require.NoError(t, first.Observe(ctx, "QueryUsers", rate_limiter.LimitInfo{Limit: 10, Remaining: 3, Reset: 1060}, clock.Now()))
+	assert.Equal(t, int64(3), mustLoad(t, second.quota).Remaining)
+}
+
+func TestNodeLeaderClock(t *testing.T) {
+	ctx := context.Background()
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	net, elector := newNetwork(), rate_limiter.NewMemoryStore()
+	leader := net.node(t, elector, "127.0.0.1:7001", WithClock(clock))
+	follower := net.node(t, elector, "127.0.0.1:7002", WithClock(clock))
+	require.NoError(t, leader.Campaign(ctx))
+	require.NoError(t, follower.Campaign(ctx))
+
+	require.NoError(t, follower.Observe(ctx, "QueryUsers", rate_limiter.LimitInfo{Limit: 10, Remaining: 0, Reset: 1060}, clock.Now()))
+	// a follower whose clock is past the reset is still blocked by the leader
+	blockedUntil, err := follower.Take(ctx, "QueryUsers", time.Unix(2000, 0))
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1060, 0), blockedUntil.Local())
+}
+
+func TestNodeWithoutLeader(t *testing.T) {
+	ctx := context.Background()
+	net := newNetwork()
+	node := net.node(t, rate_limiter.NewMemoryStore(), "127.0.0.1:7001")
+	assert.Empty(t, node.Leader())
+	require.NoError(t, node.Observe(ctx, "QueryUsers", rate_limiter.LimitInfo{Limit: 10, Remaining: 3, Reset: time.Now().Add(time.Minute).Unix()}, time.Now()))
+	assert.Equal(t, int64(3), mustLoad(t, node.quota).Remaining, "admitted locally")
+}
+
+func TestNodeLeaderUnreachable(t *testing.T) {
+	ctx := context.Background()
+	net, elector := newNetwork(), rate_limiter.NewMemoryStore()
+	_, err := elector.Campaign(ctx, DefaultElection, "127.0.0.1:7001", time.Now(), time.Minute)
+	require.NoError(t, err)
+	follower := net.node(t, elector, "127.0.0.1:7002")
+	require.NoError(t, follower.Campaign(ctx))
+
+	_, err = follower.Take(ctx, "QueryUsers", time.Now())
+	assert.Error(t, err)
+
+	// the limiter fails open
+	rLimit := rate_limiter.NewRateLimiter(rate_limiter.QueryUsers, rate_limiter.WithStore(follower))
+	assert.NoError(t, rLimit.TryCallApi(exhausted(time.Now().Add(time.Minute).Unix())))
+}
+
+func TestNodeRun(t *testing.T) {
+	net, elector := newNetwork(), rate_limiter.NewMemoryStore()
+	first := net.node(t, elector, "127.0.0.1:7001", WithLeaseTTL(60*time.Millisecond))
+	second := net.node(t, elector, "127.0.0.1:7002", WithLeaseTTL(60*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan error, 1)
+	go func() { firstDone <- first.Run(ctx) }()
+	require.Eventually(t, first.IsLeader, time.Second, time.Millisecond)
+
+	secondCtx, cancelSecond := context.WithCancel(context.Background())
+	defer cancelSecond()
+	go second.Run(secondCtx)
+	require.Eventually(t, func() bool { return second.Leader() == "127.0.0.1:7001" }, time.Second, time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-firstDone, context.Canceled)
+	assert.Eventually(t, second.IsLeader, time.Second, time.Millisecond, "failover")
+}
@@ -0,0 +1,92 @@
+package remote
+
+import (
+	"context"
+	"time"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// quotaServer serves the Quota service from the store of the quota, on the
+// clock of the leader: the clocks of the followers may drift.
+type quotaServer struct {
+	UnimplementedQuotaServer
+	store *rate_limiter.MemoryStore
+	clock rate_limiter.Clock
+}
+
+func (s *quotaServer) Take(ctx context.Context, req *TakeRequest) (*TakeResponse, error) {
+	blockedUntil, err := s.store.TakeN(ctx, req.Api, s.clock.Now(), int(max(req.N, 1)))
+	if err != nil {
+		return nil, err
+	}
+	return &TakeResponse{BlockedUntilMs: toUnixMilli(blockedUntil)}, nil
+}
+
+func (s *quotaServer) Observe(ctx context.Context, req *ObserveRequest) (*ObserveResponse, error) {
+	info := rate_limiter.LimitInfo{Limit: req.Limit, Remaining: req.Remaining, Reset: req.Reset_}
+	if err := s.store.Observe(ctx, req.Api, info, s.clock.Now()); err != nil {
+		return nil, err
+	}
+	return &ObserveResponse{}, nil
+}
+
+func (s *quotaServer) Load(ctx context.Context, req *LoadRequest) (*LoadResponse, error) {
+	state, err := s.store.Load(ctx, req.Api)
+	if err != nil {
+		return nil, err
+	}
+	return &LoadResponse{
+		Limit:          state.Limit,
+		Remaining:      state.Remaining,
+		ResetMs:        toUnixMilli(state.Reset),
+		BlockedUntilMs: toUnixMilli(state.BlockedUntil),
+	}, nil
+}
+
+// leaderClient calls the Quota service of the leader.
+type leaderClient struct {
+	client QuotaClient
+}
+
+func (l leaderClient) takeN(ctx context.Context, api string, n int) (time.Time, error) {
+	resp, err := l.client.Take(ctx, &TakeRequest{Api: api, N: int64(n)})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return unixMilli(resp.BlockedUntilMs), nil
+}
+
+func (l leaderClient) observe(ctx context.Context, api string, info rate_limiter.LimitInfo) error {
+	_, err := l.client.Observe(ctx, &ObserveRequest{Api: api, Limit: info.Limit, Remaining: info.Remaining, Reset_: info.Reset})
+	return err
+}
+
+func (l leaderClient) load(ctx context.Context, api string) (rate_limiter.State, error) {
+	resp, err := l.client.Load(ctx, &LoadRequest{Api: api})
+	if err != nil {
+		return rate_limiter.State{}, err
+	}
+	return rate_limiter.State{
+		Limit:        resp.Limit,
+		Remaining:    resp.Remaining,
+		Reset:        unixMilli(resp.ResetMs),
+		BlockedUntil: unixMilli(resp.BlockedUntilMs),
+	}, nil
+}
+
+// unixMilli returns the time of ms Unix milliseconds, the zero time for 0.
+func unixMilli(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// toUnixMilli returns t in Unix milliseconds, 0 for the zero time.
+func toUnixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
@@ -0,0 +1,168 @@
+package rate_limiter
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// InfDuration is the delay of a Reservation which is not OK.
+const InfDuration = time.Duration(math.MaxInt64)
+
+// Reservation holds calls reserved by Reserve, telling when they may be made.
+type Reservation struct {
+	r         *RateLimiter
+	ok        bool
+	n         int
+	left      int   // calls reserved not made yet
+	window    int64 // reset of the window reserved, 0 when not accounted
+	timeToAct time.Time
+}
+
+// Reserve reserves n calls of the rate limit windows of the api and tells how
+// long to wait before making them, e.g. to plan work ahead instead of
+// blocking in the limiter. The calls reserved count against the remaining
+// calls of their window, as reported by the latest response, until made
+// through the Reservation or cancelled: the other calls are admitted on the
+// calls left unreserved only. The windows after the current one are assumed
+// to allow the whole limit. The reservation is not OK when n exceeds
+// the limit. While the quota is unknown, calls may be made right away.
+func (r *RateLimiter) Reserve(n int) *Reservation {
+	now := r.getClock().Now()
+	info, ok := r.lastInfo.Load()
+	res := &Reservation{r: r, ok: true, n: n, timeToAct: now}
+	if n <= 0 || !ok || info.Reset <= now.Unix() {
+		return res
+	}
+	window := int64(defaultRateLimitWindow / time.Second)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if info.Remaining-r.headroom.Load()-int64(r.reserved[info.Reset]) >= int64(n) && r.blocked == nil {
+		res.window, res.left = info.Reset, n
+		r.reserveLocked(now, info.Reset, n)
+		return res
+	}
+	budget := info.Limit - r.headroom.Load()
+	if budget < int64(n) {
+		res.ok = false
+		return res
+	}
+	// the first next window with room for the calls
+	reset := info.Reset + window
+	for budget-int64(r.reserved[reset]) < int64(n) {
+		reset += window
+	}
+	res.window, res.left = reset, n
+	res.timeToAct = time.Unix(reset-window, 0)
+	r.reserveLocked(now, reset, n)
+	return res
+}
+
+// reserveLocked accounts n calls to the window ending at reset, forgetting
+// the windows over. r.mu must be held.
+func (r *RateLimiter) reserveLocked(now time.Time, reset int64, n int) {
+	if r.reserved == nil {
+		r.reserved = make(map[int64]int)
+	}
+	for window := range r.reserved {
+		if window <= now.Unix() {
+			delete(r.reserved, window)
+		}
+	}
+	r.reserved[reset] += n
+}
+
+// reservedLocked returns the calls reserved in the window ending at reset but
+// those of res, which a call of res is about to make. r.mu must be held.
+func (r *RateLimiter) reservedLocked(reset int64, res *Reservation) int64 {
+	reserved := r.reserved[reset]
+	if res != nil && res.window == reset {
+		reserved -= res.left
+	}
+	return int64(max(reserved, 0))
+}
+
+// consumeReservation counts a call of the given cost made through res, if not
+// nil, against the calls it reserved, once admitted.
+func (r *RateLimiter) consumeReservation(res *Reservation, cost int) {
+	if res == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.consumeLocked(res, cost)
+}
+
+// consumeLocked counts a call of the given cost made through res against the
+// calls it reserved. r.mu must be held.
+func (r *RateLimiter) consumeLocked(res *Reservation, cost int) {
+	if res == nil || res.window == 0 {
+		return
+	}
+	used := min(cost, res.left)
+	res.left -= used
+	r.reserved[res.window] -= used
+	if r.reserved[res.window] <= 0 {
+		delete(r.reserved, res.window)
+	}
+}
+
+type reservationKey struct{}
+
+// reservationFrom returns the Reservation a call of ctx is made through, nil
+// when none.
+func reservationFrom(ctx context.Context) *Reservation {
+	res, _ := ctx.Value(reservationKey{}).(*Reservation)
+	return res
+}
+
+// CallApiWithContext makes one of the calls reserved, as
+// RateLimiter.CallApiWithContext does, admitted on the calls reserved while
+// any is left.
+func (res *Reservation) CallApiWithContext(ctx context.Context, apiCall GetStreamApiCaller, opts ...CallOption) error {
+	return res.r.CallApiWithContext(context.WithValue(ctx, reservationKey{}, res), apiCall, opts...)
+}
+
+// TryCallApi is like CallApiWithContext, without waiting, as
+// RateLimiter.TryCallApi does.
+func (res *Reservation) TryCallApi(apiCall GetStreamApiCaller, opts ...CallOption) error {
+	ctx := context.WithValue(context.Background(), reservationKey{}, res)
+	return res.r.run(ctx, Invocation{Try: true, Options: ApplyCallOptions(opts...), call: streamCall(apiCall)})
+}
+
+// OK reports whether the calls could be reserved, i.e. do not exceed the
+// limit of a window.
+func (res *Reservation) OK() bool {
+	return res.ok
+}
+
+// Delay returns how long to wait before making the calls reserved.
+func (res *Reservation) Delay() time.Duration {
+	return res.DelayFrom(res.r.getClock().Now())
+}
+
+// DelayFrom returns how long to wait from now before making the calls
+// reserved, InfDuration when not OK.
+func (res *Reservation) DelayFrom(now time.Time) time.Duration {
+	if !res.ok {
+		return InfDuration
+	}
+	return max(res.timeToAct.Sub(now), 0)
+}
+
+// Cancel gives back the calls reserved, not made yet, to their window.
+func (res *Reservation) Cancel() {
+	if !res.ok {
+		return
+	}
+	res.r.mu.Lock()
+	defer res.r.mu.Unlock()
+	if res.window == 0 {
+		return
+	}
+	res.r.consumeLocked(res, res.left)
+	res.window = 0
+	// calls may be waiting for the calls given back
+	res.r.wakeLocked()
+}
@@ -0,0 +1,124 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestReserve(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+
+	// unknown quota
+	res := rLimit.Reserve(50)
+	assert.True(t, res.OK())
+	assert.Zero(t, res.Delay())
+
+	call := func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 5, Reset: 1030}}, nil
+	}
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), call))
+
+	now := rLimit.Reserve(3)
+	assert.True(t, now.OK())
+	assert.Zero(t, now.Delay())
+
+	// 2 calls left in the current window, the next one starts at 1030
+	next := rLimit.Reserve(3)
+	assert.True(t, next.OK())
+	assert.Equal(t, 30*time.Second, next.Delay())
+	assert.Equal(t, 20*time.Second, next.DelayFrom(time.Unix(1010, 0)))
+
+	// 7 calls left in the next window, the one after starts at 1090
+	assert.Equal(t, 90*time.Second, rLimit.Reserve(8).Delay())
+
+	// cancelling gives the calls back
+	now.Cancel()
+	assert.Zero(t, rLimit.Reserve(5).Delay())
+
+	tooMany := rLimit.Reserve(11)
+	assert.False(t, tooMany.OK())
+	assert.Equal(t, InfDuration, tooMany.Delay())
+}
+
+func TestReserveConsumedByCalls(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+	remaining := int64(4)
+	call := func() (*stream.Response, error) {
+		remaining--
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: remaining, Reset: 1030}}, nil
+	}
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), call))
+	res := rLimit.Reserve(2)
+	assert.Zero(t, res.Delay())
+
+	// the calls reserved are made, leaving room for another reservation
+	require.NoError(t, res.CallApiWithContext(context.Background(), call))
+	require.NoError(t, res.TryCallApi(call))
+	assert.Equal(t, int64(1), rLimit.Remaining())
+	assert.Zero(t, rLimit.Reserve(1).Delay())
+}
+
+func TestReserveHoldsBackOtherCalls(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithMaxConcurrency(2))
+	remaining := int64(3)
+	call := func() (*stream.Response, error) {
+		remaining--
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: remaining, Reset: 1030}}, nil
+	}
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), call))
+	res := rLimit.Reserve(2)
+	require.Zero(t, res.Delay())
+
+	// the 2 calls left are reserved
+	var rateLimited ErrRateLimited
+	require.ErrorAs(t, rLimit.TryCallApi(call), &rateLimited)
+	assert.Equal(t, time.Unix(1030, 0), rateLimited.ResetAt)
+
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), call)
+	}()
+	blockUntil(t, clock, 1)
+	select {
+	case err := <-done:
+		t.Fatalf("unreserved call admitted: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// the reservation is made on the calls it holds
+	require.NoError(t, res.TryCallApi(call))
+	assert.Equal(t, int64(1), rLimit.Remaining())
+
+	// cancelling gives the last call back to the waiting call
+	res.Cancel()
+	require.NoError(t, receive(t, done))
+	assert.Equal(t, int64(0), rLimit.Remaining())
+}
+
+func TestReserveReleasedAtWindowEnd(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+	call := func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 2, Reset: 1030}}, nil
+	}
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), call))
+	require.Zero(t, rLimit.Reserve(2).Delay())
+
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), call)
+	}()
+	blockUntil(t, clock, 1)
+	clock.Advance(30 * time.Second)
+	require.NoError(t, receive(t, done))
+}
@@ -0,0 +1,33 @@
+package rate_limiter
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithResumeJitter delays each call resumed by the end of a block, e.g. a rate
+// limit reset, by a random duration up to spread, so that the calls queued
+// behind the barrier spread over the first moments of the new window instead
+// of firing at once. The delay honours WithMaxWait and the context. Values not
+// positive are ignored.
+func WithResumeJitter(spread time.Duration) Option {
+	return func(r *RateLimiter) {
+		if spread > 0 {
+			r.jitter = spread
+		}
+	}
+}
+
+// jitterResume waits, while holding a token, the random delay of
+// WithResumeJitter of a call resumed after a block.
+func (r *RateLimiter) jitterResume(ctx context.Context, timeout *maxWaitTimer) error {
+	if r.jitter <= 0 {
+		return nil
+	}
+	delay := time.Duration(rand.Int63n(int64(r.jitter)))
+	trace.SpanFromContext(ctx).AddEvent("jittered")
+	return r.waitUntil(ctx, r.getClock().Now().Add(delay), timeout)
+}
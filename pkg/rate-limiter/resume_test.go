@@ -0,0 +1,66 @@
+package rate_limiter
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestWithResumeJitter(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithMaxConcurrency(3), WithResumeJitter(time.Second))
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1060}}, nil
+	}))
+	require.True(t, rLimit.IsBlocked())
+
+	var calls atomic.Int32
+	done := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			done <- rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+				calls.Add(1)
+				return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 9, Reset: 1120}}, nil
+			})
+		}()
+	}
+	require.Eventually(t, func() bool { return len(rLimit.tokens()) == 3 }, time.Second, time.Millisecond)
+
+	// the reset timer, then the jitter of every resumed call
+	clock.Advance(time.Minute)
+	blockUntil(t, clock, 3)
+	assert.Zero(t, calls.Load(), "resumed calls wait for their jitter")
+
+	clock.Advance(time.Second)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, receive(t, done))
+	}
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestResumeJitterHonoursContext(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithResumeJitter(time.Hour))
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1060}}, nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallApiWithContext(ctx, nil)
+	}()
+	require.Eventually(t, func() bool { return len(rLimit.tokens()) == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Minute)
+	blockUntil(t, clock, 1)
+	cancel()
+	assert.ErrorIs(t, receive(t, done), context.Canceled)
+	assert.Empty(t, rLimit.tokens(), "the token is released")
+}
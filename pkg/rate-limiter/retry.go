@@ -0,0 +1,149 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Backoff returns the delay before a retry, attempt being 1 for the first one.
+type Backoff interface {
+	Delay(attempt int) time.Duration
+}
+
+// BackoffFunc is a function implementing Backoff.
+type BackoffFunc func(attempt int) time.Duration
+
+func (f BackoffFunc) Delay(attempt int) time.Duration {
+	return f(attempt)
+}
+
+// ConstantBackoff waits the same interval before every retry.
+func ConstantBackoff(interval time.Duration) Backoff {
+	return BackoffFunc(func(int) time.Duration {
+		return interval
+	})
+}
+
+// ExponentialBackoff doubles the delay at every retry, starting from initial
+// and capped to max when max is greater than zero.
+func ExponentialBackoff(initial, max time.Duration) Backoff {
+	return BackoffFunc(func(attempt int) time.Duration {
+		delay := time.Duration(float64(initial) * math.Pow(2, float64(attempt-1)))
+		if max > 0 && (delay > max || delay < 0) {
+			return max
+		}
+		return delay
+	})
+}
+
+// Jittered randomizes the delays of backoff in [delay/2, delay), so that
+// concurrent callers do not retry all at once.
+func Jittered(backoff Backoff) Backoff {
+	return BackoffFunc(func(attempt int) time.Duration {
+		delay := backoff.Delay(attempt)
+		if delay <= 0 {
+			return delay
+		}
+		return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	})
+}
+
+// RetryPolicy configures the retries of failed api calls, see WithRetry.
+type RetryPolicy struct {
+	// MaxAttempts caps the number of attempts, the first call included.
+	MaxAttempts int
+	// Backoff computes the delay between attempts, no delay when nil.
+	Backoff Backoff
+	// Retryable tells whether err is worth a retry, IsTransient when nil.
+	Retryable func(err error) bool
+	// Budget caps the retries to a share of the calls, none by default.
+	Budget RetryBudget
+}
+
+// IsTransient reports whether err is a rate limit (429) or server (5xx) error
+// returned by Stream, or a network timeout.
+func IsTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if apiErr, ok := asStreamError(err); ok {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retrying is the built-in interceptor of WithRetry, calling the api through
+// the limiter until it succeeds, the error is not retryable or the attempts are
+// over. Attempts do not hold a slot while waiting for the next one, and since
+// a 429 error blocks the limiter the next attempt also waits for the rate limit
+// reset. TryCallApi never retries.
+func (r *RateLimiter) retrying(next Caller) Caller {
+	return func(ctx context.Context, inv Invocation) error {
+		if inv.Try {
+			return next(ctx, inv)
+		}
+		return r.callWithRetry(ctx, inv, next)
+	}
+}
+
+func (r *RateLimiter) callWithRetry(ctx context.Context, inv Invocation, next Caller) error {
+	policy := r.retry
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = IsTransient
+	}
+	clock := r.getClock()
+	logger := inv.logger
+	if r.retries != nil {
+		r.retries.call(clock.Now())
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := next(ctx, inv)
+		if err == nil || attempt >= policy.MaxAttempts || !retryable(err) {
+			return err
+		}
+		if r.retries != nil && !r.retries.retry(clock.Now()) {
+			r.recordRetry(true)
+			logAttrs(ctx, logger, slog.LevelDebug, "retry budget spent", []slog.Attr{
+				slog.String("api", r.apiName),
+				slog.Int("attempt", attempt),
+			}, "Not retrying api %s after attempt %d/%d, retry budget spent: %v\n", r.apiName, attempt, policy.MaxAttempts, err)
+			return err
+		}
+		r.recordRetry(false)
+
+		var delay time.Duration
+		if policy.Backoff != nil {
+			delay = policy.Backoff.Delay(attempt)
+		}
+		logAttrs(ctx, logger, slog.LevelDebug, "retrying call", []slog.Attr{
+			slog.String("api", r.apiName),
+			slog.Int("attempt", attempt),
+			slog.Duration("wait", delay),
+		}, "Retrying api %s in %v after attempt %d/%d: %v\n", r.apiName, delay, attempt, policy.MaxAttempts, err)
+		r.emit(Event{Type: RetryScheduled, ResumeAt: clock.Now().Add(delay), Err: err})
+		trace.SpanFromContext(ctx).AddEvent("retry scheduled", trace.WithAttributes(
+			attribute.Int("attempt", attempt),
+			attribute.Int64("delay_ms", delay.Milliseconds()),
+		))
+
+		select {
+		case <-clock.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-r.closing():
+			return r.errClosed()
+		}
+	}
+}
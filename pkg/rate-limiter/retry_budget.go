@@ -0,0 +1,119 @@
+package rate_limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// retryBudgetSlots is the number of slots the window of a RetryBudget rolls
+// by.
+const retryBudgetSlots = 10
+
+// RetryBudget caps the retries of a limiter to a share of its calls, so that
+// retry storms do not amplify the load on Stream during an incident, see
+// RetryPolicy.Budget. The retries beyond the budget are not attempted, the
+// call failing with the error of its latest attempt.
+type RetryBudget struct {
+	// Ratio is the highest share of retries among the calls within Window,
+	// e.g. 0.1 for 10%. No budget when not positive.
+	Ratio float64
+	// Window is the period over which the calls and retries are counted,
+	// rolling by a tenth of it (default 1m).
+	Window time.Duration
+	// MinRetries are allowed within Window whatever the Ratio, e.g. so that an
+	// api seldom called still retries.
+	MinRetries int
+}
+
+// RetryBudgetStats is the consumption of the RetryBudget of a limiter over
+// its window, see Stats.
+type RetryBudgetStats struct {
+	// Retries counts the retries attempted, Allowed how many the budget
+	// allows.
+	Retries int64
+	Allowed int64
+}
+
+type retryBudgetSlot struct {
+	slot    int64 // index of the slot since the epoch
+	calls   int64
+	retries int64
+}
+
+// retryBudget counts the calls and retries of a limiter over the window of
+// its RetryBudget.
+type retryBudget struct {
+	config RetryBudget
+	slot   time.Duration
+
+	mu    sync.Mutex
+	slots [retryBudgetSlots]retryBudgetSlot
+}
+
+func newRetryBudget(config RetryBudget) *retryBudget {
+	if config.Window <= 0 {
+		config.Window = time.Minute
+	}
+	return &retryBudget{config: config, slot: max(config.Window/retryBudgetSlots, 1)}
+}
+
+// bucketLocked returns the slot of now, recycling it when it held an older
+// one. b.mu must be held.
+func (b *retryBudget) bucketLocked(now time.Time) *retryBudgetSlot {
+	slot := now.UnixNano() / int64(b.slot)
+	s := &b.slots[slot%retryBudgetSlots]
+	if s.slot != slot {
+		*s = retryBudgetSlot{slot: slot}
+	}
+	return s
+}
+
+// usageLocked returns the retries within the window ending at now, and how
+// many the budget allows. b.mu must be held.
+func (b *retryBudget) usageLocked(now time.Time) (retries, allowed int64) {
+	slot := now.UnixNano() / int64(b.slot)
+	var calls int64
+	for _, s := range b.slots {
+		if s.slot <= slot && s.slot > slot-retryBudgetSlots {
+			calls += s.calls
+			retries += s.retries
+		}
+	}
+	return retries, max(int64(b.config.Ratio*float64(calls)), int64(b.config.MinRetries))
+}
+
+// call counts a call at now, its retries aside.
+func (b *retryBudget) call(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bucketLocked(now).calls++
+}
+
+// retry counts a retry at now, reporting false without counting it when the
+// budget is spent.
+func (b *retryBudget) retry(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if retries, allowed := b.usageLocked(now); retries >= allowed {
+		return false
+	}
+	b.bucketLocked(now).retries++
+	return true
+}
+
+func (b *retryBudget) stats(now time.Time) RetryBudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	retries, allowed := b.usageLocked(now)
+	return RetryBudgetStats{Retries: retries, Allowed: allowed}
+}
+
+func (r *RateLimiter) recordRetry(denied bool) {
+	r.stats.record(r.getClock().Now(), func(b *statsBucket) {
+		if denied {
+			b.retriesDenied++
+		} else {
+			b.retries++
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryBudget(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := newRetryBudget(RetryBudget{Ratio: 0.5, MinRetries: 1})
+
+	b.call(now)
+	assert.True(t, b.retry(now), "allowed by MinRetries")
+	assert.False(t, b.retry(now))
+	b.call(now)
+	assert.False(t, b.retry(now), "half of 2 calls")
+	b.call(now)
+	b.call(now)
+	assert.True(t, b.retry(now))
+	assert.Equal(t, RetryBudgetStats{Retries: 2, Allowed: 2}, b.stats(now))
+
+	assert.Equal(t, RetryBudgetStats{Retries: 0, Allowed: 1}, b.stats(now.Add(time.Minute)), "the window rolled")
+	b.call(now.Add(30 * time.Second))
+	assert.Equal(t, RetryBudgetStats{Retries: 2, Allowed: 2}, b.stats(now.Add(30*time.Second)))
+}
+
+func TestWithRetryBudget(t *testing.T) {
+	clock := &instantClock{now: time.Unix(1000, 0)}
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithRetry(RetryPolicy{
+		MaxAttempts: 3,
+		Budget:      RetryBudget{Ratio: 0.5, MinRetries: 1},
+	}))
+
+	var attempts []int
+	for i := 0; i < 4; i++ {
+		n := 0
+		assert.Error(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+			n++
+			return nil, stream.Error{StatusCode: 503}
+		}))
+		attempts = append(attempts, n)
+	}
+	assert.Equal(t, []int{2, 1, 1, 2}, attempts)
+
+	stats := rLimit.Stats()
+	assert.Equal(t, int64(2), stats.LastMinute.Retries)
+	assert.Equal(t, int64(4), stats.LastMinute.RetriesDenied)
+	assert.Equal(t, RetryBudgetStats{Retries: 2, Allowed: 2}, stats.RetryBudget)
+	assert.Equal(t, &RetryBudgetStatus{Retries: 2, Allowed: 2}, rLimit.Status().RetryBudget)
+}
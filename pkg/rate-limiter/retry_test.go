@@ -0,0 +1,167 @@
+package rate_limiter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+)
+
+// instantClock fires every After right away, moving the time forward and
+// recording the requested delays.
+type instantClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	delays []time.Duration
+}
+
+func (c *instantClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *instantClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.delays = append(c.delays, d)
+	if d > 0 {
+		c.now = c.now.Add(d)
+	}
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, ConstantBackoff(time.Second).Delay(5))
+
+	exponential := ExponentialBackoff(100*time.Millisecond, time.Second)
+	assert.Equal(t, 100*time.Millisecond, exponential.Delay(1))
+	assert.Equal(t, 200*time.Millisecond, exponential.Delay(2))
+	assert.Equal(t, 800*time.Millisecond, exponential.Delay(4))
+	assert.Equal(t, time.Second, exponential.Delay(5))
+	assert.Equal(t, time.Second, exponential.Delay(100))
+
+	jittered := Jittered(ConstantBackoff(time.Second))
+	for i := 0; i < 100; i++ {
+		delay := jittered.Delay(1)
+		assert.GreaterOrEqual(t, delay, 500*time.Millisecond)
+		assert.LessOrEqual(t, delay, time.Second)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "Too many requests", err: stream.Error{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "Server error", err: stream.Error{StatusCode: http.StatusBadGateway}, want: true},
+		{name: "Wrapped server error", err: fmt.Errorf("query: %w", stream.Error{StatusCode: http.StatusServiceUnavailable}), want: true},
+		{name: "Pointer server error", err: &stream.Error{StatusCode: http.StatusInternalServerError}, want: true},
+		{name: "Bad request", err: stream.Error{StatusCode: http.StatusBadRequest}, want: false},
+		{name: "Context deadline", err: context.DeadlineExceeded, want: false},
+		{name: "Generic error", err: assert.AnError, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTransient(tt.err))
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	ok := &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 10}}
+
+	tests := []struct {
+		name         string
+		policy       RetryPolicy
+		errs         []error
+		wantAttempts int
+		wantDelays   []time.Duration
+		wantError    assert.ErrorAssertionFunc
+	}{
+		{
+			name:         "Transient errors are retried",
+			policy:       RetryPolicy{MaxAttempts: 3, Backoff: ExponentialBackoff(time.Second, 0)},
+			errs:         []error{stream.Error{StatusCode: 503}, stream.Error{StatusCode: 500}},
+			wantAttempts: 3,
+			wantDelays:   []time.Duration{time.Second, 2 * time.Second},
+			wantError:    assert.NoError,
+		},
+		{
+			name:         "Attempts are capped",
+			policy:       RetryPolicy{MaxAttempts: 2, Backoff: ConstantBackoff(time.Second)},
+			errs:         []error{stream.Error{StatusCode: 503}, stream.Error{StatusCode: 503}, nil},
+			wantAttempts: 2,
+			wantDelays:   []time.Duration{time.Second},
+			wantError:    assert.Error,
+		},
+		{
+			name:         "Permanent errors are not retried",
+			policy:       RetryPolicy{MaxAttempts: 3},
+			errs:         []error{stream.Error{StatusCode: 400}},
+			wantAttempts: 1,
+			wantError:    assert.Error,
+		},
+		{
+			name:   "Too many requests waits for the reset",
+			policy: RetryPolicy{MaxAttempts: 3, Backoff: ConstantBackoff(time.Second)},
+			errs: []error{stream.Error{
+				StatusCode: http.StatusTooManyRequests,
+				RateLimit:  &stream.RateLimitInfo{Reset: 1030},
+			}},
+			wantAttempts: 2,
+			wantDelays:   []time.Duration{time.Second, 30 * time.Second},
+			wantError:    assert.NoError,
+		},
+		{
+			name: "Custom retryable",
+			policy: RetryPolicy{MaxAttempts: 3, Retryable: func(err error) bool {
+				return err == assert.AnError
+			}},
+			errs:         []error{assert.AnError},
+			wantAttempts: 2,
+			wantDelays:   []time.Duration{0},
+			wantError:    assert.NoError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &instantClock{now: time.Unix(1000, 0)}
+			rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithRetry(tt.policy))
+
+			attempts := 0
+			err := rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+				attempts++
+				if attempts <= len(tt.errs) && tt.errs[attempts-1] != nil {
+					return nil, tt.errs[attempts-1]
+				}
+				return ok, nil
+			})
+			tt.wantError(t, err)
+			assert.Equal(t, tt.wantAttempts, attempts)
+			clock.mu.Lock()
+			defer clock.mu.Unlock()
+			assert.ElementsMatch(t, tt.wantDelays, clock.delays)
+		})
+	}
+}
+
+func TestWithRetryStopsOnContextDone(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithRetry(RetryPolicy{MaxAttempts: 5, Backoff: ConstantBackoff(time.Hour)}))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := rLimit.CallApiWithContext(ctx, func() (resp *stream.Response, err error) {
+		return nil, stream.Error{StatusCode: 503}
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
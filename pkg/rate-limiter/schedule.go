@@ -0,0 +1,260 @@
+package rate_limiter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+// ThrottleRule caps the calls of an api to Rate calls per second during a
+// period recurring every week, e.g. a reduced rate on working hours for the
+// background jobs, see WithSchedule.
+type ThrottleRule struct {
+	// Days are the days the period starts on, every day when empty.
+	Days []time.Weekday
+	// From and To are the times of day, since midnight, the period starts and
+	// ends at, ending the next day when To is not after From, e.g. 22h to 6h.
+	From, To time.Duration
+	// Location is the time zone of Days, From and To (default UTC).
+	Location *time.Location
+	// Rate is the calls per second during the period. When not positive, the
+	// period is quiet hours: the calls wait for its end.
+	Rate float64
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseThrottleRule returns the ThrottleRule of rate calls per second over the
+// period of spec, written as optional days followed by the times of day, e.g.
+// "Mon-Fri 09:00-18:00", "Sat,Sun 00:00-24:00" or "22:00-06:00" for every
+// night. The days are their first 3 letters, as lists or ranges.
+func ParseThrottleRule(spec string, rate float64) (ThrottleRule, error) {
+	rule := ThrottleRule{Rate: rate}
+	fields := strings.Fields(spec)
+	if len(fields) == 2 {
+		days, err := parseDays(fields[0])
+		if err != nil {
+			return ThrottleRule{}, fmt.Errorf("schedule %q: %w", spec, err)
+		}
+		rule.Days = days
+		fields = fields[1:]
+	}
+	if len(fields) != 1 {
+		return ThrottleRule{}, fmt.Errorf("schedule %q: want [days] HH:MM-HH:MM", spec)
+	}
+	from, to, ok := strings.Cut(fields[0], "-")
+	var err error
+	if rule.From, err = parseTimeOfDay(from); err == nil && ok {
+		rule.To, err = parseTimeOfDay(to)
+	}
+	if err != nil || !ok {
+		return ThrottleRule{}, fmt.Errorf("schedule %q: want [days] HH:MM-HH:MM", spec)
+	}
+	return rule, nil
+}
+
+// parseDays parses days like "Mon-Fri" or "Sat,Sun".
+func parseDays(spec string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, part := range strings.Split(strings.ToLower(spec), ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		if !isRange {
+			last = first
+		}
+		from, ok := weekdays[first]
+		to, ok2 := weekdays[last]
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("unknown days %q", part)
+		}
+		for d := from; ; d = (d + 1) % 7 {
+			days = append(days, d)
+			if d == to {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseTimeOfDay parses a time of day like "09:30" into the time since
+// midnight, up to "24:00".
+func parseTimeOfDay(spec string) (time.Duration, error) {
+	hours, minutes, ok := strings.Cut(spec, ":")
+	h, err := strconv.Atoi(hours)
+	if err != nil || !ok || len(minutes) != 2 {
+		return 0, fmt.Errorf("invalid time of day %q", spec)
+	}
+	m, err := strconv.Atoi(minutes)
+	if err != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, fmt.Errorf("invalid time of day %q", spec)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// WithSchedule throttles the calls of the api by time of day and day of week,
+// e.g. so that background sync jobs run at a reduced rate during business
+// hours and at full rate overnight, on top of the Strategy of the limiter, if
+// any. The first of rules whose period holds the time of a call applies, the
+// calls being unthrottled outside of them. Passed to WithApiOptions, it
+// schedules a single endpoint of a LimiterManager. It replaces the rules of a
+// previous WithSchedule; rules with times of day outside [0, 24h] are ignored.
+func WithSchedule(rules ...ThrottleRule) Option {
+	return func(r *RateLimiter) {
+		r.schedule = nil
+		for _, rule := range rules {
+			if rule.From >= 0 && rule.From <= day && rule.To >= 0 && rule.To <= day {
+				r.schedule = append(r.schedule, rule)
+			}
+		}
+	}
+}
+
+// at returns whether the period of the rule holds t, and its end.
+func (rule *ThrottleRule) at(t time.Time) (time.Time, bool) {
+	loc := rule.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+	length := rule.To - rule.From
+	if length <= 0 {
+		length += day
+	}
+	// the period of the day before may still be running
+	for offset := -1; offset <= 0; offset++ {
+		midnight := time.Date(t.Year(), t.Month(), t.Day()+offset, 0, 0, 0, 0, loc)
+		if !rule.on(midnight.Weekday()) {
+			continue
+		}
+		start := midnight.Add(rule.From)
+		if end := start.Add(length); !t.Before(start) && t.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (rule *ThrottleRule) on(weekday time.Weekday) bool {
+	if len(rule.Days) == 0 {
+		return true
+	}
+	for _, d := range rule.Days {
+		if d == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// throttleSchedule is the Strategy of WithSchedule, spacing the calls by the
+// rate of the rule applying at their start, once admitted by the strategy it
+// wraps.
+type throttleSchedule struct {
+	rules []ThrottleRule
+	next  Strategy // the strategy of the limiter, if any
+
+	mu    sync.Mutex
+	after time.Time // when the next throttled call may start
+	prev  time.Time // after, before the latest call
+}
+
+var _ StrategyCanceler = (*throttleSchedule)(nil)
+
+func newThrottleSchedule(rules []ThrottleRule, next Strategy) *throttleSchedule {
+	return &throttleSchedule{rules: rules, next: next}
+}
+
+// rule returns the rule applying at t, if any, and the end of its period.
+func (s *throttleSchedule) rule(t time.Time) (*ThrottleRule, time.Time) {
+	for i := range s.rules {
+		if end, ok := s.rules[i].at(t); ok {
+			return &s.rules[i], end
+		}
+	}
+	return nil, time.Time{}
+}
+
+// slotLocked returns when a call may start from t, after the quiet hours and
+// once the interval of the rate of its rule elapsed since the previous call,
+// and the rule applying then, if any. s.mu must be held.
+func (s *throttleSchedule) slotLocked(t time.Time) (at time.Time, rule *ThrottleRule) {
+	// bounded, in case of quiet hours never ending
+	for i := 0; i <= 7*len(s.rules); i++ {
+		rule, end := s.rule(t)
+		switch {
+		case rule == nil:
+			return t, nil
+		case rule.Rate <= 0:
+			t = end
+		case s.after.After(t) && !s.after.Before(end):
+			t = end
+		case s.after.After(t):
+			return s.after, rule
+		default:
+			return t, rule
+		}
+	}
+	return t, nil
+}
+
+// takeLocked spaces the call after one starting at t by the rate of rule.
+// s.mu must be held.
+func (s *throttleSchedule) takeLocked(at time.Time, rule *ThrottleRule) {
+	s.prev = s.after
+	if rule != nil {
+		s.after = at.Add(time.Duration(float64(time.Second) / rule.Rate))
+	}
+}
+
+func (s *throttleSchedule) Reserve(now time.Time) time.Time {
+	at := now
+	if s.next != nil {
+		at = s.next.Reserve(now)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	at, rule := s.slotLocked(at)
+	s.takeLocked(at, rule)
+	return at
+}
+
+func (s *throttleSchedule) TryReserve(now time.Time) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	at, rule := s.slotLocked(now)
+	if at.After(now) {
+		return at, false
+	}
+	if s.next != nil {
+		if at, ok := s.next.TryReserve(now); !ok {
+			return at, false
+		}
+	}
+	s.takeLocked(now, rule)
+	return now, true
+}
+
+func (s *throttleSchedule) Cancel(now time.Time) {
+	s.mu.Lock()
+	s.after = s.prev
+	s.mu.Unlock()
+	cancelReservation(s.next, now)
+}
+
+func (s *throttleSchedule) Observe(now time.Time, info *LimitInfo, err error) {
+	if s.next != nil {
+		s.next.Observe(now, info, err)
+	}
+}
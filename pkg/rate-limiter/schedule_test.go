@@ -0,0 +1,140 @@
+package rate_limiter
+
+import (
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestParseThrottleRule(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    ThrottleRule
+		wantErr bool
+	}{
+		{
+			spec: "Mon-Fri 09:00-18:30",
+			want: ThrottleRule{
+				Days: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+				From: 9 * time.Hour, To: 18*time.Hour + 30*time.Minute, Rate: 2,
+			},
+		},
+		{
+			spec: "sat,Sun 00:00-24:00",
+			want: ThrottleRule{Days: []time.Weekday{time.Saturday, time.Sunday}, To: 24 * time.Hour, Rate: 2},
+		},
+		{
+			spec: "Fri-Mon 22:00-06:00",
+			want: ThrottleRule{
+				Days: []time.Weekday{time.Friday, time.Saturday, time.Sunday, time.Monday},
+				From: 22 * time.Hour, To: 6 * time.Hour, Rate: 2,
+			},
+		},
+		{spec: "22:00-06:00", want: ThrottleRule{From: 22 * time.Hour, To: 6 * time.Hour, Rate: 2}},
+		{spec: "Mon-Fry 09:00-18:00", wantErr: true},
+		{spec: "09:00", wantErr: true},
+		{spec: "09:00-24:01", wantErr: true},
+		{spec: "9h-18h", wantErr: true},
+		{spec: "Mon 09:00-10:00 extra", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			rule, err := ParseThrottleRule(tt.spec, 2)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rule)
+		})
+	}
+}
+
+func TestThrottleRuleAt(t *testing.T) {
+	// Friday nights, in UTC+2
+	rule := ThrottleRule{
+		Days:     []time.Weekday{time.Friday},
+		From:     22 * time.Hour,
+		To:       6 * time.Hour,
+		Location: time.FixedZone("CEST", 2*60*60),
+	}
+	friday := time.Date(2026, 10, 16, 0, 0, 0, 0, rule.Location)
+
+	_, ok := rule.at(friday.Add(21 * time.Hour))
+	assert.False(t, ok)
+	end, ok := rule.at(friday.Add(22 * time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, friday.Add(30*time.Hour), end)
+	end, ok = rule.at(friday.Add(29 * time.Hour).UTC())
+	assert.True(t, ok, "still running on saturday")
+	assert.True(t, friday.Add(30*time.Hour).Equal(end))
+	_, ok = rule.at(friday.Add(30 * time.Hour))
+	assert.False(t, ok)
+	_, ok = rule.at(friday.Add(2 * time.Hour))
+	assert.False(t, ok, "not on thursday nights")
+}
+
+func TestThrottleSchedule(t *testing.T) {
+	monday := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	schedule := newThrottleSchedule([]ThrottleRule{
+		{Days: []time.Weekday{time.Monday}, From: 9 * time.Hour, To: 18 * time.Hour, Rate: 2},
+		{From: 0, To: 6 * time.Hour}, // quiet hours
+	}, nil)
+
+	now := monday.Add(10 * time.Hour)
+	assert.Equal(t, now, schedule.Reserve(now))
+	assert.Equal(t, now.Add(500*time.Millisecond), schedule.Reserve(now))
+	at, ok := schedule.TryReserve(now)
+	assert.False(t, ok)
+	assert.Equal(t, now.Add(time.Second), at)
+
+	// the spacing ends with the rule
+	end := monday.Add(18 * time.Hour)
+	assert.Equal(t, end.Add(-time.Second/4), schedule.Reserve(end.Add(-time.Second/4)))
+	assert.Equal(t, end, schedule.Reserve(end.Add(-time.Second/8)))
+	assert.Equal(t, end, schedule.Reserve(end))
+
+	// the quiet hours hold the calls until their end
+	night := monday.Add(27 * time.Hour)
+	at, ok = schedule.TryReserve(night)
+	assert.False(t, ok)
+	assert.Equal(t, monday.Add(30*time.Hour), at)
+	assert.Equal(t, monday.Add(30*time.Hour), schedule.Reserve(night))
+	assert.Equal(t, monday.Add(34*time.Hour), schedule.Reserve(monday.Add(34*time.Hour)), "not throttled on tuesday")
+}
+
+func TestThrottleScheduleNeverEnding(t *testing.T) {
+	now := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	schedule := newThrottleSchedule([]ThrottleRule{{To: 24 * time.Hour}}, nil)
+	at, ok := schedule.TryReserve(now)
+	assert.False(t, ok)
+	assert.True(t, at.After(now))
+}
+
+func TestWithSchedule(t *testing.T) {
+	clock := clocktest.NewFake(time.Date(2026, 10, 12, 3, 0, 0, 0, time.UTC))
+	quiet, err := ParseThrottleRule("00:00-06:00", 0)
+	require.NoError(t, err)
+	manager := NewLimiterManager(WithClock(clock), WithApiOptions(QueryUsers, WithSchedule(quiet, ThrottleRule{From: -time.Hour})))
+
+	rLimit := manager.For(QueryUsers)
+	require.IsType(t, &throttleSchedule{}, rLimit.strategy)
+	assert.Len(t, rLimit.strategy.(*throttleSchedule).rules, 1, "invalid rule ignored")
+	assert.Nil(t, manager.For(SendMessage).strategy)
+
+	apiCall := func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 1000, Remaining: 1000, Reset: clock.Now().Unix() + 60}}, nil
+	}
+	var rateLimited ErrRateLimited
+	require.ErrorAs(t, rLimit.TryCallApi(apiCall), &rateLimited)
+	assert.Equal(t, time.Date(2026, 10, 12, 6, 0, 0, 0, time.UTC), rateLimited.ResetAt)
+	require.NoError(t, manager.For(SendMessage).TryCallApi(apiCall))
+
+	clock.Advance(3 * time.Hour)
+	require.NoError(t, rLimit.TryCallApi(apiCall))
+}
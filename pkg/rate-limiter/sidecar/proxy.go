@@ -0,0 +1,174 @@
+// Package sidecar is a reverse proxy in front of the Stream api rate limiting
+// the requests going through it, so that the services calling Stream with the
+// SDKs of other languages are protected without any change but their base
+// URL. Each request is admitted by the limiter of its endpoint, named after
+// its method and path, see Route, and the X-RateLimit-* headers of its
+// response update the limiter, see rate_limiter.Transport.
+package sidecar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"time"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// DefaultTarget is the Stream Chat api.
+const DefaultTarget = "https://chat.stream-io-api.com"
+
+// tooManyRequestsCode is the code of the Stream errors for too many requests.
+const tooManyRequestsCode = 9
+
+// Proxy is an http.Handler proxying the requests to the Stream api through the
+// limiters of a LimiterManager.
+type Proxy struct {
+	target   *url.URL
+	manager  *rate_limiter.LimiterManager
+	base     http.RoundTripper
+	routes   []Route
+	fallback rate_limiter.GetStreamApiName
+
+	custom, defaults router
+	proxy            *httputil.ReverseProxy
+}
+
+// Option configures a Proxy.
+type Option func(*Proxy)
+
+// WithRoutes adds routes, taking precedence over DefaultRoutes, e.g. for the
+// endpoints missing from them.
+func WithRoutes(routes ...Route) Option {
+	return func(p *Proxy) {
+		p.routes = append(p.routes, routes...)
+	}
+}
+
+// WithFallback limits the requests matching no route with the limiter of api;
+// by default they are proxied without any limit.
+func WithFallback(api rate_limiter.GetStreamApiName) Option {
+	return func(p *Proxy) {
+		p.fallback = api
+	}
+}
+
+// WithTransport replaces http.DefaultTransport, performing the requests to
+// the target.
+func WithTransport(base http.RoundTripper) Option {
+	return func(p *Proxy) {
+		p.base = base
+	}
+}
+
+// New returns a Proxy to target, e.g. DefaultTarget, admitting the requests
+// with the limiters of manager.
+func New(target string, manager *rate_limiter.LimiterManager, opts ...Option) (*Proxy, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, errors.New("sidecar: target must be an absolute URL")
+	}
+	p := &Proxy{target: u, manager: manager, base: http.DefaultTransport}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.custom, p.defaults = newRouter(p.routes), newRouter(DefaultRoutes)
+	p.proxy = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(p.target)
+			r.SetXForwarded()
+		},
+		Transport:    p.transport(),
+		ErrorHandler: p.handleError,
+	}
+	return p, nil
+}
+
+// endpointKey carries the endpoint of a request from ServeHTTP to the
+// Transport.
+type endpointKey struct{}
+
+func (p *Proxy) transport() http.RoundTripper {
+	limited := &rate_limiter.Transport{
+		Base:     p.base,
+		Limiters: p.manager,
+		Endpoint: func(req *http.Request) rate_limiter.GetStreamApiName {
+			return req.Context().Value(endpointKey{}).(rate_limiter.GetStreamApiName)
+		},
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if _, ok := req.Context().Value(endpointKey{}).(rate_limiter.GetStreamApiName); !ok {
+			return p.base.RoundTrip(req)
+		}
+		return limited.RoundTrip(req)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Endpoint returns the endpoint of a request of method to path, false when
+// no route matches it and there is no fallback.
+func (p *Proxy) Endpoint(method, path string) (rate_limiter.GetStreamApiName, bool) {
+	if api, ok := p.custom.match(method, path); ok {
+		return api, true
+	}
+	if api, ok := p.defaults.match(method, path); ok {
+		return api, true
+	}
+	return p.fallback, p.fallback != ""
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if api, ok := p.Endpoint(req.Method, req.URL.Path); ok {
+		req = req.WithContext(context.WithValue(req.Context(), endpointKey{}, api))
+	}
+	p.proxy.ServeHTTP(w, req)
+}
+
+// handleError answers the requests turned down by their limiter as Stream
+// does for too many requests, with a Retry-After header when the reset is
+// known, and the requests the target failed to answer with a 502.
+func (p *Proxy) handleError(w http.ResponseWriter, req *http.Request, err error) {
+	var rateLimited rate_limiter.ErrRateLimited
+	switch {
+	case errors.As(err, &rateLimited):
+		if !rateLimited.ResetAt.IsZero() {
+			retryAfter := max(time.Until(rateLimited.ResetAt).Round(time.Second), time.Second)
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter/time.Second)))
+		}
+		writeError(w, http.StatusTooManyRequests, tooManyRequestsCode, err)
+	case errors.Is(err, rate_limiter.ErrWaitTimeout), errors.Is(err, rate_limiter.ErrQueueFull):
+		writeError(w, http.StatusTooManyRequests, tooManyRequestsCode, err)
+	case errors.Is(err, rate_limiter.ErrClosed), errors.Is(err, rate_limiter.ErrDraining),
+		errors.Is(err, rate_limiter.ErrPaused), errors.Is(err, rate_limiter.ErrCircuitOpen):
+		writeError(w, http.StatusServiceUnavailable, 0, err)
+	case errors.Is(err, context.Canceled):
+		// the client is gone
+		w.WriteHeader(499)
+	default:
+		writeError(w, http.StatusBadGateway, 0, err)
+	}
+}
+
+// writeError writes an error in the format of the Stream errors, for the SDKs
+// to report it as they report the errors of Stream.
+func writeError(w http.ResponseWriter, statusCode, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(map[string]any{
+		"code":       code,
+		"message":    err.Error(),
+		"StatusCode": statusCode,
+	})
+}
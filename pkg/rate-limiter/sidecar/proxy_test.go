@@ -0,0 +1,147 @@
+package sidecar
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// newStream serves a fake Stream api, reporting remaining calls for every
+// request.
+func newStream(t *testing.T, remaining *atomic.Int64, reset time.Time) (*httptest.Server, *atomic.Int64) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls.Add(1)
+		w.Header().Set(stream.HeaderRateLimit, "10")
+		w.Header().Set(stream.HeaderRateRemaining, strconv.FormatInt(remaining.Load(), 10))
+		w.Header().Set(stream.HeaderRateReset, strconv.FormatInt(reset.Unix(), 10))
+		w.Header().Set("X-Path", req.Method+" "+req.URL.Path+"?"+req.URL.RawQuery)
+		w.Write([]byte(`{"duration":"1ms"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func newProxy(t *testing.T, target string, manager *rate_limiter.LimiterManager, opts ...Option) *httptest.Server {
+	proxy, err := New(target, manager, opts...)
+	require.NoError(t, err)
+	server := httptest.NewServer(proxy)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProxy(t *testing.T) {
+	var remaining atomic.Int64
+	remaining.Store(5)
+	reset := time.Now().Add(time.Minute)
+	upstream, calls := newStream(t, &remaining, reset)
+	manager := rate_limiter.NewLimiterManager(rate_limiter.WithMaxWait(10 * time.Millisecond))
+	proxy := newProxy(t, upstream.URL, manager)
+
+	resp, err := http.Get(proxy.URL + "/users?api_key=key")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "GET /users?api_key=key", resp.Header.Get("X-Path"))
+	assert.Equal(t, int64(5), manager.For(rate_limiter.QueryUsers).Remaining())
+
+	// the response exhausting the quota blocks the endpoint until the reset
+	remaining.Store(0)
+	resp, err = http.Get(proxy.URL + "/users")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, manager.For(rate_limiter.QueryUsers).IsBlocked())
+
+	resp, err = http.Get(proxy.URL + "/users")
+	require.NoError(t, err)
+	var body struct {
+		Code       int    `json:"code"`
+		Message    string `json:"message"`
+		StatusCode int    `json:"StatusCode"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, http.StatusTooManyRequests, body.StatusCode)
+	assert.Equal(t, tooManyRequestsCode, body.Code)
+	assert.Equal(t, int64(2), calls.Load(), "turned down by the sidecar")
+
+	// other endpoints have their own limiter
+	resp, err = http.Post(proxy.URL+"/channels", "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int64(0), manager.For(rate_limiter.QueryChannels).Remaining())
+}
+
+func TestProxyRetryAfter(t *testing.T) {
+	limited := rate_limiter.ErrRateLimited{ApiName: "QueryUsers", ResetAt: time.Now().Add(30 * time.Second)}
+	rec := httptest.NewRecorder()
+	p, err := New(DefaultTarget, rate_limiter.NewLimiterManager())
+	require.NoError(t, err)
+	p.handleError(rec, httptest.NewRequest("GET", "/users", nil), limited)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+
+	rec = httptest.NewRecorder()
+	p.handleError(rec, httptest.NewRequest("GET", "/users", nil), rate_limiter.ErrDraining)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestProxyUnmatched(t *testing.T) {
+	var remaining atomic.Int64
+	upstream, _ := newStream(t, &remaining, time.Now().Add(time.Minute))
+	manager := rate_limiter.NewLimiterManager()
+	proxy := newProxy(t, upstream.URL, manager)
+
+	// not limited, even though exhausted
+	for i := 0; i < 2; i++ {
+		resp, err := http.Get(proxy.URL + "/og")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.Empty(t, manager.Status())
+
+	proxy = newProxy(t, upstream.URL, manager, WithFallback("Other"), WithRoutes(Route{"GET", "/og", "GetOG"}, Route{"GET", "/users", "MyUsers"}))
+	resp, err := http.Get(proxy.URL + "/og")
+	require.NoError(t, err)
+	resp.Body.Close()
+	resp, err = http.Get(proxy.URL + "/rate_limits")
+	require.NoError(t, err)
+	resp.Body.Close()
+	resp, err = http.Get(proxy.URL + "/users")
+	require.NoError(t, err)
+	resp.Body.Close()
+	var apis []string
+	for _, status := range manager.Status() {
+		apis = append(apis, status.Api)
+	}
+	assert.Equal(t, []string{"GetOG", "MyUsers", "Other"}, apis)
+}
+
+func TestProxyBadGateway(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	upstream.Close()
+	proxy := newProxy(t, upstream.URL, rate_limiter.NewLimiterManager())
+	resp, err := http.Get(proxy.URL + "/users")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+}
+
+func TestNewInvalidTarget(t *testing.T) {
+	_, err := New("chat.stream-io-api.com", rate_limiter.NewLimiterManager())
+	assert.Error(t, err)
+}
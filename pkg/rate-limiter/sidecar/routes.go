@@ -0,0 +1,184 @@
+package sidecar
+
+import (
+	"sort"
+	"strings"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// Route names the endpoint, hence the limiter, of the requests of Method to
+// Path, whose segments in braces, as {id}, match any segment.
+type Route struct {
+	Method string
+	Path   string
+	Api    rate_limiter.GetStreamApiName
+}
+
+// DefaultRoutes are the routes of the Stream Chat REST api, as called by the
+// server side SDKs.
+var DefaultRoutes = []Route{
+	// channels
+	{"POST", "/channels", rate_limiter.QueryChannels},
+	{"POST", "/channels/{type}/query", rate_limiter.GetOrCreateChannel},
+	{"POST", "/channels/{type}/{id}/query", rate_limiter.GetOrCreateChannel},
+	{"POST", "/channels/{type}/{id}", rate_limiter.UpdateChannel},
+	{"PATCH", "/channels/{type}/{id}", rate_limiter.UpdateChannelPartial},
+	{"DELETE", "/channels/{type}/{id}", rate_limiter.DeleteChannel},
+	{"POST", "/channels/delete", rate_limiter.DeleteChannels},
+	{"POST", "/channels/{type}/{id}/truncate", rate_limiter.TruncateChannel},
+	{"POST", "/channels/{type}/{id}/hide", rate_limiter.HideChannel},
+	{"POST", "/channels/{type}/{id}/show", rate_limiter.ShowChannel},
+	{"POST", "/channels/{type}/{id}/read", rate_limiter.MarkRead},
+	{"POST", "/channels/read", rate_limiter.MarkAllRead},
+	{"POST", "/channels/{type}/{id}/event", rate_limiter.SendEvent},
+	{"POST", "/channels/{type}/{id}/file", rate_limiter.UploadFile},
+	{"POST", "/channels/{type}/{id}/image", rate_limiter.UploadImage},
+	{"DELETE", "/channels/{type}/{id}/file", rate_limiter.DeleteFile},
+	{"DELETE", "/channels/{type}/{id}/image", rate_limiter.DeleteImage},
+	// members
+	{"GET", "/members", rate_limiter.QueryMembers},
+	// messages
+	{"POST", "/channels/{type}/{id}/message", rate_limiter.SendMessage},
+	{"GET", "/channels/{type}/{id}/messages", rate_limiter.GetManyMessages},
+	{"GET", "/messages/{id}", rate_limiter.GetMessage},
+	{"POST", "/messages/{id}", rate_limiter.UpdateMessage},
+	{"PUT", "/messages/{id}", rate_limiter.UpdateMessagePartial},
+	{"DELETE", "/messages/{id}", rate_limiter.DeleteMessage},
+	{"POST", "/messages/{id}/commit", rate_limiter.CommitMessage},
+	{"POST", "/messages/{id}/translate", rate_limiter.TranslateMessage},
+	{"GET", "/messages/{id}/replies", rate_limiter.GetReplies},
+	{"POST", "/messages/{id}/action", rate_limiter.RunMessageAction},
+	{"GET", "/search", rate_limiter.Search},
+	// reactions
+	{"POST", "/messages/{id}/reaction", rate_limiter.SendReaction},
+	{"DELETE", "/messages/{id}/reaction/{type}", rate_limiter.DeleteReaction},
+	{"GET", "/messages/{id}/reactions", rate_limiter.GetReactions},
+	// users
+	{"GET", "/users", rate_limiter.QueryUsers},
+	{"POST", "/users", rate_limiter.UpdateUsers},
+	{"PATCH", "/users", rate_limiter.UpdateUsersPartial},
+	{"POST", "/guest", rate_limiter.CreateGuestUser},
+	{"DELETE", "/users/{id}", rate_limiter.DeleteUser},
+	{"POST", "/users/delete", rate_limiter.DeleteUsers},
+	{"POST", "/users/{id}/deactivate", rate_limiter.DeactivateUser},
+	{"POST", "/users/deactivate", rate_limiter.DeactivateUsers},
+	{"POST", "/users/{id}/reactivate", rate_limiter.ReactivateUser},
+	{"POST", "/users/reactivate", rate_limiter.ReactivateUsers},
+	{"POST", "/users/{id}/event", rate_limiter.SendUserCustomEvent},
+	// moderation
+	{"POST", "/moderation/ban", rate_limiter.BanUser},
+	{"DELETE", "/moderation/ban", rate_limiter.UnBanUser},
+	{"GET", "/query_banned_users", rate_limiter.QueryBannedUsers},
+	{"POST", "/moderation/flag", rate_limiter.Flag},
+	{"POST", "/moderation/mute", rate_limiter.Mute},
+	{"POST", "/moderation/unmute", rate_limiter.Unmute},
+	{"POST", "/moderation/mute/channel", rate_limiter.MuteChannel},
+	{"POST", "/moderation/unmute/channel", rate_limiter.UnmuteChannel},
+	{"GET", "/moderation/flags/message", rate_limiter.QueryMessageFlags},
+	{"POST", "/moderation/reports", rate_limiter.QueryFlagReports},
+	{"PATCH", "/moderation/reports/{id}", rate_limiter.ReviewFlagReport},
+	// devices
+	{"POST", "/devices", rate_limiter.AddDevice},
+	{"DELETE", "/devices", rate_limiter.DeleteDevice},
+	{"GET", "/devices", rate_limiter.GetDevices},
+	// exports
+	{"POST", "/export_channels", rate_limiter.ExportChannels},
+	{"GET", "/export_channels/{id}", rate_limiter.GetExportChannelsTask},
+	{"GET", "/users/{id}/export", rate_limiter.ExportUser},
+	{"GET", "/tasks/{id}", rate_limiter.GetTask},
+	// imports
+	{"POST", "/import_urls", rate_limiter.CreateImportURL},
+	{"POST", "/imports", rate_limiter.CreateImport},
+	{"GET", "/imports/{id}", rate_limiter.GetImport},
+	{"GET", "/imports", rate_limiter.ListImports},
+	// push
+	{"POST", "/check_push", rate_limiter.CheckPush},
+	{"POST", "/check_sqs", rate_limiter.CheckSqs},
+	{"POST", "/push_providers", rate_limiter.UpsertPushProvider},
+	{"DELETE", "/push_providers/{type}/{name}", rate_limiter.DeletePushProvider},
+	{"GET", "/push_providers", rate_limiter.ListPushProviders},
+	// app
+	{"GET", "/app", rate_limiter.GetAppSettings},
+	{"PATCH", "/app", rate_limiter.UpdateAppSettings},
+	{"POST", "/channeltypes", rate_limiter.CreateChannelType},
+	{"GET", "/channeltypes/{name}", rate_limiter.GetChannelType},
+	{"GET", "/channeltypes", rate_limiter.ListChannelTypes},
+	{"PUT", "/channeltypes/{name}", rate_limiter.UpdateChannelType},
+	{"DELETE", "/channeltypes/{name}", rate_limiter.DeleteChannelType},
+	{"POST", "/commands", rate_limiter.CreateCommand},
+	{"GET", "/commands/{name}", rate_limiter.GetCommand},
+	{"GET", "/commands", rate_limiter.ListCommands},
+	{"PUT", "/commands/{name}", rate_limiter.UpdateCommand},
+	{"DELETE", "/commands/{name}", rate_limiter.DeleteCommand},
+	{"POST", "/blocklists", rate_limiter.CreateBlocklist},
+	{"GET", "/blocklists/{name}", rate_limiter.GetBlocklist},
+	{"GET", "/blocklists", rate_limiter.ListBlocklists},
+	{"PUT", "/blocklists/{name}", rate_limiter.UpdateBlocklist},
+	{"DELETE", "/blocklists/{name}", rate_limiter.DeleteBlocklist},
+}
+
+// compiledRoute is a Route split into segments, "" for the wildcards.
+type compiledRoute struct {
+	segments []string
+	api      rate_limiter.GetStreamApiName
+}
+
+// router matches the requests against routes, by method.
+type router map[string][]compiledRoute
+
+// newRouter returns the router of routes: a route takes precedence over the
+// ones with a wildcard where it has a literal segment, the earliest segment
+// deciding, then over the routes following it.
+func newRouter(routes []Route) router {
+	r := router{}
+	for _, route := range routes {
+		segments := split(route.Path)
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+				segments[i] = ""
+			}
+		}
+		method := strings.ToUpper(route.Method)
+		r[method] = append(r[method], compiledRoute{segments: segments, api: route.Api})
+	}
+	for _, compiled := range r {
+		sort.SliceStable(compiled, func(i, j int) bool {
+			a, b := compiled[i].segments, compiled[j].segments
+			for k := 0; k < len(a) && k < len(b); k++ {
+				if (a[k] == "") != (b[k] == "") {
+					return a[k] != ""
+				}
+			}
+			return false
+		})
+	}
+	return r
+}
+
+// match returns the endpoint of a request of method to path, false when no
+// route matches it.
+func (r router) match(method, path string) (rate_limiter.GetStreamApiName, bool) {
+	segments := split(path)
+next:
+	for _, route := range r[method] {
+		if len(route.segments) != len(segments) {
+			continue
+		}
+		for i, segment := range route.segments {
+			if segment != "" && segment != segments[i] {
+				continue next
+			}
+		}
+		return route.api, true
+	}
+	return "", false
+}
+
+func split(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
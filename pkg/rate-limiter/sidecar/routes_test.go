@@ -0,0 +1,67 @@
+package sidecar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+func TestDefaultRoutes(t *testing.T) {
+	r := newRouter(DefaultRoutes)
+	for _, tc := range []struct {
+		method, path string
+		want         rate_limiter.GetStreamApiName
+	}{
+		{"POST", "/channels", rate_limiter.QueryChannels},
+		{"POST", "/channels/messaging/general/query", rate_limiter.GetOrCreateChannel},
+		{"POST", "/channels/messaging/query", rate_limiter.GetOrCreateChannel},
+		{"POST", "/channels/messaging/general", rate_limiter.UpdateChannel},
+		{"POST", "/channels/delete", rate_limiter.DeleteChannels},
+		{"POST", "/channels/read", rate_limiter.MarkAllRead},
+		{"POST", "/channels/messaging/general/message", rate_limiter.SendMessage},
+		{"DELETE", "/messages/42/reaction/like", rate_limiter.DeleteReaction},
+		{"GET", "/users", rate_limiter.QueryUsers},
+		{"PATCH", "/users/", rate_limiter.UpdateUsersPartial},
+		{"POST", "/users/deactivate", rate_limiter.DeactivateUsers},
+		{"POST", "/users/jane/deactivate", rate_limiter.DeactivateUser},
+		{"DELETE", "/users/jane", rate_limiter.DeleteUser},
+	} {
+		got, ok := r.match(tc.method, tc.path)
+		assert.True(t, ok, tc.path)
+		assert.Equal(t, tc.want, got, "%s %s", tc.method, tc.path)
+	}
+
+	for _, route := range DefaultRoutes {
+		_, ok := rate_limiter.LookupEndpoint(route.Api)
+		assert.True(t, ok, "unknown endpoint %s", route.Api)
+	}
+
+	_, ok := r.match("GET", "/channels")
+	assert.False(t, ok, "method")
+	_, ok = r.match("POST", "/channels/messaging/general/unknown")
+	assert.False(t, ok)
+	_, ok = r.match("GET", "/")
+	assert.False(t, ok)
+}
+
+func TestRoutePrecedence(t *testing.T) {
+	r := newRouter([]Route{
+		{"GET", "/things/{id}/{part}", "Parts"},
+		{"GET", "/things/{id}/name", "Names"},
+		{"get", "/things/mine/{part}", "Mine"},
+		{"GET", "/things/{id}/name", "Shadowed"},
+	})
+	for path, want := range map[string]rate_limiter.GetStreamApiName{
+		"/things/42/size":  "Parts",
+		"/things/42/name":  "Names",
+		"/things/mine/age": "Mine",
+		// the earliest literal segment decides
+		"/things/mine/name": "Mine",
+	} {
+		got, ok := r.match("GET", path)
+		assert.True(t, ok, path)
+		assert.Equal(t, want, got, path)
+	}
+}
@@ -0,0 +1,85 @@
+package rate_limiter
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// WithMaxBlock caps how long a reported reset may block the api, e.g. when
+// the reset is far off because of a clock skew not estimated yet, or a bogus
+// response. The calls resume after d at most, and are blocked again by the
+// next response if the quota is still exhausted. Values not positive are
+// ignored.
+func WithMaxBlock(d time.Duration) Option {
+	return func(r *RateLimiter) {
+		if d > 0 {
+			r.maxBlock = d
+		}
+	}
+}
+
+// clockSkew estimates how far the clock of the server is ahead of the local
+// one, from the server time of the responses.
+type clockSkew struct {
+	mu       sync.Mutex
+	estimate time.Duration
+	known    bool
+}
+
+// observe records a response dated serverTime, with a precision of a second,
+// received at now.
+func (s *clockSkew) observe(serverTime, now time.Time) {
+	// the date is truncated to the second, half a second early on average
+	sample := serverTime.Add(500 * time.Millisecond).Sub(now)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.known {
+		s.estimate, s.known = sample, true
+		return
+	}
+	// smoothed, as the delay of each response adds up to its sample
+	s.estimate += (sample - s.estimate) / 4
+}
+
+func (s *clockSkew) get() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.estimate
+}
+
+// ObserveServerTime records the server time reported by a response received
+// now, e.g. by its Date header, to estimate the clock skew between the server
+// and the host: the resets reported by Stream are Unix timestamps of the clock
+// of the server, compared with the local clock to block the api. The Transport
+// and CallWithHeaders record the Date header of every response.
+func (r *RateLimiter) ObserveServerTime(serverTime time.Time) {
+	r.skew.observe(serverTime, r.getClock().Now())
+}
+
+// ClockSkew returns the estimated clock skew, positive when the clock of the
+// server is ahead of the local one, 0 until a server time is observed.
+func (r *RateLimiter) ClockSkew() time.Duration {
+	return r.skew.get()
+}
+
+// serverNow returns the current time of the server, as estimated.
+func (r *RateLimiter) serverNow() time.Time {
+	return r.getClock().Now().Add(r.skew.get())
+}
+
+// localReset returns the local time of a reset reported by the server.
+func (r *RateLimiter) localReset(reset int64) time.Time {
+	return time.Unix(reset, 0).Add(-r.skew.get())
+}
+
+// logResetPassed reports a response exhausting the quota with a reset already
+// passed, which does not block the api.
+func (r *RateLimiter) logResetPassed(ctx context.Context, logger Logger, reset int64) {
+	logAttrs(ctx, logger, slog.LevelDebug, "reset already passed", []slog.Attr{
+		slog.String("api", r.apiName),
+		slog.Int64("reset", reset),
+		slog.Duration("skew", r.skew.get()),
+	}, "Reset %d of %s already passed, not blocking\n", reset, r.apiName)
+}
@@ -0,0 +1,83 @@
+package rate_limiter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestClockSkew(t *testing.T) {
+	var skew clockSkew
+	now := time.Unix(1000, 0)
+	assert.Zero(t, skew.get())
+
+	skew.observe(now.Add(10*time.Second), now)
+	assert.Equal(t, 10500*time.Millisecond, skew.get(), "first sample")
+	skew.observe(now.Add(14*time.Second), now)
+	assert.Equal(t, 11500*time.Millisecond, skew.get(), "smoothed")
+}
+
+func TestBlockCompensatesSkew(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+	// the server is 30 seconds ahead
+	rLimit.ObserveServerTime(clock.Now().Add(29500 * time.Millisecond))
+	require.Equal(t, 30*time.Second, rLimit.ClockSkew())
+
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1090}}, nil
+	}))
+	require.True(t, rLimit.IsBlocked())
+	assert.Equal(t, time.Minute, rLimit.EstimateWait(), "the reset is a minute away on the clock of the server")
+
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool { return !rLimit.IsBlocked() }, time.Second, time.Millisecond)
+}
+
+func TestResetAlreadyPassed(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+	// the server is 30 seconds ahead, past the reset
+	rLimit.ObserveServerTime(clock.Now().Add(29500 * time.Millisecond))
+
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1020}}, nil
+	}))
+	assert.False(t, rLimit.IsBlocked())
+}
+
+func TestWithMaxBlock(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithMaxBlock(10*time.Second), WithMaxBlock(0))
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 4600}}, nil
+	}))
+	require.True(t, rLimit.IsBlocked())
+	assert.Equal(t, 10*time.Second, rLimit.EstimateWait())
+
+	clock.Advance(10 * time.Second)
+	require.Eventually(t, func() bool { return !rLimit.IsBlocked() }, time.Second, time.Millisecond)
+}
+
+func TestCallWithHeadersObservesDate(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+	header := http.Header{}
+	header.Set("Date", time.Unix(1020, 0).UTC().Format(http.TimeFormat))
+	header.Set(stream.HeaderRateLimit, "10")
+	header.Set(stream.HeaderRateRemaining, "0")
+	header.Set(stream.HeaderRateReset, "60") // seconds until the reset
+
+	require.NoError(t, rLimit.CallWithHeaders(context.Background(), func() (http.Header, bool, error) {
+		return header, false, nil
+	}))
+	assert.Equal(t, 20500*time.Millisecond, rLimit.ClockSkew())
+	assert.Equal(t, 59500*time.Millisecond, rLimit.EstimateWait())
+}
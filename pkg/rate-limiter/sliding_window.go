@@ -0,0 +1,96 @@
+package rate_limiter
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// SlidingWindow is a Strategy admitting at most limit calls within any window,
+// counting the calls made through the limiter. Unlike the rate limit reported
+// by Stream it does not need any RateLimitInfo, e.g. when the wrapped call
+// fails or strips it.
+type SlidingWindow struct {
+	limit  int
+	window time.Duration
+
+	mu     sync.Mutex
+	starts []time.Time // sorted start times of the calls, possibly in the future
+}
+
+var _ StrategyCanceler = (*SlidingWindow)(nil)
+
+// NewSlidingWindow returns a SlidingWindow admitting limit calls per window.
+// A limit lower than 1 is raised to 1.
+func NewSlidingWindow(limit int, window time.Duration) *SlidingWindow {
+	if limit < 1 {
+		limit = 1
+	}
+	return &SlidingWindow{limit: limit, window: window}
+}
+
+// next drops the calls out of the window and returns when the next call may
+// start.
+func (w *SlidingWindow) next(now time.Time) time.Time {
+	expired := sort.Search(len(w.starts), func(i int) bool {
+		return w.starts[i].After(now.Add(-w.window))
+	})
+	w.starts = w.starts[expired:]
+	n := len(w.starts)
+	if n < w.limit {
+		return now
+	}
+	// once the limit-th latest call leaves the window, not before the latest
+	// one so that starts stay sorted
+	at := w.starts[n-w.limit].Add(w.window)
+	if last := w.starts[n-1]; last.After(at) {
+		at = last
+	}
+	if now.After(at) {
+		return now
+	}
+	return at
+}
+
+func (w *SlidingWindow) add(at time.Time) {
+	i := sort.Search(len(w.starts), func(i int) bool {
+		return w.starts[i].After(at)
+	})
+	w.starts = append(w.starts, time.Time{})
+	copy(w.starts[i+1:], w.starts[i:])
+	w.starts[i] = at
+}
+
+// removeLatest drops the latest start.
+func (w *SlidingWindow) removeLatest() {
+	if len(w.starts) > 0 {
+		w.starts = w.starts[:len(w.starts)-1]
+	}
+}
+
+func (w *SlidingWindow) Reserve(now time.Time) time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	at := w.next(now)
+	w.add(at)
+	return at
+}
+
+func (w *SlidingWindow) TryReserve(now time.Time) (time.Time, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	at := w.next(now)
+	if at.After(now) {
+		return at, false
+	}
+	w.add(at)
+	return at, true
+}
+
+func (w *SlidingWindow) Cancel(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.removeLatest()
+}
+
+func (w *SlidingWindow) Observe(now time.Time, info *LimitInfo, err error) {}
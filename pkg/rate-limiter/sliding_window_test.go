@@ -0,0 +1,65 @@
+package rate_limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	window := NewSlidingWindow(3, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		_, ok := window.TryReserve(now.Add(time.Duration(i) * 10 * time.Second))
+		require.True(t, ok, "call %d", i)
+	}
+	// calls at 1000, 1010 and 1020 fill the window
+	at, ok := window.TryReserve(now.Add(30 * time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, time.Unix(1060, 0), at)
+
+	// reservations wait for the oldest calls to leave the window
+	assert.Equal(t, time.Unix(1060, 0), window.Reserve(now.Add(30*time.Second)))
+	assert.Equal(t, time.Unix(1070, 0), window.Reserve(now.Add(30*time.Second)))
+
+	_, ok = window.TryReserve(time.Unix(1080, 0))
+	assert.True(t, ok, "the call at 1020 left the window")
+	assert.Len(t, window.starts, 3)
+}
+
+func TestSlidingWindowReservesInOrder(t *testing.T) {
+	now := time.Unix(1000, 0)
+	window := NewSlidingWindow(2, time.Minute)
+
+	window.Reserve(now)
+	window.Reserve(now)
+	assert.Equal(t, time.Unix(1060, 0), window.Reserve(now))
+	assert.Equal(t, time.Unix(1060, 0), window.Reserve(time.Unix(1060, 0)))
+	assert.Equal(t, []time.Time{time.Unix(1060, 0), time.Unix(1060, 0)}, window.starts)
+
+	at, ok := window.TryReserve(time.Unix(1119, 0))
+	assert.False(t, ok)
+	assert.Equal(t, time.Unix(1120, 0), at)
+}
+
+func TestSlidingWindowCancel(t *testing.T) {
+	now := time.Unix(1000, 0)
+	window := NewSlidingWindow(1, time.Minute)
+
+	_, ok := window.TryReserve(now)
+	require.True(t, ok)
+	assert.Equal(t, time.Unix(1060, 0), window.Reserve(now))
+	window.Cancel(now)
+	assert.Equal(t, time.Unix(1060, 0), window.Reserve(now), "the latest call is given back")
+	window.Cancel(now)
+	window.Cancel(now)
+	_, ok = window.TryReserve(now)
+	assert.True(t, ok)
+}
+
+func TestNewSlidingWindowRaisesLimit(t *testing.T) {
+	assert.Equal(t, 1, NewSlidingWindow(0, time.Second).limit)
+}
@@ -0,0 +1,53 @@
+// Package slogadapter plugs a *slog.Logger into the rate limiter, so that
+// limiter messages carry structured attributes instead of formatted strings.
+package slogadapter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+)
+
+// Adapter implements both rate_limiter.Logger and rate_limiter.StructuredLogger.
+type Adapter struct {
+	logger *slog.Logger
+}
+
+var (
+	_ rate_limiter.Logger           = (*Adapter)(nil)
+	_ rate_limiter.StructuredLogger = (*Adapter)(nil)
+)
+
+// New returns an Adapter for logger, or for slog.Default() when logger is nil.
+func New(logger *slog.Logger) *Adapter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Adapter{logger: logger}
+}
+
+func (a *Adapter) Debugf(format string, args ...interface{}) {
+	a.logf(slog.LevelDebug, format, args...)
+}
+
+func (a *Adapter) Tracef(format string, args ...interface{}) {
+	a.logf(rate_limiter.LevelTrace, format, args...)
+}
+
+func (a *Adapter) Warnf(format string, args ...interface{}) {
+	a.logf(slog.LevelWarn, format, args...)
+}
+
+func (a *Adapter) LogAttrs(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	a.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+func (a *Adapter) logf(level slog.Level, format string, args ...interface{}) {
+	ctx := context.Background()
+	if !a.logger.Enabled(ctx, level) {
+		return
+	}
+	a.logger.Log(ctx, level, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,80 @@
+package slogadapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rate_limiter "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func newJSONAdapter(buf *bytes.Buffer) *Adapter {
+	return New(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: rate_limiter.LevelTrace})))
+}
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		var line map[string]any
+		require.NoError(t, decoder.Decode(&line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestAdapterFormatted(t *testing.T) {
+	var buf bytes.Buffer
+	adapter := newJSONAdapter(&buf)
+
+	adapter.Tracef("trace %s", "a")
+	adapter.Debugf("debug %s", "b")
+	adapter.Warnf("warn %s", "c")
+
+	lines := decodeLines(t, &buf)
+	require.Len(t, lines, 3)
+	assert.Equal(t, "DEBUG-4", lines[0]["level"])
+	assert.Equal(t, "trace a", lines[0]["msg"])
+	assert.Equal(t, "DEBUG", lines[1]["level"])
+	assert.Equal(t, "WARN", lines[2]["level"])
+}
+
+func TestAdapterStructuredLimiterLogs(t *testing.T) {
+	var buf bytes.Buffer
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := rate_limiter.NewRateLimiter(rate_limiter.QueryUsers, rate_limiter.WithLogger(newJSONAdapter(&buf)), rate_limiter.WithClock(clock))
+	reset := int64(1060)
+
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Limit: 10, Reset: reset}}, nil
+	}))
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	// the barrier is lifted once the reset has been logged
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 9, Limit: 10}}, nil
+	}))
+
+	lines := decodeLines(t, &buf)
+	require.Len(t, lines, 5)
+	assert.Equal(t, "api called", lines[0]["msg"])
+	assert.Equal(t, "QueryUsers", lines[0]["api"])
+	assert.Equal(t, float64(0), lines[0]["remaining"])
+	assert.Equal(t, float64(10), lines[0]["limit"])
+	assert.Equal(t, "no more calls left", lines[1]["msg"])
+	assert.Equal(t, float64(reset), lines[1]["reset"])
+	assert.Equal(t, "blocking calls until reset", lines[2]["msg"])
+	assert.Equal(t, "restarting calls", lines[3]["msg"])
+}
+
+func TestNewDefaultsToSlogDefault(t *testing.T) {
+	assert.Same(t, slog.Default(), New(nil).logger)
+}
@@ -0,0 +1,148 @@
+package rate_limiter
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// slowStart ramps up the calls in flight of a limiter after its first call,
+// and again after being idle for as long as the ramp lasts.
+type slowStart struct {
+	initial  int
+	step     int
+	interval time.Duration
+	full     int // the concurrency ending the ramp
+
+	mu       sync.Mutex
+	start    time.Time // of the current ramp, zero before the first call
+	lastCall time.Time // when the last call returned
+	active   int
+	wake     chan struct{} // closed once a call returns
+}
+
+// WithSlowStart ramps the concurrency up after startup and after a long idle
+// period, instead of unleashing the full budget at once, so that a freshly
+// deployed fleet does not burst all together: the first call allows initial
+// calls in flight, raised by step every interval up to the WithMaxConcurrency
+// slots. The ramp starts over once the limiter has been idle for as long as a
+// whole ramp lasts. Non-positive values are ignored.
+func WithSlowStart(initial, step int, interval time.Duration) Option {
+	return func(r *RateLimiter) {
+		if initial > 0 && step > 0 && interval > 0 {
+			// sized by NewRateLimiter once the slots are known
+			r.slowStart = &slowStart{initial: initial, step: step, interval: interval}
+		}
+	}
+}
+
+// duration returns how long a whole ramp lasts.
+func (s *slowStart) duration() time.Duration {
+	steps := (s.full - s.initial + s.step - 1) / s.step
+	return time.Duration(max(steps, 0)) * s.interval
+}
+
+// startLocked returns when the ramp of a call at now starts: now for the
+// first call and after an idle period. s.mu must be held.
+func (s *slowStart) startLocked(now time.Time) time.Time {
+	if s.start.IsZero() || (s.active == 0 && !s.lastCall.IsZero() && now.Sub(s.lastCall) >= s.duration()) {
+		return now
+	}
+	return s.start
+}
+
+// limitLocked returns the calls allowed in flight at now by the ramp started
+// at start, and when the next step raises it, zero once the ramp is over.
+func (s *slowStart) limitLocked(start, now time.Time) (int, time.Time) {
+	steps := int(now.Sub(start) / s.interval)
+	limit := s.initial + steps*s.step
+	if limit >= s.full {
+		return math.MaxInt, time.Time{}
+	}
+	return limit, start.Add(time.Duration(steps+1) * s.interval)
+}
+
+// enter counts a call in flight when the ramp allows it, otherwise it returns
+// a channel closed once a call returns, and when the next step raises the
+// limit.
+func (s *slowStart) enter(now time.Time) (chan struct{}, time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.start = s.startLocked(now)
+	limit, next := s.limitLocked(s.start, now)
+	if s.active < limit {
+		s.active++
+		return nil, time.Time{}, true
+	}
+	if s.wake == nil {
+		s.wake = make(chan struct{})
+	}
+	return s.wake, next, false
+}
+
+func (s *slowStart) leave(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active--
+	s.lastCall = now
+	if s.wake != nil {
+		close(s.wake)
+		s.wake = nil
+	}
+}
+
+// limit returns the calls allowed in flight by the ramp at now.
+func (s *slowStart) limit(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	limit, _ := s.limitLocked(s.startLocked(now), now)
+	return limit
+}
+
+// enterSlowStart waits, while holding a token, until the ramp of WithSlowStart,
+// if set, admits the call, reporting whether it had to wait.
+func (r *RateLimiter) enterSlowStart(ctx context.Context, timeout *maxWaitTimer) (bool, error) {
+	if r.slowStart == nil {
+		return false, nil
+	}
+	clock := r.getClock()
+	waited := false
+	for {
+		wake, next, ok := r.slowStart.enter(clock.Now())
+		if ok {
+			return waited, nil
+		}
+		waited = true
+		var step <-chan time.Time
+		if !next.IsZero() {
+			step = clock.After(next.Sub(clock.Now()))
+		}
+		select {
+		case <-wake:
+		case <-step:
+		case <-ctx.Done():
+			return waited, ctx.Err()
+		case <-timeout.C():
+			return waited, r.errWaitTimeout()
+		case <-r.closing():
+			return waited, r.errClosed()
+		}
+	}
+}
+
+// tryEnterSlowStart is like enterSlowStart, without waiting.
+func (r *RateLimiter) tryEnterSlowStart() bool {
+	if r.slowStart == nil {
+		return true
+	}
+	_, _, ok := r.slowStart.enter(r.getClock().Now())
+	return ok
+}
+
+// leaveSlowStart counts out a call admitted by enterSlowStart.
+func (r *RateLimiter) leaveSlowStart() {
+	if r.slowStart != nil {
+		r.slowStart.leave(r.getClock().Now())
+	}
+}
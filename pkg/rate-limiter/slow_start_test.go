@@ -0,0 +1,59 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestWithSlowStart(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithMaxConcurrency(10), WithSlowStart(2, 4, 10*time.Second))
+	assert.Equal(t, 2, rLimit.Concurrency())
+
+	entered := make(chan struct{}, 10)
+	unblock := make(chan struct{})
+	call := func() (*stream.Response, error) {
+		entered <- struct{}{}
+		<-unblock
+		return &stream.Response{}, nil
+	}
+	done := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			done <- rLimit.CallApiWithContext(context.Background(), call)
+		}()
+	}
+	<-entered
+	<-entered
+	blockUntil(t, clock, 1)
+	assert.Empty(t, entered, "the third call waits for the next step")
+	var rateLimited ErrRateLimited
+	assert.ErrorAs(t, rLimit.TryCallApi(call), &rateLimited)
+
+	clock.Advance(10 * time.Second)
+	<-entered
+	assert.Equal(t, 6, rLimit.Concurrency())
+	clock.Advance(10 * time.Second)
+	assert.Equal(t, 10, rLimit.Concurrency(), "ramp over")
+
+	close(unblock)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, receive(t, done))
+	}
+	clock.Advance(19 * time.Second)
+	assert.Equal(t, 10, rLimit.Concurrency())
+	clock.Advance(time.Second)
+	assert.Equal(t, 2, rLimit.Concurrency(), "ramping up again after idling as long as a ramp")
+}
+
+func TestWithSlowStartIgnoresInvalid(t *testing.T) {
+	assert.Nil(t, NewRateLimiter(QueryUsers, WithSlowStart(0, 1, time.Second)).slowStart)
+	assert.Nil(t, NewRateLimiter(QueryUsers, WithSlowStart(1, 1, 0)).slowStart)
+}
@@ -0,0 +1,76 @@
+package rate_limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// WithMaxRate caps the calls to rps per second, spacing them by at least 1/rps
+// without bursts, on top of the Strategy of the limiter, if any. It arrests the
+// spikes below the burst limit of Stream, e.g. of the callers resumed at once
+// by a reset. Values not positive are ignored.
+func WithMaxRate(rps float64) Option {
+	return func(r *RateLimiter) {
+		if rps > 0 {
+			r.maxRate = rps
+		}
+	}
+}
+
+// spikeArrest is the Strategy of WithMaxRate, starting each call one interval
+// after the previous one at least, once admitted by the strategy it wraps.
+type spikeArrest struct {
+	interval time.Duration
+	next     Strategy // the strategy of the limiter, if any
+
+	mu    sync.Mutex
+	after time.Time // when the next call may start
+}
+
+var _ StrategyCanceler = (*spikeArrest)(nil)
+
+func newSpikeArrest(rps float64, next Strategy) *spikeArrest {
+	return &spikeArrest{interval: time.Duration(float64(time.Second) / rps), next: next}
+}
+
+func (s *spikeArrest) Reserve(now time.Time) time.Time {
+	at := now
+	if s.next != nil {
+		at = s.next.Reserve(now)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.after.After(at) {
+		at = s.after
+	}
+	s.after = at.Add(s.interval)
+	return at
+}
+
+func (s *spikeArrest) TryReserve(now time.Time) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.after.After(now) {
+		return s.after, false
+	}
+	if s.next != nil {
+		if at, ok := s.next.TryReserve(now); !ok {
+			return at, false
+		}
+	}
+	s.after = now.Add(s.interval)
+	return now, true
+}
+
+func (s *spikeArrest) Cancel(now time.Time) {
+	s.mu.Lock()
+	s.after = s.after.Add(-s.interval)
+	s.mu.Unlock()
+	cancelReservation(s.next, now)
+}
+
+func (s *spikeArrest) Observe(now time.Time, info *LimitInfo, err error) {
+	if s.next != nil {
+		s.next.Observe(now, info, err)
+	}
+}
@@ -0,0 +1,67 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestSpikeArrest(t *testing.T) {
+	now := time.Unix(1000, 0)
+	spike := newSpikeArrest(4, nil)
+
+	at, ok := spike.TryReserve(now)
+	assert.True(t, ok)
+	assert.Equal(t, now, at)
+	at, ok = spike.TryReserve(now)
+	assert.False(t, ok, "no burst")
+	assert.Equal(t, now.Add(250*time.Millisecond), at)
+
+	// reservations are spaced by the interval
+	assert.Equal(t, now.Add(250*time.Millisecond), spike.Reserve(now))
+	assert.Equal(t, now.Add(500*time.Millisecond), spike.Reserve(now))
+	assert.Equal(t, now.Add(time.Hour), spike.Reserve(now.Add(time.Hour)))
+}
+
+func TestSpikeArrestWrapsStrategy(t *testing.T) {
+	now := time.Unix(1000, 0)
+	// the bucket admits a call every 2 seconds, slower than the max rate
+	spike := newSpikeArrest(10, NewTokenBucket(0.5, 1))
+
+	assert.Equal(t, now, spike.Reserve(now))
+	assert.Equal(t, now.Add(2*time.Second), spike.Reserve(now))
+	at, ok := spike.TryReserve(now.Add(2100 * time.Millisecond))
+	assert.False(t, ok, "turned down by the bucket")
+	assert.Equal(t, now.Add(4*time.Second), at)
+}
+
+func TestWithMaxRate(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	bucket := NewTokenBucket(100, 100)
+	// the order of the options does not matter
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithMaxRate(2), WithStrategy(bucket), WithMaxRate(0))
+	require.IsType(t, &spikeArrest{}, rLimit.strategy)
+	assert.Same(t, bucket, rLimit.strategy.(*spikeArrest).next)
+
+	apiCall := func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 1000, Remaining: 1000, Reset: clock.Now().Unix() + 60}}, nil
+	}
+	require.NoError(t, rLimit.TryCallApi(apiCall))
+	var rateLimited ErrRateLimited
+	require.ErrorAs(t, rLimit.TryCallApi(apiCall), &rateLimited)
+	assert.Equal(t, clock.Now().Add(500*time.Millisecond), rateLimited.ResetAt)
+
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), apiCall)
+	}()
+	blockUntil(t, clock, 1)
+	clock.Advance(500 * time.Millisecond)
+	require.NoError(t, receive(t, done))
+}
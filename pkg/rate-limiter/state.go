@@ -0,0 +1,78 @@
+package rate_limiter
+
+import (
+	"context"
+	"time"
+)
+
+// observe records the rate limit info of the latest response and the call time.
+func (r *RateLimiter) observe(calledAt time.Time, info *LimitInfo) {
+	r.lastCallAt.Store(calledAt.UnixNano())
+	r.observeInfo(info)
+}
+
+// observeInfo records the rate limit info, from a response or a preflight.
+func (r *RateLimiter) observeInfo(info *LimitInfo) {
+	if info != nil {
+		r.lastInfo.Store(*info)
+		r.getMetrics().SetRemaining(r.apiName, info.Remaining)
+	}
+}
+
+// Remaining returns the remaining calls reported by the latest response,
+// or -1 when no response has been observed yet.
+func (r *RateLimiter) Remaining() int64 {
+	info, ok := r.lastInfo.Load()
+	if !ok {
+		return -1
+	}
+	return info.Remaining
+}
+
+// ResetAt returns when the current rate limit window resets according to the
+// latest response, or the zero time when no response has been observed yet.
+func (r *RateLimiter) ResetAt() time.Time {
+	info, ok := r.lastInfo.Load()
+	if !ok {
+		return time.Time{}
+	}
+	return time.Unix(info.Reset, 0)
+}
+
+// IsBlocked reports whether calls are blocked until the rate limit is reset.
+func (r *RateLimiter) IsBlocked() bool {
+	blocked, _ := r.barrier()
+	return blocked != nil
+}
+
+// LastCallAt returns when the api was last called through the limiter,
+// or the zero time when it was never called.
+func (r *RateLimiter) LastCallAt() time.Time {
+	calledAt := r.lastCallAt.Load()
+	if calledAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, calledAt)
+}
+
+// EstimateWait returns how long a call made now would wait for the rate limit
+// reset, zero when the api is available. The wait for a busy slot or for the
+// strategy, if any, is not accounted.
+func (r *RateLimiter) EstimateWait() time.Duration {
+	now := r.getClock().Now()
+	if blocked, resetAt := r.barrier(); blocked != nil {
+		return max(resetAt.Sub(now), 0)
+	}
+	info, ok := r.lastInfo.Load()
+	if !ok || info.Remaining > r.headroom.Load() {
+		return 0
+	}
+	return max(time.Unix(info.Reset, 0).Sub(now), 0)
+}
+
+// WaitUntilAvailable waits, without taking a slot, until the api is no longer
+// blocked, e.g. to serve cached data meanwhile. It returns the ctx error once
+// ctx is done, ErrClosed when the limiter is closed meanwhile.
+func (r *RateLimiter) WaitUntilAvailable(ctx context.Context) error {
+	return r.waitBarrier(ctx, &maxWaitTimer{clock: r.getClock()})
+}
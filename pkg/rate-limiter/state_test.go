@@ -0,0 +1,92 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestStateInspection(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock := clocktest.NewFake(now)
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+
+	assert.Equal(t, int64(-1), rLimit.Remaining())
+	assert.True(t, rLimit.ResetAt().IsZero())
+	assert.True(t, rLimit.LastCallAt().IsZero())
+	assert.False(t, rLimit.IsBlocked())
+
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 3, Limit: 5, Reset: 1060}}, nil
+	}))
+	assert.Equal(t, int64(3), rLimit.Remaining())
+	assert.Equal(t, time.Unix(1060, 0), rLimit.ResetAt())
+	assert.Equal(t, now, rLimit.LastCallAt())
+	assert.False(t, rLimit.IsBlocked())
+
+	assert.Error(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		return nil, assert.AnError
+	}))
+	assert.Equal(t, int64(3), rLimit.Remaining())
+
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Limit: 5, Reset: 1060}}, nil
+	}))
+	blockUntil(t, clock, 1)
+	assert.Equal(t, int64(0), rLimit.Remaining())
+	assert.True(t, rLimit.IsBlocked())
+
+	clock.Set(time.Unix(1060, 0))
+	assert.Eventually(t, func() bool { return !rLimit.IsBlocked() }, time.Second, time.Millisecond)
+}
+
+func TestEstimateWait(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithHeadroom(2))
+	assert.Zero(t, rLimit.EstimateWait())
+
+	call := func(remaining int64) GetStreamApiCaller {
+		return func() (*stream.Response, error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: remaining, Reset: 1030}}, nil
+		}
+	}
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), call(5)))
+	assert.Zero(t, rLimit.EstimateWait())
+
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), call(2)))
+	assert.True(t, rLimit.IsBlocked())
+	assert.Equal(t, 30*time.Second, rLimit.EstimateWait())
+	clock.Advance(10 * time.Second)
+	assert.Equal(t, 20*time.Second, rLimit.EstimateWait())
+}
+
+func TestWaitUntilAvailable(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock))
+	require.NoError(t, rLimit.WaitUntilAvailable(context.Background()))
+
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Reset: 1030}}, nil
+	}))
+	done := make(chan error)
+	go func() {
+		done <- rLimit.WaitUntilAvailable(context.Background())
+	}()
+	select {
+	case <-done:
+		t.Fatal("available before the reset")
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(30 * time.Second)
+	assert.NoError(t, receive(t, done))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, rLimit.WaitUntilAvailable(ctx), context.Canceled)
+}
@@ -0,0 +1,213 @@
+package rate_limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// waitBins is the number of bins of the wait histograms, bin i counting the
+// waits below 1ms<<i, the last one the longer waits too.
+const waitBins = 24
+
+// WindowStats sums up the calls of a limiter over a rolling window.
+type WindowStats struct {
+	// Calls counts the calls of the api, Errors the failed ones.
+	Calls  int64
+	Errors int64
+	// Blocks counts the api being blocked until a rate limit reset.
+	Blocks int64
+	// Rejections counts the calls turned down without calling the api, e.g.
+	// by TryCallApi or with an ErrWaitTimeout.
+	Rejections int64
+	// Retries counts the retries of WithRetry, RetriesDenied the ones not
+	// attempted as the RetryBudget was spent.
+	Retries       int64
+	RetriesDenied int64
+	// AvgWait and P99Wait are how long the calls waited before being
+	// admitted; P99Wait is rounded up to a power of 2 milliseconds, capped by
+	// the longest wait.
+	AvgWait time.Duration
+	P99Wait time.Duration
+}
+
+// Stats sums up the calls of a limiter over the last minute, 5 minutes and
+// hour, see RateLimiter.Stats.
+type Stats struct {
+	Api          string
+	LastMinute   WindowStats
+	Last5Minutes WindowStats
+	LastHour     WindowStats
+	// RetryBudget is the consumption of the RetryBudget, zero without one.
+	RetryBudget RetryBudgetStats
+}
+
+// statsBucket sums up the calls of a slot of a rollingStats ring.
+type statsBucket struct {
+	slot          int64 // index of the slot since the epoch
+	calls         int64
+	errors        int64
+	blocks        int64
+	rejections    int64
+	retries       int64
+	retriesDenied int64
+	waits         int64
+	waitSum       time.Duration
+	waitMax       time.Duration
+	histogram     [waitBins]uint32
+}
+
+// add adds the sums of b to s.
+func (s *statsBucket) add(b *statsBucket) {
+	s.calls += b.calls
+	s.errors += b.errors
+	s.blocks += b.blocks
+	s.rejections += b.rejections
+	s.retries += b.retries
+	s.retriesDenied += b.retriesDenied
+	s.waits += b.waits
+	s.waitSum += b.waitSum
+	s.waitMax = max(s.waitMax, b.waitMax)
+	for i, n := range b.histogram {
+		s.histogram[i] += n
+	}
+}
+
+// windowStats returns the WindowStats of the sums of s.
+func (s *statsBucket) windowStats() WindowStats {
+	stats := WindowStats{
+		Calls:         s.calls,
+		Errors:        s.errors,
+		Blocks:        s.blocks,
+		Rejections:    s.rejections,
+		Retries:       s.retries,
+		RetriesDenied: s.retriesDenied,
+	}
+	if s.waits == 0 {
+		return stats
+	}
+	stats.AvgWait = s.waitSum / time.Duration(s.waits)
+	rank := (s.waits*99 + 99) / 100
+	var seen int64
+	for i, n := range s.histogram {
+		if seen += int64(n); seen >= rank {
+			stats.P99Wait = min(time.Millisecond<<i, s.waitMax)
+			break
+		}
+	}
+	return stats
+}
+
+// statsRing is a ring of slots of the same duration.
+type statsRing struct {
+	slot    time.Duration
+	buckets []statsBucket
+}
+
+// bucket returns the bucket of the slot of now, recycling it when it held an
+// older slot.
+func (r *statsRing) bucket(now time.Time) *statsBucket {
+	slot := now.UnixNano() / int64(r.slot)
+	b := &r.buckets[slot%int64(len(r.buckets))]
+	if b.slot != slot {
+		*b = statsBucket{slot: slot}
+	}
+	return b
+}
+
+// sum sums up the slots of the window ending at now, the slot of now
+// included.
+func (r *statsRing) sum(now time.Time, window time.Duration) statsBucket {
+	slot := now.UnixNano() / int64(r.slot)
+	slots := int64(window / r.slot)
+	var sum statsBucket
+	for i := range r.buckets {
+		if b := &r.buckets[i]; b.slot <= slot && b.slot > slot-slots {
+			sum.add(b)
+		}
+	}
+	return sum
+}
+
+// rollingStats records the calls of a limiter for Stats, in 10 second slots
+// for the last minute and 1 minute slots for the last hour.
+type rollingStats struct {
+	mu      sync.Mutex
+	seconds *statsRing // allocated on first use
+	minutes *statsRing
+}
+
+// record applies f to the buckets of now.
+func (s *rollingStats) record(now time.Time, f func(b *statsBucket)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seconds == nil {
+		s.seconds = &statsRing{slot: 10 * time.Second, buckets: make([]statsBucket, 6)}
+		s.minutes = &statsRing{slot: time.Minute, buckets: make([]statsBucket, 60)}
+	}
+	f(s.seconds.bucket(now))
+	f(s.minutes.bucket(now))
+}
+
+func (r *RateLimiter) recordCall(calledAt time.Time, err error) {
+	r.stats.record(calledAt, func(b *statsBucket) {
+		b.calls++
+		if err != nil {
+			b.errors++
+		}
+	})
+}
+
+func (r *RateLimiter) recordWait(admittedAt time.Time, wait time.Duration) {
+	bin := 0
+	for bin < waitBins-1 && wait >= time.Millisecond<<bin {
+		bin++
+	}
+	r.stats.record(admittedAt, func(b *statsBucket) {
+		b.waits++
+		b.waitSum += wait
+		b.waitMax = max(b.waitMax, wait)
+		b.histogram[bin]++
+	})
+}
+
+func (r *RateLimiter) recordBlock() {
+	r.stats.record(r.getClock().Now(), func(b *statsBucket) { b.blocks++ })
+}
+
+func (r *RateLimiter) recordRejection() {
+	r.stats.record(r.getClock().Now(), func(b *statsBucket) { b.rejections++ })
+}
+
+// Stats sums up the calls of r over the last minute, 5 minutes and hour, e.g.
+// for an admin endpoint or a periodic log summary without Prometheus. The
+// windows roll by 10 seconds for the last minute and by a minute otherwise.
+func (r *RateLimiter) Stats() Stats {
+	now := r.getClock().Now()
+	stats := Stats{Api: r.apiName}
+	if r.retries != nil {
+		stats.RetryBudget = r.retries.stats(now)
+	}
+	r.stats.mu.Lock()
+	defer r.stats.mu.Unlock()
+	if r.stats.seconds == nil {
+		return stats
+	}
+	minute := r.stats.seconds.sum(now, time.Minute)
+	fiveMinutes := r.stats.minutes.sum(now, 5*time.Minute)
+	hour := r.stats.minutes.sum(now, time.Hour)
+	stats.LastMinute = minute.windowStats()
+	stats.Last5Minutes = fiveMinutes.windowStats()
+	stats.LastHour = hour.windowStats()
+	return stats
+}
+
+// Stats returns the Stats of the limiters created so far, by api name or
+// bucket, see WithBucket.
+func (m *LimiterManager) Stats() map[GetStreamApiName]Stats {
+	limiters := m.limiters.values()
+	stats := make(map[GetStreamApiName]Stats, len(limiters))
+	for _, r := range limiters {
+		stats[GetStreamApiName(r.apiName)] = r.Stats()
+	}
+	return stats
+}
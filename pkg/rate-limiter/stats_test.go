@@ -0,0 +1,88 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestStats(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	manager := NewLimiterManager(WithClock(clock))
+	rLimit := manager.For(QueryUsers)
+	assert.Equal(t, Stats{Api: string(QueryUsers)}, rLimit.Stats())
+
+	respond := func(remaining int64, err error) GetStreamApiCaller {
+		return func() (*stream.Response, error) {
+			return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: remaining, Reset: 1060}}, err
+		}
+	}
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), respond(5, nil)))
+	require.Error(t, rLimit.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return nil, stream.Error{StatusCode: 500}
+	}))
+	require.NoError(t, rLimit.CallApiWithContext(context.Background(), respond(0, nil)))
+	require.Error(t, rLimit.TryCallApi(nil), "blocked")
+
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), okResponse)
+	}()
+	blockUntil(t, clock, 1)
+	require.Eventually(t, func() bool { return len(rLimit.tokens()) == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Minute)
+	require.NoError(t, receive(t, done))
+
+	assert.Equal(t, map[GetStreamApiName]Stats{QueryUsers: {
+		Api: string(QueryUsers),
+		// the calls of 1000 are past the minute
+		LastMinute: WindowStats{Calls: 1, AvgWait: time.Minute, P99Wait: time.Minute},
+		Last5Minutes: WindowStats{
+			Calls:      4,
+			Errors:     1,
+			Blocks:     1,
+			Rejections: 1,
+			AvgWait:    15 * time.Second,
+			P99Wait:    time.Minute,
+		},
+		LastHour: WindowStats{
+			Calls:      4,
+			Errors:     1,
+			Blocks:     1,
+			Rejections: 1,
+			AvgWait:    15 * time.Second,
+			P99Wait:    time.Minute,
+		},
+	}}, manager.Stats())
+
+	clock.Advance(10 * time.Minute)
+	stats := rLimit.Stats()
+	assert.Zero(t, stats.Last5Minutes)
+	assert.Equal(t, int64(4), stats.LastHour.Calls)
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, Stats{Api: string(QueryUsers)}, rLimit.Stats())
+}
+
+func TestWindowStatsP99(t *testing.T) {
+	var b statsBucket
+	for i := 0; i < 99; i++ {
+		b.waits++
+		b.histogram[0]++
+	}
+	b.waits++
+	b.waitSum = 300 * time.Millisecond
+	b.waitMax = 300 * time.Millisecond
+	b.histogram[9]++ // below 512ms
+	assert.Equal(t, WindowStats{AvgWait: 3 * time.Millisecond, P99Wait: time.Millisecond}, b.windowStats())
+
+	b.histogram[0]--
+	b.histogram[9]++
+	assert.Equal(t, 300*time.Millisecond, b.windowStats().P99Wait, "capped by the longest wait")
+}
@@ -0,0 +1,114 @@
+package rate_limiter
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// LimiterStatus is the JSON snapshot of a limiter served by StatusHandler.
+type LimiterStatus struct {
+	Api string `json:"api"`
+	// Remaining is -1 before the first response.
+	Remaining  int64      `json:"remaining"`
+	ResetAt    *time.Time `json:"reset_at,omitempty"`
+	Blocked    bool       `json:"blocked"`
+	Paused     bool       `json:"paused"`
+	QueueDepth int        `json:"queue_depth"`
+	InFlight   int        `json:"in_flight"`
+	Stats      struct {
+		LastMinute   WindowStatus `json:"last_minute"`
+		Last5Minutes WindowStatus `json:"last_5_minutes"`
+		LastHour     WindowStatus `json:"last_hour"`
+	} `json:"stats"`
+	RetryBudget *RetryBudgetStatus `json:"retry_budget,omitempty"`
+}
+
+// WindowStatus is the JSON form of a WindowStats, with the waits in
+// milliseconds.
+type WindowStatus struct {
+	Calls      int64   `json:"calls"`
+	Errors     int64   `json:"errors"`
+	Blocks     int64   `json:"blocks"`
+	Rejections int64   `json:"rejections"`
+	AvgWaitMs  float64 `json:"avg_wait_ms"`
+	P99WaitMs  float64 `json:"p99_wait_ms"`
+	// omitted without retries
+	Retries       int64 `json:"retries,omitempty"`
+	RetriesDenied int64 `json:"retries_denied,omitempty"`
+}
+
+// RetryBudgetStatus is the JSON form of a RetryBudgetStats.
+type RetryBudgetStatus struct {
+	Retries int64 `json:"retries"`
+	Allowed int64 `json:"allowed"`
+}
+
+func windowStatus(stats WindowStats) WindowStatus {
+	return WindowStatus{
+		Calls:         stats.Calls,
+		Errors:        stats.Errors,
+		Blocks:        stats.Blocks,
+		Rejections:    stats.Rejections,
+		AvgWaitMs:     float64(stats.AvgWait) / float64(time.Millisecond),
+		P99WaitMs:     float64(stats.P99Wait) / float64(time.Millisecond),
+		Retries:       stats.Retries,
+		RetriesDenied: stats.RetriesDenied,
+	}
+}
+
+// Status returns a snapshot of r.
+func (r *RateLimiter) Status() LimiterStatus {
+	status := LimiterStatus{
+		Api:        r.apiName,
+		Remaining:  r.Remaining(),
+		Blocked:    r.IsBlocked(),
+		Paused:     r.Paused(),
+		QueueDepth: r.QueueDepth(),
+	}
+	if resetAt := r.ResetAt(); !resetAt.IsZero() {
+		status.ResetAt = &resetAt
+	}
+	r.mu.Lock()
+	status.InFlight = r.inFlight
+	r.mu.Unlock()
+	stats := r.Stats()
+	status.Stats.LastMinute = windowStatus(stats.LastMinute)
+	status.Stats.Last5Minutes = windowStatus(stats.Last5Minutes)
+	status.Stats.LastHour = windowStatus(stats.LastHour)
+	if r.retries != nil {
+		status.RetryBudget = &RetryBudgetStatus{Retries: stats.RetryBudget.Retries, Allowed: stats.RetryBudget.Allowed}
+	}
+	return status
+}
+
+// Status returns the snapshots of the limiters created so far, sorted by api
+// name or bucket, see WithBucket.
+func (m *LimiterManager) Status() []LimiterStatus {
+	limiters := m.limiters.values()
+	statuses := make([]LimiterStatus, 0, len(limiters))
+	for _, r := range limiters {
+		statuses = append(statuses, r.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Api < statuses[j].Api })
+	return statuses
+}
+
+// StatusHandler returns an http.Handler serving the Status of the limiters of
+// m as JSON, e.g. to mount on a debug mux:
+//
+//	mux.Handle("/debug/ratelimiter", rate_limiter.StatusHandler(manager))
+func StatusHandler(m *LimiterManager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(struct {
+			Limiters []LimiterStatus `json:"limiters"`
+		}{m.Status()})
+	})
+}
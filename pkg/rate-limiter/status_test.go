@@ -0,0 +1,60 @@
+package rate_limiter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestStatusHandler(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	manager := NewLimiterManager(WithClock(clock))
+	require.NoError(t, manager.For(QueryUsers).CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1060}}, nil
+	}))
+	manager.For(CreateChannel)
+
+	recorder := httptest.NewRecorder()
+	StatusHandler(manager).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/ratelimiter", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	window := `{"calls":1,"errors":0,"blocks":1,"rejections":0,"avg_wait_ms":0,"p99_wait_ms":0}`
+	empty := `{"calls":0,"errors":0,"blocks":0,"rejections":0,"avg_wait_ms":0,"p99_wait_ms":0}`
+	resetAt, err := time.Unix(1060, 0).MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"limiters": [
+		{"api": "CreateChannel", "remaining": -1, "blocked": false, "paused": false, "queue_depth": 0, "in_flight": 0,
+		 "stats": {"last_minute": `+empty+`, "last_5_minutes": `+empty+`, "last_hour": `+empty+`}},
+		{"api": "QueryUsers", "remaining": 0, "reset_at": `+string(resetAt)+`, "blocked": true, "paused": false, "queue_depth": 0, "in_flight": 0,
+		 "stats": {"last_minute": `+window+`, "last_5_minutes": `+window+`, "last_hour": `+window+`}}
+	]}`, recorder.Body.String())
+}
+
+func TestStatusHandlerMethodNotAllowed(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	StatusHandler(NewLimiterManager()).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	assert.Equal(t, "GET, HEAD", recorder.Header().Get("Allow"))
+}
+
+func TestStatusQueueDepth(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	release := holdSlot(t, rLimit)
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), okResponse)
+	}()
+	require.Eventually(t, func() bool { return rLimit.Status().QueueDepth == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 1, rLimit.Status().InFlight)
+	release()
+	require.NoError(t, receive(t, done))
+	assert.Zero(t, rLimit.Status().QueueDepth)
+}
@@ -0,0 +1,84 @@
+package rate_limiter
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// State is the rate limit state of an api kept by a Store.
+type State struct {
+	Limit        int64     `json:"limit"`
+	Remaining    int64     `json:"remaining"`
+	Reset        time.Time `json:"reset"`
+	BlockedUntil time.Time `json:"blocked_until"`
+}
+
+// Store shares the rate limit state of the apis between limiters, possibly
+// living in different processes, see the redisstore package. MemoryStore is
+// the default, in process, implementation.
+// Implementations must be safe for concurrent use and atomic per api.
+type Store interface {
+	// Take admits a call of api at now, consuming one of the remaining calls
+	// of the current window. When the api is exhausted it returns, without
+	// consuming anything, the time until which calls are blocked.
+	Take(ctx context.Context, api string, now time.Time) (blockedUntil time.Time, err error)
+	// Observe records the rate limit info reported for api by a response
+	// received at now, blocking the api until the reset when no calls are left.
+	Observe(ctx context.Context, api string, info LimitInfo, now time.Time) error
+	// Load returns the state of api, the zero State when unknown.
+	Load(ctx context.Context, api string) (State, error)
+}
+
+// Elector is implemented by the Store electing a leader among the processes
+// sharing it, like MemoryStore and the Stores of the redisstore and etcdstore
+// packages, see the remote package.
+type Elector interface {
+	// Campaign makes candidate the leader of election until now+ttl, unless
+	// another candidate leads it until later, and returns the leader. A
+	// leader campaigning again renews its lead.
+	Campaign(ctx context.Context, election, candidate string, now time.Time, ttl time.Duration) (leader string, err error)
+}
+
+// takeFromStore admits a call through the store, if any, returning until when
+// the api is blocked by the shared state, the zero time when admitted.
+func (r *RateLimiter) takeFromStore(ctx context.Context, logger Logger, now time.Time) time.Time {
+	if r.store == nil {
+		return time.Time{}
+	}
+	var (
+		blockedUntil time.Time
+		err          error
+	)
+	if store, ok := r.store.(CostStore); ok && r.costFrom(ctx) > 1 {
+		blockedUntil, err = store.TakeN(ctx, r.apiName, now, r.costFrom(ctx))
+	} else {
+		blockedUntil, err = r.store.Take(ctx, r.apiName, now)
+	}
+	if err != nil {
+		r.logStoreError(ctx, logger, err)
+		return time.Time{}
+	}
+	if !blockedUntil.After(now) {
+		return time.Time{}
+	}
+	return blockedUntil
+}
+
+func (r *RateLimiter) observeStore(ctx context.Context, logger Logger, info *LimitInfo) {
+	if r.store == nil || info == nil {
+		return
+	}
+	if err := r.store.Observe(ctx, r.apiName, *info, r.getClock().Now()); err != nil {
+		r.logStoreError(ctx, logger, err)
+	}
+}
+
+// logStoreError reports a store failure: calls are not blocked by an
+// unavailable store, only by the local state.
+func (r *RateLimiter) logStoreError(ctx context.Context, logger Logger, err error) {
+	logAttrs(ctx, logger, slog.LevelWarn, "store unavailable", []slog.Attr{
+		slog.String("api", r.apiName),
+		slog.String("error", err.Error()),
+	}, "Store unavailable for %s: %v\n", r.apiName, err)
+}
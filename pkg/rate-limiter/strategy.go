@@ -0,0 +1,126 @@
+package rate_limiter
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Strategy shapes the calls of an api on the client side, on top of the rate
+// limit reported by Stream which always blocks exhausted apis until the reset.
+// Implementations must be safe for concurrent use.
+type Strategy interface {
+	// Reserve admits a call at now, returning when it may start.
+	Reserve(now time.Time) time.Time
+	// TryReserve admits a call only if it may start at now, otherwise it
+	// returns, without admitting it, when it could start.
+	TryReserve(now time.Time) (time.Time, bool)
+	// Observe records the outcome of a call at now: the rate limit info it
+	// reported, if any, and its error.
+	Observe(now time.Time, info *LimitInfo, err error)
+}
+
+// StrategyCanceler is implemented by the Strategy able to give back a call it
+// admitted which did not reach the api, e.g. turned down by another limiter of
+// a Chain, like TokenBucket, SlidingWindow and MultiWindow. The calls admitted
+// by other strategies stay accounted.
+type StrategyCanceler interface {
+	Strategy
+	// Cancel gives back, at now, the latest call admitted by Reserve or
+	// TryReserve.
+	Cancel(now time.Time)
+}
+
+// cancelReservation gives back the latest call admitted by strategy, if it is
+// a StrategyCanceler.
+func cancelReservation(strategy Strategy, now time.Time) {
+	if canceler, ok := strategy.(StrategyCanceler); ok {
+		canceler.Cancel(now)
+	}
+}
+
+// WithStrategy shapes the calls through strategy, e.g. a TokenBucket. It
+// replaces the pacing of WithPacing, being one strategy as well.
+func WithStrategy(strategy Strategy) Option {
+	return func(r *RateLimiter) {
+		if strategy != nil {
+			r.strategy = strategy
+		}
+	}
+}
+
+// TokenBucket is a Strategy admitting rate calls per second on average, with
+// bursts of at most burst calls, regardless of what Stream reports.
+type TokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+var _ StrategyCanceler = (*TokenBucket)(nil)
+
+// NewTokenBucket returns a full TokenBucket refilled with rate tokens per
+// second, holding at most burst tokens. A burst lower than 1 is raised to 1,
+// while a rate not positive never refills the bucket.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+// refill adds the tokens earned since the last refill.
+func (b *TokenBucket) refill(now time.Time) {
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	if now.After(b.last) {
+		b.last = now
+	}
+}
+
+// at returns when the bucket holds missing more tokens.
+func (b *TokenBucket) at(now time.Time, missing float64) time.Time {
+	if missing <= 0 {
+		return now
+	}
+	if b.rate <= 0 {
+		return now.Add(math.MaxInt64)
+	}
+	return now.Add(time.Duration(missing / b.rate * float64(time.Second)))
+}
+
+func (b *TokenBucket) Reserve(now time.Time) time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	// tokens go negative for the calls scheduled in the future
+	b.tokens--
+	return b.at(now, -b.tokens)
+}
+
+func (b *TokenBucket) TryReserve(now time.Time) (time.Time, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	if b.tokens < 1 {
+		return b.at(now, 1-b.tokens), false
+	}
+	b.tokens--
+	return now, true
+}
+
+func (b *TokenBucket) Cancel(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	b.tokens = min(b.tokens+1, b.burst)
+}
+
+func (b *TokenBucket) Observe(now time.Time, info *LimitInfo, err error) {}
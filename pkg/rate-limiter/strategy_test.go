@@ -0,0 +1,92 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Unix(1000, 0)
+	bucket := NewTokenBucket(2, 3)
+
+	for i := 0; i < 3; i++ {
+		at, ok := bucket.TryReserve(now)
+		assert.True(t, ok, "burst %d", i)
+		assert.Equal(t, now, at)
+	}
+	at, ok := bucket.TryReserve(now)
+	assert.False(t, ok)
+	assert.Equal(t, now.Add(500*time.Millisecond), at)
+
+	// reservations queue up at the refill rate
+	assert.Equal(t, now.Add(500*time.Millisecond), bucket.Reserve(now))
+	assert.Equal(t, now.Add(time.Second), bucket.Reserve(now))
+
+	// refilled, up to the burst
+	later := now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		_, ok = bucket.TryReserve(later)
+		assert.True(t, ok)
+	}
+	_, ok = bucket.TryReserve(later)
+	assert.False(t, ok)
+}
+
+func TestTokenBucketCancel(t *testing.T) {
+	now := time.Unix(1000, 0)
+	bucket := NewTokenBucket(0, 2)
+
+	_, ok := bucket.TryReserve(now)
+	assert.True(t, ok)
+	bucket.Cancel(now)
+	bucket.Cancel(now)
+	assert.Equal(t, 2.0, bucket.tokens, "up to the burst")
+	for i := 0; i < 2; i++ {
+		_, ok = bucket.TryReserve(now)
+		assert.True(t, ok)
+	}
+}
+
+func TestTokenBucketWithoutRate(t *testing.T) {
+	now := time.Unix(1000, 0)
+	bucket := NewTokenBucket(0, 0)
+
+	_, ok := bucket.TryReserve(now)
+	assert.True(t, ok, "the burst is raised to 1")
+	at, ok := bucket.TryReserve(now.Add(time.Hour))
+	assert.False(t, ok)
+	assert.True(t, at.After(now.AddDate(100, 0, 0)), "never refilled")
+}
+
+func TestWithStrategy(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	rLimit := NewRateLimiter(QueryUsers, WithClock(clock), WithStrategy(NewTokenBucket(1, 1)))
+	// plenty of calls left according to Stream
+	plenty := func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 100, Remaining: 99, Reset: 1060}}, nil
+	}
+
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), plenty))
+	var rateLimited ErrRateLimited
+	assert.ErrorAs(t, rLimit.TryCallApi(plenty), &rateLimited)
+	assert.Equal(t, time.Unix(1001, 0), rateLimited.ResetAt)
+
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), plenty)
+	}()
+	blockUntil(t, clock, 1)
+	clock.Advance(time.Second)
+	assert.NoError(t, receive(t, done))
+}
+
+func TestWithPacingIsAStrategy(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers, WithStrategy(NewTokenBucket(1, 1)), WithPacing())
+	assert.IsType(t, &pacer{}, rLimit.strategy)
+}
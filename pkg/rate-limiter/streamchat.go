@@ -0,0 +1,102 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+)
+
+// Extractors of the rate limit info reported by stream-chat-go v6.
+
+type GetStreamApiCaller func() (resp *stream.Response, err error)
+
+// streamCall runs apiCall as a limitedCall.
+func streamCall(apiCall GetStreamApiCaller) limitedCall {
+	return apiCall
+}
+
+func (apiCall GetStreamApiCaller) call() (LimitInfo, bool, error) {
+	resp, err := apiCall()
+	if err != nil || resp == nil || resp.RateLimitInfo == nil {
+		return LimitInfo{}, false, err
+	}
+	info := resp.RateLimitInfo
+	return LimitInfo{Limit: info.Limit, Remaining: info.Remaining, Reset: info.Reset}, true, nil
+}
+
+// GetStreamTypedApiCaller is an api call returning a typed payload
+// alongside the response carrying the rate limit info.
+type GetStreamTypedApiCaller[T any] func() (T, *stream.Response, error)
+
+// Call runs apiCall through the rate limiter and hands back its typed result,
+// e.g. the *stream.QueryUsersResponse of a QueryUsers call. opts adjust the
+// call, see CallOption. A call served by the fallback of WithFallback returns
+// a *ServedByFallbackError, there being no typed result.
+func Call[T any](ctx context.Context, r *RateLimiter, apiCall GetStreamTypedApiCaller[T], opts ...CallOption) (T, error) {
+	var result T
+	served := &fallbackServed{}
+	err := r.CallApiWithContext(context.WithValue(ctx, fallbackKey{}, served), func() (resp *stream.Response, err error) {
+		result, resp, err = apiCall()
+		return resp, err
+	}, opts...)
+	if err == nil && served.served {
+		err = &ServedByFallbackError{ApiName: r.apiName, Response: served.resp}
+	}
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return result, nil
+}
+
+// fromRateLimitInfo converts the rate limit info of a response, nil if missing.
+func fromRateLimitInfo(info *stream.RateLimitInfo) *LimitInfo {
+	if info == nil {
+		return nil
+	}
+	return &LimitInfo{Limit: info.Limit, Remaining: info.Remaining, Reset: info.Reset}
+}
+
+// streamError is the stream-chat-go error of a call failed with statusCode,
+// reporting info if not nil, as classified by asStreamError.
+func streamError(statusCode int, message string, info *LimitInfo) error {
+	err := stream.Error{StatusCode: statusCode, Message: message}
+	if info != nil {
+		err.RateLimit = &stream.RateLimitInfo{Limit: info.Limit, Remaining: info.Remaining, Reset: info.Reset}
+	}
+	return err
+}
+
+func asStreamError(err error) (stream.Error, bool) {
+	var apiErr stream.Error
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	var apiErrPtr *stream.Error
+	if errors.As(err, &apiErrPtr) && apiErrPtr != nil {
+		return *apiErrPtr, true
+	}
+	return stream.Error{}, false
+}
+
+// tooManyRequestsInfo returns the rate limit info of a 429 error. When the error
+// does not report a future reset, the api is considered exhausted for a whole
+// rate limit window starting from now.
+func tooManyRequestsInfo(err error, now time.Time) (*LimitInfo, bool) {
+	apiErr, ok := asStreamError(err)
+	if !ok || apiErr.StatusCode != http.StatusTooManyRequests {
+		return nil, false
+	}
+	info := LimitInfo{}
+	if apiErr.RateLimit != nil {
+		info = *fromRateLimitInfo(apiErr.RateLimit)
+	}
+	info.Remaining = 0
+	if info.Reset <= now.Unix() {
+		info.Reset = now.Add(defaultRateLimitWindow).Unix()
+	}
+	return &info, true
+}
@@ -0,0 +1,31 @@
+package rate_limiter
+
+import (
+	"testing"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitInfoConversion(t *testing.T) {
+	assert.Nil(t, fromRateLimitInfo(nil))
+
+	reported := &stream.RateLimitInfo{Limit: 60, Remaining: 12, Reset: 1020}
+	assert.Equal(t, &LimitInfo{Limit: 60, Remaining: 12, Reset: 1020}, fromRateLimitInfo(reported))
+}
+
+func TestStreamCall(t *testing.T) {
+	info, ok, err := streamCall(func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 3}}, nil
+	}).call()
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), info.Remaining)
+
+	_, ok, err = streamCall(func() (*stream.Response, error) {
+		return nil, assert.AnError
+	}).call()
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.False(t, ok)
+}
@@ -0,0 +1,330 @@
+package rate_limiter
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// tenantConfig holds the shares of the quota and the eviction of the limiters
+// of the tenants, set by their options.
+type tenantConfig struct {
+	shares       map[string]float64
+	defaultShare float64
+	idleTTL      time.Duration
+	maxLimiters  int
+}
+
+// share returns the share of the quota of tenantID, 1 when not limited.
+func (c *tenantConfig) share(tenantID string) float64 {
+	if c == nil {
+		return 1
+	}
+	if share, ok := c.shares[tenantID]; ok {
+		return share
+	}
+	if c.defaultShare > 0 {
+		return c.defaultShare
+	}
+	return 1
+}
+
+// WithTenantShare caps the calls of tenantID, made through the limiters of
+// LimiterManager.ForTenant, to share of the quota of each window reported by
+// Stream, e.g. 0.2 for a fifth of it, so that a single tenant cannot exhaust
+// the Stream app of every tenant. Shares outside (0, 1] are ignored. It has
+// no effect on NewRateLimiter.
+func WithTenantShare(tenantID string, share float64) Option {
+	return func(r *RateLimiter) {
+		if share <= 0 || share > 1 {
+			return
+		}
+		if r.tenants == nil {
+			r.tenants = &tenantConfig{}
+		}
+		if r.tenants.shares == nil {
+			r.tenants.shares = make(map[string]float64)
+		}
+		r.tenants.shares[tenantID] = share
+	}
+}
+
+// WithDefaultTenantShare is WithTenantShare for the tenants without a share of
+// their own, which may use the whole quota otherwise.
+func WithDefaultTenantShare(share float64) Option {
+	return func(r *RateLimiter) {
+		if share <= 0 || share > 1 {
+			return
+		}
+		if r.tenants == nil {
+			r.tenants = &tenantConfig{}
+		}
+		r.tenants.defaultShare = share
+	}
+}
+
+// WithTenantIdleTTL drops the limiters of LimiterManager.ForTenant idle for
+// ttl, i.e. not requested nor called, without call in flight and not blocked,
+// so that the limiters of past tenants do not pile up; they are recreated on
+// next use. It should exceed the rate limit window, a new limiter knowing
+// nothing about the quota consumed by its tenant. Values not positive are
+// ignored. It has no effect on NewRateLimiter.
+func WithTenantIdleTTL(ttl time.Duration) Option {
+	return func(r *RateLimiter) {
+		if ttl <= 0 {
+			return
+		}
+		if r.tenants == nil {
+			r.tenants = &tenantConfig{}
+		}
+		r.tenants.idleTTL = ttl
+	}
+}
+
+// WithMaxTenantLimiters caps the limiters of LimiterManager.ForTenant to n,
+// dropping the least recently used idle one to make room for a new one. The
+// limiters keep growing beyond n while none is idle. Values lower than 1 are
+// ignored. It has no effect on NewRateLimiter.
+func WithMaxTenantLimiters(n int) Option {
+	return func(r *RateLimiter) {
+		if n < 1 {
+			return
+		}
+		if r.tenants == nil {
+			r.tenants = &tenantConfig{}
+		}
+		r.tenants.maxLimiters = n
+	}
+}
+
+// TenantMetrics is implemented by the Metrics recording the number of
+// limiters of the tenants, like the Collector of the metrics package.
+type TenantMetrics interface {
+	// SetTenantLimiters records the number of live tenant limiters.
+	SetTenantLimiters(count int)
+}
+
+type tenantKey struct {
+	tenantID string
+	apiName  GetStreamApiName
+}
+
+// tenantLimiter is a limiter of a tenant, with when it was last requested.
+type tenantLimiter struct {
+	r        *RateLimiter
+	lastUsed atomic.Int64 // unix nanos
+}
+
+// idleFor returns how long the limiter has been neither requested nor called
+// at now.
+func (t *tenantLimiter) idleFor(now time.Time) time.Duration {
+	last := max(t.lastUsed.Load(), t.r.lastCallAt.Load())
+	return now.Sub(time.Unix(0, last))
+}
+
+// isIdle reports whether r has no call in flight and is not blocked.
+func (r *RateLimiter) isIdle() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls == 0 && r.blocked == nil
+}
+
+// TenantLimiters returns the number of live limiters of the tenants.
+func (m *LimiterManager) TenantLimiters() int {
+	return m.tenantLimiters.len()
+}
+
+// ForTenant returns the RateLimiter of apiName, or of its bucket, for the
+// calls of tenantID, creating it on first use. Each tenant has limiters of its
+// own, limited to the share of the quota of the tenant, see WithTenantShare;
+// their calls pass the limiter of the endpoint returned by For too, and the
+// global one if any, so that the tenants are blocked as the endpoint once
+// Stream reports the quota to be exhausted.
+func (m *LimiterManager) ForTenant(tenantID string, apiName GetStreamApiName) *RateLimiter {
+	apiName = m.bucket(apiName)
+	key := tenantKey{tenantID: tenantID, apiName: apiName}
+	now := m.now()
+	if t, ok := m.tenantLimiters.load(key); ok && !m.tenantSweepDue(now) {
+		t.lastUsed.Store(now.UnixNano())
+		return t.r
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.evictTenantsLocked(now)
+	if t, ok := m.tenantLimiters.load(key); ok {
+		t.lastUsed.Store(now.UnixNano())
+		return t.r
+	}
+	if m.tenants != nil && m.tenants.maxLimiters > 0 && m.tenantLimiters.len() >= m.tenants.maxLimiters {
+		m.evictLeastRecentTenantLocked()
+	}
+	opts := m.opts
+	if share := m.tenants.share(tenantID); share < 1 {
+		opts = append(opts[:len(opts):len(opts)], withTenantQuota(share))
+	}
+	r := NewRateLimiter(apiName, opts...)
+	// the Store of WithStore is taken by the parent, under the same api: the
+	// tenant keeps its state in memory not to count its calls twice
+	r.store = NewMemoryStore()
+	r.tenant = tenantID
+	r.parent = m.forLocked(apiName)
+	m.applyConfigLocked(apiName, r)
+	if m.closed {
+		r.shutdown()
+	}
+	if m.draining {
+		r.startDrain()
+	}
+	t := &tenantLimiter{r: r}
+	t.lastUsed.Store(now.UnixNano())
+	m.tenantLimiters.store(key, t)
+	m.observeTenantsLocked()
+	return r
+}
+
+// tenantSweepDue reports whether the idle tenant limiters are to be scanned
+// at now.
+func (m *LimiterManager) tenantSweepDue(now time.Time) bool {
+	return m.tenants != nil && m.tenants.idleTTL > 0 && now.UnixNano() >= m.tenantSweep.Load()
+}
+
+// evictTenantsLocked drops the tenant limiters idle for the idle TTL, scanning
+// them at most once per TTL. m.mu must be held.
+func (m *LimiterManager) evictTenantsLocked(now time.Time) {
+	if !m.tenantSweepDue(now) {
+		return
+	}
+	m.tenantSweep.Store(now.Add(m.tenants.idleTTL).UnixNano())
+	evicted := false
+	m.tenantLimiters.each(func(key tenantKey, t *tenantLimiter) {
+		if t.idleFor(now) >= m.tenants.idleTTL && t.r.isIdle() {
+			m.tenantLimiters.delete(key)
+			evicted = true
+		}
+	})
+	if evicted {
+		m.observeTenantsLocked()
+	}
+}
+
+// evictLeastRecentTenantLocked drops the least recently used idle tenant
+// limiter, if any. m.mu must be held.
+func (m *LimiterManager) evictLeastRecentTenantLocked() {
+	var (
+		lru  tenantKey
+		last int64
+	)
+	found := false
+	m.tenantLimiters.each(func(key tenantKey, t *tenantLimiter) {
+		if used := t.lastUsed.Load(); (!found || used < last) && t.r.isIdle() {
+			lru, last, found = key, used, true
+		}
+	})
+	if found {
+		m.tenantLimiters.delete(lru)
+	}
+}
+
+// observeTenantsLocked records the number of tenant limiters into the metrics,
+// if they implement TenantMetrics. m.mu must be held.
+func (m *LimiterManager) observeTenantsLocked() {
+	if metrics, ok := m.metrics.(TenantMetrics); ok {
+		metrics.SetTenantLimiters(m.tenantLimiters.len())
+	}
+}
+
+// withTenantQuota limits the calls of a limiter to share of the quota, on top
+// of its Strategy, if any.
+func withTenantQuota(share float64) Option {
+	return func(r *RateLimiter) {
+		r.strategy = &tenantQuota{share: share, next: r.strategy}
+	}
+}
+
+// tenantQuota is the Strategy of the limiters of a tenant, admitting up to its
+// budget, a share of the limit reported by Stream, calls per window. The calls
+// beyond the budget are scheduled in the following windows.
+type tenantQuota struct {
+	share float64
+	next  Strategy // the strategy of the limiter, if any
+
+	mu     sync.Mutex
+	budget int64 // calls per window, 0 until the limit is known
+	reset  time.Time
+	used   int64 // calls admitted since the start of the window
+}
+
+var _ StrategyCanceler = (*tenantQuota)(nil)
+
+// rollLocked moves the window forward once its reset is past, carrying the
+// calls scheduled beyond the budget. q.mu must be held.
+func (q *tenantQuota) rollLocked(now time.Time) {
+	if q.reset.IsZero() || now.Before(q.reset) {
+		return
+	}
+	windows := 1 + int64(now.Sub(q.reset)/defaultRateLimitWindow)
+	q.used = max(q.used-windows*q.budget, 0)
+	q.reset = q.reset.Add(time.Duration(windows) * defaultRateLimitWindow)
+}
+
+// slotLocked returns when the next call may start, in the window where
+// the budget is not consumed yet. q.mu must be held.
+func (q *tenantQuota) slotLocked(now time.Time) time.Time {
+	if q.budget == 0 || q.reset.IsZero() || q.used < q.budget {
+		return now
+	}
+	return q.reset.Add(time.Duration(q.used/q.budget-1) * defaultRateLimitWindow)
+}
+
+func (q *tenantQuota) Reserve(now time.Time) time.Time {
+	q.mu.Lock()
+	q.rollLocked(now)
+	at := q.slotLocked(now)
+	q.used++
+	q.mu.Unlock()
+	if q.next != nil {
+		return q.next.Reserve(at)
+	}
+	return at
+}
+
+func (q *tenantQuota) TryReserve(now time.Time) (time.Time, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollLocked(now)
+	if at := q.slotLocked(now); at.After(now) {
+		return at, false
+	}
+	if q.next != nil {
+		if at, ok := q.next.TryReserve(now); !ok {
+			return at, false
+		}
+	}
+	q.used++
+	return now, true
+}
+
+func (q *tenantQuota) Cancel(now time.Time) {
+	q.mu.Lock()
+	q.used = max(q.used-1, 0)
+	q.mu.Unlock()
+	cancelReservation(q.next, now)
+}
+
+func (q *tenantQuota) Observe(now time.Time, info *LimitInfo, err error) {
+	if q.next != nil {
+		q.next.Observe(now, info, err)
+	}
+	if info == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if info.Limit > 0 {
+		q.budget = max(int64(math.Floor(q.share*float64(info.Limit))), 1)
+	}
+	if reset := time.Unix(info.Reset, 0); info.Reset > 0 && reset.After(now) {
+		q.reset = reset
+	}
+}
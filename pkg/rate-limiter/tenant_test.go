@@ -0,0 +1,166 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestForTenant(t *testing.T) {
+	manager := NewLimiterManager(WithEndpointBuckets())
+	jane := manager.ForTenant("jane", QueryUsers)
+	assert.Same(t, jane, manager.ForTenant("jane", QueryUsers))
+	assert.NotSame(t, jane, manager.ForTenant("john", QueryUsers))
+	assert.NotSame(t, jane, manager.For(QueryUsers))
+	assert.NotSame(t, jane, manager.ForTenant("jane", QueryChannels))
+	assert.Same(t, manager.ForTenant("jane", AddMembers), manager.ForTenant("jane", AddMembers.Bucket()))
+	assert.Nil(t, jane.strategy, "whole quota by default")
+}
+
+func TestForTenantSharedStore(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	store := NewMemoryStore()
+	ctx := context.Background()
+	require.NoError(t, store.Observe(ctx, string(QueryUsers), LimitInfo{Limit: 10, Remaining: 5, Reset: clock.Now().Unix() + 60}, clock.Now()))
+	manager := NewLimiterManager(WithClock(clock), WithStore(store))
+
+	require.NoError(t, manager.ForTenant("jane", QueryUsers).TryCallApi(func() (*stream.Response, error) {
+		return &stream.Response{}, nil
+	}))
+	state, err := store.Load(ctx, string(QueryUsers))
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, state.Remaining, "taken once, by the endpoint limiter")
+}
+
+func TestTenantShare(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	manager := NewLimiterManager(WithClock(clock), WithTenantShare("noisy", 0.2))
+	reset := clock.Now().Unix() + 60
+	apiCall := func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 9, Reset: reset}}, nil
+	}
+
+	noisy := manager.ForTenant("noisy", QueryUsers)
+	require.NoError(t, noisy.TryCallApi(apiCall))
+	require.NoError(t, noisy.TryCallApi(apiCall))
+	var limited ErrRateLimited
+	require.ErrorAs(t, noisy.TryCallApi(apiCall), &limited, "2 calls out of 10")
+	assert.Equal(t, time.Unix(reset, 0), limited.ResetAt)
+
+	quiet := manager.ForTenant("quiet", QueryUsers)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, quiet.TryCallApi(apiCall))
+	}
+
+	// the next call of the noisy tenant waits for the next window
+	done := make(chan error)
+	go func() {
+		done <- noisy.CallApiWithContext(context.Background(), apiCall)
+	}()
+	blockUntil(t, clock, 1)
+	clock.Advance(time.Minute)
+	require.NoError(t, receive(t, done))
+}
+
+func TestDefaultTenantShare(t *testing.T) {
+	manager := NewLimiterManager(WithDefaultTenantShare(0.5), WithTenantShare("vip", 1), WithTenantShare("bogus", 2))
+	assert.Equal(t, 0.5, manager.tenants.share("jane"))
+	assert.Equal(t, 1.0, manager.tenants.share("vip"))
+	assert.Equal(t, 0.5, manager.tenants.share("bogus"), "ignored")
+	assert.NotNil(t, manager.ForTenant("jane", QueryUsers).strategy)
+	assert.Nil(t, manager.ForTenant("vip", QueryUsers).strategy)
+}
+
+func TestTenantQuotaSchedulesWindows(t *testing.T) {
+	now := time.Unix(1000, 0)
+	reset := now.Add(time.Minute)
+	quota := &tenantQuota{share: 0.1}
+	quota.Observe(now, &LimitInfo{Limit: 20, Remaining: 19, Reset: reset.Unix()}, nil)
+
+	var starts []time.Time
+	for i := 0; i < 5; i++ {
+		starts = append(starts, quota.Reserve(now))
+	}
+	assert.Equal(t, []time.Time{now, now, reset, reset, reset.Add(time.Minute)}, starts, "2 calls per window")
+
+	// once the window is over, the calls scheduled in it are carried over
+	_, ok := quota.TryReserve(reset)
+	assert.False(t, ok)
+	at, ok := quota.TryReserve(reset.Add(2 * time.Minute))
+	assert.True(t, ok)
+	assert.Equal(t, reset.Add(2*time.Minute), at)
+}
+
+func TestTenantQuotaWrapsStrategy(t *testing.T) {
+	bucket := NewTokenBucket(0, 1)
+	quota := &tenantQuota{share: 0.5, next: bucket}
+	_, ok := quota.TryReserve(time.Unix(1000, 0))
+	assert.True(t, ok)
+	_, ok = quota.TryReserve(time.Unix(1000, 0))
+	assert.False(t, ok, "bucket empty")
+}
+
+func TestCloseClosesTenants(t *testing.T) {
+	manager := NewLimiterManager()
+	jane := manager.ForTenant("jane", QueryUsers)
+	require.NoError(t, manager.Close(context.Background()))
+	assert.ErrorIs(t, jane.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return nil, nil
+	}), ErrClosed)
+	assert.ErrorIs(t, manager.ForTenant("john", QueryUsers).TryCallApi(func() (*stream.Response, error) {
+		return nil, nil
+	}), ErrClosed)
+}
+
+func TestTenantIdleTTL(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	manager := NewLimiterManager(WithClock(clock), WithTenantIdleTTL(10*time.Minute))
+	jane := manager.ForTenant("jane", QueryUsers)
+	john := manager.ForTenant("john", QueryUsers)
+	assert.Equal(t, 2, manager.TenantLimiters())
+
+	clock.Advance(5 * time.Minute)
+	require.NoError(t, john.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 9}}, nil
+	}))
+	clock.Advance(5 * time.Minute)
+	assert.Same(t, john, manager.ForTenant("john", QueryUsers), "called 5 minutes ago")
+	assert.Equal(t, 1, manager.TenantLimiters())
+	assert.NotSame(t, jane, manager.ForTenant("jane", QueryUsers), "recreated")
+	assert.Equal(t, 2, manager.TenantLimiters())
+}
+
+func TestTenantIdleTTLKeepsBlocked(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	manager := NewLimiterManager(WithClock(clock), WithTenantIdleTTL(time.Minute))
+	jane := manager.ForTenant("jane", QueryUsers)
+	require.NoError(t, jane.CallApiWithContext(context.Background(), func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: clock.Now().Unix() + 3600}}, nil
+	}))
+	clock.Advance(2 * time.Minute)
+	assert.Same(t, jane, manager.ForTenant("jane", QueryUsers))
+	manager.ForTenant("john", QueryUsers)
+	assert.Equal(t, 2, manager.TenantLimiters())
+}
+
+func TestMaxTenantLimiters(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	manager := NewLimiterManager(WithClock(clock), WithMaxTenantLimiters(2))
+	jane := manager.ForTenant("jane", QueryUsers)
+	clock.Advance(time.Second)
+	john := manager.ForTenant("john", QueryUsers)
+	clock.Advance(time.Second)
+	manager.ForTenant("jane", QueryUsers)
+
+	// john is the least recently used
+	manager.ForTenant("jack", QueryUsers)
+	assert.Equal(t, 2, manager.TenantLimiters())
+	assert.Same(t, jane, manager.ForTenant("jane", QueryUsers))
+	assert.NotSame(t, john, manager.ForTenant("john", QueryUsers))
+}
@@ -0,0 +1,69 @@
+package rate_limiter
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const tracerName = "github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter"
+
+const (
+	attrApi       = attribute.Key("getstream.api")
+	attrRemaining = attribute.Key("getstream.ratelimit.remaining")
+	attrLimit     = attribute.Key("getstream.ratelimit.limit")
+	attrReset     = attribute.Key("getstream.ratelimit.reset")
+)
+
+func (r *RateLimiter) getTracer() trace.Tracer {
+	if r.tracer == nil {
+		return noop.NewTracerProvider().Tracer(tracerName)
+	}
+	return r.tracer
+}
+
+// noSpan is the span of the calls while tracing is disabled.
+var noSpan = trace.SpanFromContext(context.Background())
+
+// startSpan starts the span wrapping a whole call through the limiter.
+func (r *RateLimiter) startSpan(ctx context.Context) (context.Context, trace.Span) {
+	if _, ok := r.getTracer().(noop.Tracer); ok {
+		// spare the allocations of the noop tracer, still hiding a recording
+		// span of ctx from the events of the limiter
+		if trace.SpanFromContext(ctx).IsRecording() {
+			ctx = trace.ContextWithSpan(ctx, noSpan)
+		}
+		return ctx, noSpan
+	}
+	return r.getTracer().Start(ctx, "RateLimiter "+r.apiName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrApi.String(r.apiName)),
+	)
+}
+
+func traceRateLimitInfo(span trace.Span, info *LimitInfo) {
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(
+		attrRemaining.Int64(info.Remaining),
+		attrLimit.Int64(info.Limit),
+		attrReset.Int64(info.Reset),
+	)
+}
+
+// traceError records err as an event of span, once per failed attempt.
+func traceError(span trace.Span, err error) {
+	span.RecordError(err)
+}
+
+// traceStatus sets the status of span from the outcome of the whole call, so
+// that a call succeeding after retries is not reported as failed.
+func traceStatus(span trace.Span, err error) {
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,106 @@
+package rate_limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func eventNames(span sdktrace.ReadOnlySpan) []string {
+	var names []string
+	for _, event := range span.Events() {
+		names = append(names, event.Name)
+	}
+	return names
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	now := time.Unix(1000, 0)
+	clock := clocktest.NewFake(now)
+	rLimit := NewRateLimiter(QueryUsers, WithTracerProvider(provider), WithClock(clock))
+
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 0, Limit: 10, Reset: 1060}}, nil
+	}))
+	blockUntil(t, clock, 1)
+
+	done := make(chan error)
+	go func() {
+		done <- rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+			return nil, assert.AnError
+		})
+	}()
+	assert.Eventually(t, func() bool {
+		started := recorder.Started()
+		return len(started) == 2 && len(started[1].Events()) == 1
+	}, time.Second, time.Millisecond)
+	clock.Set(time.Unix(1060, 0))
+	assert.Error(t, receive(t, done))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "RateLimiter QueryUsers", spans[0].Name())
+	assert.Empty(t, eventNames(spans[0]))
+	assert.Contains(t, spans[0].Attributes(), attribute.Int64("getstream.ratelimit.remaining", 0))
+	assert.Contains(t, spans[0].Attributes(), attribute.Int64("getstream.ratelimit.limit", 10))
+	assert.Contains(t, spans[0].Attributes(), attribute.Int64("getstream.ratelimit.reset", 1060))
+
+	assert.Contains(t, spans[1].Attributes(), attribute.String("getstream.api", "QueryUsers"))
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	names := eventNames(spans[1])
+	assert.Contains(t, names, "blocked")
+	assert.Contains(t, names, "resumed")
+	assert.Contains(t, names, "exception")
+}
+
+func TestTracingTryCallApiRateLimited(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	rLimit := NewRateLimiter(QueryUsers, WithTracerProvider(provider))
+	rLimit.tokens() <- struct{}{}
+
+	assert.Error(t, rLimit.TryCallApi(func() (resp *stream.Response, err error) {
+		return nil, nil
+	}))
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
+
+func TestTracingRetriesShareOneSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	rLimit := NewRateLimiter(QueryUsers,
+		WithTracerProvider(provider),
+		WithClock(&instantClock{now: time.Unix(1000, 0)}),
+		WithRetry(RetryPolicy{MaxAttempts: 3, Backoff: ConstantBackoff(time.Second), Retryable: func(error) bool { return true }}),
+	)
+
+	attempts := 0
+	assert.NoError(t, rLimit.CallApiWithContext(context.Background(), func() (resp *stream.Response, err error) {
+		attempts++
+		if attempts < 3 {
+			return nil, assert.AnError
+		}
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Remaining: 9, Limit: 10}}, nil
+	}))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, []string{"exception", "retry scheduled", "exception", "retry scheduled"}, eventNames(spans[0]))
+	assert.Equal(t, codes.Unset, spans[0].Status().Code, "the call eventually succeeded")
+	assert.Contains(t, spans[0].Attributes(), attribute.Int64("getstream.ratelimit.remaining", 9))
+}
@@ -0,0 +1,113 @@
+package rate_limiter
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Transport is an http.RoundTripper rate limiting the requests with the
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers of
+// the responses, e.g. for the Stream REST endpoints called directly or other
+// apis reporting their quota the same way. A 429 response blocks the endpoint
+// like a Stream 429 error, and is still returned to the caller. A Transport
+// must not be copied after first use.
+type Transport struct {
+	// Base performs the requests, http.DefaultTransport when nil.
+	Base http.RoundTripper
+	// Limiters holds the limiters of the endpoints, a LimiterManager with the
+	// default options when nil.
+	Limiters *LimiterManager
+	// Endpoint names the endpoint of a request, the host by default, so that
+	// each endpoint has its own limiter.
+	Endpoint func(req *http.Request) GetStreamApiName
+
+	defaultLimiters LimiterManager
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// HeaderCaller performs a call reporting the quota with rate limit headers, as
+// the X-RateLimit-* headers of an HTTP response. throttled reports a call
+// rejected for too many requests, e.g. answered with a 429.
+type HeaderCaller func() (header http.Header, throttled bool, err error)
+
+// CallWithHeaders is like CallRateLimited, for calls reporting the quota with
+// rate limit headers. The Date header, if any, is recorded by
+// ObserveServerTime.
+func (r *RateLimiter) CallWithHeaders(ctx context.Context, call HeaderCaller) error {
+	return r.CallRateLimited(ctx, func() (*LimitInfo, bool, error) {
+		header, throttled, err := call()
+		if date, dateErr := http.ParseTime(header.Get("Date")); dateErr == nil {
+			r.ObserveServerTime(date)
+		}
+		return rateLimitFromHeaders(header, r.serverNow()), throttled, err
+	})
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	err := t.limiters().For(t.endpoint(req)).CallWithHeaders(req.Context(), func() (http.Header, bool, error) {
+		if resp != nil {
+			// retried after a 429
+			resp.Body.Close()
+		}
+		var err error
+		resp, err = t.base().RoundTrip(req)
+		if err != nil {
+			return nil, false, err
+		}
+		return resp.Header, resp.StatusCode == http.StatusTooManyRequests, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+func (t *Transport) limiters() *LimiterManager {
+	if t.Limiters == nil {
+		return &t.defaultLimiters
+	}
+	return t.Limiters
+}
+
+func (t *Transport) endpoint(req *http.Request) GetStreamApiName {
+	if t.Endpoint == nil {
+		return GetStreamApiName(req.URL.Host)
+	}
+	return t.Endpoint(req)
+}
+
+// The rate limit headers, as sent by Stream.
+const (
+	headerRateLimit     = "X-Ratelimit-Limit"
+	headerRateRemaining = "X-Ratelimit-Remaining"
+	headerRateReset     = "X-Ratelimit-Reset"
+)
+
+// rateLimitFromHeaders parses the rate limit headers of a response received at
+// now, nil when they are missing. A reset lower than a day is taken as the
+// seconds until the reset rather than a Unix timestamp, as sent by some apis.
+func rateLimitFromHeaders(header http.Header, now time.Time) *LimitInfo {
+	remaining, err := strconv.ParseInt(header.Get(headerRateRemaining), 10, 64)
+	if err != nil {
+		return nil
+	}
+	info := &LimitInfo{Remaining: remaining}
+	info.Limit, _ = strconv.ParseInt(header.Get(headerRateLimit), 10, 64)
+	reset, _ := strconv.ParseInt(header.Get(headerRateReset), 10, 64)
+	if reset > 0 && reset < int64(24*time.Hour/time.Second) {
+		reset += now.Unix()
+	}
+	info.Reset = reset
+	return info
+}
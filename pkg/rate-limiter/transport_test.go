@@ -0,0 +1,96 @@
+package rate_limiter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Unix()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(stream.HeaderRateLimit, "20")
+		w.Header().Set(stream.HeaderRateRemaining, r.URL.Query().Get("remaining"))
+		w.Header().Set(stream.HeaderRateReset, strconv.FormatInt(reset, 10))
+	}))
+	t.Cleanup(srv.Close)
+	manager := NewLimiterManager()
+	client := &http.Client{Transport: &Transport{Limiters: manager}}
+
+	resp, err := client.Get(srv.URL + "?remaining=5")
+	require.NoError(t, err)
+	resp.Body.Close()
+	limiter := manager.For(GetStreamApiName(resp.Request.URL.Host))
+	assert.Equal(t, int64(5), limiter.Remaining())
+	assert.False(t, limiter.IsBlocked())
+
+	resp, err = client.Get(srv.URL + "?remaining=0")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.True(t, limiter.IsBlocked())
+	assert.Equal(t, time.Unix(reset, 0), limiter.ResetAt())
+}
+
+func TestTransportTooManyRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(stream.HeaderRateRemaining, "0")
+		w.Header().Set(stream.HeaderRateReset, "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(srv.Close)
+	manager := NewLimiterManager()
+	client := &http.Client{Transport: &Transport{
+		Limiters: manager,
+		Endpoint: func(*http.Request) GetStreamApiName { return QueryUsers },
+	}}
+
+	before := time.Now()
+	resp, err := client.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	limiter := manager.For(QueryUsers)
+	assert.True(t, limiter.IsBlocked())
+	// the reset is given in seconds from now
+	assert.WithinDuration(t, before.Add(30*time.Second), limiter.ResetAt(), 2*time.Second)
+}
+
+func TestTransportWithoutHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+	transport := &Transport{}
+	client := &http.Client{Transport: transport}
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	limiter := transport.limiters().For(GetStreamApiName(srv.Listener.Addr().String()))
+	assert.False(t, limiter.IsBlocked())
+}
+
+func TestCallWithHeaders(t *testing.T) {
+	rLimit := NewRateLimiter(QueryUsers)
+	header := http.Header{}
+	header.Set(stream.HeaderRateRemaining, "0")
+	header.Set(stream.HeaderRateReset, "60")
+	cause := errors.New("slow down")
+
+	err := rLimit.CallWithHeaders(context.Background(), func() (http.Header, bool, error) {
+		return header, true, cause
+	})
+	assert.Same(t, cause, err)
+	assert.True(t, rLimit.IsBlocked())
+}
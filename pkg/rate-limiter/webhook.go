@@ -0,0 +1,225 @@
+package rate_limiter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// The types of the Signal of WebhookHandler.
+const (
+	// SignalPause pauses the limiters, see Pause, until Until if set.
+	SignalPause = "ratelimiter.pause"
+	// SignalResume resumes the limiters, see Resume.
+	SignalResume = "ratelimiter.resume"
+	// SignalThrottle overrides the remaining calls of the limiters until
+	// Until, a minute from now by default, see SetRemaining.
+	SignalThrottle = "ratelimiter.throttle"
+)
+
+// maxWebhookBody bounds the body of the requests of WebhookHandler.
+const maxWebhookBody = 1 << 20
+
+// Signal adjusts the limiters of a LimiterManager, see WebhookHandler. It
+// applies to the limiters of Apis and of the endpoints of Categories, to every
+// limiter when both are empty. Only the limiters already created by the
+// manager are adjusted, a webhook never creating any.
+type Signal struct {
+	Type       string             `json:"type"`
+	Apis       []GetStreamApiName `json:"apis,omitempty"`
+	Categories []EndpointCategory `json:"categories,omitempty"`
+	Until      time.Time          `json:"until"`
+	Remaining  int64              `json:"remaining,omitempty"`
+}
+
+// WebhookOption configures WebhookHandler.
+type WebhookOption func(*webhook)
+
+// WithWebhookVerifier makes WebhookHandler reject the requests whose
+// X-Signature header verify does not accept, e.g. the VerifyWebhook of a
+// stream.Client checking the signature of the webhooks of Stream.
+func WithWebhookVerifier(verify func(body, signature []byte) bool) WebhookOption {
+	return func(w *webhook) {
+		w.verify = verify
+	}
+}
+
+// WithWebhookRule applies signal on every webhook of type eventType, e.g. of
+// Stream or of a status page, its own type being ignored.
+func WithWebhookRule(eventType string, signal Signal) WebhookOption {
+	return func(w *webhook) {
+		w.rules[eventType] = append(w.rules[eventType], signal)
+	}
+}
+
+type pendingResume struct {
+	stop func() bool
+}
+
+type webhook struct {
+	m      *LimiterManager
+	verify func(body, signature []byte) bool
+	rules  map[string][]Signal
+
+	mu      sync.Mutex
+	resumes map[*RateLimiter]*pendingResume // of the pauses with Until
+}
+
+// WebhookHandler returns an http.Handler adjusting the limiters of m on the
+// JSON webhooks it receives, so that the quota policy can be driven from
+// outside in real time, e.g. pausing the writes while Stream reports a
+// degraded service:
+//
+//	mux.Handle("/webhooks/ratelimiter", rate_limiter.WebhookHandler(manager,
+//		rate_limiter.WithWebhookVerifier(client.VerifyWebhook),
+//		rate_limiter.WithWebhookRule("incident.opened", rate_limiter.Signal{
+//			Type:       rate_limiter.SignalPause,
+//			Categories: []rate_limiter.EndpointCategory{rate_limiter.CategoryMessages},
+//		})))
+//
+// A webhook is a Signal itself when its type is one of SignalPause,
+// SignalResume and SignalThrottle, otherwise it triggers the signals of its
+// rules, if any, see WithWebhookRule. The other webhooks are acknowledged and
+// ignored, not to be retried. A signal naming apis known neither as endpoints,
+// see Endpoints, nor as limiters of m, or unknown categories, is rejected with
+// a 400. Without WithWebhookVerifier, the webhooks are not authenticated:
+// mount the handler on an internal mux only.
+func WebhookHandler(m *LimiterManager, opts ...WebhookOption) http.Handler {
+	w := &webhook{m: m, rules: map[string][]Signal{}, resumes: map[*RateLimiter]*pendingResume{}}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+func (w *webhook) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		rw.Header().Set("Allow", "POST")
+		http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(rw, req.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if w.verify != nil && !w.verify(body, []byte(req.Header.Get("X-Signature"))) {
+		http.Error(rw, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	var signal Signal
+	if err := json.Unmarshal(body, &signal); err != nil {
+		http.Error(rw, fmt.Sprintf("invalid webhook: %v", err), http.StatusBadRequest)
+		return
+	}
+	switch signal.Type {
+	case SignalPause, SignalResume, SignalThrottle:
+		if err := w.validate(signal); err != nil {
+			http.Error(rw, fmt.Sprintf("invalid webhook: %v", err), http.StatusBadRequest)
+			return
+		}
+		w.apply(req, signal)
+	default:
+		for _, rule := range w.rules[signal.Type] {
+			w.apply(req, rule)
+		}
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// apply applies signal to the limiters it selects.
+func (w *webhook) apply(req *http.Request, signal Signal) {
+	now := w.m.now()
+	for _, r := range w.limiters(signal) {
+		r := r
+		switch signal.Type {
+		case SignalPause:
+			w.stopResume(r)
+			r.Pause()
+			if wait := signal.Until.Sub(now); !signal.Until.IsZero() && wait > 0 {
+				pending := &pendingResume{}
+				w.mu.Lock()
+				w.resumes[r] = pending
+				pending.stop = afterFunc(r.getClock(), wait, func() {
+					w.mu.Lock()
+					current := w.resumes[r] == pending
+					if current {
+						delete(w.resumes, r)
+					}
+					w.mu.Unlock()
+					if current {
+						r.Resume()
+					}
+				})
+				w.mu.Unlock()
+			}
+		case SignalResume:
+			w.stopResume(r)
+			r.Resume()
+		case SignalThrottle:
+			until := signal.Until
+			if until.IsZero() {
+				until = now.Add(time.Minute)
+			}
+			r.SetRemaining(req.Context(), signal.Remaining, until)
+		}
+	}
+}
+
+// stopResume cancels the pending resume of r, if any, superseded by a new
+// signal.
+func (w *webhook) stopResume(r *RateLimiter) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if pending, ok := w.resumes[r]; ok {
+		pending.stop()
+		delete(w.resumes, r)
+	}
+}
+
+// validate checks that the apis of signal are known endpoints or limiters of
+// the manager, and that its categories are known.
+func (w *webhook) validate(signal Signal) error {
+	categories := map[EndpointCategory]bool{}
+	for _, endpoint := range Endpoints() {
+		categories[endpoint.Category] = true
+	}
+	for _, api := range signal.Apis {
+		if !w.m.Known(api) {
+			return fmt.Errorf("unknown api %q", api)
+		}
+	}
+	for _, category := range signal.Categories {
+		if !categories[category] {
+			return fmt.Errorf("unknown category %q", category)
+		}
+	}
+	return nil
+}
+
+// limiters returns the existing limiters selected by signal, once each.
+func (w *webhook) limiters(signal Signal) []*RateLimiter {
+	if len(signal.Apis) == 0 && len(signal.Categories) == 0 {
+		return w.m.limiters.values()
+	}
+	apis := append([]GetStreamApiName{}, signal.Apis...)
+	for _, category := range signal.Categories {
+		for _, endpoint := range Endpoints() {
+			if endpoint.Category == category {
+				apis = append(apis, endpoint.Name)
+			}
+		}
+	}
+	var limiters []*RateLimiter
+	seen := map[*RateLimiter]bool{}
+	for _, api := range apis {
+		if r, ok := w.m.limiters.load(w.m.bucket(api)); ok && !seen[r] {
+			seen[r] = true
+			limiters = append(limiters, r)
+		}
+	}
+	return limiters
+}
@@ -0,0 +1,119 @@
+package rate_limiter
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func postWebhook(t *testing.T, handler http.Handler, body string, header ...string) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/ratelimiter", strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestWebhookHandlerSignals(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	manager := NewLimiterManager(WithClock(clock))
+	handler := WebhookHandler(manager)
+	for _, api := range []GetStreamApiName{QueryUsers, SendReaction, GetReactions, SendMessage} {
+		manager.For(api)
+	}
+
+	assert.Equal(t, http.StatusNoContent, postWebhook(t, handler, `{"type":"ratelimiter.pause","apis":["QueryUsers"],"categories":["reactions"]}`))
+	assert.True(t, manager.For(QueryUsers).Paused())
+	assert.True(t, manager.For(SendReaction).Paused())
+	assert.True(t, manager.For(GetReactions).Paused())
+	assert.False(t, manager.For(SendMessage).Paused())
+
+	assert.Equal(t, http.StatusNoContent, postWebhook(t, handler, `{"type":"ratelimiter.resume","categories":["reactions"]}`))
+	assert.False(t, manager.For(SendReaction).Paused())
+	assert.True(t, manager.For(QueryUsers).Paused())
+
+	// every limiter, until a minute from now
+	manager.For("CustomEndpoint")
+	assert.Equal(t, http.StatusNoContent, postWebhook(t, handler, `{"type":"ratelimiter.pause","until":"1970-01-01T00:17:40Z"}`))
+	for _, r := range manager.limiters.values() {
+		assert.True(t, r.Paused(), r.apiName)
+	}
+	clock.Advance(time.Minute)
+	for _, r := range manager.limiters.values() {
+		assert.False(t, r.Paused(), r.apiName)
+	}
+
+	assert.Equal(t, http.StatusNoContent, postWebhook(t, handler, `{"type":"ratelimiter.throttle","apis":["SendMessage"]}`))
+	assert.True(t, manager.For(SendMessage).IsBlocked())
+	assert.Equal(t, int64(0), manager.For(SendMessage).Remaining())
+	assert.Equal(t, clock.Now().Add(time.Minute), manager.For(SendMessage).ResetAt())
+
+	assert.Equal(t, http.StatusNoContent, postWebhook(t, handler, `{"type":"message.new"}`), "ignored")
+	assert.Equal(t, http.StatusBadRequest, postWebhook(t, handler, `{`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/webhooks/ratelimiter", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestWebhookHandlerRulesAndSignature(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	manager := NewLimiterManager(WithClock(clock))
+	secret := []byte("secret")
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(body))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	handler := WebhookHandler(manager,
+		WithWebhookVerifier(func(body, signature []byte) bool {
+			return hmac.Equal([]byte(sign(string(body))), signature)
+		}),
+		WithWebhookRule("incident.opened", Signal{Type: SignalPause, Categories: []EndpointCategory{CategoryMessages}, Until: clock.Now().Add(time.Hour)}),
+		WithWebhookRule("incident.resolved", Signal{Type: SignalResume, Categories: []EndpointCategory{CategoryMessages}}),
+	)
+	for _, api := range []GetStreamApiName{SendMessage, GetReplies, QueryUsers} {
+		manager.For(api)
+	}
+
+	body := `{"type":"incident.opened"}`
+	assert.Equal(t, http.StatusUnauthorized, postWebhook(t, handler, body, "X-Signature", "invalid"))
+	assert.False(t, manager.For(SendMessage).Paused())
+
+	require.Equal(t, http.StatusNoContent, postWebhook(t, handler, body, "X-Signature", sign(body)))
+	assert.True(t, manager.For(SendMessage).Paused())
+	assert.True(t, manager.For(GetReplies).Paused())
+	assert.False(t, manager.For(QueryUsers).Paused())
+
+	body = `{"type":"incident.resolved"}`
+	require.Equal(t, http.StatusNoContent, postWebhook(t, handler, body, "X-Signature", sign(body)))
+	assert.False(t, manager.For(SendMessage).Paused())
+	assert.Equal(t, 0, clock.Waiters(), "pending resume stopped")
+}
+
+func TestWebhookHandlerUnknownApis(t *testing.T) {
+	manager := NewLimiterManager()
+	handler := WebhookHandler(manager)
+	manager.For("CustomEndpoint")
+
+	assert.Equal(t, http.StatusBadRequest, postWebhook(t, handler, `{"type":"ratelimiter.pause","apis":["QueryUsers","Bogus"]}`))
+	assert.Equal(t, http.StatusBadRequest, postWebhook(t, handler, `{"type":"ratelimiter.pause","categories":["bogus"]}`))
+	assert.Equal(t, 1, manager.limiters.len(), "no limiter created")
+
+	assert.Equal(t, http.StatusNoContent, postWebhook(t, handler, `{"type":"ratelimiter.pause","apis":["QueryUsers","CustomEndpoint"]}`))
+	assert.True(t, manager.For("CustomEndpoint").Paused())
+	_, created := manager.limiters.load(QueryUsers)
+	assert.False(t, created, "known endpoint, without a limiter yet")
+}
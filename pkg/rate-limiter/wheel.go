@@ -0,0 +1,234 @@
+package rate_limiter
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	wheelBits   = 6
+	wheelSlots  = 1 << wheelBits
+	wheelMask   = wheelSlots - 1
+	wheelLevels = 4
+	// wheelSpan is the number of ticks covered by the levels, beyond which
+	// timers are parked in the last slot of the last level
+	wheelSpan = 1 << (wheelBits * wheelLevels)
+)
+
+// wheelTimer is a function scheduled on a TimingWheel.
+type wheelTimer struct {
+	at    int64 // tick when it is due
+	f     func()
+	level int // -1 once fired or stopped
+	slot  int
+}
+
+// TimingWheel is a TimerClock scheduling the functions of AfterFunc on a
+// hierarchical timing wheel, on top of another clock: the functions due in
+// the same tick are called together, on a single timer of the underlying
+// clock, armed for the next tick with functions due only. It spares a timer
+// per limiter blocked until its reset when thousands of limiters share it,
+// e.g. the limiters of the tenants of a LimiterManager:
+//
+//	wheel := NewTimingWheel(nil, time.Second)
+//	manager := NewLimiterManager(WithClock(wheel))
+//
+// Functions are called up to a tick late, never early, one after the other,
+// so they should not block. Stopping one, e.g. as a later response pushes back
+// a reset, removes it from the wheel right away. Now and After are those of the
+// underlying clock.
+type TimingWheel struct {
+	clock Clock
+	tick  time.Duration
+	start time.Time
+
+	mu      sync.Mutex
+	current int64 // last tick processed
+	levels  [wheelLevels][wheelSlots]map[*wheelTimer]struct{}
+	counts  [wheelLevels]int // timers per level
+	armedAt int64            // tick the underlying timer is armed for
+	disarm  func() bool      // stops the underlying timer
+}
+
+var _ TimerClock = (*TimingWheel)(nil)
+
+// NewTimingWheel returns a TimingWheel ticking every tick on clock, the real
+// clock when nil. Ticks not positive default to a second, the resolution of
+// the resets reported by Stream.
+func NewTimingWheel(clock Clock, tick time.Duration) *TimingWheel {
+	if clock == nil {
+		clock = realClock{}
+	}
+	if tick <= 0 {
+		tick = time.Second
+	}
+	return &TimingWheel{clock: clock, tick: tick, start: clock.Now()}
+}
+
+func (w *TimingWheel) Now() time.Time {
+	return w.clock.Now()
+}
+
+func (w *TimingWheel) After(d time.Duration) <-chan time.Time {
+	return w.clock.After(d)
+}
+
+// AfterFunc schedules f in the tick where d elapses, returning a func removing
+// it from the wheel, which reports false if f already ran or was stopped.
+func (w *TimingWheel) AfterFunc(d time.Duration, f func()) (stop func() bool) {
+	now := w.clock.Now()
+	// rounded up, so that f is never called early
+	at := int64((now.Add(d).Sub(w.start) + w.tick - 1) / w.tick)
+
+	w.mu.Lock()
+	t := &wheelTimer{at: max(at, w.current+1), f: f}
+	w.addLocked(t)
+	w.armLocked(now)
+	w.mu.Unlock()
+	return func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return w.removeLocked(t)
+	}
+}
+
+// Len returns the number of functions scheduled and not called yet.
+func (w *TimingWheel) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := 0
+	for _, count := range w.counts {
+		n += count
+	}
+	return n
+}
+
+// addLocked puts t in the slot of the lowest level covering its tick. w.mu
+// must be held.
+func (w *TimingWheel) addLocked(t *wheelTimer) {
+	at := min(t.at, w.current+wheelSpan-1)
+	delta := at - w.current
+	level := 0
+	for level < wheelLevels-1 && delta >= 1<<(wheelBits*(level+1)) {
+		level++
+	}
+	t.level = level
+	t.slot = int(at>>(wheelBits*level)) & wheelMask
+	slot := &w.levels[level][t.slot]
+	if *slot == nil {
+		*slot = make(map[*wheelTimer]struct{})
+	}
+	(*slot)[t] = struct{}{}
+	w.counts[level]++
+}
+
+// removeLocked takes t off the wheel, reporting false if it already was. w.mu
+// must be held.
+func (w *TimingWheel) removeLocked(t *wheelTimer) bool {
+	if t.level < 0 {
+		return false
+	}
+	delete(w.levels[t.level][t.slot], t)
+	w.counts[t.level]--
+	t.level = -1
+	return true
+}
+
+// advance processes the ticks elapsed since the last one processed, calling
+// the functions due, and arms the underlying timer for the next tick due.
+func (w *TimingWheel) advance() {
+	now := w.clock.Now()
+	var due []func()
+
+	w.mu.Lock()
+	target := int64(now.Sub(w.start) / w.tick)
+	for w.current < target {
+		if w.counts[0] == 0 {
+			// nothing to fire before the next cascade
+			next := (w.current>>wheelBits + 1) << wheelBits
+			if next > target {
+				w.current = target
+				break
+			}
+			w.current = next - 1
+		}
+		w.current++
+		due = w.tickLocked(due)
+	}
+	w.armLocked(now)
+	w.mu.Unlock()
+
+	// called without the lock, as they may schedule functions again
+	for _, f := range due {
+		f()
+	}
+}
+
+// tickLocked cascades the timers of the higher levels reaching the lowest
+// one at the current tick, then appends the functions due to due. w.mu must
+// be held.
+func (w *TimingWheel) tickLocked(due []func()) []func() {
+	for level := 1; level < wheelLevels; level++ {
+		if w.current&(1<<(wheelBits*level)-1) != 0 {
+			break
+		}
+		slot := int(w.current>>(wheelBits*level)) & wheelMask
+		timers := w.levels[level][slot]
+		w.levels[level][slot] = nil
+		w.counts[level] -= len(timers)
+		for t := range timers {
+			w.addLocked(t)
+		}
+	}
+	slot := int(w.current) & wheelMask
+	for t := range w.levels[0][slot] {
+		if t.at > w.current {
+			// parked beyond the span of the wheel
+			delete(w.levels[0][slot], t)
+			w.counts[0]--
+			w.addLocked(t)
+			continue
+		}
+		w.removeLocked(t)
+		due = append(due, t.f)
+	}
+	return due
+}
+
+// armLocked arms the underlying timer for the next tick with timers due, or
+// cascading timers, unless it is armed for an earlier tick not processed yet.
+// w.mu must be held.
+func (w *TimingWheel) armLocked(now time.Time) {
+	next := w.nextLocked()
+	if next == 0 || (w.armedAt > w.current && w.armedAt <= next) {
+		return
+	}
+	if w.disarm != nil {
+		// a timer which could not be stopped in time finds nothing to process
+		w.disarm()
+	}
+	w.armedAt = next
+	d := w.start.Add(time.Duration(next) * w.tick).Sub(now)
+	// a timer of a tick already passed still goes through the underlying
+	// clock, so that advance does not run holding w.mu
+	w.disarm = afterFunc(w.clock, max(d, time.Nanosecond), w.advance)
+}
+
+// nextLocked returns the next tick to process, 0 without timers. w.mu must be
+// held.
+func (w *TimingWheel) nextLocked() int64 {
+	if w.counts[0] > 0 {
+		for i := int64(1); i <= wheelSlots; i++ {
+			if len(w.levels[0][int(w.current+i)&wheelMask]) > 0 {
+				return w.current + i
+			}
+		}
+	}
+	for level := 1; level < wheelLevels; level++ {
+		if w.counts[level] > 0 {
+			span := int64(1) << (wheelBits * level)
+			return (w.current/span + 1) * span
+		}
+	}
+	return 0
+}
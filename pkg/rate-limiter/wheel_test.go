@@ -0,0 +1,130 @@
+package rate_limiter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	stream "github.com/GetStream/stream-chat-go/v6"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sw360cab/getstream-rate-limiter/pkg/rate-limiter/clocktest"
+)
+
+func TestTimingWheelCoalescesTimers(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	wheel := NewTimingWheel(clock, time.Second)
+
+	fired := 0
+	for i := 0; i < 1000; i++ {
+		wheel.AfterFunc(time.Duration(i%3+1)*time.Second, func() { fired++ })
+	}
+	assert.Equal(t, 1000, wheel.Len())
+	assert.Equal(t, 1, clock.Waiters(), "a single timer armed")
+
+	clock.Advance(time.Second)
+	assert.Equal(t, 334, fired)
+	clock.Advance(2 * time.Second)
+	assert.Equal(t, 1000, fired)
+	assert.Equal(t, 0, wheel.Len())
+	assert.Equal(t, 0, clock.Waiters(), "no timer armed without functions")
+}
+
+func TestTimingWheelNeverFiresEarly(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	wheel := NewTimingWheel(clock, time.Second)
+
+	var firedAt time.Time
+	wheel.AfterFunc(1500*time.Millisecond, func() { firedAt = clock.Now() })
+	clock.Advance(1500 * time.Millisecond)
+	assert.True(t, firedAt.IsZero(), "fired before the end of its tick")
+	clock.Advance(500 * time.Millisecond)
+	assert.Equal(t, time.Unix(1002, 0), firedAt)
+}
+
+func TestTimingWheelCascades(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	wheel := NewTimingWheel(clock, time.Second)
+
+	delays := []time.Duration{
+		63 * time.Second,
+		64 * time.Second,
+		100 * time.Second,
+		4096 * time.Second,
+		5000 * time.Second,
+		300000 * time.Second,
+		wheelSpan * 2 * time.Second, // beyond the span of the wheel
+	}
+	fired := make(map[time.Duration]time.Time)
+	for _, d := range delays {
+		d := d
+		wheel.AfterFunc(d, func() { fired[d] = clock.Now() })
+	}
+	for wheel.Len() > 0 {
+		deadline, ok := clock.NextDeadline()
+		if !assert.True(t, ok, "no timer armed") {
+			return
+		}
+		clock.Set(deadline)
+	}
+	for _, d := range delays {
+		assert.Equal(t, time.Unix(1000, 0).Add(d), fired[d], fmt.Sprint(d))
+	}
+}
+
+func TestTimingWheelStop(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	wheel := NewTimingWheel(clock, time.Second)
+
+	stop := wheel.AfterFunc(100*time.Second, func() { t.Error("fired after stop") })
+	fired := false
+	wheel.AfterFunc(200*time.Second, func() { fired = true })
+	assert.True(t, stop())
+	assert.False(t, stop(), "already stopped")
+	assert.Equal(t, 1, wheel.Len())
+
+	clock.Advance(200 * time.Second)
+	assert.True(t, fired)
+	assert.Equal(t, 0, wheel.Len())
+}
+
+func TestTimingWheelSchedulesEarlierTimer(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	wheel := NewTimingWheel(clock, time.Second)
+
+	wheel.AfterFunc(time.Hour, func() {})
+	fired := false
+	wheel.AfterFunc(2*time.Second, func() { fired = true })
+	assert.Equal(t, 1, clock.Waiters(), "timer rearmed")
+	deadline, _ := clock.NextDeadline()
+	assert.Equal(t, time.Unix(1002, 0), deadline)
+
+	clock.Advance(2 * time.Second)
+	assert.True(t, fired)
+}
+
+func TestTimingWheelSharedByLimiters(t *testing.T) {
+	clock := clocktest.NewFake(time.Unix(1000, 0))
+	manager := NewLimiterManager(WithClock(NewTimingWheel(clock, time.Second)))
+	exhausted := func() (*stream.Response, error) {
+		return &stream.Response{RateLimitInfo: &stream.RateLimitInfo{Limit: 10, Remaining: 0, Reset: 1060}}, nil
+	}
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, manager.For(GetStreamApiName(fmt.Sprint("api-", i))).TryCallApi(exhausted))
+	}
+	assert.Equal(t, 1, clock.Waiters(), "resets share a single timer")
+
+	// a later response pushes back the reset of one api
+	pushed := manager.For("api-0")
+	assert.NoError(t, pushed.applyRateLimits(context.Background(), stream.RateLimitsMap{
+		"api-0": {Limit: 10, Remaining: 0, Reset: 1120},
+	}))
+
+	clock.Advance(time.Minute)
+	assert.False(t, manager.For("api-1").IsBlocked())
+	assert.True(t, pushed.IsBlocked())
+	clock.Advance(time.Minute)
+	assert.False(t, pushed.IsBlocked())
+}